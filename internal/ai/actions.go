@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// maxActionItems ограничивает число задач, чтобы клавиатура с кнопками оставалась обозримой
+const maxActionItems = 10
+
+// ExtractActionItems выделяет из текста задачи по шаблону промпта promptTemplate (с %s на месте текста).
+// Модель отвечает задачами по одной в строке или словом "НЕТ"; пустой результат означает, что задач нет.
+func (s *Service) ExtractActionItems(ctx context.Context, text, promptTemplate string) ([]string, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(fmt.Sprintf(promptTemplate, text))}},
+	}
+	answer, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+	return parseActionItems(answer), nil
+}
+
+// parseActionItems разбирает ответ модели: убирает маркеры списков и нумерацию, пропускает пустые строки
+func parseActionItems(answer string) []string {
+	var items []string
+	for _, line := range strings.Split(answer, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*•– ")
+		if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" {
+			line = line[i+1:]
+		}
+		line = strings.TrimSpace(strings.ReplaceAll(line, "**", ""))
+		if line == "" || strings.EqualFold(strings.Trim(line, "."), "нет") {
+			continue
+		}
+		items = append(items, line)
+		if len(items) == maxActionItems {
+			break
+		}
+	}
+	return items
+}
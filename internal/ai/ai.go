@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/genai"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
 )
 
 type Config struct {
@@ -19,11 +22,33 @@ type Config struct {
 	PrimaryModelRetries  int
 	FallbackModelRetries int
 	RetryDelay           time.Duration
+
+	// Просить модель помечать неразборчивые фрагменты вместо угадывания
+	FlagUnclear bool
+
+	// Промпты резюме для отдельных языков (код ISO 639-1), см. SummarizeTextIn
+	LanguageProfiles map[string]LanguageProfile
+
+	// Просить модель ставить метки времени [ММ:СС] в начале абзацев транскрипции
+	Timestamps bool
 }
 
 type Service struct {
 	client *genai.Client
 	conf   Config
+
+	// Объединение коротких суммирований в один запрос при всплесках нагрузки; nil - выключено
+	coalescer *coalescer
+	// Кэш готовых суммирований (см. EnableResponseCache); nil - выключен
+	responses *responseCache
+
+	// Промпты, заданные во время работы (см. SetPrompts)
+	promptsMu sync.RWMutex
+	prompts   Prompts
+
+	// Модели, закрепленные за чатами (см. SetChatModel)
+	modelsMu   sync.RWMutex
+	chatModels map[string]string
 }
 
 func NewService(client *genai.Client, conf Config) *Service { return &Service{client: client, conf: conf} }
@@ -38,9 +63,11 @@ func isRetryable(err error) bool {
 
 func (s *Service) generateWithRetry(ctx context.Context, contents []*genai.Content) (string, error) {
 	var lastErr error
+	primary := s.primaryModel(ctx)
 	for attempt := 1; attempt <= s.conf.PrimaryModelRetries; attempt++ {
-		resp, err := s.client.Models.GenerateContent(ctx, s.conf.PrimaryModel, contents, nil)
+		resp, err := s.client.Models.GenerateContent(ctx, primary, contents, nil)
 		if err == nil {
+			recordUsage(ctx, primary, resp.UsageMetadata)
 			if txt := resp.Text(); txt != "" { return txt, nil }
 			lastErr = fmt.Errorf("API вернул пустой текстовый ответ")
 		} else { lastErr = err }
@@ -50,6 +77,7 @@ func (s *Service) generateWithRetry(ctx context.Context, contents []*genai.Conte
 	for attempt := 1; attempt <= s.conf.FallbackModelRetries; attempt++ {
 		resp, err := s.client.Models.GenerateContent(ctx, s.conf.FallbackModel, contents, nil)
 		if err == nil {
+			recordUsage(ctx, s.conf.FallbackModel, resp.UsageMetadata)
 			if txt := resp.Text(); txt != "" { return txt, nil }
 			lastErr = fmt.Errorf("API вернул пустой текстовый ответ")
 		} else { lastErr = err }
@@ -62,19 +90,50 @@ func (s *Service) generateWithRetry(ctx context.Context, contents []*genai.Conte
 func (s *Service) AudioToText(ctx context.Context, filePath string, readFile func(string) ([]byte, error)) (string, error) {
 	audioData, err := readFile(filePath)
 	if err != nil { return "", fmt.Errorf("не удалось прочитать аудиофайл: %w", err) }
-	prompt := genai.NewPartFromText("Пожалуйста, транскрибируйте этот аудио файл в текст на том языке, на котором говорят в записи. Верните только текст транскрипции без дополнительных комментариев.")
-	audioPart := genai.NewPartFromBytes(audioData, "audio/mpeg")
+	instruction := "Пожалуйста, транскрибируйте этот аудио файл в текст на том языке, на котором говорят в записи. Верните только текст транскрипции без дополнительных комментариев."
+	if s.conf.FlagUnclear { instruction += " " + unclearInstruction }
+	if s.conf.Timestamps { instruction += " " + timestampInstruction }
+	if extra := transcribeInstruction(ctx); extra != "" { instruction += "\n\n" + extra }
+	prompt := genai.NewPartFromText(instruction)
+	audioPart := genai.NewPartFromBytes(audioData, media.AudioMIME(filePath))
 	contents := []*genai.Content{{Parts: []*genai.Part{prompt, audioPart}}}
 	return s.generateWithRetry(ctx, contents)
 }
 
 func (s *Service) SummarizeText(ctx context.Context, textToSummarize, promptTemplate string) (string, error) {
+	systemPrompt := strings.TrimSpace(s.systemPrompt(ctx) + "\n\n" + glossaryInstruction(ctx))
+	if s.responses == nil {
+		return s.summarizeText(ctx, systemPrompt, promptTemplate, textToSummarize)
+	}
+	key := s.responseKey(ctx, systemPrompt, promptTemplate, textToSummarize)
+	if summary, found := s.responses.get(ctx, key); found {
+		return summary, nil
+	}
+	summary, err := s.summarizeText(ctx, systemPrompt, promptTemplate, textToSummarize)
+	if err == nil {
+		s.responses.set(ctx, key, summary)
+	}
+	return summary, err
+}
+
+func (s *Service) summarizeText(ctx context.Context, systemPrompt, promptTemplate, textToSummarize string) (string, error) {
+	// Объединенный запрос идет в основную модель, поэтому чаты с закрепленной моделью не объединяются
+	if s.coalescer != nil && s.chatModel(ctx) == "" {
+		return s.coalescer.summarize(ctx, s, systemPrompt, promptTemplate, textToSummarize)
+	}
+	return s.summarize(ctx, systemPrompt, promptTemplate, textToSummarize)
+}
+
+func (s *Service) summarize(ctx context.Context, systemPrompt, promptTemplate, textToSummarize string) (string, error) {
 	userPrompt := fmt.Sprintf(promptTemplate, textToSummarize)
 	contents := []*genai.Content{
-		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(s.conf.SystemPrompt)}},
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(systemPrompt)}},
 		{Role: "model", Parts: []*genai.Part{genai.NewPartFromText("Понял, буду следовать указанным правилам форматирования и структуры.")}},
 		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(userPrompt)}},
 	}
+	if onDelta, ok := ctx.Value(summaryStreamKey{}).(SummaryStream); ok {
+		return s.generateStream(ctx, contents, onDelta)
+	}
 	return s.generateWithRetry(ctx, contents)
 }
 
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const chaptersPrompt = `Ниже - расшифровка длинной записи, абзацы которой начинаются с меток времени [ММ:СС]. Разбейте запись на 3-10 глав по сменам темы. Ответьте только JSON-массивом без пояснений и разметки: [{"time": "метка абзаца, с которого начинается глава, без скобок", "title": "название главы, 2-6 слов"}]. Первая глава начинается с первого абзаца, метки берите только из расшифровки. Названия пишите на языке записи.
+
+Расшифровка:
+%s`
+
+// Chapter - глава длинной записи: момент начала и название
+type Chapter struct {
+	Time  string `json:"time"`
+	Title string `json:"title"`
+}
+
+// Chapters делит расшифровку с метками времени на главы. Главы с метками, которых нет среди stamps,
+// отбрасываются, как и повторы одной метки.
+func (s *Service) Chapters(ctx context.Context, transcript string, stamps []string) ([]Chapter, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(fmt.Sprintf(chaptersPrompt, transcript))}},
+	}
+	answer, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+	var chapters []Chapter
+	if err := parseJSONAnswer(answer, &chapters); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ответ с главами: %w", err)
+	}
+	known := stampSet(stamps)
+	valid := chapters[:0]
+	for _, c := range chapters {
+		c.Title, c.Time = strings.TrimSpace(c.Title), strings.Trim(c.Time, "[] ")
+		if c.Title != "" && known[c.Time] {
+			valid = append(valid, c)
+			delete(known, c.Time)
+		}
+	}
+	return valid, nil
+}
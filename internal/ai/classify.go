@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+)
+
+// AudioClass - доли речи, музыки и шума в записи по оценке модели, от 0 до 1
+type AudioClass struct {
+	Speech float64
+	Music  float64
+	Noise  float64
+}
+
+var reAudioClass = regexp.MustCompile(`(?i)\b(speech|music|noise)\s*[=:]\s*([01](?:[.,]\d+)?)`)
+
+// ClassifyAudio оценивает, что в записи: речь, музыка или шум. Вызывается до транскрипции,
+// чтобы не пересказывать "услышанные" в музыке или шуме слова.
+func (s *Service) ClassifyAudio(ctx context.Context, filePath string, readFile func(string) ([]byte, error)) (AudioClass, error) {
+	audioData, err := readFile(filePath)
+	if err != nil {
+		return AudioClass{}, fmt.Errorf("не удалось прочитать аудиофайл: %w", err)
+	}
+	prompt := genai.NewPartFromText("Оцените, из чего состоит эта аудиозапись: разборчивая речь (speech), музыка или пение (music), шум или тишина (noise). " +
+		"Ответьте одной строкой вида \"speech=0.8 music=0.1 noise=0.1\" - доли от 0 до 1 в сумме 1, без других комментариев.")
+	audioPart := genai.NewPartFromBytes(audioData, media.AudioMIME(filePath))
+	contents := []*genai.Content{{Parts: []*genai.Part{prompt, audioPart}}}
+	text, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return AudioClass{}, err
+	}
+	return parseAudioClass(text)
+}
+
+func parseAudioClass(text string) (AudioClass, error) {
+	var class AudioClass
+	matches := reAudioClass.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return class, fmt.Errorf("не удалось разобрать ответ классификации: %q", text)
+	}
+	for _, m := range matches {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(m[2], ",", "."), 64)
+		if err != nil {
+			return class, fmt.Errorf("не удалось разобрать ответ классификации: %q", text)
+		}
+		switch strings.ToLower(m[1]) {
+		case "speech":
+			class.Speech = v
+		case "music":
+			class.Music = v
+		case "noise":
+			class.Noise = v
+		}
+	}
+	return class, nil
+}
@@ -0,0 +1,236 @@
+package ai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// CoalesceConfig - настройки объединения суммирований. Пока одновременно идет меньше Threshold
+// суммирований, каждое выполняется отдельным запросом; сверх порога короткие тексты (до MaxChars
+// символов) одного чата с одинаковыми промптами собираются в течение Wait в пакеты до MaxBatch текстов
+// и суммируются одним запросом, чтобы не упираться в лимит запросов в минуту. Тексты разных чатов
+// в один запрос не попадают.
+type CoalesceConfig struct {
+	Threshold int
+	MaxBatch  int
+	MaxChars  int
+	Wait      time.Duration
+}
+
+// EnableCoalescing включает объединение коротких суммирований в пакеты при всплесках нагрузки
+func (s *Service) EnableCoalescing(conf CoalesceConfig) {
+	if conf.Threshold <= 0 || conf.MaxBatch < 2 {
+		return
+	}
+	s.coalescer = &coalescer{conf: conf, pending: make(map[string]*summaryBatch)}
+}
+
+type coalescer struct {
+	conf CoalesceConfig
+
+	mu       sync.Mutex
+	inFlight int
+	pending  map[string]*summaryBatch
+}
+
+type summaryBatch struct {
+	systemPrompt   string
+	promptTemplate string
+	items          []*batchItem
+}
+
+// batchTimeout ограничивает пакетный запрос: он не привязан к контексту одного из текстов
+const batchTimeout = 2 * time.Minute
+
+type batchItem struct {
+	ctx  context.Context
+	text string
+	done chan batchResult
+}
+
+type batchResult struct {
+	summary string
+	err     error
+}
+
+func (c *coalescer) summarize(ctx context.Context, s *Service, systemPrompt, promptTemplate, text string) (string, error) {
+	chat, _ := ctx.Value(chatKey{}).(string)
+	c.mu.Lock()
+	if c.inFlight < c.conf.Threshold || len([]rune(text)) > c.conf.MaxChars || chat == "" {
+		c.inFlight++
+		c.mu.Unlock()
+		defer c.release()
+		return s.summarize(ctx, systemPrompt, promptTemplate, text)
+	}
+	key := chat + "\x00" + systemPrompt + "\x00" + promptTemplate
+	b := c.pending[key]
+	if b == nil {
+		b = &summaryBatch{systemPrompt: systemPrompt, promptTemplate: promptTemplate}
+		c.pending[key] = b
+		time.AfterFunc(c.conf.Wait, func() { c.flush(s, key, b) })
+	}
+	item := &batchItem{ctx: ctx, text: text, done: make(chan batchResult, 1)}
+	b.items = append(b.items, item)
+	full := len(b.items) >= c.conf.MaxBatch
+	c.mu.Unlock()
+	if full {
+		c.flush(s, key, b)
+	}
+	select {
+	case res := <-item.done:
+		return res.summary, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (c *coalescer) release() {
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+}
+
+// flush отправляет пакет, если его еще не отправили (по таймеру или по заполнению)
+func (c *coalescer) flush(s *Service, key string, b *summaryBatch) {
+	c.mu.Lock()
+	if c.pending[key] != b {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.pending, key)
+	c.inFlight++
+	c.mu.Unlock()
+	go func() {
+		defer c.release()
+		c.run(s, b)
+	}()
+}
+
+func (c *coalescer) run(s *Service, b *summaryBatch) {
+	if len(b.items) == 1 {
+		item := b.items[0]
+		summary, err := s.summarize(item.ctx, b.systemPrompt, b.promptTemplate, item.text)
+		item.done <- batchResult{summary, err}
+		return
+	}
+	log.Printf("Объединение %d суммирований в один запрос", len(b.items))
+	ctx, cancel := context.WithTimeout(context.Background(), batchTimeout)
+	defer cancel()
+	ctx = WithUsage(ctx)
+	marker := batchMarker()
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(b.systemPrompt)}},
+		{Role: "model", Parts: []*genai.Part{genai.NewPartFromText("Понял, буду следовать указанным правилам форматирования и структуры.")}},
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(batchPrompt(b, marker))}},
+	}
+	answer, err := s.generateWithRetry(ctx, contents)
+	var summaries map[int]string
+	if err == nil {
+		summaries, err = parseBatchAnswer(answer, marker, len(b.items))
+	}
+	// Расход пакетного запроса делится поровну между всеми текстами пакета
+	usage := UsageFrom(ctx)
+	for i, item := range b.items {
+		addUsageShare(item.ctx, usage, len(b.items))
+		if summary, ok := summaries[i+1]; ok {
+			item.done <- batchResult{summary: summary}
+			continue
+		}
+		// Ответ для текста не найден или пакетный запрос не удался - суммируем текст отдельно
+		go func(item *batchItem) {
+			summary, err := s.summarize(item.ctx, b.systemPrompt, b.promptTemplate, item.text)
+			item.done <- batchResult{summary, err}
+		}(item)
+	}
+	if err != nil {
+		log.Printf("Ошибка объединенного суммирования, тексты суммируются по отдельности: %v", err)
+	}
+}
+
+// batchMarker возвращает случайную метку разделителей пакета: текст записи не может заранее
+// подделать разделитель, не зная метки
+func batchMarker() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// batchPrompt собирает один запрос из нескольких текстов с общим заданием и пронумерованными разделителями с меткой marker
+func batchPrompt(b *summaryBatch, marker string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Ниже %d независимых текстов. Выполни для каждого из них отдельно одно и то же задание, не смешивая тексты между собой.\n", len(b.items))
+	fmt.Fprintf(&sb, "Задание: %s\n", fmt.Sprintf(b.promptTemplate, "(текст приведен ниже)"))
+	fmt.Fprintf(&sb, "Ответ для каждого текста начни отдельной строкой вида \"=== ОТВЕТ %s N ===\", где N - номер текста, и не добавляй ничего вне ответов.\n", marker)
+	for i, item := range b.items {
+		fmt.Fprintf(&sb, "\n=== ТЕКСТ %s %d ===\n%s\n", marker, i+1, item.text)
+	}
+	return sb.String()
+}
+
+// parseBatchAnswer разбирает ответ на пакетный запрос из count текстов по разделителям "=== ОТВЕТ <marker> N ===".
+// Повторный разделитель, номер вне пакета или метка в самом тексте ответа означают, что разделители
+// подделаны (например, текстом записи) или модель ошиблась: такой ответ отбрасывается целиком.
+func parseBatchAnswer(answer, marker string, count int) (map[int]string, error) {
+	re := regexp.MustCompile(`(?m)^\s*=+\s*ОТВЕТ\s+` + regexp.QuoteMeta(marker) + `\s+(\d+)\s*=+\s*$`)
+	summaries := make(map[int]string)
+	matches := re.FindAllStringSubmatchIndex(answer, -1)
+	if len(matches) > count {
+		return nil, fmt.Errorf("в пакетном ответе %d разделителей на %d текстов", len(matches), count)
+	}
+	for i, m := range matches {
+		n, err := strconv.Atoi(answer[m[2]:m[3]])
+		if err != nil || n < 1 || n > count {
+			return nil, fmt.Errorf("в пакетном ответе лишний разделитель %q", answer[m[0]:m[1]])
+		}
+		if _, dup := summaries[n]; dup {
+			return nil, fmt.Errorf("в пакетном ответе повторяется ответ %d", n)
+		}
+		end := len(answer)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		summary := strings.TrimSpace(answer[m[1]:end])
+		if strings.Contains(summary, marker) {
+			return nil, fmt.Errorf("в пакетном ответе %d встречается метка разделителя", n)
+		}
+		summaries[n] = summary
+	}
+	for n, summary := range summaries {
+		if summary == "" {
+			delete(summaries, n)
+		}
+	}
+	return summaries, nil
+}
+
+// addUsageShare записывает в контекст долю 1/parts расхода пакетного запроса
+func addUsageShare(ctx context.Context, u Usage, parts int) {
+	t, ok := ctx.Value(usageKey{}).(*usageTracker)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.PromptTokens += u.PromptTokens / parts
+	t.usage.CandidatesTokens += u.CandidatesTokens / parts
+	t.usage.TotalTokens += u.TotalTokens / parts
+	for _, model := range u.Models {
+		found := false
+		for _, m := range t.usage.Models {
+			found = found || m == model
+		}
+		if !found {
+			t.usage.Models = append(t.usage.Models, model)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type glossaryKey struct{}
+
+type namesKey struct{}
+
+type transcribeHintKey struct{}
+
+// WithTranscribeHint возвращает контекст, в котором к промпту транскрипции добавляется hint
+// (например, указания для технических встреч); пустой hint ничего не меняет
+func WithTranscribeHint(ctx context.Context, hint string) context.Context {
+	if hint == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, transcribeHintKey{}, hint)
+}
+
+// transcribeInstruction возвращает добавки к промпту транскрипции из ctx: подсказку и словарь
+func transcribeInstruction(ctx context.Context) string {
+	hint, _ := ctx.Value(transcribeHintKey{}).(string)
+	return strings.TrimSpace(hint + "\n\n" + glossaryInstruction(ctx))
+}
+
+// WithGlossary возвращает контекст, в котором транскрипция и суммирование просят модель писать
+// термины так, как указано в glossary (как слышится → как писать)
+func WithGlossary(ctx context.Context, glossary map[string]string) context.Context {
+	if len(glossary) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, glossaryKey{}, glossary)
+}
+
+// WithNames возвращает контекст, в котором транскрипция и суммирование подсказывают модели имена
+// людей, которые часто встречаются в чате, чтобы она узнавала и правильно писала их
+func WithNames(ctx context.Context, names []string) context.Context {
+	if len(names) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, namesKey{}, names)
+}
+
+// glossaryInstruction возвращает добавку к промпту со словарем терминов и именами участников из ctx
+// или пустую строку
+func glossaryInstruction(ctx context.Context) string {
+	var parts []string
+	if names, _ := ctx.Value(namesKey{}).([]string); len(names) > 0 {
+		parts = append(parts, "В записи могут упоминаться участники чата, пишите их имена так: "+strings.Join(names, ", ")+".")
+	}
+	if terms := termsInstruction(ctx); terms != "" {
+		parts = append(parts, terms)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func termsInstruction(ctx context.Context) string {
+	glossary, _ := ctx.Value(glossaryKey{}).(map[string]string)
+	if len(glossary) == 0 {
+		return ""
+	}
+	terms := make([]string, 0, len(glossary))
+	for from := range glossary {
+		terms = append(terms, from)
+	}
+	sort.Strings(terms)
+	var b strings.Builder
+	b.WriteString("Пишите названия и термины так, как указано в словаре (слева - как может звучать или быть записано, справа - правильное написание):")
+	for _, from := range terms {
+		b.WriteString("\n" + from + " = " + glossary[from])
+	}
+	return b.String()
+}
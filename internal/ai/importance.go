@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"google.golang.org/genai"
+)
+
+var scoreRe = regexp.MustCompile(`\d+`)
+
+// ImportanceScore оценивает по шаблону промпта promptTemplate (с %s на месте текста), насколько важен текст:
+// от 0 (обычный разговор) до 10 (решения, договоренности, сроки). Из ответа берется первое число.
+func (s *Service) ImportanceScore(ctx context.Context, text, promptTemplate string) (int, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(fmt.Sprintf(promptTemplate, text))}},
+	}
+	answer, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return 0, err
+	}
+	score, err := strconv.Atoi(scoreRe.FindString(answer))
+	if err != nil {
+		return 0, fmt.Errorf("модель не вернула оценку важности: %q", answer)
+	}
+	return min(score, 10), nil
+}
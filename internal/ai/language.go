@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+)
+
+var reLanguageLine = regexp.MustCompile(`(?i)^\s*lang(?:uage)?\s*:\s*([a-z]{2,3})\s*$`)
+
+// TranscribeDetect транскрибирует аудио на языке оригинала и определяет этот язык.
+// Код языка (ISO 639-1) пустой, если модель его не указала.
+func (s *Service) TranscribeDetect(ctx context.Context, filePath string, readFile func(string) ([]byte, error)) (transcript, language string, err error) {
+	audioData, err := readFile(filePath)
+	if err != nil {
+		return "", "", fmt.Errorf("не удалось прочитать аудиофайл: %w", err)
+	}
+	instruction := "Пожалуйста, транскрибируйте этот аудио файл в текст на том языке, на котором говорят в записи, без перевода. " +
+		"В первой строке ответа укажите код языка записи по ISO 639-1 в виде \"lang: xx\", со второй строки верните только текст транскрипции без дополнительных комментариев."
+	if s.conf.FlagUnclear {
+		instruction += " " + unclearInstruction
+	}
+	if s.conf.Timestamps {
+		instruction += " " + timestampInstruction
+	}
+	if extra := transcribeInstruction(ctx); extra != "" {
+		instruction += "\n\n" + extra
+	}
+	prompt := genai.NewPartFromText(instruction)
+	audioPart := genai.NewPartFromBytes(audioData, media.AudioMIME(filePath))
+	contents := []*genai.Content{{Parts: []*genai.Part{prompt, audioPart}}}
+	text, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return "", "", err
+	}
+	transcript, language = splitLanguageLine(text)
+	return transcript, language, nil
+}
+
+func splitLanguageLine(text string) (string, string) {
+	first, rest, _ := strings.Cut(strings.TrimLeft(text, "\n"), "\n")
+	m := reLanguageLine.FindStringSubmatch(first)
+	if m == nil {
+		return strings.TrimSpace(text), ""
+	}
+	return strings.TrimSpace(rest), strings.ToLower(m[1])
+}
+
+// LanguageProfile - промпты резюме на одном языке; пустое поле - промпт по умолчанию
+type LanguageProfile struct {
+	System string
+	User   string
+}
+
+// SummarizeTextIn суммирует текст, как SummarizeText, но просит написать ответ на языке language
+// (код ISO 639-1) независимо от языка исходного текста; пустой language - без указания языка.
+// Если для языка задан профиль, вместо промптов по умолчанию используются промпты профиля:
+// системный - если в ctx нет промпта персоны, шаблон - если передан обычный шаблон резюме.
+func (s *Service) SummarizeTextIn(ctx context.Context, textToSummarize, promptTemplate, language string) (string, error) {
+	if language == "" {
+		return s.SummarizeText(ctx, textToSummarize, promptTemplate)
+	}
+	if profile, ok := s.conf.LanguageProfiles[language]; ok {
+		if _, custom := ctx.Value(systemPromptKey{}).(string); !custom {
+			ctx = WithSystemPrompt(ctx, profile.System)
+		}
+		if profile.User != "" && promptTemplate == s.Prompts().User {
+			return s.SummarizeText(ctx, textToSummarize, profile.User)
+		}
+	}
+	instruction := fmt.Sprintf("\n\nНапишите ответ на языке %s (код ISO 639-1 %q), даже если исходный текст на другом языке: при необходимости переведите его. Это указание важнее требования о языке ответа из системных инструкций.",
+		LanguageName(language), language)
+	return s.SummarizeText(ctx, textToSummarize, promptTemplate+strings.ReplaceAll(instruction, "%", "%%"))
+}
+
+var languageNames = map[string]string{
+	"ru": "русский", "en": "английский", "uk": "украинский", "be": "белорусский", "kk": "казахский",
+	"de": "немецкий", "fr": "французский", "es": "испанский", "it": "итальянский", "pt": "португальский",
+	"pl": "польский", "tr": "турецкий", "zh": "китайский", "ja": "японский", "ko": "корейский", "ar": "арабский",
+}
+
+// LanguageName возвращает русское название языка по коду ISO 639-1 или сам код, если название неизвестно
+func LanguageName(code string) string {
+	if name, ok := languageNames[strings.ToLower(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// LanguageCode находит код языка по коду или русскому названию в любом падеже ("английский",
+// "на английском"); false, если язык не распознан
+func LanguageCode(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := languageNames[name]; ok {
+		return name, true
+	}
+	for code, full := range languageNames {
+		// отбрасываем окончание прилагательного: "английский" -> "английск"
+		stem := string([]rune(full)[:len([]rune(full))-2])
+		if strings.HasPrefix(name, stem) {
+			return code, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,58 @@
+package ai
+
+import "context"
+
+type chatKey struct{}
+
+// WithChat возвращает контекст запросов для чата platform/chatID: по нему Service выбирает модель,
+// закрепленную за чатом (SetChatModel)
+func WithChat(ctx context.Context, platform, chatID string) context.Context {
+	return context.WithValue(ctx, chatKey{}, ChatModelKey(platform, chatID))
+}
+
+// ChatModelKey - ключ чата в SetChatModels
+func ChatModelKey(platform, chatID string) string { return platform + ":" + chatID }
+
+// SetChatModels заменяет все закрепления моделей за чатами; ключ - ChatModelKey
+func (s *Service) SetChatModels(models map[string]string) {
+	s.modelsMu.Lock()
+	defer s.modelsMu.Unlock()
+	s.chatModels = make(map[string]string, len(models))
+	for chat, model := range models {
+		s.chatModels[chat] = model
+	}
+}
+
+// SetChatModel закрепляет за чатом модель; пустая model возвращает основную модель из Config
+func (s *Service) SetChatModel(platform, chatID, model string) {
+	s.modelsMu.Lock()
+	defer s.modelsMu.Unlock()
+	if model == "" {
+		delete(s.chatModels, ChatModelKey(platform, chatID))
+		return
+	}
+	if s.chatModels == nil {
+		s.chatModels = make(map[string]string)
+	}
+	s.chatModels[ChatModelKey(platform, chatID)] = model
+}
+
+// chatModel возвращает модель, закрепленную за чатом из ctx, или пустую строку
+func (s *Service) chatModel(ctx context.Context) string {
+	chat, ok := ctx.Value(chatKey{}).(string)
+	if !ok {
+		return ""
+	}
+	s.modelsMu.RLock()
+	defer s.modelsMu.RUnlock()
+	return s.chatModels[chat]
+}
+
+// primaryModel возвращает основную модель для запроса: закрепленную за чатом или из Config.
+// Резервная модель общая для всех чатов.
+func (s *Service) primaryModel(ctx context.Context) string {
+	if model := s.chatModel(ctx); model != "" {
+		return model
+	}
+	return s.conf.PrimaryModel
+}
@@ -0,0 +1,62 @@
+package ai
+
+import "context"
+
+type systemPromptKey struct{}
+
+// WithSystemPrompt возвращает контекст, в котором суммирование использует prompt вместо системного
+// промпта из Config (например, промпт выбранной в чате персоны); пустой prompt ничего не меняет
+func WithSystemPrompt(ctx context.Context, prompt string) context.Context {
+	if prompt == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, systemPromptKey{}, prompt)
+}
+
+func (s *Service) systemPrompt(ctx context.Context) string {
+	if prompt, ok := ctx.Value(systemPromptKey{}).(string); ok {
+		return prompt
+	}
+	return s.Prompts().System
+}
+
+// Prompts - промпты, заданные во время работы поверх Config; пустое поле - промпт из Config
+type Prompts struct {
+	System string
+	User   string
+	Short  string
+}
+
+// SetPrompts заменяет промпты, заданные во время работы (команда /admin prompts)
+func (s *Service) SetPrompts(p Prompts) {
+	s.promptsMu.Lock()
+	defer s.promptsMu.Unlock()
+	s.prompts = p
+}
+
+// Prompts возвращает действующие промпты: заданные во время работы или из Config
+func (s *Service) Prompts() Prompts {
+	s.promptsMu.RLock()
+	defer s.promptsMu.RUnlock()
+	p := s.prompts
+	if p.System == "" {
+		p.System = s.conf.SystemPrompt
+	}
+	if p.User == "" {
+		p.User = s.conf.UserPromptTemplate
+	}
+	if p.Short == "" {
+		p.Short = s.conf.ShortPromptTemplate
+	}
+	return p
+}
+
+// UserPromptTemplate возвращает шаблон резюме, заданный во время работы, или def, если он не задан
+func (s *Service) UserPromptTemplate(def string) string {
+	s.promptsMu.RLock()
+	defer s.promptsMu.RUnlock()
+	if s.prompts.User != "" {
+		return s.prompts.User
+	}
+	return def
+}
@@ -0,0 +1,25 @@
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const punctuatePrompt = `Расставьте в тексте расшифровки ниже знаки препинания и заглавные буквы и разбейте его на абзацы по смыслу, разделяя абзацы пустой строкой. Слова не меняйте, не добавляйте и не удаляйте, метки вида [неразборчиво ММ:СС] и метки времени [ММ:СС] в начале абзацев оставьте как есть. Верните только текст, без комментариев.
+
+Текст:
+`
+
+// PunctuateTranscript просит модель восстановить в расшифровке пунктуацию, заглавные буквы и абзацы
+func (s *Service) PunctuateTranscript(ctx context.Context, text string) (string, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(punctuatePrompt + text)}},
+	}
+	punctuated, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(punctuated), nil
+}
@@ -0,0 +1,35 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// ExtractQuotes выбирает из текста ключевые дословные цитаты по шаблону промпта promptTemplate (с %s на месте текста).
+// Цитаты, которых нет в тексте дословно, отбрасываются: модель иногда пересказывает вместо цитирования.
+func (s *Service) ExtractQuotes(ctx context.Context, text, promptTemplate string) ([]string, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(fmt.Sprintf(promptTemplate, text))}},
+	}
+	answer, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+	var quotes []string
+	for _, q := range parseActionItems(answer) {
+		q = strings.Trim(q, "\"«»“”„ ")
+		if q != "" && containsVerbatim(text, q) {
+			quotes = append(quotes, q)
+		}
+	}
+	return quotes, nil
+}
+
+// containsVerbatim проверяет, что quote встречается в text с точностью до регистра и пробелов
+func containsVerbatim(text, quote string) bool {
+	normalize := func(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+	return strings.Contains(normalize(text), normalize(strings.TrimRight(quote, ".…")))
+}
@@ -0,0 +1,26 @@
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const redactPrompt = `Скройте в тексте ниже персональные данные: полные имена и фамилии людей замените на [имя], почтовые адреса и адреса проживания - на [адрес], номера телефонов - на [телефон], номера банковских карт и счетов - на [карта], в том числе если они произнесены словами. Названия компаний, городов без точного адреса и имена без фамилии оставьте. Остальной текст верните дословно, без комментариев.
+
+Текст:
+`
+
+// RedactPII просит модель скрыть в тексте имена, адреса и номера, которые не находят регулярные выражения
+// (format.RedactPII): например, продиктованные словами
+func (s *Service) RedactPII(ctx context.Context, text string) (string, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(redactPrompt + text)}},
+	}
+	redacted, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(redacted), nil
+}
@@ -0,0 +1,37 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+)
+
+const refineInstruction = "Ниже - черновая транскрипция этой аудиозаписи. Прослушайте запись еще раз и исправьте ошибки распознавания: " +
+	"неверно услышанные слова, имена, названия, термины, числа, суммы и даты. Не пересказывайте, не сокращайте и не переводите текст, " +
+	"сохраните язык записи и метки вида [неразборчиво ММ:СС], если фрагмент по-прежнему не удается разобрать, и метки времени [ММ:СС] в начале абзацев. " +
+	"Верните только исправленный текст транскрипции без комментариев."
+
+// RefineTranscript отправляет модели запись вместе с черновой транскрипцией и просит исправить ошибки
+// распознавания. Второй проход удваивает стоимость распознавания, поэтому включается настройкой.
+func (s *Service) RefineTranscript(ctx context.Context, filePath string, readFile func(string) ([]byte, error), transcript string) (string, error) {
+	audioData, err := readFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("не удалось прочитать аудиофайл: %w", err)
+	}
+	instruction := refineInstruction
+	if extra := transcribeInstruction(ctx); extra != "" {
+		instruction += "\n\n" + extra
+	}
+	prompt := genai.NewPartFromText(instruction + "\n\nЧерновая транскрипция:\n" + transcript)
+	audioPart := genai.NewPartFromBytes(audioData, media.AudioMIME(filePath))
+	contents := []*genai.Content{{Parts: []*genai.Part{prompt, audioPart}}}
+	refined, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(refined), nil
+}
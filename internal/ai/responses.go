@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+)
+
+// responseCache - кэш готовых суммирований: повторное "кратко" или тот же стиль для той же расшифровки
+// возвращается сразу, без обращения к модели
+type responseCache struct {
+	c   cache.Cache
+	ttl time.Duration
+}
+
+// EnableResponseCache включает кэширование суммирований в c на срок ttl; ttl <= 0 - выключено
+func (s *Service) EnableResponseCache(c cache.Cache, ttl time.Duration) {
+	if c == nil || ttl <= 0 {
+		return
+	}
+	s.responses = &responseCache{c: c, ttl: ttl}
+}
+
+// responseKey - ключ кэша для суммирования: хеш модели, системного промпта (персона, глоссарий, вариант
+// эксперимента), шаблона (стиль, язык ответа и его текущая версия) и текста. Изменение любого из них,
+// например нового шаблона через /admin prompts, дает новый ключ.
+func (s *Service) responseKey(ctx context.Context, systemPrompt, promptTemplate, text string) string {
+	h := sha256.New()
+	for _, part := range []string{s.primaryModel(ctx), systemPrompt, promptTemplate, text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return cache.SummaryResponseKey(hex.EncodeToString(h.Sum(nil)))
+}
+
+func (r *responseCache) get(ctx context.Context, key string) (string, bool) {
+	summary, found, err := r.c.Get(ctx, key)
+	if err != nil {
+		log.Printf("Ошибка чтения кэша резюме: %v", err)
+		return "", false
+	}
+	if found {
+		slog.Debug("Резюме взято из кэша", "key", key)
+	}
+	return summary, found
+}
+
+func (r *responseCache) set(ctx context.Context, key, summary string) {
+	if err := r.c.Set(ctx, key, summary, r.ttl); err != nil {
+		log.Printf("Ошибка записи резюме в кэш: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+type summaryStreamKey struct{}
+
+// SummaryStream получает резюме по мере генерации: delta - очередной фрагмент текста. restart
+// означает, что генерация началась заново, и уже полученные фрагменты нужно отбросить.
+type SummaryStream func(delta string, restart bool)
+
+// WithSummaryStream возвращает контекст, в котором резюме генерируется потоком и передается в onDelta.
+// Резюме из кэша и объединенных запросов приходят только целиком, через результат SummarizeText.
+func WithSummaryStream(ctx context.Context, onDelta SummaryStream) context.Context {
+	return context.WithValue(ctx, summaryStreamKey{}, onDelta)
+}
+
+// generateStream генерирует ответ основной моделью потоком, передавая фрагменты в onDelta. Если поток
+// оборвался или ответ пуст, ответ генерируется заново обычным generateWithRetry (с повторами и
+// резервной моделью), а onDelta получает restart.
+func (s *Service) generateStream(ctx context.Context, contents []*genai.Content, onDelta SummaryStream) (string, error) {
+	model := s.primaryModel(ctx)
+	var sb strings.Builder
+	var usage *genai.GenerateContentResponseUsageMetadata
+	var streamErr error
+	for resp, err := range s.client.Models.GenerateContentStream(ctx, model, contents, nil) {
+		if err != nil {
+			streamErr = err
+			break
+		}
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+		if txt := resp.Text(); txt != "" {
+			sb.WriteString(txt)
+			onDelta(txt, false)
+		}
+	}
+	recordUsage(ctx, model, usage)
+	if streamErr == nil && sb.Len() > 0 {
+		return sb.String(), nil
+	}
+	if streamErr != nil {
+		log.Printf("Ошибка потоковой генерации резюме, повтор без потока: %v", streamErr)
+	}
+	if sb.Len() > 0 {
+		onDelta("", true)
+	}
+	return s.generateWithRetry(ctx, contents)
+}
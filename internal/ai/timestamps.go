@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const timestampInstruction = "Разбейте транскрипцию на абзацы по смыслу и начинайте каждый абзац с новой строки с метки времени его начала от начала записи в виде [ММ:СС], например [04:10]."
+
+const linkSummaryPrompt = `Ниже - расшифровка записи, абзацы которой начинаются с меток времени [ММ:СС], и резюме этой записи. Для каждого главного пункта резюме найдите абзац расшифровки, на котором он основан. Ответьте только JSON-массивом без пояснений и разметки: [{"point": "короткая формулировка пункта, 2-6 слов", "time": "метка абзаца без скобок, например 04:10"}]. Берите метки только из расшифровки; пункты, которых в расшифровке нет, пропустите.
+
+Расшифровка:
+%s
+
+Резюме:
+%s`
+
+// SummaryLink связывает пункт резюме с фрагментом расшифровки, начинающимся в момент Time
+type SummaryLink struct {
+	Point string `json:"point"`
+	Time  string `json:"time"`
+}
+
+// LinkSummary просит модель указать для пунктов резюме метки времени фрагментов расшифровки,
+// на которых они основаны. Метки, которых нет среди stamps (меток абзацев расшифровки), отбрасываются.
+func (s *Service) LinkSummary(ctx context.Context, transcript, summary string, stamps []string) ([]SummaryLink, error) {
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(fmt.Sprintf(linkSummaryPrompt, transcript, summary))}},
+	}
+	answer, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+	var links []SummaryLink
+	if err := parseJSONAnswer(answer, &links); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ответ со ссылками на расшифровку: %w", err)
+	}
+	known := stampSet(stamps)
+	valid := links[:0]
+	for _, l := range links {
+		l.Point, l.Time = strings.TrimSpace(l.Point), strings.Trim(l.Time, "[] ")
+		if l.Point != "" && known[l.Time] {
+			valid = append(valid, l)
+		}
+	}
+	return valid, nil
+}
+
+// parseJSONAnswer разбирает JSON из ответа модели, убирая обрамление ```json ... ```
+func parseJSONAnswer(answer string, v any) error {
+	answer = strings.TrimSpace(answer)
+	answer = strings.TrimPrefix(strings.TrimPrefix(answer, "```json"), "```")
+	answer = strings.TrimSuffix(strings.TrimSpace(answer), "```")
+	return json.Unmarshal([]byte(answer), v)
+}
+
+func stampSet(stamps []string) map[string]bool {
+	known := make(map[string]bool, len(stamps))
+	for _, stamp := range stamps {
+		known[stamp] = true
+	}
+	return known
+}
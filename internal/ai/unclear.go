@@ -0,0 +1,7 @@
+package ai
+
+// unclearInstruction добавляется к промпту транскрипции: у Gemini нет оценок уверенности по словам,
+// поэтому модель просят саму отмечать места, где она не уверена, в формате, который понимает format.HighlightUnclear
+const unclearInstruction = "Не угадывайте слова, которые не удается разобрать (шум, перебивание, невнятная речь): " +
+	"вместо такого слова или фрагмента вставьте метку [неразборчиво ММ:СС], где ММ:СС - время начала фрагмента от начала записи, например [неразборчиво 02:13]. " +
+	"Если запись разборчива полностью, меток не ставьте."
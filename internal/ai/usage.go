@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// Usage - суммарный расход токенов и список использованных моделей
+type Usage struct {
+	PromptTokens     int
+	CandidatesTokens int
+	TotalTokens      int
+	Models           []string
+}
+
+type usageKey struct{}
+
+type usageTracker struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// WithUsage возвращает контекст, в котором Service накапливает расход токенов по всем запросам
+func WithUsage(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(usageKey{}).(*usageTracker); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, usageKey{}, &usageTracker{})
+}
+
+// UsageFrom возвращает накопленный расход для контекста, созданного WithUsage
+func UsageFrom(ctx context.Context) Usage {
+	t, ok := ctx.Value(usageKey{}).(*usageTracker)
+	if !ok {
+		return Usage{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.usage
+	u.Models = append([]string(nil), t.usage.Models...)
+	return u
+}
+
+func recordUsage(ctx context.Context, model string, md *genai.GenerateContentResponseUsageMetadata) {
+	t, ok := ctx.Value(usageKey{}).(*usageTracker)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if md != nil {
+		t.usage.PromptTokens += int(md.PromptTokenCount)
+		t.usage.CandidatesTokens += int(md.CandidatesTokenCount)
+		t.usage.TotalTokens += int(md.TotalTokenCount)
+	}
+	for _, m := range t.usage.Models {
+		if m == model {
+			return
+		}
+	}
+	t.usage.Models = append(t.usage.Models, model)
+}
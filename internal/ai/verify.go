@@ -0,0 +1,22 @@
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// VerifySummary просит модель сверить резюме с расшифровкой и исправить или пометить утверждения,
+// которых в записи нет. Возвращает исправленное резюме.
+func (s *Service) VerifySummary(ctx context.Context, prompt, transcript, summary string) (string, error) {
+	text := prompt + "\n\nРасшифровка:\n" + transcript + "\n\nРезюме:\n" + summary
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(text)}},
+	}
+	verified, err := s.generateWithRetry(ctx, contents)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(verified), nil
+}
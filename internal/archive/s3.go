@@ -0,0 +1,253 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+)
+
+type Config struct {
+	Endpoint      string
+	AccessKey     string
+	SecretKey     string
+	Bucket        string
+	Region        string
+	UseSSL        bool
+	Prefix        string
+	RetentionDays int
+}
+
+// S3 архивирует исходные и сконвертированные аудиофайлы в S3-совместимое хранилище (AWS S3, MinIO).
+// Используется path-style адресация и подпись AWS Signature V4.
+type S3 struct {
+	conf Config
+	http *http.Client
+}
+
+// NewS3 проверяет доступ к хранилищу, создает бакет при необходимости и применяет правило хранения
+func NewS3(ctx context.Context, conf Config, httpClient *http.Client) (*S3, error) {
+	if conf.Region == "" {
+		conf.Region = "us-east-1"
+	}
+	a := &S3{conf: conf, http: httpClient}
+	exists, err := a.bucketExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := a.makeBucket(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if conf.RetentionDays > 0 {
+		if err := a.applyRetention(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func (a *S3) bucketExists(ctx context.Context) (bool, error) {
+	resp, err := a.do(ctx, http.MethodHead, "", "", nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить бакет %s: %w", a.conf.Bucket, err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("не удалось проверить бакет %s, статус: %s", a.conf.Bucket, resp.Status)
+}
+
+func (a *S3) makeBucket(ctx context.Context) error {
+	var body []byte
+	if a.conf.Region != "us-east-1" {
+		body = []byte(`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><LocationConstraint>` +
+			a.conf.Region + `</LocationConstraint></CreateBucketConfiguration>`)
+	}
+	return a.expectOK(a.do(ctx, http.MethodPut, "", "", body, nil))
+}
+
+// applyRetention задает lifecycle-правило, удаляющее архивные объекты под префиксом через RetentionDays дней
+func (a *S3) applyRetention(ctx context.Context) error {
+	body := []byte(fmt.Sprintf(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule>`+
+		`<ID>voice-shut-up-retention</ID><Status>Enabled</Status><Filter><Prefix>%s</Prefix></Filter>`+
+		`<Expiration><Days>%d</Days></Expiration></Rule></LifecycleConfiguration>`, a.conf.Prefix, a.conf.RetentionDays))
+	sum := md5.Sum(body)
+	headers := map[string]string{"Content-MD5": base64.StdEncoding.EncodeToString(sum[:]), "Content-Type": "application/xml"}
+	if err := a.expectOK(a.do(ctx, http.MethodPut, "", "lifecycle=", body, headers)); err != nil {
+		return fmt.Errorf("не удалось применить правило хранения к бакету %s: %w", a.conf.Bucket, err)
+	}
+	return nil
+}
+
+func (a *S3) objectKey(src pipeline.Source, suffix string) string {
+	messageID := strings.NewReplacer("/", "_", "$", "_").Replace(src.MessageID)
+	return fmt.Sprintf("%s%s/%s/%s/%s-%s", a.conf.Prefix, src.Platform, src.ChatID, time.Now().UTC().Format("2006/01/02"), messageID, suffix)
+}
+
+func (a *S3) upload(ctx context.Context, key, path, contentType string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать %s для архивирования: %w", path, err)
+	}
+	if err := a.expectOK(a.do(ctx, http.MethodPut, key, "", data, map[string]string{"Content-Type": contentType})); err != nil {
+		return fmt.Errorf("не удалось загрузить %s в архив: %w", key, err)
+	}
+	return nil
+}
+
+func (a *S3) Archive(ctx context.Context, src pipeline.Source, inputPath, audioPath string) (string, string, error) {
+	originalKey := a.objectKey(src, "original"+filepath.Ext(inputPath))
+	if err := a.upload(ctx, originalKey, inputPath, "application/octet-stream"); err != nil {
+		return "", "", err
+	}
+	audioKey := a.objectKey(src, "audio.mp3")
+	if media.PassThrough(audioPath) {
+		audioKey = a.objectKey(src, "audio"+filepath.Ext(audioPath))
+	}
+	if err := a.upload(ctx, audioKey, audioPath, media.AudioMIME(audioPath)); err != nil {
+		return originalKey, "", err
+	}
+	log.Printf("Файлы сообщения %s заархивированы: %s, %s", src.MessageID, originalKey, audioKey)
+	return originalKey, audioKey, nil
+}
+
+func (a *S3) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		resp, err := a.do(ctx, http.MethodDelete, key, "", nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// S3 отвечает 204 и для удаленных, и для отсутствующих объектов
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("не удалось удалить %s из архива, статус: %s", key, resp.Status)
+		}
+	}
+	return nil
+}
+
+func (a *S3) expectOK(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("статус %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// do выполняет подписанный запрос к бакету (key == "") или объекту
+func (a *S3) do(ctx context.Context, method, key, rawQuery string, body []byte, headers map[string]string) (*http.Response, error) {
+	scheme := "https"
+	if !a.conf.UseSSL {
+		scheme = "http"
+	}
+	path := "/" + a.conf.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+	canonicalURI := uriEncode(path)
+	url := scheme + "://" + a.conf.Endpoint + canonicalURI
+	if rawQuery != "" {
+		url += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса к S3: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	a.sign(req, canonicalURI, rawQuery, body, time.Now().UTC())
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к S3: %w", err)
+	}
+	return resp, nil
+}
+
+// sign добавляет заголовки AWS Signature V4
+func (a *S3) sign(req *http.Request, canonicalURI, rawQuery string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadSum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payloadSum[:])
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signed := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + signed[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, canonicalURI, rawQuery, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	scope := date + "/" + a.conf.Region + "/s3/aws4_request"
+	requestSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+a.conf.SecretKey), date)
+	key = hmacSHA256(key, a.conf.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.conf.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode кодирует путь по правилам SigV4: все, кроме unreserved-символов и '/'
+func uriEncode(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
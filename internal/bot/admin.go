@@ -0,0 +1,247 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// adminCommands - подкоманды /admin, доступные только администраторам бота (BOT_ADMIN_IDS)
+var adminCommands = map[string]commandHandler{
+	"prompts":    (*App).adminPrompts,
+	"broadcast":  (*App).adminBroadcast,
+	"model":      (*App).adminModel,
+	"audit":      (*App).adminAudit,
+	"budget":     (*App).adminBudget,
+	"experiment": (*App).adminExperiment,
+	"flags":      (*App).adminFlags,
+	"loglevel":   (*App).adminLogLevel,
+	"trace":      (*App).adminTrace,
+	"notes":      (*App).adminNotes,
+}
+
+const adminUsage = "Использование: /admin prompts show|set|reset, /admin broadcast <текст>, /admin model <ID чата> <модель>|reset, /admin audit [N|csv], /admin budget [ID чата], /admin experiment, /admin flags, /admin loglevel, /admin trace, /admin notes <ID чата> <страница> или /admin maintenance on|off"
+
+// isBotAdmin сообщает, является ли автор сообщения администратором бота
+func (a *App) isBotAdmin(msg *telegram.Message) bool {
+	return msg.From != nil && slices.Contains(a.cfg.BotAdminIDs, msg.From.ID)
+}
+
+func (a *App) cmdAdmin(msg *telegram.Message, args string) {
+	if !a.isBotAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Команда доступна только администраторам бота.", msg.MessageID, "")
+		return
+	}
+	name, rest := cutWord(args)
+	handler, ok := adminCommands[strings.ToLower(name)]
+	if !ok {
+		_ = a.tele.SendMessage(msg.Chat.ID, adminUsage, msg.MessageID, "")
+		return
+	}
+	handler(a, msg, rest)
+}
+
+// cutWord отделяет первое слово s от остатка; переводы строк в остатке сохраняются
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// loadBotSettings применяет сохраненные глобальные настройки бота при запуске
+func (a *App) loadBotSettings() {
+	if a.botSettings == nil {
+		return
+	}
+	settings, err := a.botSettings.GetBotSettings(context.Background())
+	if err != nil {
+		log.Printf("Ошибка чтения настроек бота: %v", err)
+		return
+	}
+	a.ai.SetPrompts(promptsFromSettings(settings))
+	a.ai.SetChatModels(settings.ChatModels)
+	a.experiment.Store(settings.Experiment)
+	a.flags.SetOverrides(settings.Flags)
+	if settings.Maintenance {
+		log.Printf("Бот запущен в режиме обслуживания")
+		a.setMaintenance(true, settings.MaintenanceQueue)
+	}
+}
+
+func promptsFromSettings(s store.BotSettings) ai.Prompts {
+	return ai.Prompts{System: s.SystemPrompt, User: s.UserPromptTemplate, Short: s.ShortPromptTemplate}
+}
+
+const promptsUsage = "Использование:\n" +
+	"/admin prompts show [system|user|short] - показать действующие промпты\n" +
+	"/admin prompts set system|user|short <текст> - заменить промпт (в user и short текст подставляется вместо %s)\n" +
+	"/admin prompts reset [system|user|short] - вернуть промпт (или все) из конфигурации"
+
+var promptTitles = map[string]string{
+	"system": "Системный промпт",
+	"user":   "Шаблон резюме",
+	"short":  "Шаблон краткого резюме",
+}
+
+// promptField возвращает поле настроек бота для промпта name
+func promptField(s *store.BotSettings, name string) *string {
+	switch name {
+	case "system":
+		return &s.SystemPrompt
+	case "user":
+		return &s.UserPromptTemplate
+	case "short":
+		return &s.ShortPromptTemplate
+	}
+	return nil
+}
+
+func (a *App) adminPrompts(msg *telegram.Message, args string) {
+	if a.botSettings == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Хранилище настроек бота не настроено.", msg.MessageID, "")
+		return
+	}
+	ctx := context.Background()
+	action, rest := cutWord(args)
+	name, text := cutWord(rest)
+	name = strings.ToLower(name)
+	switch strings.ToLower(action) {
+	case "show", "":
+		a.showPrompts(msg, name)
+		return
+	case "set", "reset":
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, promptsUsage, msg.MessageID, "")
+		return
+	}
+
+	settings, err := a.botSettings.GetBotSettings(ctx)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек бота: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки бота.", msg.MessageID, "")
+		return
+	}
+	var reply string
+	if strings.ToLower(action) == "set" {
+		field := promptField(&settings, name)
+		if field == nil || text == "" {
+			_ = a.tele.SendMessage(msg.Chat.ID, promptsUsage, msg.MessageID, "")
+			return
+		}
+		if name != "system" && strings.Count(text, "%s") != 1 {
+			_ = a.tele.SendMessage(msg.Chat.ID, "В шаблоне должен быть ровно один %s - на его место подставляется текст записи.", msg.MessageID, "")
+			return
+		}
+		*field = text
+		reply = promptTitles[name] + " заменен."
+	} else {
+		if name == "" {
+			settings.SystemPrompt, settings.UserPromptTemplate, settings.ShortPromptTemplate = "", "", ""
+			reply = "Все промпты возвращены к значениям из конфигурации."
+		} else if field := promptField(&settings, name); field != nil {
+			*field = ""
+			reply = promptTitles[name] + " возвращен к значению из конфигурации."
+		} else {
+			_ = a.tele.SendMessage(msg.Chat.ID, promptsUsage, msg.MessageID, "")
+			return
+		}
+	}
+	if err := a.botSettings.SaveBotSettings(ctx, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек бота: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки бота.", msg.MessageID, "")
+		return
+	}
+	a.ai.SetPrompts(promptsFromSettings(settings))
+	log.Printf("Администратор %d изменил промпты: %s %s", msg.From.ID, action, name)
+	_ = a.tele.SendMessage(msg.Chat.ID, reply, msg.MessageID, "")
+}
+
+// showPrompts отправляет действующие промпты (или один промпт name) отдельными сообщениями
+func (a *App) showPrompts(msg *telegram.Message, name string) {
+	prompts := a.ai.Prompts()
+	values := map[string]string{"system": prompts.System, "user": prompts.User, "short": prompts.Short}
+	names := []string{"system", "user", "short"}
+	if name != "" {
+		if _, ok := values[name]; !ok {
+			_ = a.tele.SendMessage(msg.Chat.ID, promptsUsage, msg.MessageID, "")
+			return
+		}
+		names = []string{name}
+	}
+	for _, n := range names {
+		text := fmt.Sprintf("%s (%s):\n\n%s", promptTitles[n], n, values[n])
+		if runes := []rune(text); len(runes) > a.cfg.MaxMessageLength {
+			text = string(runes[:a.cfg.MaxMessageLength-1]) + "…"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, text, msg.MessageID, "")
+	}
+}
+
+const modelUsage = "Использование:\n" +
+	"/admin model - список чатов с закрепленными моделями\n" +
+	"/admin model <ID чата> <модель> - закрепить за чатом модель (например, gemini-2.5-pro)\n" +
+	"/admin model <ID чата> reset - вернуть чату основную модель"
+
+func (a *App) adminModel(msg *telegram.Message, args string) {
+	if a.botSettings == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Хранилище настроек бота не настроено.", msg.MessageID, "")
+		return
+	}
+	ctx := context.Background()
+	settings, err := a.botSettings.GetBotSettings(ctx)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек бота: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки бота.", msg.MessageID, "")
+		return
+	}
+	chat, model := cutWord(args)
+	if chat == "" {
+		if len(settings.ChatModels) == 0 {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Закрепленных моделей нет: все чаты используют основную модель.\n\n"+modelUsage, msg.MessageID, "")
+			return
+		}
+		var b strings.Builder
+		b.WriteString("Закрепленные модели:\n")
+		for _, key := range slices.Sorted(maps.Keys(settings.ChatModels)) {
+			fmt.Fprintf(&b, "%s - %s\n", strings.TrimPrefix(key, "telegram:"), settings.ChatModels[key])
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, b.String(), msg.MessageID, "")
+		return
+	}
+	if _, err := strconv.ParseInt(chat, 10, 64); err != nil || model == "" || strings.ContainsFunc(model, unicode.IsSpace) {
+		_ = a.tele.SendMessage(msg.Chat.ID, modelUsage, msg.MessageID, "")
+		return
+	}
+	key := ai.ChatModelKey("telegram", chat)
+	var reply string
+	if strings.EqualFold(model, "reset") {
+		delete(settings.ChatModels, key)
+		model = ""
+		reply = fmt.Sprintf("Чат %s снова использует основную модель.", chat)
+	} else {
+		if settings.ChatModels == nil {
+			settings.ChatModels = make(map[string]string)
+		}
+		settings.ChatModels[key] = model
+		reply = fmt.Sprintf("За чатом %s закреплена модель %s.", chat, model)
+	}
+	if err := a.botSettings.SaveBotSettings(ctx, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек бота: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки бота.", msg.MessageID, "")
+		return
+	}
+	a.ai.SetChatModel("telegram", chat, model)
+	log.Printf("Администратор %d закрепил за чатом %s модель %q", msg.From.ID, chat, model)
+	_ = a.tele.SendMessage(msg.Chat.ID, reply, msg.MessageID, "")
+}
@@ -2,17 +2,29 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"log"
+	"math/rand/v2"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/flags"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/leader"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/queue"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
 )
 
@@ -21,72 +33,204 @@ type App struct {
 	tele       *telegram.Client
 	ai         *ai.Service
 	media      *media.Processor
-	cache      map[int]string
-}
+	pipe       *pipeline.Pipeline
+	records    store.TranscriptStore
+	settings   store.SettingsStore
+	quotas     store.QuotaStore
+	audit      store.AuditLog
+	reminders  store.ReminderStore
+	cache      cache.Cache
+	layout     *format.Layout
 
-func NewApp(cfg config.Config, tele *telegram.Client, aiSvc *ai.Service, mediaProc *media.Processor) *App {
-	return &App{cfg: cfg, tele: tele, ai: aiSvc, media: mediaProc, cache: make(map[int]string)}
+	// Места для одновременно обрабатываемых обновлений; nil - без ограничения
+	updateSlots chan struct{}
+	// Одновременные одинаковые запросы: обработка одного файла и "кратко" для одного сообщения
+	mediaFlights flightGroup[*pipeline.Result]
+	shortFlights flightGroup[string]
+	// Очередь заданий с приоритетами; nil - задания не ограничиваются
+	scheduler *jobScheduler
+	// Глобальные настройки бота (команда /admin)
+	botSettings store.BotSettingsStore
+	// Идет рассылка объявления (/admin broadcast)
+	broadcasting atomic.Bool
+	// Режим обслуживания (/admin maintenance)
+	maintenance maintenanceState
+	// Отправленные администраторам уведомления о лимитах расходов
+	budgetAlerts budgetAlerts
+	// Идущий A/B-эксперимент с промптами (/admin experiment); nil - экспериментов нет
+	experiment atomic.Pointer[store.Experiment]
+	// Флаги функций (/admin flags)
+	flags *flags.Set
+	// Временные трассировки чатов (/admin trace)
+	traces chatTraces
+	// Имена участников чатов для подсказок распознаванию
+	participants chatParticipants
+	// Языки входящих записей и сообщений по чатам для автоматического выбора языка интерфейса
+	chatLanguages chatLanguages
+	// Лимит заданий пользователей (USER_RATE_LIMIT) и подписки на уведомления о готовности
+	rates    userRates
+	watchers readyWatchers
+	// Неудавшиеся задания, которые можно повторить ("повтори" или кнопка "Повторить")
+	failed failedJobs
+	// Незавершенные задания, которые возобновляются после перезапуска; nil - не сохраняются
+	jobs    store.JobStore
+	resumed resumedJobs
+	// Задания, которые обрабатывает эта реплика (replicaID): их UpdatedAt продлевается, пока они идут
+	replicaID string
+	running   runningJobs
+	// Часовой пояс по умолчанию (TIMEZONE) для чатов без своего
+	location *time.Location
+	// Подписчики на ход обработки (/api/events веб-панели)
+	events eventHub
+	// Цепочка отправки сообщений по шаблону (outgoingChain), паузы между сообщениями в чатах и их счетчики
+	send         sendFunc
+	pacer        chatPacer
+	sentMessages messageCounter
+	// Дописывание в "Итоги дня" по одному, чтобы резюме не затирали друг друга
+	dailyMu sync.Mutex
+	// Блокировка ведущей реплики (POLL_LOCK); nil - реплика одна
+	pollLock leader.Lock
+	// Внешняя очередь заданий (JOB_QUEUE_URL); nil - медиа обрабатывается в этом процессе
+	jobQueue queue.Queue
 }
 
-func (a *App) sendFormattedMessage(chatID int64, replyTo int, text, title string, useSpoiler bool) {
-	fullText := text
-	if title != "" {
-		fullText = fmt.Sprintf("<b>%s</b>\n\n%s", title, text)
+// NewApp создает приложение; stores.Transcripts может быть nil, если хранение расшифровок не настроено
+func NewApp(cfg config.Config, tele *telegram.Client, aiSvc *ai.Service, mediaProc *media.Processor, pipe *pipeline.Pipeline, stores store.Stores, c cache.Cache, layout *format.Layout) *App {
+	a := &App{cfg: cfg, tele: tele, ai: aiSvc, media: mediaProc, pipe: pipe,
+		records: stores.Transcripts, settings: stores.Settings, quotas: stores.Quotas, audit: stores.Audit, reminders: stores.Reminders, cache: c, layout: layout,
+		botSettings: stores.Bot, flags: newFeatureFlags(cfg.FeatureFlags), jobs: stores.Jobs,
+		location: defaultLocation(cfg.Timezone), replicaID: newReplicaID()}
+	if cfg.MaxPendingUpdates > 0 {
+		a.updateSlots = make(chan struct{}, cfg.MaxPendingUpdates)
 	}
-	if useSpoiler {
-		parts := strings.SplitN(fullText, "\n\n", 2)
-		if len(parts) > 1 {
-			fullText = fmt.Sprintf("%s\n\n<tg-spoiler>%s</tg-spoiler>", parts[0], parts[1])
-		} else {
-			fullText = fmt.Sprintf("<tg-spoiler>%s</tg-spoiler>", fullText)
-		}
+	if cfg.MaxConcurrentJobs > 0 {
+		a.scheduler = newJobScheduler(cfg.MaxConcurrentJobs, cfg.JobShareGroup, cfg.JobShareLargeGroup)
 	}
-	msgs := format.SplitMessage(fullText, a.cfg.MaxMessageLength)
-	for _, m := range msgs {
-		if err := a.tele.SendMessage(chatID, m, replyTo, "HTML"); err != nil {
-			log.Printf("Ошибка отправки части сообщения в чат %d: %v", chatID, err)
-			_ = a.tele.SendMessage(chatID, m, replyTo, "")
-		}
+	a.send = a.outgoingChain()
+	a.loadBotSettings()
+	return a
+}
+
+func transcriptKey(chatID int64, messageID int) string {
+	return cache.TranscriptKey("telegram", strconv.FormatInt(chatID, 10), strconv.Itoa(messageID))
+}
+
+func summaryKey(chatID int64, messageID int) string {
+	return cache.SummaryKey("telegram", strconv.FormatInt(chatID, 10), strconv.Itoa(messageID))
+}
+
+func (a *App) cachedTranscript(ctx context.Context, chatID int64, messageID int) (string, bool) {
+	text, found, err := a.cache.Get(ctx, transcriptKey(chatID, messageID))
+	if err != nil {
+		log.Printf("Ошибка чтения кэша для сообщения %d: %v", messageID, err)
+		return "", false
 	}
+	return text, found
+}
+
+// sendFormattedMessage собирает сообщение по шаблону раскладки и отправляет его частями в чат сообщения in
+// ответом на replyTo; ряды кнопок actions показываются под сообщением. Возвращает ID первой части или 0,
+// если сообщение не отправлено. Сообщение проходит цепочку outgoingChain.
+func (a *App) sendFormattedMessage(in *telegram.Message, replyTo int, d format.LayoutData, actions ...[]telegram.InlineKeyboardButton) int {
+	sent := a.send(&outgoing{in: in, replyTo: replyTo, data: d, actions: actions})
+	if sent == nil {
+		return 0
+	}
+	return sent.MessageID
 }
 
 func (a *App) handleUpdate(update telegram.Update) {
-	if update.Message == nil { return }
+	if update.CallbackQuery != nil {
+		a.handleCallback(update.CallbackQuery)
+		return
+	}
+	if update.InlineQuery != nil {
+		a.handleInlineQuery(update.InlineQuery)
+		return
+	}
+	if update.BusinessConnection != nil {
+		a.onBusinessConnection(update.BusinessConnection)
+		return
+	}
+	if update.BusinessMessage != nil {
+		if a.holdForMaintenance(update, update.BusinessMessage) {
+			return
+		}
+		a.handleBusinessMessage(update.BusinessMessage)
+		return
+	}
 	msg := update.Message
-	log.Printf("Получено сообщение от %d в чате %d", msg.From.ID, msg.Chat.ID)
+	if msg == nil {
+		// Посты каналов, где бот - администратор, приходят без отправителя
+		msg = update.ChannelPost
+	}
+	if msg == nil { return }
+	log.Printf("Получено сообщение от %d в чате %d", senderID(msg), msg.Chat.ID)
+	a.noteParticipant(msg)
+	if msg.From != nil {
+		a.noteLanguage(msg.Chat.ID, msg.From.LanguageCode)
+	}
 
+	if msg.ReplyToMessage != nil && strings.ToLower(strings.TrimSpace(msg.Text)) == "повтори" {
+		if a.retryReply(msg) {
+			return
+		}
+	}
 	if msg.ReplyToMessage != nil && strings.ToLower(strings.TrimSpace(msg.Text)) == "кратко" {
-		if originalText, found := a.cache[msg.ReplyToMessage.MessageID]; found {
-			_ = a.tele.SendMessage(msg.Chat.ID, "Создаю еще более краткое резюме...", msg.MessageID, "")
-			ctx := context.Background()
-			shortSummary, err := a.ai.SummarizeText(ctx, originalText, a.cfg.ShortPromptTemplate)
-			if err != nil {
-				_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка при создании краткого резюме: %v", err), msg.MessageID, "")
-			} else {
-				a.sendFormattedMessage(msg.Chat.ID, msg.MessageID, format.FormatHTML(shortSummary), "Краткое резюме", false)
-			}
+		if target, found := a.compressTarget(context.Background(), msg.Chat.ID, msg.ReplyToMessage.MessageID); found {
+			a.shortSummary(msg, target)
 		}
 		return
 	}
 
-	if strings.HasPrefix(msg.Text, "/start") {
-		welcome := fmt.Sprintf(
-			"Привет! Я бот, который может транскрибировать и суммировать голосовые сообщения, видео и аудиофайлы.\n\n"+
-			"Просто отправь мне голосовое сообщение, видео или аудиофайл (mp3, wav, oga), и я преобразую его в текст и создам краткое резюме.\n\n"+
-			"P.S Данный бот работает на мощностях Google Gemini AI, использует модели %s и %s для транскрипции и суммаризации\n\n"+
-			"Важно: максимальный размер файла для обработки - %d МБ.",
-			a.cfg.PrimaryModel, a.cfg.FallbackModel, a.cfg.MaxFileSize/(1024*1024),
-		)
-		_ = a.tele.SendMessage(msg.Chat.ID, welcome, msg.MessageID, "")
+	if a.handleCommand(msg) {
+		return
+	}
+
+	if a.isLongText(msg) {
+		if a.autoProcess(msg) && !a.holdForMaintenance(update, msg) {
+			a.processText(msg, msg.Text)
+		}
 		return
 	}
 
 	if msg.Animation != nil || msg.Sticker != nil || msg.Text != "" {
-		reply := fmt.Sprintf("Извините, я работаю только с голосовыми сообщениями, видео и аудиофайлами (mp3, wav, oga). Максимальный размер файла - %d МБ.", a.cfg.MaxFileSize/(1024*1024))
+		if msg.Chat.Type == "channel" {
+			return
+		}
+		if a.isQuiet(context.Background(), msg) {
+			return
+		}
+		reply := fmt.Sprintf("Извините, я работаю только с голосовыми сообщениями, видео, аудиофайлами (mp3, wav, oga, ogg, opus) и длинными текстами (от %d символов или файлом .txt). Максимальный размер файла - %d МБ.", a.cfg.LongTextMinLength, a.cfg.MaxFileSize/(1024*1024))
 		_ = a.tele.SendMessage(msg.Chat.ID, reply, msg.MessageID, "")
 		return
 	}
 
+	if !a.autoProcess(msg) || a.holdForMaintenance(update, msg) {
+		return
+	}
+	a.dispatchMedia(msg)
+}
+
+// processMedia расшифровывает и резюмирует медиа из сообщения msg
+func (a *App) processMedia(msg *telegram.Message) {
+	if msg.Document != nil && isTextDocument(msg.Document) {
+		a.processTextDocument(msg)
+		return
+	}
+	if msg.PaidMedia != nil {
+		if !a.featureEnabled(flagPaidMedia, msg.Chat.ID) {
+			return
+		}
+		video := msg.PaidMedia.Video()
+		if video == nil {
+			a.notifyError(msg, "Это платный контент: пока он не оплачен, бот видит только размытое превью и не может его расшифровать. Пришлите запись обычным сообщением.")
+			return
+		}
+		paid := *msg
+		paid.Video = video
+		msg = &paid
+	}
 	var fileSize int64
 	isSupportedDocument := true
 	if msg.Voice != nil {
@@ -99,71 +243,426 @@ func (a *App) handleUpdate(update telegram.Update) {
 		fileSize = msg.VideoNote.FileSize
 	} else if msg.Document != nil {
 		fileSize = msg.Document.FileSize
-		supported := []string{".mp3", ".wav", ".oga"}
+		supported := []string{".mp3", ".wav", ".oga", ".ogg", ".opus"}
 		ok := false
 		for _, ext := range supported { if strings.HasSuffix(strings.ToLower(msg.Document.FileName), ext) { ok = true; break } }
-		isSupportedDocument = ok
+		// Боты-мосты часто пересылают голосовые и видео документами без имени файла, но с MIME-типом
+		mimeType := strings.ToLower(msg.Document.MimeType)
+		isSupportedDocument = ok || a.featureEnabled(flagMimeDocuments, msg.Chat.ID) && (strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/"))
 	} else { return }
 
 	if fileSize > a.cfg.MaxFileSize {
-		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Ваш файл слишком большой.", a.cfg.MaxFileSize/(1024*1024)), msg.MessageID, "")
+		a.notifyError(msg, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Ваш файл слишком большой.", a.cfg.MaxFileSize/(1024*1024)))
 		return
 	}
 	if !isSupportedDocument {
-		_ = a.tele.SendMessage(msg.Chat.ID, "Извините, я могу обрабатывать только аудиофайлы форматов mp3, wav и oga.", msg.MessageID, "")
+		a.notifyError(msg, "Извините, я могу обрабатывать только аудио- и видеофайлы (mp3, wav, oga, ogg, opus и другие) и текстовые файлы .txt.")
+		return
+	}
+	if a.rejectTooLong(msg, a.recordingDuration(msg, "")) {
 		return
 	}
 
-	_ = a.tele.SendMessage(msg.Chat.ID, "Обрабатываю ваш медиафайл, это может занять некоторое время...", msg.MessageID, "")
-	audioPath, err := a.media.SaveAndProcessMedia(msg, a.tele)
+	if a.rejectOverBudget(msg) || a.rejectRateLimited(msg) {
+		return
+	}
+	voiceCommand := a.voiceCommandCandidate(msg)
+	if !voiceCommand {
+		a.sendStatus(msg, i18n.T(a.uiLanguage(msg), i18n.StatusProcessing))
+	}
+	a.persistJob(msg, jobQueued)
+	defer a.finishJob(msg)
+	release := a.scheduleJob(msg)
+	defer release()
+	defer a.notifyWatchers(msg)
+	a.persistJob(msg, jobRunning)
+	inputPath, audioPath, isVideo, err := a.media.SaveTelegramMedia(msg, a.tele)
+	if errors.Is(err, telegram.ErrFileTooLarge) {
+		a.notifyError(msg, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Ваш файл слишком большой.", a.cfg.MaxFileSize/(1024*1024)))
+		return
+	}
 	if err != nil {
 		log.Printf("Ошибка обработки медиа для сообщения %d: %v", msg.MessageID, err)
-		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Произошла ошибка при обработке медиафайла: %v", err), msg.MessageID, "")
+		a.notifyError(msg, fmt.Sprintf("Произошла ошибка при обработке медиафайла: %v", err))
 		return
 	}
+	defer os.Remove(inputPath)
 	defer os.Remove(audioPath)
+	duration := a.recordingDuration(msg, inputPath)
+	if a.rejectTooLong(msg, duration) {
+		return
+	}
+
+	job := pipeline.Job{
+		Source: pipeline.Source{
+			Platform:  "telegram",
+			ChatID:    strconv.FormatInt(msg.Chat.ID, 10),
+			UserID:    strconv.FormatInt(senderID(msg), 10),
+			MessageID: strconv.Itoa(msg.MessageID),
+		},
+		InputPath: inputPath,
+		IsVideo:   isVideo,
+		AudioPath: audioPath,
+		Duration:  duration,
+		Language:  a.chatLanguage(context.Background(), msg),
+		Redact:    a.chatSettings(context.Background(), msg).RedactPII,
+	}
+	if voiceCommand {
+		if a.runVoiceCommand(msg, &job) {
+			return
+		}
+		a.sendStatus(msg, i18n.T(a.uiLanguage(msg), i18n.StatusProcessing))
+	}
+	a.runJob(msg, job)
+}
 
-	ctx := context.Background()
-	transcriptedText, err := a.ai.AudioToText(ctx, audioPath, os.ReadFile)
+// runJob прогоняет задание через конвейер и отправляет расшифровку (только для медиа) и резюме
+// Если тот же файл уже обрабатывается для этого чата, задание не запускается повторно: результат
+// первого берется целиком (в истории он сохраняется только для первого сообщения).
+func (a *App) runJob(msg *telegram.Message, job pipeline.Job) {
+	// Расход считается и для неудачных заданий, чтобы попасть в журнал аудита
+	usageCtx := ai.WithUsage(a.promptContext(context.Background(), msg))
+	trace, traced := a.traceFor(msg.Chat.ID)
+	jt := &jobTrace{}
+	if traced || a.events.active() {
+		usageCtx = pipeline.WithTrace(usageCtx, func(stage string, d time.Duration) {
+			if traced {
+				jt.add(stage, d)
+			}
+			a.publishJob(msg, jobEvent{Type: eventStage, Stage: stage, Duration: d.Round(time.Millisecond).String()})
+		})
+	}
+	if a.events.active() {
+		usageCtx = ai.WithSummaryStream(usageCtx, func(delta string, restart bool) {
+			a.publishJob(msg, jobEvent{Type: eventSummaryDelta, Text: delta, Restart: restart})
+		})
+	}
+	started := time.Now()
+	run := func() (*pipeline.Result, error) {
+		return a.pipe.Run(usageCtx, job, func(transcript string) {
+			a.publishJob(msg, jobEvent{Type: eventTranscript, Text: transcript})
+			a.showTranscript(msg, job, transcript)
+		})
+	}
+	var res *pipeline.Result
+	var err error
+	if key := mediaFlightKey(msg); key != "" {
+		var shared bool
+		if res, err, shared = a.mediaFlights.do(key, run); shared {
+			log.Printf("Сообщение %d: этот файл уже обрабатывался, используется общий результат", msg.MessageID)
+			if res != nil {
+				a.showTranscript(msg, job, res.Transcript)
+			}
+		}
+	} else {
+		res, err = run()
+	}
+	usage := ai.UsageFrom(usageCtx)
+	if res != nil && len(usage.Models) == 0 {
+		// Общий результат чужого задания: расход посчитан в его контексте
+		usage = res.Usage
+	}
+	a.auditJob(msg, job, usage, err)
+	if traced {
+		a.sendTrace(trace, msg, jt, time.Since(started), err)
+	}
 	if err != nil {
-		log.Printf("Ошибка транскрипции для сообщения %d: %v", msg.MessageID, err)
-		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Произошла ошибка при транскрипции аудио: %v", err), msg.MessageID, "")
+		a.publishJob(msg, jobEvent{Type: eventError, Text: err.Error()})
+		a.rememberFailed(msg, job, res)
+		a.reportPipelineError(msg, err)
 		return
 	}
-	if transcriptedText == "" {
-		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось распознать речь в аудио.", msg.MessageID, "")
-		return
+	if err := a.cache.Set(context.Background(), summaryKey(msg.Chat.ID, msg.MessageID), res.Summary, a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка записи резюме в кэш для сообщения %d: %v", msg.MessageID, err)
+	}
+	a.publishJob(msg, jobEvent{Type: eventSummary, Text: res.Summary})
+	a.noteLanguage(msg.Chat.ID, res.Language)
+	title := "Summary"
+	if res.Language != "" && res.Language != job.Language {
+		title += " (перевод, язык записи: " + ai.LanguageName(res.Language) + ")"
 	}
+	actions := append(append(styleButtons(msg.MessageID), extractButtons(msg.MessageID)...), a.shareButtons(msg, res.Summary)...)
+	actions = append(actions, a.mergeButton(msg)...)
+	actions = append(actions, a.studyButton(msg)...)
+	if exp, variant := a.chatVariant(context.Background(), msg); variant != nil {
+		a.countExperiment(exp, variant.Name, metricSummaries)
+		actions = append(actions, voteButtons(variant.Name, msg.MessageID)...)
+	}
+	sent := a.sendFormattedMessage(msg, msg.MessageID, format.LayoutData{Kind: format.KindSummary, Title: title,
+		Body: format.FormatHTML(res.Summary), Duration: mediaDurationText(msg), Origin: forwardOrigin(msg), Model: strings.Join(res.Usage.Models, ", "),
+		Tokens: res.Usage.TotalTokens, Latency: res.Latency.Round(100 * time.Millisecond).String()},
+		actions...)
+	a.rememberCompressible(msg.Chat.ID, sent, compressible{Source: msg.MessageID})
+	go a.autoPin(msg, sent, res.Summary)
+	if a.isQuiet(context.Background(), msg) {
+		a.react(msg, reactionDone)
+	}
+	log.Printf("Обработка сообщения %d успешно завершена", msg.MessageID)
+}
 
-	a.cache[msg.MessageID] = transcriptedText
-	a.sendFormattedMessage(msg.Chat.ID, msg.MessageID, html.EscapeString(transcriptedText), "Transcription", false)
+// showTranscript кэширует расшифровку сообщения и показывает ее (для медиа)
+func (a *App) showTranscript(msg *telegram.Message, job pipeline.Job, transcript string) {
+	if err := a.cache.Set(context.Background(), transcriptKey(msg.Chat.ID, msg.MessageID), transcript, a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка записи в кэш для сообщения %d: %v", msg.MessageID, err)
+	}
+	a.rememberLast(msg.Chat.ID, msg.MessageID)
+	if job.InputPath == "" {
+		// Текст у пользователя уже есть, повторять его незачем
+		return
+	}
+	sent := a.sendFormattedMessage(msg, msg.MessageID, format.LayoutData{Kind: format.KindTranscript, Title: "Transcription",
+		Body: html.EscapeString(transcript), Duration: mediaDurationText(msg), Origin: forwardOrigin(msg)})
+	a.rememberCompressible(msg.Chat.ID, sent, compressible{Source: msg.MessageID})
+}
 
-	summary, err := a.ai.SummarizeText(ctx, transcriptedText, a.cfg.UserPromptTemplate)
-	if err != nil {
-		log.Printf("Ошибка суммирования для сообщения %d: %v", msg.MessageID, err)
-		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Произошла ошибка при создании резюме: %v", err), msg.MessageID, "")
+func (a *App) reportPipelineError(msg *telegram.Message, err error) {
+	log.Printf("Ошибка обработки сообщения %d: %v", msg.MessageID, err)
+	var stageErr *pipeline.StageError
+	lang := a.uiLanguage(msg)
+	if !errors.As(err, &stageErr) {
+		a.noteRetryMessage(msg, a.notifyError(msg, i18n.T(lang, i18n.ErrGeneric, err), a.retryButton(msg)...))
 		return
 	}
-	a.sendFormattedMessage(msg.Chat.ID, msg.MessageID, format.FormatHTML(summary), "Summary", true)
-	log.Printf("Обработка сообщения %d успешно завершена", msg.MessageID)
+	var text string
+	switch {
+	case errors.Is(err, pipeline.ErrEmptyTranscript):
+		text = i18n.T(lang, i18n.ErrEmptyTranscript)
+	case errors.Is(err, pipeline.ErrMusic):
+		text = i18n.T(lang, i18n.ErrMusic)
+	case errors.Is(err, pipeline.ErrNoise):
+		text = i18n.T(lang, i18n.ErrNoise)
+	case stageErr.Stage == pipeline.StageConvert:
+		text = i18n.T(lang, i18n.ErrConvert, stageErr.Err)
+	case stageErr.Stage == pipeline.StageTranscribe:
+		text = i18n.T(lang, i18n.ErrTranscribe, stageErr.Err)
+	case stageErr.Stage == pipeline.StageRedact:
+		text = i18n.T(lang, i18n.ErrRedact, stageErr.Err)
+	default:
+		text = i18n.T(lang, i18n.ErrSummarize, stageErr.Err)
+	}
+	a.noteRetryMessage(msg, a.notifyError(msg, text, a.retryButton(msg)...))
+}
+
+func mediaDuration(msg *telegram.Message) int {
+	switch {
+	case msg.Voice != nil:
+		return msg.Voice.Duration
+	case msg.Audio != nil:
+		return msg.Audio.Duration
+	case msg.Video != nil:
+		return msg.Video.Duration
+	case msg.VideoNote != nil:
+		return msg.VideoNote.Duration
+	}
+	return 0
 }
 
+// SetPollLock включает выбор ведущей реплики: PollUpdates получает обновления, отправляет напоминания
+// и возобновляет задания только пока реплика держит блокировку lock
+func (a *App) SetPollLock(lock leader.Lock) { a.pollLock = lock }
+
+// PollUpdates получает обновления через long polling. С блокировкой (SetPollLock) реплика ждет ее,
+// а захватив, сама возобновляет незавершенные задания и отправляет напоминания; если блокировка
+// потеряна, реплика останавливается и снова ждет, а обновления получает другая.
 func (a *App) PollUpdates() {
-	var offset int
+	// Пока у бота установлен вебхук, getUpdates не работает
+	if err := a.tele.DeleteWebhook(); err != nil {
+		log.Printf("Не удалось отключить вебхук: %v", err)
+	}
+	if a.pollLock == nil {
+		a.pollLoop(context.Background())
+		return
+	}
+	log.Printf("Ожидание блокировки ведущей реплики")
 	for {
-		updates, err := a.tele.GetUpdates(offset)
+		held, err := a.pollLock.Acquire(context.Background())
+		if err != nil {
+			log.Printf("Не удалось захватить блокировку ведущей реплики: %v", err)
+			<-time.After(a.cfg.PollBackoffMax)
+			continue
+		}
+		log.Printf("Реплика стала ведущей и начинает получать обновления")
+		a.ResumeJobs(held)
+		go a.RunReminders(held)
+		a.pollLoop(held)
+		log.Printf("Реплика перестала быть ведущей, получение обновлений остановлено")
+		if err := a.pollLock.Release(context.Background()); err != nil {
+			log.Printf("Не удалось освободить блокировку ведущей реплики: %v", err)
+		}
+	}
+}
+
+// pollLoop получает и обрабатывает обновления, пока ctx не отменен. С блокировкой offset хранится
+// в общем кэше, чтобы следующая ведущая реплика не получила уже обработанные обновления.
+func (a *App) pollLoop(ctx context.Context) {
+	var offset, failures int
+	if a.pollLock != nil {
+		offset = a.loadPollOffset(ctx)
+	}
+	for ctx.Err() == nil {
+		updates, err := a.tele.GetUpdates(ctx, offset)
+		if ctx.Err() != nil {
+			// Блокировка потеряна во время запроса: эти обновления получит новая ведущая реплика
+			return
+		}
 		if err != nil {
-			log.Printf("Ошибка получения обновлений: %v. Повтор через 3 секунды.", err)
-			timeSleep := a.cfg.RetryDelay
-			if timeSleep <= 0 { timeSleep = 3 * time.Second }
-			<-time.After(timeSleep)
+			failures++
+			delay := pollBackoff(failures, a.cfg.PollBackoffMin, a.cfg.PollBackoffMax)
+			if errors.Is(err, telegram.ErrConflict) {
+				// Повторять чаще бессмысленно: второй экземпляр не уйдет сам
+				delay = a.cfg.PollBackoffMax
+				log.Printf("Конфликт getUpdates: обновления этого бота уже получает другой экземпляр с тем же токеном или установлен вебхук (%v). Повтор через %s.", err, delay.Round(time.Millisecond))
+			} else {
+				log.Printf("Ошибка получения обновлений (попытка %d): %v. Повтор через %s.", failures, err, delay.Round(time.Millisecond))
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
 			continue
 		}
+		if failures > 0 {
+			log.Printf("Получение обновлений восстановлено после %d неудачных попыток", failures)
+			failures = 0
+		}
 		for _, update := range updates {
 			if update.UpdateID >= offset { offset = update.UpdateID + 1 }
-			go a.handleUpdate(update)
+		}
+		// offset сохраняется до обработки: следующая ведущая реплика не возьмет эти обновления повторно
+		if a.pollLock != nil && len(updates) > 0 {
+			a.savePollOffset(offset)
+		}
+		for _, update := range updates {
+			a.acquireUpdateSlot()
+			go func(update telegram.Update) {
+				defer a.releaseUpdateSlot()
+				a.handleUpdate(update)
+			}(update)
 		}
 	}
 }
 
+func (a *App) loadPollOffset(ctx context.Context) int {
+	v, found, err := a.cache.Get(ctx, cache.PollOffsetKey("telegram"))
+	if err != nil {
+		log.Printf("Ошибка чтения offset из кэша: %v", err)
+	}
+	if !found {
+		return 0
+	}
+	offset, _ := strconv.Atoi(v)
+	return offset
+}
 
+func (a *App) savePollOffset(offset int) {
+	// Telegram хранит неподтвержденные обновления сутки, дольше offset не нужен
+	if err := a.cache.Set(context.Background(), cache.PollOffsetKey("telegram"), strconv.Itoa(offset), 24*time.Hour); err != nil {
+		log.Printf("Ошибка записи offset в кэш: %v", err)
+	}
+}
+
+// acquireUpdateSlot ждет свободного места в очереди обработки. Пока очередь заполнена (например,
+// Gemini или ffmpeg недоступны и задания копятся), новые обновления не запрашиваются и остаются
+// неподтвержденными в Telegram, а не накапливаются в памяти.
+func (a *App) acquireUpdateSlot() {
+	if a.updateSlots == nil {
+		return
+	}
+	select {
+	case a.updateSlots <- struct{}{}:
+		return
+	default:
+	}
+	log.Printf("Очередь обработки заполнена (%d обновлений), прием обновлений приостановлен", cap(a.updateSlots))
+	a.updateSlots <- struct{}{}
+	log.Printf("Очередь обработки освободилась, прием обновлений возобновлен")
+}
+
+func (a *App) releaseUpdateSlot() {
+	if a.updateSlots != nil {
+		<-a.updateSlots
+	}
+}
+
+// recordingDuration возвращает длительность записи из сообщения. У документов ее нет: если задан
+// MAX_DURATION_MINUTES и файл уже скачан в path, она определяется через ffprobe. 0 - длительность неизвестна
+func (a *App) recordingDuration(msg *telegram.Message, path string) time.Duration {
+	if d := mediaDuration(msg); d > 0 {
+		return time.Duration(d) * time.Second
+	}
+	if path == "" || a.cfg.MaxDuration <= 0 {
+		return 0
+	}
+	d, err := media.Duration(path)
+	if err != nil {
+		log.Printf("Не удалось определить длительность сообщения %d: %v", msg.MessageID, err)
+		return 0
+	}
+	return d
+}
+
+// rejectTooLong отвечает ошибкой и возвращает true, если запись длиннее MAX_DURATION_MINUTES: размер
+// длинной записи с низким битрейтом проходит проверку MaxFileSize, но распознавание не укладывается в таймауты
+func (a *App) rejectTooLong(msg *telegram.Message, d time.Duration) bool {
+	if a.cfg.MaxDuration <= 0 || d <= a.cfg.MaxDuration {
+		return false
+	}
+	log.Printf("Сообщение %d длиной %s превышает лимит %s", msg.MessageID, d, a.cfg.MaxDuration)
+	a.notifyError(msg, fmt.Sprintf("Извините, максимальная длительность записи - %d мин. Ваша запись длится %s.", int(a.cfg.MaxDuration.Minutes()), format.Duration(d)))
+	return true
+}
+
+// mediaDurationText возвращает длительность медиа для шаблона сообщения или пустую строку
+func mediaDurationText(msg *telegram.Message) string {
+	if d := mediaDuration(msg); d > 0 {
+		return format.Duration(time.Duration(d) * time.Second)
+	}
+	return ""
+}
+
+// senderID возвращает ID отправителя сообщения: пользователя, а для сообщений от имени
+// канала или анонимного администратора - ID этого чата; 0, если отправитель неизвестен
+func senderID(msg *telegram.Message) int64 {
+	switch {
+	case msg.From != nil:
+		return msg.From.ID
+	case msg.SenderChat != nil:
+		return msg.SenderChat.ID
+	}
+	return 0
+}
+
+// forwardOrigin описывает источник пересланного сообщения (и бота, через которого оно отправлено)
+// для заголовка или возвращает ""
+func forwardOrigin(msg *telegram.Message) string {
+	var origin string
+	if msg.ForwardOrigin != nil {
+		origin = msg.ForwardOrigin.Describe()
+	}
+	if msg.ViaBot != nil {
+		if origin != "" {
+			origin += ", "
+		}
+		origin += "через бота " + msg.ViaBot.Name()
+	}
+	return origin
+}
+
+// pollBackoff возвращает паузу перед повтором getUpdates после failures неудач подряд: пауза растет
+// вдвое от minDelay до maxDelay, а случайный разброс в половину паузы не дает нескольким экземплярам
+// переподключаться одновременно
+func pollBackoff(failures int, minDelay, maxDelay time.Duration) time.Duration {
+	if minDelay <= 0 {
+		minDelay = time.Second
+	}
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+	delay := maxDelay
+	if failures < 31 {
+		if d := minDelay << max(failures-1, 0); d > 0 && d < maxDelay {
+			delay = d
+		}
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// auditActionMedia - действие в журнале аудита для каждого обработанного сообщения
+const auditActionMedia = "media"
+
+// mediaAudit - подробности обработки сообщения, хранятся в AuditEntry.Details в виде JSON
+type mediaAudit struct {
+	MessageID int    `json:"message_id"`
+	Kind      string `json:"kind"`
+	// Размер файла в байтах; для текстов - длина в символах
+	Size     int64    `json:"size,omitempty"`
+	Duration int      `json:"duration,omitempty"`
+	Models   []string `json:"models,omitempty"`
+	Tokens   int      `json:"tokens,omitempty"`
+	// "ok" или этап конвейера, на котором произошла ошибка
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	// Вариант A/B-эксперимента, в котором участвовал чат
+	Variant string `json:"variant,omitempty"`
+}
+
+// mediaKind возвращает тип содержимого сообщения для журнала аудита
+func mediaKind(msg *telegram.Message) string {
+	switch {
+	case msg.Voice != nil:
+		return "voice"
+	case msg.Audio != nil:
+		return "audio"
+	case msg.Video != nil:
+		return "video"
+	case msg.VideoNote != nil:
+		return "video_note"
+	case msg.Document != nil:
+		return "document"
+	}
+	return "text"
+}
+
+// auditJob записывает в журнал аудита, кто что прислал, сколько это стоило и чем закончилась обработка
+func (a *App) auditJob(msg *telegram.Message, job pipeline.Job, usage ai.Usage, jobErr error) {
+	if a.audit == nil {
+		return
+	}
+	entry := mediaAudit{
+		MessageID: msg.MessageID,
+		Kind:      mediaKind(msg),
+		Duration:  int(job.Duration.Seconds()),
+		Models:    usage.Models,
+		Tokens:    usage.TotalTokens,
+		Outcome:   "ok",
+	}
+	if exp, variant := a.chatVariant(context.Background(), msg); variant != nil {
+		entry.Variant = exp.Name + "/" + variant.Name
+	}
+	if file := mediaFile(msg); file != nil {
+		entry.Size = file.FileSize
+	} else {
+		entry.Size = int64(len([]rune(job.Transcript)))
+	}
+	if jobErr != nil {
+		entry.Outcome, entry.Error = "error", jobErr.Error()
+		var stageErr *pipeline.StageError
+		if errors.As(jobErr, &stageErr) {
+			entry.Outcome = stageErr.Stage
+		}
+	}
+	details, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Ошибка маршалинга аудита сообщения %d: %v", msg.MessageID, err)
+		return
+	}
+	if err := a.audit.AppendAudit(context.Background(), store.AuditEntry{
+		Platform: job.Source.Platform,
+		ChatID:   job.Source.ChatID,
+		UserID:   job.Source.UserID,
+		Action:   auditActionMedia,
+		Details:  string(details),
+	}); err != nil {
+		log.Printf("Ошибка записи аудита сообщения %d: %v", msg.MessageID, err)
+	}
+}
+
+const auditUsage = "Использование:\n" +
+	"/admin audit [N] - последние N обработанных сообщений (по умолчанию 20)\n" +
+	"/admin audit csv [7d|24h|all] - журнал за период файлом CSV (по умолчанию за 30 дней)"
+
+func (a *App) adminAudit(msg *telegram.Message, args string) {
+	if a.audit == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Журнал аудита не настроен.", msg.MessageID, "")
+		return
+	}
+	ctx := context.Background()
+	first, rest := cutWord(strings.ToLower(args))
+	if first == "csv" {
+		now := time.Now()
+		since := now.AddDate(0, 0, -30)
+		if rest != "" {
+			var ok bool
+			var err error
+			if since, ok, err = parsePeriod(rest, now); !ok || err != nil {
+				_ = a.tele.SendMessage(msg.Chat.ID, auditUsage, msg.MessageID, "")
+				return
+			}
+		}
+		a.sendAuditCSV(ctx, msg, since, now)
+		return
+	}
+	limit := 20
+	if first != "" {
+		n, err := strconv.Atoi(first)
+		if err != nil || n <= 0 || rest != "" {
+			_ = a.tele.SendMessage(msg.Chat.ID, auditUsage, msg.MessageID, "")
+			return
+		}
+		limit = n
+	}
+	entries, err := a.audit.ListAudit(ctx, store.AuditQuery{Action: auditActionMedia, Limit: limit})
+	if err != nil {
+		log.Printf("Ошибка чтения журнала аудита: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать журнал аудита.", msg.MessageID, "")
+		return
+	}
+	if len(entries) == 0 {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Журнал аудита пуст.", msg.MessageID, "")
+		return
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		var m mediaAudit
+		_ = json.Unmarshal([]byte(e.Details), &m)
+		line := fmt.Sprintf("%s чат %s, от %s: %s", e.CreatedAt.Format("02.01 15:04"), e.ChatID, e.UserID, m.Kind)
+		if m.Duration > 0 {
+			line += fmt.Sprintf(", %d с", m.Duration)
+		}
+		if len(m.Models) > 0 {
+			line += fmt.Sprintf(", %s, %d токенов", strings.Join(m.Models, "+"), m.Tokens)
+		}
+		line += ", " + m.Outcome + "\n"
+		if b.Len()+len(line) > a.cfg.MaxMessageLength {
+			break
+		}
+		b.WriteString(line)
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, b.String(), msg.MessageID, "")
+}
+
+// sendAuditCSV отправляет журнал обработанных сообщений с момента since файлом CSV
+func (a *App) sendAuditCSV(ctx context.Context, msg *telegram.Message, since, now time.Time) {
+	entries, err := a.audit.ListAudit(ctx, store.AuditQuery{Action: auditActionMedia, Since: since})
+	if err != nil {
+		log.Printf("Ошибка чтения журнала аудита: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать журнал аудита.", msg.MessageID, "")
+		return
+	}
+	if len(entries) == 0 {
+		_ = a.tele.SendMessage(msg.Chat.ID, "За выбранный период журнал аудита пуст.", msg.MessageID, "")
+		return
+	}
+	data, err := encodeAuditCSV(entries)
+	if err != nil {
+		log.Printf("Ошибка формирования журнала аудита: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сформировать файл журнала.", msg.MessageID, "")
+		return
+	}
+	fileName := fmt.Sprintf("audit-%s.csv", now.Format("20060102"))
+	caption := fmt.Sprintf("Журнал аудита: %d сообщений", len(entries))
+	if err := a.tele.SendDocument(msg.Chat.ID, fileName, data, telegram.SendOptions{ReplyTo: msg.MessageID, Caption: caption}); err != nil {
+		log.Printf("Ошибка отправки журнала аудита: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось отправить файл журнала.", msg.MessageID, "")
+	}
+}
+
+func encodeAuditCSV(entries []store.AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"time", "platform", "chat_id", "user_id", "message_id", "kind", "size", "duration_sec", "models", "tokens", "outcome", "error", "variant"})
+	for _, e := range entries {
+		var m mediaAudit
+		if err := json.Unmarshal([]byte(e.Details), &m); err != nil {
+			return nil, fmt.Errorf("событие аудита %d: %w", e.ID, err)
+		}
+		_ = w.Write([]string{
+			e.CreatedAt.UTC().Format(time.RFC3339), e.Platform, e.ChatID, e.UserID,
+			strconv.Itoa(m.MessageID), m.Kind, strconv.FormatInt(m.Size, 10), strconv.Itoa(m.Duration),
+			strings.Join(m.Models, ";"), strconv.Itoa(m.Tokens), m.Outcome, m.Error, m.Variant,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
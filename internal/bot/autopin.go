@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const autoPinUsage = "Использование: /autopin on [срок] | off. Бот закрепляет резюме, в которых есть решения, договоренности или сроки, " +
+	"и открепляет их через срок: например, /autopin on 12h или /autopin on 3d; 0 — не откреплять. Боту нужно право закреплять сообщения."
+
+// parsePinPeriod разбирает срок открепления вида "12h", "90m", "3d", "1w" или "0" (не откреплять)
+func parsePinPeriod(s string) (time.Duration, bool) {
+	s = strings.ToLower(s)
+	if strings.HasSuffix(s, "h") || strings.HasSuffix(s, "m") {
+		d, err := time.ParseDuration(s)
+		return d, err == nil && d >= 0
+	}
+	days, ok := parseRetentionDays(s)
+	return time.Duration(days) * 24 * time.Hour, ok
+}
+
+func describePinPeriod(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "не открепляются"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("открепляются через %d дн.", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("открепляются через %d ч", d/time.Hour)
+	default:
+		return "открепляются через " + d.String()
+	}
+}
+
+// autoPinPeriod возвращает срок открепления для чата: заданный через /autopin или AUTO_PIN_DURATION
+func (a *App) autoPinPeriod(settings store.ChatSettings) time.Duration {
+	if d, ok := parsePinPeriod(settings.AutoPinFor); settings.AutoPinFor != "" && ok {
+		return d
+	}
+	return a.cfg.AutoPinDuration
+}
+
+// autoPin в чатах с /autopin on закрепляет отправленное резюме sentID, если модель оценила его важность
+// не ниже AUTO_PIN_THRESHOLD, и планирует открепление. В режиме "Итоги дня" резюме уже закреплены.
+func (a *App) autoPin(msg *telegram.Message, sentID int, summary string) {
+	ctx := context.Background()
+	settings := a.chatSettings(ctx, msg)
+	if !settings.AutoPin || settings.DailyNotes || sentID == 0 {
+		return
+	}
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	score, err := a.ai.ImportanceScore(ai.WithChat(ctx, "telegram", chatID), summary, a.cfg.AutoPinPrompt)
+	if err != nil {
+		log.Printf("Ошибка оценки важности резюме %d в чате %d: %v", sentID, msg.Chat.ID, err)
+		return
+	}
+	if score < a.cfg.AutoPinThreshold {
+		return
+	}
+	if err := a.tele.PinChatMessage(msg.Chat.ID, sentID, true); err != nil {
+		log.Printf("Не удалось закрепить резюме %d в чате %d (нужно право закреплять сообщения): %v", sentID, msg.Chat.ID, err)
+		return
+	}
+	log.Printf("Резюме %d в чате %d закреплено, важность %d", sentID, msg.Chat.ID, score)
+	period := a.autoPinPeriod(settings)
+	if period <= 0 {
+		return
+	}
+	unpin := &store.Reminder{Kind: store.ReminderUnpin, Platform: "telegram", ChatID: chatID, MessageID: strconv.Itoa(sentID), DueAt: time.Now().Add(period)}
+	if err := a.reminders.AddReminder(ctx, unpin); err != nil {
+		log.Printf("Ошибка планирования открепления резюме %d в чате %d: %v", sentID, msg.Chat.ID, err)
+	}
+}
+
+func (a *App) cmdAutoPin(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	fields := strings.Fields(strings.ToLower(args))
+	if len(fields) == 0 {
+		state := "выключено"
+		if settings.AutoPin {
+			state = "включено, резюме " + describePinPeriod(a.autoPinPeriod(settings))
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Автозакрепление важных резюме "+state+".\n\n"+autoPinUsage, msg.MessageID, "")
+		return
+	}
+	var period string
+	switch {
+	case len(fields) <= 2 && (fields[0] == "on" || fields[0] == "вкл"):
+		settings.AutoPin = true
+		if len(fields) == 2 {
+			if _, ok := parsePinPeriod(fields[1]); !ok {
+				_ = a.tele.SendMessage(msg.Chat.ID, autoPinUsage, msg.MessageID, "")
+				return
+			}
+			period = fields[1]
+		}
+	case len(fields) == 1 && (fields[0] == "off" || fields[0] == "выкл"):
+		settings.AutoPin = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, autoPinUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять режим могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.AutoPinFor = period
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if settings.AutoPin {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Автозакрепление включено: важные резюме "+describePinPeriod(a.autoPinPeriod(settings))+".", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Автозакрепление выключено.", msg.MessageID, "")
+	}
+}
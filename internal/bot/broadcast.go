@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// adminBroadcast рассылает объявление во все известные чаты, кроме отказавшихся через /news off
+func (a *App) adminBroadcast(msg *telegram.Message, args string) {
+	text := strings.TrimSpace(args)
+	if text == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /admin broadcast <текст объявления>", msg.MessageID, "")
+		return
+	}
+	if !a.broadcasting.CompareAndSwap(false, true) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Предыдущая рассылка еще не закончилась.", msg.MessageID, "")
+		return
+	}
+	chats, err := a.broadcastChats(context.Background())
+	if err != nil {
+		a.broadcasting.Store(false)
+		log.Printf("Ошибка получения списка чатов для рассылки: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось получить список чатов.", msg.MessageID, "")
+		return
+	}
+	log.Printf("Администратор %d запустил рассылку в %d чатов", msg.From.ID, len(chats))
+	_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Рассылка запущена: %d чатов.", len(chats)), msg.MessageID, "")
+	go func() {
+		defer a.broadcasting.Store(false)
+		sent, failed := a.broadcast(chats, text)
+		log.Printf("Рассылка завершена: отправлено %d, ошибок %d", sent, failed)
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Рассылка завершена: отправлено %d, ошибок %d.", sent, failed), msg.MessageID, "")
+	}()
+}
+
+// broadcastChats собирает чаты Telegram с записями или настройками, исключая отказавшиеся от объявлений
+func (a *App) broadcastChats(ctx context.Context) ([]int64, error) {
+	var refs []store.ChatRef
+	if a.records != nil {
+		withRecords, err := a.records.ChatsWithRecords(ctx)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, withRecords...)
+	}
+	withSettings, err := a.settings.ChatsWithSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refs = append(refs, withSettings...)
+
+	seen := make(map[int64]bool, len(refs))
+	var chats []int64
+	for _, ref := range refs {
+		if ref.Platform != "telegram" {
+			continue
+		}
+		chatID, err := strconv.ParseInt(ref.ChatID, 10, 64)
+		if err != nil || seen[chatID] {
+			continue
+		}
+		seen[chatID] = true
+		settings, err := a.settings.GetSettings(ctx, ref.Platform, ref.ChatID)
+		if err != nil {
+			return nil, err
+		}
+		if !settings.NoAnnouncements {
+			chats = append(chats, chatID)
+		}
+	}
+	return chats, nil
+}
+
+// broadcast отправляет text в чаты не чаще одного сообщения за BroadcastInterval
+func (a *App) broadcast(chats []int64, text string) (sent, failed int) {
+	interval := a.cfg.BroadcastInterval
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i, chatID := range chats {
+		if i > 0 {
+			<-ticker.C
+		}
+		if err := a.tele.SendMessage(chatID, text, 0, ""); err != nil {
+			log.Printf("Ошибка отправки объявления в чат %d: %v", chatID, err)
+			failed++
+			continue
+		}
+		sent++
+	}
+	return sent, failed
+}
+
+func (a *App) cmdNews(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	const usage = "Использование: /news on|off. Объявления - редкие сообщения от разработчиков бота о работах и новых возможностях."
+	var off bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "включены"
+		if settings.NoAnnouncements {
+			state = "выключены"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Объявления "+state+".\n\n"+usage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		off = false
+	case "off", "выкл", "нет":
+		off = true
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять эту настройку могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.NoAnnouncements = off
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if off {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Объявления выключены.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Объявления включены.", msg.MessageID, "")
+	}
+}
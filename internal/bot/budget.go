@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// budgetLimit - один из лимитов расходов (бота или чата, за сутки или месяц)
+type budgetLimit struct {
+	quota  store.Quota
+	limit  int64
+	name   string
+	retry  string
+	budget config.Budget
+}
+
+// budgetAlerts помнит, о каких превышениях лимитов уже сообщено администраторам бота
+type budgetAlerts struct {
+	mu   sync.Mutex
+	sent map[string]bool
+}
+
+// budgetLimits возвращает заданные лимиты расходов для чата chatID
+func (a *App) budgetLimits(platform, chatID string, now time.Time) []budgetLimit {
+	day, month := store.DayPeriod(now), store.MonthPeriod(now)
+	chat := store.ChatSubject(chatID)
+	all := []budgetLimit{
+		{store.Quota{Subject: store.GlobalSubject, Period: day}, 0, "дневной лимит бота", "завтра", a.cfg.BudgetDaily},
+		{store.Quota{Subject: store.GlobalSubject, Period: month}, 0, "месячный лимит бота", "в следующем месяце", a.cfg.BudgetMonthly},
+		{store.Quota{Platform: platform, Subject: chat, Period: day}, 0, "дневной лимит чата", "завтра", a.cfg.BudgetChatDaily},
+		{store.Quota{Platform: platform, Subject: chat, Period: month}, 0, "месячный лимит чата", "в следующем месяце", a.cfg.BudgetChatMonthly},
+	}
+	var limits []budgetLimit
+	for _, l := range all {
+		if l.limit = l.budget.Limit(a.cfg.TokenPricePerMillion); l.limit > 0 {
+			l.quota.Metric = store.MetricTokens
+			limits = append(limits, l)
+		}
+	}
+	return limits
+}
+
+// exceededBudget возвращает первый исчерпанный лимит расходов для чата. Если счетчик прочитать
+// не удалось, лимит не применяется, чтобы сбой хранилища не останавливал бота.
+func (a *App) exceededBudget(ctx context.Context, platform, chatID string) (budgetLimit, bool) {
+	for _, l := range a.budgetLimits(platform, chatID, time.Now()) {
+		used, err := a.quotas.GetUsage(ctx, l.quota)
+		if err != nil {
+			log.Printf("Ошибка чтения расходов (%s): %v", l.name, err)
+			continue
+		}
+		if used >= l.limit {
+			a.alertBudget(l, chatID, used)
+			return l, true
+		}
+	}
+	return budgetLimit{}, false
+}
+
+// rejectOverBudget отклоняет новое задание, если исчерпан лимит расходов; возвращает true, если отклонено
+func (a *App) rejectOverBudget(msg *telegram.Message) bool {
+	l, over := a.exceededBudget(context.Background(), "telegram", strconv.FormatInt(msg.Chat.ID, 10))
+	if !over {
+		return false
+	}
+	log.Printf("Сообщение %d в чате %d отклонено: исчерпан %s", msg.MessageID, msg.Chat.ID, l.name)
+	a.notifyError(msg, fmt.Sprintf("Исчерпан %s расходов на обработку, новые записи пока не принимаются. Попробуйте %s.", l.name, l.retry))
+	return true
+}
+
+// alertBudget один раз за период сообщает администраторам бота об исчерпании лимита
+func (a *App) alertBudget(l budgetLimit, chatID string, used int64) {
+	key := l.quota.Platform + ":" + l.quota.Subject + ":" + l.quota.Period
+	a.budgetAlerts.mu.Lock()
+	if a.budgetAlerts.sent[key] {
+		a.budgetAlerts.mu.Unlock()
+		return
+	}
+	if a.budgetAlerts.sent == nil {
+		a.budgetAlerts.sent = make(map[string]bool)
+	}
+	a.budgetAlerts.sent[key] = true
+	a.budgetAlerts.mu.Unlock()
+
+	text := fmt.Sprintf("Исчерпан %s (%s): израсходовано %d из %d токенов. Новые задания отклоняются.", l.name, l.quota.Period, used, l.limit)
+	if l.quota.Subject != store.GlobalSubject {
+		text = fmt.Sprintf("Чат %s: %s", chatID, text)
+	}
+	log.Print(text)
+	for _, admin := range a.cfg.BotAdminIDs {
+		if err := a.tele.SendMessage(admin, text, 0, ""); err != nil {
+			log.Printf("Не удалось уведомить администратора %d о лимите расходов: %v", admin, err)
+		}
+	}
+}
+
+// adminBudget показывает расходы токенов бота (или чата) за сутки и месяц и заданные лимиты
+func (a *App) adminBudget(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	now := time.Now()
+	chatID, _ := cutWord(args)
+	scope, title := store.Quota{Subject: store.GlobalSubject}, "Расходы бота"
+	if chatID != "" {
+		if _, err := strconv.ParseInt(chatID, 10, 64); err != nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /admin budget [ID чата]", msg.MessageID, "")
+			return
+		}
+		scope, title = store.Quota{Platform: "telegram", Subject: store.ChatSubject(chatID)}, "Расходы чата "+chatID
+	}
+	limits := map[store.Quota]int64{}
+	for _, l := range a.budgetLimits("telegram", chatID, now) {
+		limits[l.quota] = l.limit
+	}
+	text := title + " (токены):\n"
+	for _, p := range []struct{ name, period string }{{"сутки", store.DayPeriod(now)}, {"месяц", store.MonthPeriod(now)}} {
+		q := scope
+		q.Metric, q.Period = store.MetricTokens, p.period
+		used, err := a.quotas.GetUsage(ctx, q)
+		if err != nil {
+			log.Printf("Ошибка чтения расходов: %v", err)
+			_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать расходы.", msg.MessageID, "")
+			return
+		}
+		if limit := limits[q]; limit > 0 {
+			text += fmt.Sprintf("%s: %d из %d\n", p.name, used, limit)
+		} else {
+			text += fmt.Sprintf("%s: %d, без лимита\n", p.name, used)
+		}
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, text, msg.MessageID, "")
+}
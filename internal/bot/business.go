@@ -0,0 +1,177 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+func businessKey(connectionID string) string {
+	return cache.BusinessKey("telegram", connectionID)
+}
+
+// onBusinessConnection запоминает подключение (или отключение) бота к бизнес-аккаунту пользователя
+func (a *App) onBusinessConnection(conn *telegram.BusinessConnection) {
+	log.Printf("Бизнес-подключение %s пользователя %d: включено=%t, ответы=%t", conn.ID, conn.User.ID, conn.IsEnabled, conn.Replies())
+	data, err := json.Marshal(conn)
+	if err == nil {
+		err = a.cache.Set(context.Background(), businessKey(conn.ID), string(data), 0)
+	}
+	if err != nil {
+		log.Printf("Ошибка сохранения бизнес-подключения %s: %v", conn.ID, err)
+	}
+}
+
+// businessConnection возвращает подключение из кэша, а если его там нет (например, после
+// перезапуска с кэшем в памяти) - запрашивает у Telegram
+func (a *App) businessConnection(id string) (*telegram.BusinessConnection, error) {
+	if v, found, err := a.cache.Get(context.Background(), businessKey(id)); err == nil && found {
+		var conn telegram.BusinessConnection
+		if err := json.Unmarshal([]byte(v), &conn); err == nil {
+			return &conn, nil
+		}
+	}
+	conn, err := a.tele.GetBusinessConnection(id)
+	if err != nil {
+		return nil, err
+	}
+	a.onBusinessConnection(conn)
+	return conn, nil
+}
+
+// handleBusinessMessage расшифровывает голосовые и видео, полученные пользователем через его
+// аккаунт Telegram Business. Если подключение разрешает отвечать, расшифровка и резюме отправляются
+// ответом в тот же чат от имени пользователя, иначе - ему в личный чат с ботом. Собственные
+// сообщения владельца аккаунта не обрабатываются.
+func (a *App) handleBusinessMessage(msg *telegram.Message) {
+	if msg.Voice == nil && msg.VideoNote == nil && msg.Audio == nil && msg.Video == nil {
+		return
+	}
+	conn, err := a.businessConnection(msg.BusinessConnectionID)
+	if err != nil {
+		log.Printf("Не удалось получить бизнес-подключение %s: %v", msg.BusinessConnectionID, err)
+		return
+	}
+	if !conn.IsEnabled || msg.From == nil || msg.From.ID == conn.User.ID || !a.featureEnabled(flagBusiness, conn.UserChatID) {
+		return
+	}
+	log.Printf("Получено бизнес-сообщение %d от %d для пользователя %d", msg.MessageID, msg.From.ID, conn.User.ID)
+	// Настройки (язык, персона, режим ответов) берутся из личного чата владельца с ботом
+	owner := &telegram.Message{Chat: &telegram.Chat{ID: conn.UserChatID, Type: "private"}, From: &conn.User}
+	sender := msg.From.Name()
+	if l, over := a.exceededBudget(context.Background(), "telegram", strconv.FormatInt(msg.Chat.ID, 10)); over {
+		_ = a.tele.SendMessage(conn.UserChatID, fmt.Sprintf("Сообщение от %s не обработано: исчерпан %s расходов. Попробуйте %s.", sender, l.name, l.retry), 0, "")
+		return
+	}
+
+	tooLong := func(d time.Duration) bool {
+		if a.cfg.MaxDuration <= 0 || d <= a.cfg.MaxDuration {
+			return false
+		}
+		_ = a.tele.SendMessage(conn.UserChatID, fmt.Sprintf("Сообщение от %s длиннее %d мин, расшифровать его не получится.", sender, int(a.cfg.MaxDuration.Minutes())), 0, "")
+		return true
+	}
+	if tooLong(a.recordingDuration(msg, "")) {
+		return
+	}
+
+	release := a.scheduleJob(msg)
+	defer release()
+	inputPath, audioPath, isVideo, err := a.media.SaveTelegramMedia(msg, a.tele)
+	if errors.Is(err, telegram.ErrFileTooLarge) {
+		_ = a.tele.SendMessage(conn.UserChatID, fmt.Sprintf("Сообщение от %s больше %d МБ, расшифровать его не получится.", sender, a.cfg.MaxFileSize/(1024*1024)), 0, "")
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка обработки медиа для бизнес-сообщения %d: %v", msg.MessageID, err)
+		_ = a.tele.SendMessage(conn.UserChatID, fmt.Sprintf("Не удалось обработать сообщение от %s: %v", sender, err), 0, "")
+		return
+	}
+	defer os.Remove(inputPath)
+	defer os.Remove(audioPath)
+	// У документов длительность известна только после скачивания
+	duration := a.recordingDuration(msg, inputPath)
+	if tooLong(duration) {
+		return
+	}
+
+	ctx := context.Background()
+	job := pipeline.Job{
+		Source: pipeline.Source{
+			Platform:  "telegram",
+			ChatID:    strconv.FormatInt(msg.Chat.ID, 10),
+			UserID:    strconv.FormatInt(msg.From.ID, 10),
+			MessageID: strconv.Itoa(msg.MessageID),
+		},
+		InputPath: inputPath,
+		IsVideo:   isVideo,
+		AudioPath: audioPath,
+		Duration:  duration,
+		Language:  a.chatLanguage(ctx, owner),
+		Redact:    a.chatSettings(ctx, owner).RedactPII,
+	}
+	res, err := a.pipe.Run(a.promptContext(ctx, owner), job, nil)
+	if err != nil && res == nil {
+		log.Printf("Ошибка обработки бизнес-сообщения %d: %v", msg.MessageID, err)
+		_ = a.tele.SendMessage(conn.UserChatID, fmt.Sprintf("Не удалось расшифровать сообщение от %s: %v", sender, err), 0, "")
+		return
+	}
+
+	messages := []format.LayoutData{{Kind: format.KindTranscript, Title: "Transcription", Body: html.EscapeString(res.Transcript), Duration: mediaDurationText(msg)}}
+	if err != nil {
+		log.Printf("Ошибка создания резюме для бизнес-сообщения %d: %v", msg.MessageID, err)
+	} else {
+		messages = append(messages, format.LayoutData{Kind: format.KindSummary, Title: "Summary", Body: format.FormatHTML(res.Summary), Duration: mediaDurationText(msg)})
+	}
+	for _, d := range messages {
+		if conn.Replies() {
+			a.sendBusinessReply(conn, msg, owner, d)
+			continue
+		}
+		d.Origin = "от " + sender
+		a.sendFormattedMessage(owner, 0, d)
+	}
+	log.Printf("Обработка бизнес-сообщения %d успешно завершена", msg.MessageID)
+}
+
+// sendBusinessReply отправляет сообщение ответом на msg от имени владельца бизнес-аккаунта.
+// Кнопок под ним нет: нажимать их будет собеседник, а не владелец.
+func (a *App) sendBusinessReply(conn *telegram.BusinessConnection, msg, owner *telegram.Message, d format.LayoutData) {
+	settings := a.chatSettings(context.Background(), owner)
+	if d.Kind == format.KindTranscript {
+		if settings.Verbosity == store.VerbosityQuiet {
+			return
+		}
+		d.Body = format.HighlightUnclear(d.Body)
+	}
+	if settings.MaskProfanity {
+		d.Body = format.MaskProfanityHTML(d.Body)
+	}
+	text, err := a.layout.Render(d)
+	if err != nil {
+		log.Printf("Ошибка шаблона бизнес-сообщения для чата %d: %v", msg.Chat.ID, err)
+		text = fmt.Sprintf("<b>%s</b>\n\n%s", html.EscapeString(d.Title), d.Body)
+	}
+	for _, part := range format.SplitHTMLNumbered(format.SanitizeHTML(text), d.Title, a.cfg.MaxMessageLength) {
+		opts := telegram.SendOptions{ReplyTo: msg.MessageID, ParseMode: "HTML", BusinessConnectionID: conn.ID, ProtectContent: settings.ProtectContent}
+		if _, err := a.tele.Send(msg.Chat.ID, part, opts); err != nil {
+			log.Printf("Ошибка отправки бизнес-ответа в чат %d: %v", msg.Chat.ID, err)
+			opts.ParseMode = ""
+			if _, err := a.tele.Send(msg.Chat.ID, format.StripHTML(part), opts); err != nil {
+				return
+			}
+		}
+	}
+}
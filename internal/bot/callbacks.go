@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+type callbackHandler func(a *App, q *telegram.CallbackQuery, payload string)
+
+// callbacks сопоставляет префикс callback_data ("префикс:данные") с обработчиком
+var callbacks = map[string]callbackHandler{
+	"hist":   (*App).onHistoryCallback,
+	"forget": (*App).onForgetCallback,
+	"page":   (*App).onPageCallback,
+	"style":  (*App).onStyleCallback,
+	"todo":   (*App).onTodoCallback,
+	"remind": (*App).onRemindCallback,
+	"quotes": (*App).onQuotesCallback,
+	"merge":  (*App).onMergeCallback,
+	"vote":   (*App).onVoteCallback,
+	"study":  (*App).onStudyCallback,
+	"notify": (*App).onNotifyCallback,
+	"retry":  (*App).onRetryCallback,
+}
+
+func (a *App) handleCallback(q *telegram.CallbackQuery) {
+	prefix, payload, _ := strings.Cut(q.Data, ":")
+	handler, found := callbacks[prefix]
+	if !found || q.Message == nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	log.Printf("Получен callback %q от %d в чате %d", q.Data, q.From.ID, q.Message.Chat.ID)
+	handler(a, q, payload)
+}
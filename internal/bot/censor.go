@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+func (a *App) cmdCensor(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	const usage = "Использование: /censor on|off. При включенной маскировке нецензурные слова в расшифровках и резюме заменяются первой буквой со звездочками (с***)."
+	var mask bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "выключена"
+		if settings.MaskProfanity {
+			state = "включена"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Маскировка мата "+state+".\n\n"+usage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		mask = true
+	case "off", "выкл", "нет":
+		mask = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять маскировку могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.MaskProfanity = mask
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if mask {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Маскировка мата включена.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Маскировка мата выключена.", msg.MessageID, "")
+	}
+}
@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+type commandHandler func(a *App, msg *telegram.Message, args string)
+
+var commands = map[string]commandHandler{
+	"start":     (*App).cmdStart,
+	"export":    (*App).cmdExport,
+	"notes":     (*App).cmdNotes,
+	"search":    (*App).cmdSearch,
+	"history":   (*App).cmdHistory,
+	"forgetme":  (*App).cmdForgetMe,
+	"retention": (*App).cmdRetention,
+	"style":     (*App).cmdStyle,
+	"language":  (*App).cmdLanguage,
+	"short":     (*App).cmdShort,
+	"translate": (*App).cmdTranslate,
+	"verbosity": (*App).cmdVerbosity,
+	"quiet":     (*App).cmdQuiet,
+	"auto":      (*App).cmdAuto,
+	"spoiler":   (*App).cmdSpoiler,
+	"censor":    (*App).cmdCensor,
+	"protect":   (*App).cmdProtect,
+	"silent":    (*App).cmdSilent,
+	"admin":     (*App).cmdAdmin,
+	"news":      (*App).cmdNews,
+	"usage":     (*App).cmdUsage,
+	"redact":    (*App).cmdRedact,
+	"persona":   (*App).cmdPersona,
+	"glossary":  (*App).cmdGlossary,
+	"uilang":    (*App).cmdUILang,
+	"timezone":  (*App).cmdTimezone,
+	"daily":     (*App).cmdDaily,
+	"autopin":   (*App).cmdAutoPin,
+	"dm":        (*App).cmdDM,
+}
+
+// parseCommand разбирает "/cmd@bot аргументы" на имя команды и аргументы
+func parseCommand(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	name, args, _ := strings.Cut(strings.TrimPrefix(text, "/"), " ")
+	name, _, _ = strings.Cut(name, "@")
+	return strings.ToLower(name), strings.TrimSpace(args), name != ""
+}
+
+// handleCommand выполняет команду из сообщения и сообщает, была ли она распознана
+func (a *App) handleCommand(msg *telegram.Message) bool {
+	name, args, ok := parseCommand(msg.Text)
+	if !ok {
+		return false
+	}
+	handler, found := commands[name]
+	if !found {
+		return false
+	}
+	handler(a, msg, args)
+	return true
+}
+
+func (a *App) cmdStart(msg *telegram.Message, _ string) {
+	welcome := i18n.T(a.uiLanguage(msg), i18n.Welcome, a.cfg.PrimaryModel, a.cfg.FallbackModel, a.cfg.MaxFileSize/(1024*1024))
+	_ = a.tele.SendMessage(msg.Chat.ID, welcome, msg.MessageID, "")
+}
+
+// uiLanguage выбирает язык текстов интерфейса: язык, выбранный через /uilang, затем преобладающий
+// язык записей и сообщений чата, язык Telegram отправителя, язык резюме чата и SUMMARY_LANGUAGE
+func (a *App) uiLanguage(msg *telegram.Message) string {
+	var user string
+	if msg.From != nil {
+		user = msg.From.LanguageCode
+	}
+	settings := a.chatSettings(context.Background(), msg)
+	return i18n.Resolve(settings.UILanguage, a.dominantLanguage(msg.Chat.ID), user, settings.Language, a.cfg.SummaryLanguage)
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+)
+
+// Сколько раз можно сжимать одно резюме ответом "кратко"
+const maxCompressDepth = 3
+
+// compressible - сообщение бота, которое можно сжать ответом "кратко". Расшифровка и резюме записи
+// Source имеют Depth 0 и сжимаются из расшифровки; краткие резюме хранят свой текст и число сжатий.
+type compressible struct {
+	Source int    `json:"source"`
+	Depth  int    `json:"depth,omitempty"`
+	Text   string `json:"text,omitempty"`
+}
+
+func compressKey(chatID int64, messageID int) string {
+	return cache.CompressKey("telegram", strconv.FormatInt(chatID, 10), strconv.Itoa(messageID))
+}
+
+// rememberCompressible запоминает, что отправленное сообщение sentID можно сжать ответом "кратко"
+func (a *App) rememberCompressible(chatID int64, sentID int, item compressible) {
+	if sentID == 0 {
+		return
+	}
+	data, err := json.Marshal(item)
+	if err == nil {
+		err = a.cache.Set(context.Background(), compressKey(chatID, sentID), string(data), a.cfg.CacheTTL)
+	}
+	if err != nil {
+		log.Printf("Ошибка записи в кэш сообщения %d для сжатия: %v", sentID, err)
+	}
+}
+
+// compressTarget находит, что сжимать в ответ на сообщение messageID: исходную запись, расшифровку
+// или резюме бота (текст - расшифровка) или уже краткое резюме (текст - само резюме)
+func (a *App) compressTarget(ctx context.Context, chatID int64, messageID int) (compressible, bool) {
+	if transcript, found := a.cachedTranscript(ctx, chatID, messageID); found {
+		return compressible{Source: messageID, Text: transcript}, true
+	}
+	data, found, err := a.cache.Get(ctx, compressKey(chatID, messageID))
+	if err != nil {
+		log.Printf("Ошибка чтения кэша для сообщения %d: %v", messageID, err)
+		return compressible{}, false
+	}
+	if !found {
+		return compressible{}, false
+	}
+	var item compressible
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		log.Printf("Ошибка разбора кэша для сообщения %d: %v", messageID, err)
+		return compressible{}, false
+	}
+	if item.Depth == 0 {
+		item.Text, found = a.cachedTranscript(ctx, chatID, item.Source)
+	}
+	return item, found
+}
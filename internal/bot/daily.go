@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Сколько помнить сообщение "Итоги дня": после смены даты оно больше не дописывается
+const dailyNotesTTL = 48 * time.Hour
+
+// dailyNotes - текущее сообщение "Итоги дня" чата: дата в часовом поясе чата, ID и текст (HTML),
+// к которому дописываются новые резюме
+type dailyNotes struct {
+	Date      string `json:"date"`
+	MessageID int    `json:"message_id"`
+	Text      string `json:"text"`
+}
+
+func dailyNotesKey(chatID int64) string {
+	return cache.DailyNotesKey("telegram", strconv.FormatInt(chatID, 10))
+}
+
+// dailyOutgoing в чатах с /daily on дописывает резюме в закрепленное сообщение "Итоги дня" вместо
+// отдельного сообщения. Если дописать не удалось, резюме отправляется как обычно.
+func (a *App) dailyOutgoing(next sendFunc) sendFunc {
+	return func(m *outgoing) *telegram.Message {
+		if !m.settings.DailyNotes || m.data.Kind != format.KindSummary {
+			return next(m)
+		}
+		if sent := a.appendDailyNotes(m); sent != nil {
+			return sent
+		}
+		return next(m)
+	}
+}
+
+// appendDailyNotes дописывает резюме m в сегодняшнее сообщение "Итоги дня", а если его еще нет или
+// в нем не осталось места - отправляет и закрепляет новое. Возвращает сообщение с итогами или nil.
+func (a *App) appendDailyNotes(m *outgoing) *telegram.Message {
+	ctx := context.Background()
+	chatID := m.in.Chat.ID
+	now := time.Now().In(a.chatLocation(ctx, m.in))
+	date := now.Format("2006-01-02")
+	entry := dailyEntry(m, now)
+
+	a.dailyMu.Lock()
+	defer a.dailyMu.Unlock()
+	notes := a.loadDailyNotes(ctx, chatID)
+	if notes.Date == date && notes.MessageID != 0 {
+		text := notes.Text + "\n\n" + entry
+		if fitsMessage(text, a.cfg.MaxMessageLength) {
+			err := a.tele.EditMessageText(chatID, notes.MessageID, text, telegram.SendOptions{ParseMode: "HTML"})
+			if err == nil {
+				notes.Text = text
+				a.saveDailyNotes(ctx, chatID, notes)
+				return &telegram.Message{MessageID: notes.MessageID, Chat: m.in.Chat}
+			}
+			// Сообщение могли удалить: начинаем новое
+			log.Printf("Ошибка дописывания итогов дня в чате %d: %v", chatID, err)
+		}
+	}
+
+	title := "Итоги дня " + now.Format("02.01.2006")
+	if notes.Date == date {
+		title += " (продолжение)"
+	}
+	text := "<b>" + title + "</b>\n\n" + entry
+	if !fitsMessage(text, a.cfg.MaxMessageLength) {
+		return nil
+	}
+	sent, err := a.tele.Send(chatID, text, telegram.SendOptions{ParseMode: "HTML", ProtectContent: m.opts.ProtectContent, DisableNotification: m.opts.DisableNotification})
+	if err != nil {
+		log.Printf("Ошибка отправки итогов дня в чат %d: %v", chatID, err)
+		return nil
+	}
+	if err := a.tele.PinChatMessage(chatID, sent.MessageID, true); err != nil {
+		log.Printf("Не удалось закрепить итоги дня в чате %d (нужно право закреплять сообщения): %v", chatID, err)
+	}
+	a.saveDailyNotes(ctx, chatID, dailyNotes{Date: date, MessageID: sent.MessageID, Text: text})
+	return sent
+}
+
+// dailyEntry собирает запись для "Итогов дня": время, автор записи и резюме
+func dailyEntry(m *outgoing, now time.Time) string {
+	header := "<b>" + now.Format("15:04") + "</b>"
+	if from := m.in.From; from != nil && !from.IsBot {
+		if name := strings.TrimSpace(from.FirstName + " " + from.LastName); name != "" {
+			header += " · " + html.EscapeString(name)
+		}
+	}
+	if m.data.Origin != "" {
+		header += " · <i>переслано: " + html.EscapeString(m.data.Origin) + "</i>"
+	}
+	body := m.data.Body
+	if m.data.Spoiler {
+		body = "<tg-spoiler>" + body + "</tg-spoiler>"
+	}
+	return format.SanitizeHTML(header + "\n" + body)
+}
+
+// fitsMessage сообщает, помещается ли HTML-текст в одно сообщение Telegram
+func fitsMessage(text string, maxLen int) bool {
+	return len(format.SplitHTML(text, maxLen)) <= 1
+}
+
+func (a *App) loadDailyNotes(ctx context.Context, chatID int64) dailyNotes {
+	var notes dailyNotes
+	data, found, err := a.cache.Get(ctx, dailyNotesKey(chatID))
+	if err != nil {
+		log.Printf("Ошибка чтения итогов дня чата %d из кэша: %v", chatID, err)
+	}
+	if found {
+		if err := json.Unmarshal([]byte(data), &notes); err != nil {
+			log.Printf("Ошибка разбора итогов дня чата %d: %v", chatID, err)
+		}
+	}
+	return notes
+}
+
+func (a *App) saveDailyNotes(ctx context.Context, chatID int64, notes dailyNotes) {
+	data, err := json.Marshal(notes)
+	if err == nil {
+		err = a.cache.Set(ctx, dailyNotesKey(chatID), string(data), dailyNotesTTL)
+	}
+	if err != nil {
+		log.Printf("Ошибка записи итогов дня чата %d в кэш: %v", chatID, err)
+	}
+}
+
+func (a *App) cmdDaily(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	const usage = "Использование: /daily on|off. В этом режиме резюме не приходят отдельными сообщениями, а дописываются " +
+		"в одно закрепленное сообщение «Итоги дня»; каждый день начинается новое. Чтобы закреплять сообщения, боту нужно это право."
+	var daily bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "выключены"
+		if settings.DailyNotes {
+			state = "включены"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Итоги дня "+state+".\n\n"+usage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		daily = true
+	case "off", "выкл", "нет":
+		daily = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять режим могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.DailyNotes = daily
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if daily {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Итоги дня включены: резюме будут дописываться в закрепленное сообщение.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Итоги дня выключены: резюме снова приходят отдельными сообщениями.", msg.MessageID, "")
+	}
+}
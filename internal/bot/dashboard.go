@@ -0,0 +1,255 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+)
+
+// Сколько последних заданий показывает панель и за какой срок считаются ошибки и активность чатов
+const (
+	dashboardRecentJobs = 50
+	dashboardWindow     = 24 * time.Hour
+	dashboardTopChats   = 20
+)
+
+// dashboardState - снимок состояния бота для веб-панели; он же отдается в JSON по /api/state
+type dashboardState struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Queue       *queueState    `json:"queue,omitempty"`
+	Maintenance bool           `json:"maintenance"`
+	Jobs        int            `json:"jobs_24h"`
+	Errors      int            `json:"errors_24h"`
+	ErrorRate   float64        `json:"error_rate_24h"`
+	Tokens      int64          `json:"tokens_24h"`
+	TokensDay   int64          `json:"tokens_today"`
+	TokensMonth int64          `json:"tokens_month"`
+	CostDay     float64        `json:"cost_today,omitempty"`
+	CostMonth   float64        `json:"cost_month,omitempty"`
+	Sent        map[string]int `json:"sent_messages"`
+	Recent      []dashboardJob `json:"recent"`
+	Chats       []chatActivity `json:"chats"`
+}
+
+type dashboardJob struct {
+	Time     time.Time `json:"time"`
+	ChatID   string    `json:"chat_id"`
+	UserID   string    `json:"user_id"`
+	Kind     string    `json:"kind"`
+	Duration int       `json:"duration,omitempty"`
+	Tokens   int       `json:"tokens,omitempty"`
+	Outcome  string    `json:"outcome"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type chatActivity struct {
+	ChatID string `json:"chat_id"`
+	Jobs   int    `json:"jobs"`
+	Errors int    `json:"errors"`
+	Tokens int64  `json:"tokens"`
+	Audio  int    `json:"audio_seconds"`
+}
+
+// RunDashboard запускает веб-панель операторов на DASHBOARD_LISTEN_ADDR. Все запросы требуют
+// DASHBOARD_TOKEN: в заголовке Authorization: Bearer или паролем HTTP Basic (имя любое).
+func (a *App) RunDashboard() error {
+	if a.cfg.DashboardToken == "" {
+		return fmt.Errorf("веб-панель не запущена: не задан DASHBOARD_TOKEN")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", a.serveDashboard)
+	mux.HandleFunc("/api/state", a.serveDashboardState)
+	mux.HandleFunc("/api/events", a.serveEvents)
+	log.Printf("Веб-панель слушает на %s", a.cfg.DashboardListenAddr)
+	return http.ListenAndServe(a.cfg.DashboardListenAddr, a.dashboardAuth(mux))
+}
+
+func (a *App) dashboardAuth(next http.Handler) http.Handler {
+	token := []byte(a.cfg.DashboardToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got string
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			got = bearer
+		} else if _, password, ok := r.BasicAuth(); ok {
+			got = password
+		}
+		if subtle.ConstantTimeCompare([]byte(got), token) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="dashboard"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// dashboardSnapshot собирает состояние очереди, последние задания, ошибки и расходы из журнала аудита и счетчиков
+func (a *App) dashboardSnapshot(ctx context.Context) (dashboardState, error) {
+	now := time.Now()
+	st := dashboardState{GeneratedAt: now, Sent: a.sentMessages.snapshot()}
+	a.maintenance.mu.Lock()
+	st.Maintenance = a.maintenance.enabled
+	a.maintenance.mu.Unlock()
+	if a.scheduler != nil {
+		q := a.scheduler.state()
+		st.Queue = &q
+	}
+	for _, c := range []struct {
+		period string
+		dst    *int64
+	}{{store.DayPeriod(now), &st.TokensDay}, {store.MonthPeriod(now), &st.TokensMonth}} {
+		used, err := a.quotas.GetUsage(ctx, store.Quota{Subject: store.GlobalSubject, Metric: store.MetricTokens, Period: c.period})
+		if err != nil {
+			return st, fmt.Errorf("не удалось прочитать расходы: %w", err)
+		}
+		*c.dst = used
+	}
+	if price := a.cfg.TokenPricePerMillion; price > 0 {
+		st.CostDay = float64(st.TokensDay) * price / 1e6
+		st.CostMonth = float64(st.TokensMonth) * price / 1e6
+	}
+	if a.audit == nil {
+		return st, nil
+	}
+	entries, err := a.audit.ListAudit(ctx, store.AuditQuery{Action: auditActionMedia, Since: now.Add(-dashboardWindow)})
+	if err != nil {
+		return st, fmt.Errorf("не удалось прочитать журнал аудита: %w", err)
+	}
+	chats := map[string]*chatActivity{}
+	for _, e := range entries {
+		var d mediaAudit
+		if err := json.Unmarshal([]byte(e.Details), &d); err != nil {
+			continue
+		}
+		failed := d.Outcome != "ok"
+		st.Jobs++
+		st.Tokens += int64(d.Tokens)
+		c := chats[e.ChatID]
+		if c == nil {
+			c = &chatActivity{ChatID: e.ChatID}
+			chats[e.ChatID] = c
+		}
+		c.Jobs++
+		c.Tokens += int64(d.Tokens)
+		c.Audio += d.Duration
+		if failed {
+			st.Errors++
+			c.Errors++
+		}
+		st.Recent = append(st.Recent, dashboardJob{Time: e.CreatedAt, ChatID: e.ChatID, UserID: e.UserID, Kind: d.Kind,
+			Duration: d.Duration, Tokens: d.Tokens, Outcome: d.Outcome, Error: d.Error})
+	}
+	if st.Jobs > 0 {
+		st.ErrorRate = float64(st.Errors) / float64(st.Jobs)
+	}
+	// Журнал идет в хронологическом порядке, панели нужны самые свежие задания первыми
+	for i, j := 0, len(st.Recent)-1; i < j; i, j = i+1, j-1 {
+		st.Recent[i], st.Recent[j] = st.Recent[j], st.Recent[i]
+	}
+	if len(st.Recent) > dashboardRecentJobs {
+		st.Recent = st.Recent[:dashboardRecentJobs]
+	}
+	for _, c := range chats {
+		st.Chats = append(st.Chats, *c)
+	}
+	sort.Slice(st.Chats, func(i, j int) bool {
+		if st.Chats[i].Jobs != st.Chats[j].Jobs {
+			return st.Chats[i].Jobs > st.Chats[j].Jobs
+		}
+		return st.Chats[i].ChatID < st.Chats[j].ChatID
+	})
+	if len(st.Chats) > dashboardTopChats {
+		st.Chats = st.Chats[:dashboardTopChats]
+	}
+	return st, nil
+}
+
+func (a *App) serveDashboardState(w http.ResponseWriter, r *http.Request) {
+	st, err := a.dashboardSnapshot(r.Context())
+	if err != nil {
+		log.Printf("Ошибка веб-панели: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
+
+func (a *App) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	st, err := a.dashboardSnapshot(r.Context())
+	if err != nil {
+		log.Printf("Ошибка веб-панели: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardPage.Execute(w, st); err != nil {
+		log.Printf("Ошибка отрисовки веб-панели: %v", err)
+	}
+}
+
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"clock":   func(t time.Time) string { return t.Format("02.01 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>VoiceShut-UP</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>VoiceShut-UP</h1>
+<p>Обновлено {{clock .GeneratedAt}}{{if .Maintenance}} · <b>режим обслуживания</b>{{end}}</p>
+
+<h2>Очередь</h2>
+{{with .Queue}}
+<p>Выполняется {{.Running}} из {{.Limit}}, ждут {{.Waiting}}, среднее задание {{.AvgJob}}</p>
+<table>
+<tr><th>Приоритет</th><th>Доля мест</th><th>Выполняется</th><th>Ждут</th></tr>
+{{range .Levels}}<tr><td>{{.Name}}</td><td>{{.Share}}</td><td>{{.Running}}</td><td>{{.Waiting}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>Очередь не ограничена (MAX_CONCURRENT_JOBS=0)</p>
+{{end}}
+
+<h2>За 24 часа</h2>
+<p>Заданий {{.Jobs}}, ошибок {{.Errors}} ({{percent .ErrorRate}}), токенов {{.Tokens}}</p>
+<p>Токенов сегодня {{.TokensDay}}, за месяц {{.TokensMonth}}{{if .CostMonth}} (≈ ${{printf "%.2f" .CostDay}} / ${{printf "%.2f" .CostMonth}}){{end}}</p>
+
+<h2>Отправлено с запуска</h2>
+<p>{{range $kind, $n := .Sent}}{{$kind}}: {{$n}} · {{else}}ничего{{end}}</p>
+
+<h2>Активность чатов</h2>
+<table>
+<tr><th>Чат</th><th>Заданий</th><th>Ошибок</th><th>Токенов</th><th>Аудио, с</th></tr>
+{{range .Chats}}<tr><td>{{.ChatID}}</td><td>{{.Jobs}}</td><td>{{.Errors}}</td><td>{{.Tokens}}</td><td>{{.Audio}}</td></tr>
+{{end}}</table>
+
+<h2>Последние задания</h2>
+<table>
+<tr><th>Время</th><th>Чат</th><th>Пользователь</th><th>Тип</th><th>Длительность, с</th><th>Токенов</th><th>Результат</th></tr>
+{{range .Recent}}<tr><td>{{clock .Time}}</td><td>{{.ChatID}}</td><td>{{.UserID}}</td><td>{{.Kind}}</td><td>{{.Duration}}</td><td>{{.Tokens}}</td>
+<td{{if ne .Outcome "ok"}} class="error" title="{{.Error}}"{{end}}>{{.Outcome}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
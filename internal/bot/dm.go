@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const dmUsage = "Использование: /dm on|off. С /dm on расшифровки и резюме ваших записей из групп приходят вам в личные сообщения, " +
+	"а в группе остается короткая отметка. Сначала напишите боту в личку /start, иначе он не сможет вам писать."
+
+// wantsDM сообщает, просил ли пользователь userID присылать его результаты в личку (/dm on).
+// Настройка хранится в настройках его личного чата с ботом: ID этого чата совпадает с ID пользователя.
+func (a *App) wantsDM(ctx context.Context, userID int64) bool {
+	settings, err := a.settings.GetSettings(ctx, "telegram", strconv.FormatInt(userID, 10))
+	if err != nil {
+		log.Printf("Ошибка чтения настроек пользователя %d: %v", userID, err)
+		return false
+	}
+	return settings.DeliverToDM
+}
+
+// dmOutgoing отправляет расшифровку и резюме записи из группы автору в личку, если он включил /dm,
+// а в группе отвечает на запись короткой отметкой. Если написать в личку не удалось (пользователь
+// не начинал диалог с ботом), результат отправляется в группу как обычно.
+func (a *App) dmOutgoing(next sendFunc) sendFunc {
+	return func(m *outgoing) *telegram.Message {
+		in, from := m.in, m.in.From
+		if in.Chat.IsPrivate() || from == nil || from.IsBot || (m.data.Kind != format.KindTranscript && m.data.Kind != format.KindSummary) ||
+			!a.wantsDM(context.Background(), from.ID) {
+			return next(m)
+		}
+		group := *m
+		dm := *in
+		dm.Chat = &telegram.Chat{ID: from.ID, Type: "private"}
+		m.in, m.replyTo, m.opts.ReplyTo = &dm, 0, 0
+		// Кнопки под результатами ссылаются на запись в группе и в личке не работают
+		m.actions = nil
+		// Итоги дня ведутся в группе, а в личку результат приходит отдельным сообщением
+		m.settings.DailyNotes = false
+		if title := in.Chat.Title; title != "" {
+			source := "«" + html.EscapeString(title) + "»"
+			if link := in.Chat.MessageLink(in.MessageID); link != "" {
+				source = `<a href="` + html.EscapeString(link) + `">` + source + "</a>"
+			}
+			m.text = "<i>Из чата " + source + "</i>\n\n" + m.text
+		}
+		if next(m) == nil {
+			log.Printf("Не удалось отправить результат пользователю %d в личку, отправляю в чат %d", from.ID, in.Chat.ID)
+			*m = group
+			return next(m)
+		}
+		if m.data.Kind == format.KindSummary && !m.settings.Quiet {
+			note := "📬 Отправил результат в личку"
+			if name := strings.TrimSpace(from.FirstName); name != "" {
+				note = "📬 " + name + ", отправил результат в личку"
+			}
+			if _, err := a.tele.Send(in.Chat.ID, note, telegram.SendOptions{ReplyTo: in.MessageID, DisableNotification: true}); err != nil {
+				log.Printf("Ошибка отправки отметки в чат %d: %v", in.Chat.ID, err)
+			}
+		}
+		// ID сообщения в личке не относится к группе: закреплять и сжимать в группе нечего
+		return nil
+	}
+}
+
+func (a *App) cmdDM(msg *telegram.Message, args string) {
+	if msg.From == nil {
+		return
+	}
+	ctx := context.Background()
+	userID := strconv.FormatInt(msg.From.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", userID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек пользователя %d: %v", msg.From.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать ваши настройки.", msg.MessageID, "")
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "в группу"
+		if settings.DeliverToDM {
+			state = "в личку"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Результаты ваших записей из групп приходят "+state+".\n\n"+dmUsage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		settings.DeliverToDM = true
+	case "off", "выкл", "нет":
+		settings.DeliverToDM = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, dmUsage, msg.MessageID, "")
+		return
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", userID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек пользователя %d: %v", msg.From.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить ваши настройки.", msg.MessageID, "")
+		return
+	}
+	if settings.DeliverToDM {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Готово: результаты ваших записей из групп будут приходить в личку.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Готово: результаты ваших записей снова приходят в группу.", msg.MessageID, "")
+	}
+}
@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Типы событий хода обработки
+const (
+	eventStage      = "stage"
+	eventTranscript = "transcript"
+	eventSummary    = "summary"
+	eventError      = "error"
+
+	// Фрагмент резюме по мере генерации; итоговое резюме (после проверки и глав) приходит событием summary
+	eventSummaryDelta = "summary_delta"
+)
+
+// eventBuffer - сколько событий ждет медленного подписчика; сверх этого события для него отбрасываются
+const eventBuffer = 64
+
+// jobEvent - событие хода обработки сообщения для подписчиков /api/events
+type jobEvent struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	ChatID    string    `json:"chat_id"`
+	MessageID string    `json:"message_id"`
+	// Завершившийся этап конвейера и его длительность (для eventStage)
+	Stage    string `json:"stage,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	// Расшифровка, резюме, фрагмент резюме или текст ошибки
+	Text string `json:"text,omitempty"`
+	// Генерация резюме началась заново: полученные фрагменты нужно отбросить (для eventSummaryDelta)
+	Restart bool `json:"restart,omitempty"`
+}
+
+// eventHub раздает события хода обработки подписчикам; значение - чат, события которого нужны ("" - все)
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan jobEvent]string
+}
+
+func (h *eventHub) subscribe(chatID string) (<-chan jobEvent, func()) {
+	ch := make(chan jobEvent, eventBuffer)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan jobEvent]string)
+	}
+	h.subs[ch] = chatID
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// active сообщает, есть ли подписчики: без них события не собираются
+func (h *eventHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs) > 0
+}
+
+// publish отправляет событие подписчикам, не блокируя обработку: медленный подписчик теряет события
+func (h *eventHub) publish(e jobEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch, chatID := range h.subs {
+		if chatID != "" && chatID != e.ChatID {
+			continue
+		}
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// publishJob публикует событие обработки сообщения msg
+func (a *App) publishJob(msg *telegram.Message, e jobEvent) {
+	if !a.events.active() {
+		return
+	}
+	e.Time = time.Now()
+	e.ChatID = strconv.FormatInt(msg.Chat.ID, 10)
+	e.MessageID = strconv.Itoa(msg.MessageID)
+	a.events.publish(e)
+}
+
+// serveEvents отдает события хода обработки потоком Server-Sent Events; параметр chat оставляет события одного чата
+func (a *App) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := a.events.subscribe(r.URL.Query().Get("chat"))
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+	// Комментарии раз в 15 секунд не дают прокси закрыть простаивающее соединение
+	ping := time.NewTicker(15 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Printf("Ошибка маршалинга события: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
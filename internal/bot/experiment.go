@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Счетчики эксперимента хранятся в QuotaStore: субъект - вариант, период - момент запуска эксперимента
+const (
+	metricSummaries = "summaries"
+	metricVotesUp   = "votes_up"
+	metricVotesDown = "votes_down"
+)
+
+// Имена экспериментов и вариантов попадают в callback_data, поэтому короткие и без двоеточий
+var experimentNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,16}$`)
+
+func experimentQuota(exp *store.Experiment, variant, metric string) store.Quota {
+	return store.Quota{
+		Subject: "exp:" + exp.Name + ":" + variant,
+		Metric:  metric,
+		Period:  exp.StartedAt.UTC().Format("20060102T150405"),
+	}
+}
+
+// chatVariant возвращает вариант идущего эксперимента, назначенный чату сообщения in. Назначение
+// случайное, но постоянное для пары эксперимент-чат. Чаты с выбранной персоной в экспериментах
+// не участвуют: их системный промпт задан явно.
+func (a *App) chatVariant(ctx context.Context, in *telegram.Message) (*store.Experiment, *store.ExperimentVariant) {
+	exp := a.experiment.Load()
+	if exp == nil || len(exp.Variants) == 0 || a.chatSettings(ctx, in).Persona != "" {
+		return nil, nil
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", exp.Name, in.Chat.ID)
+	return exp, &exp.Variants[h.Sum32()%uint32(len(exp.Variants))]
+}
+
+// countExperiment увеличивает счетчик варианта эксперимента
+func (a *App) countExperiment(exp *store.Experiment, variant, metric string) {
+	if _, err := a.quotas.AddUsage(context.Background(), experimentQuota(exp, variant, metric), 1); err != nil {
+		log.Printf("Ошибка учета эксперимента %s (%s, %s): %v", exp.Name, variant, metric, err)
+	}
+}
+
+// voteButtons - кнопки оценки резюме, полученного в варианте эксперимента
+func voteButtons(variant string, messageID int) [][]telegram.InlineKeyboardButton {
+	return [][]telegram.InlineKeyboardButton{{
+		{Text: "👍", CallbackData: fmt.Sprintf("vote:up:%s:%d", variant, messageID)},
+		{Text: "👎", CallbackData: fmt.Sprintf("vote:down:%s:%d", variant, messageID)},
+	}}
+}
+
+// onVoteCallback учитывает оценку резюме; каждый пользователь оценивает резюме один раз
+func (a *App) onVoteCallback(q *telegram.CallbackQuery, payload string) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	vote, variant, messageID := parts[0], parts[1], parts[2]
+	exp := a.experiment.Load()
+	if exp == nil || !hasVariant(exp, variant) {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Эксперимент уже завершен, спасибо!")
+		return
+	}
+	metric := metricVotesUp
+	if vote == "down" {
+		metric = metricVotesDown
+	}
+	ctx := context.Background()
+	key := cache.VoteKey("telegram", strconv.FormatInt(q.Message.Chat.ID, 10), messageID, strconv.FormatInt(q.From.ID, 10))
+	if _, found, err := a.cache.Get(ctx, key); err == nil && found {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Вы уже оценили это резюме")
+		return
+	}
+	if err := a.cache.Set(ctx, key, vote, a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка записи оценки в кэш: %v", err)
+	}
+	a.countExperiment(exp, variant, metric)
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Спасибо за оценку!")
+}
+
+func hasVariant(exp *store.Experiment, name string) bool {
+	for _, v := range exp.Variants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+const experimentUsage = "Использование:\n" +
+	"/admin experiment - результаты идущего эксперимента\n" +
+	"/admin experiment start <имя>, а на следующих строках варианты в виде «имя: системный промпт» " +
+	"(не меньше двух; вариант без промпта использует действующий системный промпт)\n" +
+	"/admin experiment stop - завершить эксперимент и показать итоги"
+
+func (a *App) adminExperiment(msg *telegram.Message, args string) {
+	action, rest := cutWord(args)
+	switch strings.ToLower(action) {
+	case "":
+		exp := a.experiment.Load()
+		if exp == nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Экспериментов нет.\n\n"+experimentUsage, msg.MessageID, "")
+			return
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, a.experimentReport(exp), msg.MessageID, "")
+	case "start":
+		exp, err := parseExperiment(rest, time.Now())
+		if err != nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("%v.\n\n%s", err, experimentUsage), msg.MessageID, "")
+			return
+		}
+		if !a.saveExperiment(msg, exp) {
+			return
+		}
+		log.Printf("Администратор %d запустил эксперимент %s (%d вариантов)", msg.From.ID, exp.Name, len(exp.Variants))
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Эксперимент %s запущен: чаты распределяются между %d вариантами.", exp.Name, len(exp.Variants)), msg.MessageID, "")
+	case "stop":
+		exp := a.experiment.Load()
+		if exp == nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Экспериментов нет.", msg.MessageID, "")
+			return
+		}
+		if !a.saveExperiment(msg, nil) {
+			return
+		}
+		log.Printf("Администратор %d завершил эксперимент %s", msg.From.ID, exp.Name)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Эксперимент завершен.\n\n"+a.experimentReport(exp), msg.MessageID, "")
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, experimentUsage, msg.MessageID, "")
+	}
+}
+
+// parseExperiment разбирает "<имя>\n<вариант>: <промпт>\n..."
+func parseExperiment(args string, now time.Time) (*store.Experiment, error) {
+	header, body, _ := strings.Cut(args, "\n")
+	exp := &store.Experiment{Name: strings.TrimSpace(header), StartedAt: now}
+	if !experimentNameRe.MatchString(exp.Name) {
+		return nil, fmt.Errorf("имя эксперимента - до 16 латинских букв, цифр, _ и -")
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, prompt, _ := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !experimentNameRe.MatchString(name) {
+			return nil, fmt.Errorf("некорректное имя варианта %q: до 16 латинских букв, цифр, _ и -", name)
+		}
+		if hasVariant(exp, name) {
+			return nil, fmt.Errorf("вариант %s указан дважды", name)
+		}
+		exp.Variants = append(exp.Variants, store.ExperimentVariant{Name: name, SystemPrompt: strings.TrimSpace(prompt)})
+	}
+	if len(exp.Variants) < 2 {
+		return nil, fmt.Errorf("нужно хотя бы два варианта")
+	}
+	return exp, nil
+}
+
+// saveExperiment сохраняет эксперимент (nil - завершает) в настройках бота и применяет его
+func (a *App) saveExperiment(msg *telegram.Message, exp *store.Experiment) bool {
+	if a.botSettings == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Хранилище настроек бота не настроено.", msg.MessageID, "")
+		return false
+	}
+	ctx := context.Background()
+	settings, err := a.botSettings.GetBotSettings(ctx)
+	if err == nil {
+		settings.Experiment = exp
+		err = a.botSettings.SaveBotSettings(ctx, settings)
+	}
+	if err != nil {
+		log.Printf("Ошибка сохранения эксперимента: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки бота.", msg.MessageID, "")
+		return false
+	}
+	a.experiment.Store(exp)
+	return true
+}
+
+// experimentReport сравнивает варианты эксперимента по числу резюме и доле положительных оценок
+func (a *App) experimentReport(exp *store.Experiment) string {
+	ctx := context.Background()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Эксперимент %s (с %s UTC):\n", exp.Name, exp.StartedAt.UTC().Format("02.01.2006 15:04"))
+	for _, v := range exp.Variants {
+		var counts [3]int64
+		for i, metric := range []string{metricSummaries, metricVotesUp, metricVotesDown} {
+			n, err := a.quotas.GetUsage(ctx, experimentQuota(exp, v.Name, metric))
+			if err != nil {
+				log.Printf("Ошибка чтения счетчиков эксперимента %s: %v", exp.Name, err)
+			}
+			counts[i] = n
+		}
+		fmt.Fprintf(&b, "%s: резюме %d, 👍 %d, 👎 %d", v.Name, counts[0], counts[1], counts[2])
+		if votes := counts[1] + counts[2]; votes > 0 {
+			fmt.Fprintf(&b, " (%d%% положительных)", counts[1]*100/votes)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
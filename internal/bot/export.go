@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+type exportItem struct {
+	MessageID       string    `json:"message_id"`
+	UserID          string    `json:"user_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Transcript      string    `json:"transcript"`
+	Summary         string    `json:"summary"`
+}
+
+type exportDocument struct {
+	ChatID     string       `json:"chat_id"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Timezone   string       `json:"timezone"`
+	Since      *time.Time   `json:"since,omitempty"`
+	Items      []exportItem `json:"items"`
+}
+
+// parseExportArgs разбирает аргументы /export: период (7d, 24h, all) и формат (json, ndjson) в любом порядке
+func parseExportArgs(args string, now time.Time) (since time.Time, ndjson bool, err error) {
+	for _, arg := range strings.Fields(strings.ToLower(args)) {
+		switch {
+		case arg == "json":
+			ndjson = false
+		case arg == "ndjson":
+			ndjson = true
+		default:
+			var ok bool
+			if since, ok, err = parsePeriod(arg, now); !ok {
+				return since, ndjson, fmt.Errorf("неизвестный аргумент %q", arg)
+			}
+			if err != nil {
+				return since, ndjson, err
+			}
+		}
+	}
+	return since, ndjson, nil
+}
+
+// parsePeriod разбирает период "7d", "24h" или "all" и возвращает его начало; ok = false,
+// если arg - не период
+func parsePeriod(arg string, now time.Time) (since time.Time, ok bool, err error) {
+	switch {
+	case arg == "all":
+		return time.Time{}, true, nil
+	case strings.HasSuffix(arg, "d"):
+		days, convErr := strconv.Atoi(strings.TrimSuffix(arg, "d"))
+		if convErr != nil || days <= 0 {
+			return since, true, fmt.Errorf("некорректный период %q", arg)
+		}
+		return now.AddDate(0, 0, -days), true, nil
+	case strings.HasSuffix(arg, "h"):
+		hours, convErr := strconv.Atoi(strings.TrimSuffix(arg, "h"))
+		if convErr != nil || hours <= 0 {
+			return since, true, fmt.Errorf("некорректный период %q", arg)
+		}
+		return now.Add(-time.Duration(hours) * time.Hour), true, nil
+	}
+	return since, false, nil
+}
+
+// encodeExport сериализует записи; время указывается в часовом поясе чата loc
+func encodeExport(chatID string, records []store.Record, since time.Time, ndjson bool, loc *time.Location) ([]byte, error) {
+	items := make([]exportItem, 0, len(records))
+	for _, r := range records {
+		items = append(items, exportItem{
+			MessageID:       r.MessageID,
+			UserID:          r.UserID,
+			CreatedAt:       r.CreatedAt.In(loc),
+			DurationSeconds: r.Duration.Seconds(),
+			Transcript:      r.Transcript,
+			Summary:         r.Summary,
+		})
+	}
+	var buf bytes.Buffer
+	if ndjson {
+		enc := json.NewEncoder(&buf)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	doc := exportDocument{ChatID: chatID, ExportedAt: time.Now().In(loc), Timezone: loc.String(), Items: items}
+	if !since.IsZero() {
+		sinceLocal := since.In(loc)
+		doc.Since = &sinceLocal
+	}
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportCaption - подпись к файлу экспорта: период, число записей, их общая длительность и дата выгрузки
+func exportCaption(records []store.Record, since, now time.Time) string {
+	var total time.Duration
+	for _, r := range records {
+		total += r.Duration
+	}
+	period := "за всё время"
+	if !since.IsZero() {
+		period = "с " + since.Format("02.01.2006 15:04")
+	}
+	caption := fmt.Sprintf("<b>Экспорт расшифровок</b> %s\nЗаписей: %d", period, len(records))
+	if total > 0 {
+		caption += ", общая длительность: " + format.Duration(total)
+	}
+	return caption + "\nВыгружено: " + now.Format("02.01.2006 15:04")
+}
+
+func (a *App) cmdExport(msg *telegram.Message, args string) {
+	if a.records == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Экспорт недоступен: постоянное хранилище не настроено.", msg.MessageID, "")
+		return
+	}
+	if msg.From == nil || !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Выгружать историю чата могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	since, ndjson, err := parseExportArgs(args, time.Now())
+	if err != nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("%v. Использование: /export [7d|24h|all] [json|ndjson]", err), msg.MessageID, "")
+		return
+	}
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	records, err := a.records.ListRecords(context.Background(), "telegram", chatID, since)
+	if err != nil {
+		log.Printf("Ошибка чтения записей для экспорта в чате %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать сохраненные записи.", msg.MessageID, "")
+		return
+	}
+	if len(records) == 0 {
+		_ = a.tele.SendMessage(msg.Chat.ID, "За выбранный период нет сохраненных расшифровок.", msg.MessageID, "")
+		return
+	}
+	loc := a.chatLocation(context.Background(), msg)
+	data, err := encodeExport(chatID, records, since, ndjson, loc)
+	if err != nil {
+		log.Printf("Ошибка формирования экспорта для чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сформировать файл экспорта.", msg.MessageID, "")
+		return
+	}
+	ext := "json"
+	if ndjson {
+		ext = "ndjson"
+	}
+	now := time.Now().In(loc)
+	fileName := fmt.Sprintf("export-%s-%s.%s", chatID, now.Format("20060102"), ext)
+	// История группы уходит только тому, кто ее запросил, в личку
+	caption := exportCaption(records, since.In(loc), now)
+	opts := telegram.SendOptions{ParseMode: "HTML", ProtectContent: a.chatSettings(context.Background(), msg).ProtectContent}
+	if msg.Chat.IsPrivate() {
+		opts.ReplyTo = msg.MessageID
+	} else if title := msg.Chat.Title; title != "" {
+		caption = "<i>Чат «" + html.EscapeString(title) + "»</i>\n" + caption
+	}
+	opts.Caption = caption
+	if err := a.tele.SendDocument(msg.From.ID, fileName, data, opts); err != nil {
+		log.Printf("Ошибка отправки экспорта чата %d пользователю %d: %v", msg.Chat.ID, msg.From.ID, err)
+		text := "Не удалось отправить файл экспорта."
+		if !msg.Chat.IsPrivate() {
+			text = "Не удалось отправить файл экспорта вам в личку: напишите боту /start в личных сообщениях и повторите команду."
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, text, msg.MessageID, "")
+		return
+	}
+	if !msg.Chat.IsPrivate() {
+		_ = a.tele.SendMessage(msg.Chat.ID, "📬 Отправил файл экспорта в личку.", msg.MessageID, "")
+	}
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/flags"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Флаги функций, которые можно выключить или раскатывать на часть чатов
+const (
+	flagVoiceCommands = "voice_commands"
+	flagPaidMedia     = "paid_media"
+	flagBusiness      = "business"
+	flagMimeDocuments = "mime_documents"
+)
+
+// flagDescriptions - описания флагов для /admin flags
+var flagDescriptions = map[string]string{
+	flagVoiceCommands: "голосовые команды в личных чатах",
+	flagPaidMedia:     "расшифровка оплаченного платного видео",
+	flagBusiness:      "обработка сообщений Telegram Business",
+	flagMimeDocuments: "документы без расширения, распознанные по MIME-типу (боты-мосты)",
+}
+
+// newFeatureFlags создает флаги: все функции включены, пока FEATURE_FLAGS не скажет иное
+func newFeatureFlags(env string) *flags.Set {
+	defaults := make(map[string]store.FlagRule, len(flagDescriptions))
+	for name := range flagDescriptions {
+		defaults[name] = store.FlagRule{Percent: 100}
+	}
+	if err := flags.ApplyEnv(defaults, env); err != nil {
+		log.Printf("Ошибка в FEATURE_FLAGS: %v", err)
+	}
+	return flags.New(defaults)
+}
+
+// featureEnabled сообщает, включена ли функция name в чате chatID
+func (a *App) featureEnabled(name string, chatID int64) bool {
+	return a.flags.Enabled(name, strconv.FormatInt(chatID, 10))
+}
+
+const flagsUsage = "Использование:\n" +
+	"/admin flags - состояние флагов\n" +
+	"/admin flags <флаг> on|off|<N>% - включить, выключить или включить для доли чатов\n" +
+	"/admin flags <флаг> chat <ID чата> on|off|default - решение для отдельного чата\n" +
+	"/admin flags <флаг> reset - вернуть значение по умолчанию (FEATURE_FLAGS)"
+
+func (a *App) adminFlags(msg *telegram.Message, args string) {
+	name, rest := cutWord(args)
+	if name == "" {
+		var b strings.Builder
+		b.WriteString("Флаги функций:\n")
+		for _, n := range a.flags.Names() {
+			rule, overridden := a.flags.Rule(n)
+			fmt.Fprintf(&b, "%s - %s: %s", n, flagDescriptions[n], flags.Describe(rule))
+			if overridden {
+				b.WriteString(" (изменен администратором)")
+			}
+			b.WriteString("\n")
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, b.String(), msg.MessageID, "")
+		return
+	}
+	if !a.flags.Known(name) {
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Неизвестный флаг %q.\n\n%s", name, flagsUsage), msg.MessageID, "")
+		return
+	}
+	if a.botSettings == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Хранилище настроек бота не настроено.", msg.MessageID, "")
+		return
+	}
+	ctx := context.Background()
+	settings, err := a.botSettings.GetBotSettings(ctx)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек бота: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки бота.", msg.MessageID, "")
+		return
+	}
+	rule, _ := a.flags.Rule(name)
+	rule.Chats = maps.Clone(rule.Chats)
+	action, value := cutWord(rest)
+	switch strings.ToLower(action) {
+	case "reset":
+		delete(settings.Flags, name)
+	case "chat":
+		chatID, state := cutWord(value)
+		if _, err := strconv.ParseInt(chatID, 10, 64); err != nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, flagsUsage, msg.MessageID, "")
+			return
+		}
+		switch strings.ToLower(state) {
+		case "on":
+			rule.Chats = setChat(rule.Chats, chatID, true)
+		case "off":
+			rule.Chats = setChat(rule.Chats, chatID, false)
+		case "default":
+			delete(rule.Chats, chatID)
+		default:
+			_ = a.tele.SendMessage(msg.Chat.ID, flagsUsage, msg.MessageID, "")
+			return
+		}
+	default:
+		percent, err := flags.ParsePercent(action)
+		if err != nil || value != "" {
+			_ = a.tele.SendMessage(msg.Chat.ID, flagsUsage, msg.MessageID, "")
+			return
+		}
+		rule.Percent = percent
+	}
+	if action != "reset" {
+		if settings.Flags == nil {
+			settings.Flags = make(map[string]store.FlagRule)
+		}
+		settings.Flags[name] = rule
+	}
+	if err := a.botSettings.SaveBotSettings(ctx, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек бота: %v", err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки бота.", msg.MessageID, "")
+		return
+	}
+	a.flags.SetOverrides(settings.Flags)
+	rule, _ = a.flags.Rule(name)
+	log.Printf("Администратор %d изменил флаг %s: %s", msg.From.ID, name, flags.Describe(rule))
+	_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Флаг %s: %s.", name, flags.Describe(rule)), msg.MessageID, "")
+}
+
+func setChat(chats map[string]bool, chatID string, on bool) map[string]bool {
+	if chats == nil {
+		chats = make(map[string]bool)
+	}
+	chats[chatID] = on
+	return chats
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// flightGroup схлопывает одновременные одинаковые запросы: пока запрос с ключом выполняется,
+// повторные ждут его результата, а не делают ту же работу еще раз. Нулевое значение готово к работе.
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+type flightCall[T any] struct {
+	wg  sync.WaitGroup
+	val T
+	err error
+}
+
+// do выполняет fn для ключа key или дожидается уже идущего вызова с тем же ключом; shared сообщает,
+// что результат получен от чужого вызова
+func (g *flightGroup[T]) do(key string, fn func() (T, error)) (val T, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+	if c, found := g.calls[key]; found {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &flightCall[T]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// mediaFlightKey - ключ для схлопывания обработки одного и того же файла, пришедшего в чат (тему)
+// несколько раз одновременно; "" - медиа без идентификатора файла
+func mediaFlightKey(msg *telegram.Message) string {
+	file := mediaFile(msg)
+	if file == nil || file.FileUniqueID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d:%s", msg.Chat.ID, threadOf(msg), file.FileUniqueID)
+}
+
+// mediaFile возвращает файл медиа из сообщения или nil
+func mediaFile(msg *telegram.Message) *telegram.MediaFile {
+	switch {
+	case msg.Voice != nil:
+		return &msg.Voice.MediaFile
+	case msg.Audio != nil:
+		return &msg.Audio.MediaFile
+	case msg.Video != nil:
+		return &msg.Video.MediaFile
+	case msg.VideoNote != nil:
+		return &msg.VideoNote.MediaFile
+	case msg.Document != nil:
+		return &msg.Document.MediaFile
+	}
+	return nil
+}
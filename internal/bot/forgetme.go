@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Области удаления для /forgetme
+const (
+	forgetScopeUser = "me"
+	forgetScopeChat = "chat"
+)
+
+// cmdForgetMe запрашивает подтверждение на удаление данных пользователя ("/forgetme")
+// или всего чата ("/forgetme chat", только для администраторов)
+func (a *App) cmdForgetMe(msg *telegram.Message, args string) {
+	if msg.From == nil {
+		return
+	}
+	scope := forgetScopeUser
+	question := "Удалить все ваши сохраненные расшифровки, резюме и статистику использования во всех чатах? Отменить удаление будет нельзя."
+	switch strings.ToLower(args) {
+	case "":
+	case forgetScopeChat:
+		if !a.isChatAdmin(msg) {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Удалять данные всего чата могут только администраторы.", msg.MessageID, "")
+			return
+		}
+		scope = forgetScopeChat
+		question = "Удалить все сохраненные расшифровки, резюме и настройки этого чата? Отменить удаление будет нельзя."
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /forgetme [chat]", msg.MessageID, "")
+		return
+	}
+	if a.cfg.NotionToken != "" || a.cfg.NotesVaultDir != "" || a.cfg.NotesWebDAVURL != "" {
+		question += "\n\nЗаметки, уже выгруженные в Notion или хранилище заметок, нужно удалить вручную."
+	}
+
+	userID := strconv.FormatInt(msg.From.ID, 10)
+	keyboard := &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: "Удалить", CallbackData: "forget:" + scope + ":" + userID},
+		{Text: "Отмена", CallbackData: "forget:cancel:" + userID},
+	}}}
+	if _, err := a.tele.Send(msg.Chat.ID, question, telegram.SendOptions{ReplyTo: msg.MessageID, ReplyMarkup: keyboard}); err != nil {
+		log.Printf("Ошибка отправки подтверждения удаления в чат %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// onForgetCallback обрабатывает "<me|chat|cancel>:<id пользователя>"; нажать кнопку может только автор запроса
+func (a *App) onForgetCallback(q *telegram.CallbackQuery, payload string) {
+	scope, rawUserID, _ := strings.Cut(payload, ":")
+	if q.From == nil || rawUserID != strconv.FormatInt(q.From.ID, 10) {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Это подтверждение предназначено другому пользователю")
+		return
+	}
+	chat := q.Message.Chat
+	edit := func(text string) {
+		if err := a.tele.EditMessageText(chat.ID, q.Message.MessageID, text, telegram.SendOptions{}); err != nil {
+			log.Printf("Ошибка изменения сообщения %d в чате %d: %v", q.Message.MessageID, chat.ID, err)
+		}
+	}
+	if scope == "cancel" {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		edit("Удаление отменено.")
+		return
+	}
+	if scope != forgetScopeUser && scope != forgetScopeChat {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	// Права могли измениться с момента запроса, поэтому проверяем их повторно
+	if scope == forgetScopeChat && !a.isUserAdmin(chat, q.From.ID) {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Удалять данные всего чата могут только администраторы")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "")
+
+	ctx := context.Background()
+	chatID := strconv.FormatInt(chat.ID, 10)
+	deleted, err := a.forgetRecords(ctx, scope, chatID, rawUserID)
+	if err == nil && scope == forgetScopeChat {
+		err = a.settings.DeleteSettings(ctx, "telegram", chatID)
+	}
+	if err == nil && scope == forgetScopeChat {
+		err = a.reminders.DeleteChatReminders(ctx, "telegram", chatID)
+	}
+	if err == nil && scope == forgetScopeUser {
+		err = a.quotas.DeleteUsage(ctx, "telegram", rawUserID)
+	}
+	if err == nil && scope == forgetScopeUser {
+		err = a.reminders.DeleteUserReminders(ctx, "telegram", rawUserID)
+	}
+	if err != nil {
+		log.Printf("Ошибка удаления данных (%s) пользователем %s в чате %d: %v", scope, rawUserID, chat.ID, err)
+		edit("Не удалось удалить данные, попробуйте позже.")
+		return
+	}
+	a.forgetDerived(ctx, deleted)
+
+	if err := a.audit.AppendAudit(ctx, store.AuditEntry{
+		Platform: "telegram",
+		ChatID:   chatID,
+		UserID:   rawUserID,
+		Action:   "forget_" + scope,
+		Details:  fmt.Sprintf("удалено записей: %d", len(deleted)),
+	}); err != nil {
+		log.Printf("Ошибка записи аудита удаления в чате %d: %v", chat.ID, err)
+	}
+	log.Printf("Удалены данные (%s) по запросу пользователя %s в чате %d: %d записей", scope, rawUserID, chat.ID, len(deleted))
+	edit(fmt.Sprintf("Готово. Удалено записей: %d.", len(deleted)))
+}
+
+func (a *App) forgetRecords(ctx context.Context, scope, chatID, userID string) ([]store.Record, error) {
+	switch {
+	case a.records == nil:
+		return nil, nil
+	case scope == forgetScopeChat:
+		return a.records.DeleteChatRecords(ctx, "telegram", chatID)
+	default:
+		return a.records.DeleteUserData(ctx, "telegram", userID)
+	}
+}
+
+// forgetDerived удаляет копии удаленных записей вне базы: кэш расшифровок и архивные файлы
+func (a *App) forgetDerived(ctx context.Context, records []store.Record) {
+	var keys []string
+	for _, r := range records {
+		if err := a.cache.Delete(ctx, cache.TranscriptKey(r.Platform, r.ChatID, r.MessageID)); err != nil {
+			log.Printf("Ошибка удаления расшифровки сообщения %s из кэша: %v", r.MessageID, err)
+		}
+		keys = append(keys, r.OriginalKey, r.AudioKey)
+	}
+	if err := a.pipe.DeleteArchived(ctx, keys...); err != nil {
+		log.Printf("Ошибка удаления архивных файлов: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Ограничения словаря: он целиком попадает в каждый промпт
+const (
+	glossaryMaxTerms = 100
+	glossaryMaxLen   = 100
+)
+
+const glossaryUsage = "Использование: /glossary add \"Кубернетес=Kubernetes\" — добавить термин (слева — как слышится, справа — как писать; можно несколько строк), " +
+	"/glossary remove Кубернетес — удалить, /glossary clear — очистить словарь. Словарь помогает модели правильно писать названия компаний и продуктов в расшифровках и резюме."
+
+// parseGlossaryTerms разбирает строки вида "Кубернетес=Kubernetes" (в кавычках или без)
+func parseGlossaryTerms(args string) (map[string]string, error) {
+	terms := make(map[string]string)
+	for _, line := range strings.Split(args, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "\"«»“”")
+		if line == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(line, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("не понял термин %q, нужно \"как слышится=как писать\"", line)
+		}
+		if len([]rune(from)) > glossaryMaxLen || len([]rune(to)) > glossaryMaxLen {
+			return nil, fmt.Errorf("термин %q длиннее %d символов", line, glossaryMaxLen)
+		}
+		terms[from] = to
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("не указан ни один термин")
+	}
+	return terms, nil
+}
+
+func (a *App) cmdGlossary(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	args = strings.TrimSpace(args)
+	action, rest := args, ""
+	if i := strings.IndexFunc(args, unicode.IsSpace); i >= 0 {
+		action, rest = args[:i], args[i:]
+	}
+	action = strings.ToLower(action)
+	if action == "" {
+		if len(settings.Glossary) == 0 {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Словарь терминов пуст.\n\n"+glossaryUsage, msg.MessageID, "")
+			return
+		}
+		terms := make([]string, 0, len(settings.Glossary))
+		for from, to := range settings.Glossary {
+			terms = append(terms, from+" → "+to)
+		}
+		sort.Strings(terms)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Словарь терминов:\n"+strings.Join(terms, "\n")+"\n\n"+glossaryUsage, msg.MessageID, "")
+		return
+	}
+	if action != "add" && action != "remove" && action != "clear" {
+		_ = a.tele.SendMessage(msg.Chat.ID, glossaryUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять словарь терминов могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	var reply string
+	switch action {
+	case "add":
+		terms, err := parseGlossaryTerms(rest)
+		if err != nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось добавить термины: "+err.Error()+".\n\n"+glossaryUsage, msg.MessageID, "")
+			return
+		}
+		if settings.Glossary == nil {
+			settings.Glossary = make(map[string]string)
+		}
+		for from, to := range terms {
+			settings.Glossary[from] = to
+		}
+		if len(settings.Glossary) > glossaryMaxTerms {
+			_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("В словаре может быть не больше %d терминов.", glossaryMaxTerms), msg.MessageID, "")
+			return
+		}
+		reply = fmt.Sprintf("Добавлено терминов: %d. Всего в словаре: %d.", len(terms), len(settings.Glossary))
+	case "remove":
+		from := strings.Trim(strings.TrimSpace(rest), "\"«»“”")
+		if _, found := settings.Glossary[from]; !found {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Термина «"+from+"» нет в словаре.", msg.MessageID, "")
+			return
+		}
+		delete(settings.Glossary, from)
+		reply = "Термин «" + from + "» удален из словаря."
+	case "clear":
+		settings.Glossary = nil
+		reply = "Словарь терминов очищен."
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, reply, msg.MessageID, "")
+}
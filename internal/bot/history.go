@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const (
+	defaultHistoryLimit = 10
+	maxHistoryLimit     = 30
+)
+
+func (a *App) cmdHistory(msg *telegram.Message, args string) {
+	if a.records == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "История недоступна: постоянное хранилище не настроено.", msg.MessageID, "")
+		return
+	}
+	limit := defaultHistoryLimit
+	if args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n <= 0 {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /history [количество]", msg.MessageID, "")
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+	records, err := a.records.RecentRecords(context.Background(), "telegram", strconv.FormatInt(msg.Chat.ID, 10), limit)
+	if err != nil {
+		log.Printf("Ошибка чтения истории чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать историю.", msg.MessageID, "")
+		return
+	}
+	if len(records) == 0 {
+		_ = a.tele.SendMessage(msg.Chat.ID, "В этом чате еще нет обработанных сообщений.", msg.MessageID, "")
+		return
+	}
+
+	loc := a.chatLocation(context.Background(), msg)
+	var b strings.Builder
+	b.WriteString("<b>Последние обработанные сообщения</b>\n\n")
+	keyboard := &telegram.InlineKeyboardMarkup{}
+	for i, r := range records {
+		line := r.CreatedAt.In(loc).Format("02.01 15:04")
+		if r.Duration > 0 {
+			line += " · " + format.Duration(r.Duration)
+		}
+		fmt.Fprintf(&b, "%d. %s — %s\n", i+1, line, html.EscapeString(format.FirstLine(r.Summary, 60)))
+		id := strconv.FormatInt(r.ID, 10)
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []telegram.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%d. Резюме", i+1), CallbackData: "hist:s:" + id},
+			{Text: fmt.Sprintf("%d. Текст", i+1), CallbackData: "hist:t:" + id},
+		})
+	}
+	if _, err := a.tele.Send(msg.Chat.ID, b.String(), telegram.SendOptions{ReplyTo: msg.MessageID, ParseMode: "HTML", ReplyMarkup: keyboard}); err != nil {
+		log.Printf("Ошибка отправки истории в чат %d: %v", msg.Chat.ID, err)
+	}
+}
+
+// onHistoryCallback открывает полное резюме ("s:<id>") или расшифровку ("t:<id>") записи из истории
+func (a *App) onHistoryCallback(q *telegram.CallbackQuery, payload string) {
+	kind, rawID, _ := strings.Cut(payload, ":")
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || a.records == nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Запись не найдена")
+		return
+	}
+	chatID := q.Message.Chat.ID
+	record, err := a.records.GetRecord(context.Background(), id)
+	if err != nil {
+		log.Printf("Ошибка чтения записи %d: %v", id, err)
+	}
+	// Запись из другого чата не выдаем, даже если кто-то подделал callback_data
+	if record == nil || record.Platform != "telegram" || record.ChatID != strconv.FormatInt(chatID, 10) {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Запись не найдена")
+		return
+	}
+	if kind == "t" && record.Transcript == "" {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Расшифровка удалена по сроку хранения")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "")
+	date := record.CreatedAt.In(a.chatLocation(context.Background(), q.Message)).Format("02.01.2006 15:04")
+	if kind == "t" {
+		a.sendFormattedMessage(q.Message, q.Message.MessageID, format.LayoutData{Kind: format.KindHistory, Title: "Расшифровка от " + date, Body: html.EscapeString(record.Transcript)})
+		return
+	}
+	a.sendFormattedMessage(q.Message, q.Message.MessageID, format.LayoutData{Kind: format.KindHistory, Title: "Резюме от " + date, Body: format.FormatHTML(record.Summary)})
+}
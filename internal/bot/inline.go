@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const (
+	inlineLimit     = 20
+	inlineCacheTime = 10
+)
+
+// handleInlineQuery ищет по резюме пользователя, написавшего "@bot запрос", и предлагает вставить одно из них в чат.
+// Пустой запрос показывает последние резюме пользователя, запрос кнопки "Поделиться" - резюме, к которому она относится.
+// Резюме из чатов с /protect on не предлагаются.
+func (a *App) handleInlineQuery(q *telegram.InlineQuery) {
+	if q.From != nil && strings.HasPrefix(q.Query, sharePrefix) {
+		if err := a.tele.AnswerInlineQuery(q.ID, a.shareResults(q), inlineCacheTime); err != nil {
+			log.Printf("Ошибка ответа на inline-запрос пользователя %d: %v", q.From.ID, err)
+		}
+		return
+	}
+	if a.records == nil || q.From == nil {
+		_ = a.tele.AnswerInlineQuery(q.ID, nil, inlineCacheTime)
+		return
+	}
+	hits, err := a.records.SearchUser(context.Background(), "telegram", strconv.FormatInt(q.From.ID, 10), q.Query, inlineLimit)
+	if err != nil {
+		log.Printf("Ошибка inline-поиска пользователя %d: %v", q.From.ID, err)
+		_ = a.tele.AnswerInlineQuery(q.ID, nil, inlineCacheTime)
+		return
+	}
+	results := make([]telegram.InlineQueryResultArticle, 0, len(hits))
+	protected := make(map[string]bool)
+	for _, hit := range hits {
+		if hit.Summary == "" || a.protectedChat(protected, hit.ChatID) {
+			continue
+		}
+		title := "Резюме от " + hit.CreatedAt.Format("02.01.2006 15:04")
+		description := format.FirstLine(hit.Summary, 100)
+		if hit.Snippet != "" {
+			description = strings.NewReplacer(store.SnippetMatchStart, "", store.SnippetMatchEnd, "").Replace(strings.Join(strings.Fields(hit.Snippet), " "))
+		}
+		text := "<b>" + title + "</b>\n\n" + format.FormatHTML(hit.Summary)
+		// В inline-результат помещается только одно сообщение
+		text = format.SplitHTML(format.SanitizeHTML(text), a.cfg.MaxMessageLength)[0]
+		results = append(results, telegram.InlineQueryResultArticle{
+			ID:                  strconv.FormatInt(hit.ID, 10),
+			Title:               title,
+			Description:         description,
+			InputMessageContent: telegram.InputTextMessageContent{MessageText: text, ParseMode: "HTML"},
+		})
+	}
+	if err := a.tele.AnswerInlineQuery(q.ID, results, inlineCacheTime); err != nil {
+		log.Printf("Ошибка ответа на inline-запрос пользователя %d: %v", q.From.ID, err)
+	}
+}
+
+// protectedChat сообщает, включен ли в чате chatID запрет пересылки (/protect on); seen запоминает
+// ответы, чтобы не читать настройки одного чата для каждого резюме
+func (a *App) protectedChat(seen map[string]bool, chatID string) bool {
+	protected, ok := seen[chatID]
+	if !ok {
+		settings, err := a.settings.GetSettings(context.Background(), "telegram", chatID)
+		if err != nil {
+			log.Printf("Ошибка чтения настроек чата %s: %v", chatID, err)
+		}
+		// Если настройки не прочитать, резюме лучше не показывать
+		protected = err != nil || settings.ProtectContent
+		seen[chatID] = protected
+	}
+	return protected
+}
@@ -0,0 +1,224 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Стадии сохраненного задания
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+)
+
+// maxResumeAttempts - сколько раз задание возобновляется после перезапусков; если бот падает
+// на нем снова и снова, задание отбрасывается, а пользователь получает сообщение
+const maxResumeAttempts = 3
+
+// Реплика продлевает свои задания каждые jobHeartbeatInterval; задание другой реплики, не продленное
+// дольше jobStaleAfter, считается брошенным (реплика упала или зависла) и возобновляется
+const (
+	jobHeartbeatInterval = 30 * time.Second
+	jobStaleAfter        = 3 * jobHeartbeatInterval
+)
+
+// newReplicaID возвращает случайный идентификатор процесса - владельца сохраненных заданий
+func newReplicaID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// resumedJobs - число попыток у возобновленных заданий по ключу "чат:сообщение",
+// чтобы не сбросить его при повторном сохранении задания
+type resumedJobs struct {
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (r *resumedJobs) set(key string, attempts int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.attempts == nil {
+		r.attempts = make(map[string]int)
+	}
+	r.attempts[key] = attempts
+}
+
+func (r *resumedJobs) get(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts[key]
+}
+
+func (r *resumedJobs) forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, key)
+}
+
+// runningJobs - задания, которые обрабатывает эта реплика, по ключу "чат:сообщение".
+// Продление и удаление задания идут под одним мьютексом, чтобы продление не вернуло удаленное задание.
+type runningJobs struct {
+	mu    sync.Mutex
+	jobs  map[string]store.PendingJob
+	start sync.Once
+}
+
+func (r *runningJobs) has(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.jobs[key]
+	return ok
+}
+
+// persistJob сохраняет задание для сообщения msg на стадии stage, чтобы возобновить его после перезапуска
+func (a *App) persistJob(msg *telegram.Message, stage string) {
+	if a.jobs == nil {
+		return
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Ошибка маршалинга сообщения %d для сохранения задания: %v", msg.MessageID, err)
+		return
+	}
+	key := watchKey(msg.Chat.ID, msg.MessageID)
+	j := store.PendingJob{
+		Platform:  "telegram",
+		ChatID:    strconv.FormatInt(msg.Chat.ID, 10),
+		MessageID: strconv.Itoa(msg.MessageID),
+		UserID:    strconv.FormatInt(senderID(msg), 10),
+		Stage:     stage,
+		Payload:   string(payload),
+		Attempts:  a.resumed.get(key),
+		Owner:     a.replicaID,
+	}
+	a.running.start.Do(func() { go a.heartbeatJobs() })
+	a.running.mu.Lock()
+	defer a.running.mu.Unlock()
+	if a.running.jobs == nil {
+		a.running.jobs = make(map[string]store.PendingJob)
+	}
+	a.running.jobs[key] = j
+	if err := a.jobs.SaveJob(context.Background(), j); err != nil {
+		log.Printf("Не удалось сохранить задание для сообщения %d: %v", msg.MessageID, err)
+	}
+}
+
+// releaseJob перестает продлевать задание для сообщения msg, не удаляя его: задание передано
+// другому процессу, который сохранит его под своим именем
+func (a *App) releaseJob(msg *telegram.Message) {
+	a.running.mu.Lock()
+	defer a.running.mu.Unlock()
+	delete(a.running.jobs, watchKey(msg.Chat.ID, msg.MessageID))
+}
+
+// heartbeatJobs каждые jobHeartbeatInterval пересохраняет задания этой реплики, обновляя их UpdatedAt,
+// чтобы другие реплики не сочли их брошенными
+func (a *App) heartbeatJobs() {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.running.mu.Lock()
+		for _, j := range a.running.jobs {
+			if err := a.jobs.SaveJob(context.Background(), j); err != nil {
+				log.Printf("Не удалось продлить задание для сообщения %s в чате %s: %v", j.MessageID, j.ChatID, err)
+			}
+		}
+		a.running.mu.Unlock()
+	}
+}
+
+// finishJob удаляет сохраненное задание: обработка закончилась (успешно или с ошибкой, о которой пользователь уже знает)
+func (a *App) finishJob(msg *telegram.Message) {
+	if a.jobs == nil {
+		return
+	}
+	key := watchKey(msg.Chat.ID, msg.MessageID)
+	a.resumed.forget(key)
+	a.running.mu.Lock()
+	defer a.running.mu.Unlock()
+	delete(a.running.jobs, key)
+	err := a.jobs.DeleteJob(context.Background(), "telegram", strconv.FormatInt(msg.Chat.ID, 10), strconv.Itoa(msg.MessageID))
+	if err != nil {
+		log.Printf("Не удалось удалить задание для сообщения %d: %v", msg.MessageID, err)
+	}
+}
+
+// ResumeJobs возобновляет задания, которые не успели завершиться до перезапуска бота, и сообщает
+// об этом их авторам. Вызывается при запуске, до получения обновлений, а с блокировкой ведущей
+// реплики - когда реплика становится ведущей (и подхватывает задания упавшей). Задания, которые еще
+// продлевает живая реплика, не трогаются; пока ctx не отменен, брошенные задания проверяются снова
+// каждые jobHeartbeatInterval.
+func (a *App) ResumeJobs(ctx context.Context) {
+	if a.jobs == nil {
+		return
+	}
+	a.resumeStaleJobs(ctx)
+	go func() {
+		ticker := time.NewTicker(jobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.resumeStaleJobs(ctx)
+			}
+		}
+	}()
+}
+
+// resumeStaleJobs возобновляет задания без живого владельца: брошенные другой репликой
+// или оставшиеся от прошлого запуска
+func (a *App) resumeStaleJobs(ctx context.Context) {
+	pending, err := a.jobs.PendingJobs(ctx)
+	if err != nil {
+		log.Printf("Не удалось прочитать незавершенные задания: %v", err)
+		return
+	}
+	var stale []store.PendingJob
+	for _, j := range pending {
+		if j.Platform != "telegram" || a.running.has(j.ChatID+":"+j.MessageID) {
+			continue
+		}
+		if j.Owner != "" && j.Owner != a.replicaID && time.Since(j.UpdatedAt) < jobStaleAfter {
+			continue
+		}
+		stale = append(stale, j)
+	}
+	if len(stale) > 0 {
+		log.Printf("Незавершенных заданий без живой реплики: %d", len(stale))
+	}
+	for _, j := range stale {
+		var msg telegram.Message
+		if err := json.Unmarshal([]byte(j.Payload), &msg); err != nil {
+			log.Printf("Не удалось разобрать задание для сообщения %s в чате %s, оно пропущено: %v", j.MessageID, j.ChatID, err)
+			if err := a.jobs.DeleteJob(ctx, j.Platform, j.ChatID, j.MessageID); err != nil {
+				log.Printf("Не удалось удалить задание для сообщения %s: %v", j.MessageID, err)
+			}
+			continue
+		}
+		if j.Attempts >= maxResumeAttempts {
+			log.Printf("Задание для сообщения %d отброшено после %d перезапусков", msg.MessageID, j.Attempts)
+			a.finishJob(&msg)
+			a.notifyError(&msg, i18n.T(a.uiLanguage(&msg), i18n.ErrResumeFailed))
+			continue
+		}
+		a.resumed.set(watchKey(msg.Chat.ID, msg.MessageID), j.Attempts+1)
+		a.persistJob(&msg, j.Stage)
+		log.Printf("Возобновляется задание для сообщения %d (стадия %s, попытка %d)", msg.MessageID, j.Stage, j.Attempts+1)
+		a.sendStatus(&msg, i18n.T(a.uiLanguage(&msg), i18n.StatusResumed))
+		go a.dispatchMedia(&msg)
+	}
+}
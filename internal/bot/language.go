@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const languageUsage = "Использование: /language <код языка ISO 639-1> (например, /language en), /language default — язык по умолчанию. В теме форума команда меняет язык только этой темы."
+
+var reLanguageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// chatLanguage возвращает язык резюме для чата (темы) сообщения in: выбранный через /language или язык по умолчанию
+func (a *App) chatLanguage(ctx context.Context, in *telegram.Message) string {
+	if language := a.chatSettings(ctx, in).Language; language != "" {
+		return language
+	}
+	return a.cfg.SummaryLanguage
+}
+
+func (a *App) describeLanguage(code string) string {
+	return fmt.Sprintf("%s (%s)", ai.LanguageName(code), code)
+}
+
+func (a *App) cmdLanguage(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	if args == "" {
+		current := "Резюме" + scopeName(msg) + " пишутся на языке: " + a.describeLanguage(a.cfg.SummaryLanguage) + " (по умолчанию)."
+		if language := settings.ForTopic(threadOf(msg)).Language; language != "" {
+			current = "Резюме" + scopeName(msg) + " пишутся на языке: " + a.describeLanguage(language) + "."
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, current+" Расшифровка всегда остается на языке записи.\n\n"+languageUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять язык резюме могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	code := strings.ToLower(strings.TrimSpace(args))
+	prefs := scopePreferences(&settings, msg)
+	switch {
+	case code == "default":
+		prefs.Language = ""
+	case reLanguageCode.MatchString(code):
+		prefs.Language = code
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, languageUsage, msg.MessageID, "")
+		return
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	code = settings.ForTopic(threadOf(msg)).Language
+	if code == "" {
+		code = a.cfg.SummaryLanguage
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, "Теперь резюме"+scopeName(msg)+" будут на языке: "+a.describeLanguage(code)+".", msg.MessageID, "")
+}
@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// maxMaintenanceQueue ограничивает число отложенных на время обслуживания обновлений
+const maxMaintenanceQueue = 1000
+
+// maintenanceState - режим обслуживания: бот принимает команды, но не обрабатывает новые записи
+// и тексты (кроме присланных администраторами бота). В режиме с очередью обновления откладываются
+// в памяти и обрабатываются после выключения режима; при перезапуске очередь теряется.
+type maintenanceState struct {
+	mu      sync.Mutex
+	enabled bool
+	queue   bool
+	pending []telegram.Update
+}
+
+// holdForMaintenance откладывает или отклоняет обновление, если включен режим обслуживания.
+// Возвращает true, если обновление обрабатывать сейчас не нужно.
+func (a *App) holdForMaintenance(update telegram.Update, msg *telegram.Message) bool {
+	m := &a.maintenance
+	m.mu.Lock()
+	if !m.enabled || a.isBotAdmin(msg) {
+		m.mu.Unlock()
+		return false
+	}
+	queued := m.queue && len(m.pending) < maxMaintenanceQueue
+	if queued {
+		m.pending = append(m.pending, update)
+	}
+	m.mu.Unlock()
+
+	// В бизнес-чатах бот не пишет от имени владельца аккаунта без необходимости
+	if msg.BusinessConnectionID != "" {
+		return true
+	}
+	if queued {
+		log.Printf("Режим обслуживания: сообщение %d в чате %d отложено", msg.MessageID, msg.Chat.ID)
+		a.notifyError(msg, "Бот на обслуживании. Запись поставлена в очередь и будет обработана, когда работы закончатся.")
+	} else {
+		a.notifyError(msg, "Бот на обслуживании, попробуйте позже.")
+	}
+	return true
+}
+
+// setMaintenance включает или выключает режим обслуживания. При выключении отложенные
+// обновления отправляются на обработку.
+func (a *App) setMaintenance(enabled, queue bool) {
+	m := &a.maintenance
+	m.mu.Lock()
+	m.enabled, m.queue = enabled, enabled && queue
+	var pending []telegram.Update
+	if !enabled {
+		pending, m.pending = m.pending, nil
+	}
+	m.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	log.Printf("Режим обслуживания выключен, обрабатываю %d отложенных обновлений", len(pending))
+	go func() {
+		for _, update := range pending {
+			a.acquireUpdateSlot()
+			go func(update telegram.Update) {
+				defer a.releaseUpdateSlot()
+				a.handleUpdate(update)
+			}(update)
+		}
+	}()
+}
+
+// Выключение режима обслуживания возвращает отложенные обновления в handleUpdate, поэтому подкоманда
+// регистрируется в init, чтобы не было цикла инициализации
+func init() {
+	adminCommands["maintenance"] = (*App).adminMaintenance
+}
+
+const maintenanceUsage = "Использование: /admin maintenance on [queue]|off. С queue новые записи откладываются и обрабатываются после выключения режима."
+
+func (a *App) adminMaintenance(msg *telegram.Message, args string) {
+	action, rest := cutWord(args)
+	var enabled, queue bool
+	switch strings.ToLower(action) {
+	case "":
+		a.maintenance.mu.Lock()
+		enabled, queue, pending := a.maintenance.enabled, a.maintenance.queue, len(a.maintenance.pending)
+		a.maintenance.mu.Unlock()
+		state := "Режим обслуживания выключен."
+		if enabled && queue {
+			state = fmt.Sprintf("Режим обслуживания включен, новые записи откладываются (в очереди: %d).", pending)
+		} else if enabled {
+			state = "Режим обслуживания включен."
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, state+"\n\n"+maintenanceUsage, msg.MessageID, "")
+		return
+	case "on":
+		enabled = true
+		switch strings.ToLower(rest) {
+		case "":
+		case "queue":
+			queue = true
+		default:
+			_ = a.tele.SendMessage(msg.Chat.ID, maintenanceUsage, msg.MessageID, "")
+			return
+		}
+	case "off":
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, maintenanceUsage, msg.MessageID, "")
+		return
+	}
+
+	if a.botSettings != nil {
+		ctx := context.Background()
+		settings, err := a.botSettings.GetBotSettings(ctx)
+		if err == nil {
+			settings.Maintenance, settings.MaintenanceQueue = enabled, queue
+			err = a.botSettings.SaveBotSettings(ctx, settings)
+		}
+		if err != nil {
+			// Режим все равно переключается, но после перезапуска вернется прежний
+			log.Printf("Ошибка сохранения режима обслуживания: %v", err)
+		}
+	}
+	a.setMaintenance(enabled, queue)
+	log.Printf("Администратор %d переключил режим обслуживания: %s %s", msg.From.ID, action, rest)
+	switch {
+	case enabled && queue:
+		_ = a.tele.SendMessage(msg.Chat.ID, "Режим обслуживания включен: новые записи откладываются до его выключения.", msg.MessageID, "")
+	case enabled:
+		_ = a.tele.SendMessage(msg.Chat.ID, "Режим обслуживания включен: на новые записи бот отвечает, что он на обслуживании.", msg.MessageID, "")
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, "Режим обслуживания выключен.", msg.MessageID, "")
+	}
+}
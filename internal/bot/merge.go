@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Сколько голосовых подряд можно объединить в одно резюме
+const maxMergeSeries = 20
+
+// voiceSeries - голосовые, которые пользователь отправил подряд с короткими паузами
+type voiceSeries struct {
+	MessageIDs []int     `json:"message_ids"`
+	Last       time.Time `json:"last"`
+}
+
+// seriesMu не дает параллельно обработанным голосовым одного пользователя потерять друг друга в серии
+var seriesMu sync.Mutex
+
+func mergeKey(chatID int64, messageID int) string {
+	return cache.MergeKey("telegram", strconv.FormatInt(chatID, 10), strconv.Itoa(messageID))
+}
+
+// mergeButton добавляет голосовое msg в серию отправителя и, если в серии больше одного сообщения,
+// возвращает кнопку "Объединить" для резюме msg
+func (a *App) mergeButton(msg *telegram.Message) [][]telegram.InlineKeyboardButton {
+	if a.cfg.MergeWindow <= 0 || msg.Voice == nil {
+		return nil
+	}
+	ctx := context.Background()
+	key := cache.SeriesKey("telegram", strconv.FormatInt(msg.Chat.ID, 10), strconv.FormatInt(senderID(msg), 10))
+
+	seriesMu.Lock()
+	defer seriesMu.Unlock()
+	var series voiceSeries
+	if data, found, err := a.cache.Get(ctx, key); err != nil {
+		log.Printf("Ошибка чтения серии голосовых в чате %d: %v", msg.Chat.ID, err)
+	} else if found {
+		_ = json.Unmarshal([]byte(data), &series)
+	}
+	now := time.Now()
+	if now.Sub(series.Last) > a.cfg.MergeWindow {
+		series.MessageIDs = nil
+	}
+	series.MessageIDs = append(series.MessageIDs, msg.MessageID)
+	if len(series.MessageIDs) > maxMergeSeries {
+		series.MessageIDs = series.MessageIDs[len(series.MessageIDs)-maxMergeSeries:]
+	}
+	series.Last = now
+	data, err := json.Marshal(series)
+	if err != nil {
+		log.Printf("Ошибка маршалинга серии голосовых: %v", err)
+		return nil
+	}
+	if err := a.cache.Set(ctx, key, string(data), a.cfg.MergeWindow); err != nil {
+		log.Printf("Ошибка записи серии голосовых в чате %d: %v", msg.Chat.ID, err)
+		return nil
+	}
+	if len(series.MessageIDs) < 2 {
+		return nil
+	}
+	if err := a.cache.Set(ctx, mergeKey(msg.Chat.ID, msg.MessageID), string(data), a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка записи серии голосовых в чате %d: %v", msg.Chat.ID, err)
+		return nil
+	}
+	return [][]telegram.InlineKeyboardButton{{{
+		Text:         fmt.Sprintf("🔗 Объединить (%d)", len(series.MessageIDs)),
+		CallbackData: fmt.Sprintf("merge:%d", msg.MessageID),
+	}}}
+}
+
+// onMergeCallback склеивает расшифровки серии голосовых и присылает одно резюме всего монолога
+func (a *App) onMergeCallback(q *telegram.CallbackQuery, payload string) {
+	messageID, err := strconv.Atoi(payload)
+	if err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	ctx := context.Background()
+	chatID := q.Message.Chat.ID
+	data, found, err := a.cache.Get(ctx, mergeKey(chatID, messageID))
+	if err != nil {
+		log.Printf("Ошибка чтения серии голосовых сообщения %d: %v", messageID, err)
+	}
+	var series voiceSeries
+	if found {
+		found = json.Unmarshal([]byte(data), &series) == nil
+	}
+	var transcripts []string
+	for _, id := range series.MessageIDs {
+		transcript, ok := a.cachedTranscript(ctx, chatID, id)
+		if !ok {
+			found = false
+			break
+		}
+		transcripts = append(transcripts, transcript)
+	}
+	if !found {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Расшифровки этих сообщений больше недоступны")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, fmt.Sprintf("Объединяю %d голосовых...", len(transcripts)))
+	ctx = a.promptContext(ctx, q.Message)
+	summary, err := a.ai.SummarizeTextIn(ctx, strings.Join(transcripts, "\n\n"), a.ai.Prompts().User, a.chatLanguage(ctx, q.Message))
+	if err != nil {
+		log.Printf("Ошибка резюме серии голосовых сообщения %d: %v", messageID, err)
+		_ = a.tele.SendMessage(chatID, fmt.Sprintf("Не удалось объединить сообщения: %v", err), messageID, "")
+		return
+	}
+	a.sendFormattedMessage(q.Message, messageID, format.LayoutData{Kind: format.KindSummary,
+		Title: fmt.Sprintf("Summary (объединено голосовых: %d)", len(transcripts)), Body: format.FormatHTML(summary)})
+}
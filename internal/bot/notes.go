@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/notes"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+var notionPageIDRe = regexp.MustCompile(`[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}`)
+
+const notesUsage = "Использование:\n/notes notion <ссылка или ID страницы> - создавать страницы с резюме в Notion\n/notes vault <папка> - сохранять заметки в хранилище Markdown (в папке чата)\n/notes off - отключить синхронизацию"
+
+const adminNotesUsage = "Использование: /admin notes <ID чата> <ссылка или ID страницы Notion> - разрешить чату страницу не из NOTION_ALLOWED_PAGES"
+
+// notionPageID извлекает ID страницы Notion из ссылки или ID; пустая строка - ID не найден
+func notionPageID(s string) string {
+	return strings.ToLower(strings.ReplaceAll(notionPageIDRe.FindString(s), "-", ""))
+}
+
+// notionPageAllowed сообщает, разрешил ли оператор бота страницу pageID в NOTION_ALLOWED_PAGES.
+// Токен Notion открывает все страницы интеграции, поэтому остальные страницы назначает администратор бота.
+func (a *App) notionPageAllowed(pageID string) bool {
+	for _, allowed := range a.cfg.NotionAllowedPages {
+		if notionPageID(allowed) == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// isChatAdmin сообщает, может ли автор сообщения менять настройки чата
+func (a *App) isChatAdmin(msg *telegram.Message) bool {
+	if msg.From == nil {
+		return msg.Chat.IsPrivate()
+	}
+	return a.isUserAdmin(msg.Chat, msg.From.ID)
+}
+
+func (a *App) isUserAdmin(chat *telegram.Chat, userID int64) bool {
+	if chat.IsPrivate() {
+		return true
+	}
+	member, err := a.tele.GetChatMember(chat.ID, userID)
+	if err != nil {
+		log.Printf("Не удалось проверить права пользователя %d в чате %d: %v", userID, chat.ID, err)
+		return false
+	}
+	return member.IsAdmin()
+}
+
+func describeNotes(dest *store.NotesDestination) string {
+	if dest == nil {
+		return "Синхронизация заметок отключена."
+	}
+	switch dest.Kind {
+	case notes.KindNotion:
+		return fmt.Sprintf("Резюме сохраняются в Notion под страницей %s.", dest.Target)
+	default:
+		return fmt.Sprintf("Резюме сохраняются в хранилище заметок, папка %q в папке чата.", dest.Target)
+	}
+}
+
+func (a *App) cmdNotes(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	if args == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, describeNotes(settings.Notes)+"\n\n"+notesUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять настройки синхронизации могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+
+	kind, target, _ := strings.Cut(args, " ")
+	target = strings.TrimSpace(target)
+	switch strings.ToLower(kind) {
+	case "off":
+		settings.Notes = nil
+	case notes.KindNotion:
+		if a.cfg.NotionToken == "" {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Интеграция с Notion не настроена администратором бота.", msg.MessageID, "")
+			return
+		}
+		pageID := notionPageID(target)
+		if pageID == "" {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось найти ID страницы Notion. "+notesUsage, msg.MessageID, "")
+			return
+		}
+		if !a.notionPageAllowed(pageID) && !a.isBotAdmin(msg) {
+			_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Эта страница Notion не разрешена для заметок. Попросите администратора бота разрешить ее: /admin notes %s %s", chatID, pageID), msg.MessageID, "")
+			return
+		}
+		settings.Notes = &store.NotesDestination{Kind: notes.KindNotion, Target: pageID}
+	case notes.KindVault:
+		if a.cfg.NotesVaultDir == "" && a.cfg.NotesWebDAVURL == "" {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Хранилище заметок не настроено администратором бота.", msg.MessageID, "")
+			return
+		}
+		folder, err := notes.SanitizeFolder(target)
+		if err != nil {
+			_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("%v. %s", err, notesUsage), msg.MessageID, "")
+			return
+		}
+		settings.Notes = &store.NotesDestination{Kind: notes.KindVault, Target: folder}
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, notesUsage, msg.MessageID, "")
+		return
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, describeNotes(settings.Notes), msg.MessageID, "")
+}
+
+// adminNotes назначает чату страницу Notion, которой нет в NOTION_ALLOWED_PAGES ("/admin notes")
+func (a *App) adminNotes(msg *telegram.Message, args string) {
+	chat, target := cutWord(args)
+	pageID := notionPageID(target)
+	if _, err := strconv.ParseInt(chat, 10, 64); err != nil || pageID == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, adminNotesUsage, msg.MessageID, "")
+		return
+	}
+	if a.cfg.NotionToken == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Интеграция с Notion не настроена.", msg.MessageID, "")
+		return
+	}
+	ctx := context.Background()
+	settings, err := a.settings.GetSettings(ctx, "telegram", chat)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %s: %v", chat, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	settings.Notes = &store.NotesDestination{Kind: notes.KindNotion, Target: pageID}
+	if err := a.settings.SaveSettings(ctx, "telegram", chat, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %s: %v", chat, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	log.Printf("Администратор %d назначил чату %s страницу Notion %s", msg.From.ID, chat, pageID)
+	_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Чат %s: %s", chat, describeNotes(settings.Notes)), msg.MessageID, "")
+}
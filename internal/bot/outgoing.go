@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// outgoingInterval - минимальный промежуток между сообщениями бота в одном чате: Telegram просит
+// не отправлять в чат больше одного сообщения в секунду
+const outgoingInterval = time.Second
+
+// outgoing - сообщение на пути к отправке. Звенья цепочки по очереди дополняют его: format собирает
+// текст по шаблону раскладки и параметры отправки, split делит текст на страницы, send отправляет их.
+type outgoing struct {
+	in       *telegram.Message
+	replyTo  int
+	data     format.LayoutData
+	actions  [][]telegram.InlineKeyboardButton
+	settings store.ChatSettings
+	opts     telegram.SendOptions
+	text     string
+	pages    []string
+}
+
+// sendFunc отправляет сообщение и возвращает его первую часть или nil, если ничего не отправлено
+type sendFunc func(m *outgoing) *telegram.Message
+
+// sendMiddleware - звено цепочки отправки: может изменить сообщение, не передавать его дальше
+// или выполнить что-то после отправки
+type sendMiddleware func(next sendFunc) sendFunc
+
+// chainSend собирает цепочку: первое звено получает сообщение первым, send - последним
+func chainSend(send sendFunc, middlewares ...sendMiddleware) sendFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		send = middlewares[i](send)
+	}
+	return send
+}
+
+// outgoingChain - цепочка отправки сообщений по шаблону раскладки: format → dm → split → rate limit → record → daily notes → send
+func (a *App) outgoingChain() sendFunc {
+	return chainSend(a.sendOutgoing, a.formatOutgoing, a.dmOutgoing, a.splitOutgoing, a.paceOutgoing, a.recordOutgoing, a.dailyOutgoing)
+}
+
+// formatOutgoing применяет настройки чата (подробность, маскировку, спойлеры), собирает текст по шаблону
+// и параметры отправки. Расшифровки в чатах с тихой подробностью не отправляются.
+func (a *App) formatOutgoing(next sendFunc) sendFunc {
+	return func(m *outgoing) *telegram.Message {
+		chatID := m.in.Chat.ID
+		m.settings = a.chatSettings(context.Background(), m.in)
+		d := &m.data
+		switch m.settings.Verbosity {
+		case store.VerbosityQuiet:
+			if d.Kind == format.KindTranscript {
+				return nil
+			}
+		case store.VerbosityVerbose:
+			d.Verbose = true
+		}
+		if m.settings.MaskProfanity {
+			d.Body = format.MaskProfanityHTML(d.Body)
+		}
+		spoilerTranscript, spoilerSummary := m.settings.Spoilers()
+		switch d.Kind {
+		case format.KindTranscript:
+			d.Spoiler = spoilerTranscript
+			d.Body = format.HighlightUnclear(d.Body)
+		case format.KindSummary:
+			d.Spoiler = spoilerSummary
+		}
+		text, err := a.layout.Render(*d)
+		if err != nil {
+			log.Printf("Ошибка шаблона сообщения для чата %d: %v", chatID, err)
+			text = fmt.Sprintf("<b>%s</b>\n\n%s", html.EscapeString(d.Title), d.Body)
+		}
+		if clean := format.SanitizeHTML(text); clean != text {
+			log.Printf("Разметка сообщения для чата %d исправлена перед отправкой", chatID)
+			text = clean
+		}
+		m.text = text
+		final := d.Kind == format.KindSummary
+		m.opts = telegram.SendOptions{ReplyTo: m.replyTo, ProtectContent: m.settings.ProtectContent, DisableNotification: m.settings.SilentFor(final)}
+		if final && m.in.Chat.IsPrivate() {
+			m.opts.MessageEffectID = a.cfg.MessageEffectID
+		}
+		return next(m)
+	}
+}
+
+// splitOutgoing делит длинный текст на пронумерованные страницы
+func (a *App) splitOutgoing(next sendFunc) sendFunc {
+	return func(m *outgoing) *telegram.Message {
+		m.pages = format.SplitHTMLNumbered(m.text, m.data.Title, a.cfg.MaxMessageLength)
+		return next(m)
+	}
+}
+
+// paceOutgoing выдерживает outgoingInterval между сообщениями в одном чате
+func (a *App) paceOutgoing(next sendFunc) sendFunc {
+	return func(m *outgoing) *telegram.Message {
+		a.pacer.wait(m.in.Chat.ID)
+		return next(m)
+	}
+}
+
+// recordOutgoing учитывает отправленные сообщения по типам (для веб-панели)
+func (a *App) recordOutgoing(next sendFunc) sendFunc {
+	return func(m *outgoing) *telegram.Message {
+		sent := next(m)
+		if sent == nil {
+			a.sentMessages.add("failed")
+			return nil
+		}
+		a.sentMessages.add(m.data.Kind)
+		slog.Debug("Сообщение отправлено", "chat", m.in.Chat.ID, "message", sent.MessageID, "kind", m.data.Kind, "pages", len(m.pages))
+		return sent
+	}
+}
+
+// sendOutgoing отправляет страницы с кнопками листания и кнопками действий
+func (a *App) sendOutgoing(m *outgoing) *telegram.Message {
+	return a.sendPages(m.in.Chat.ID, m.opts, m.pages, m.actions...)
+}
+
+// chatPacer помнит, когда в чат можно отправлять следующее сообщение
+type chatPacer struct {
+	mu   sync.Mutex
+	next map[int64]time.Time
+}
+
+// wait резервирует место для сообщения в чате chatID и ждет его наступления
+func (p *chatPacer) wait(chatID int64) {
+	p.mu.Lock()
+	if p.next == nil {
+		p.next = make(map[int64]time.Time)
+	}
+	now := time.Now()
+	at := p.next[chatID]
+	if at.Before(now) {
+		at = now
+	}
+	p.next[chatID] = at.Add(outgoingInterval)
+	// Старые отметки не нужны: отметка в прошлом ничего не ограничивает
+	if len(p.next) > 1000 {
+		for id, t := range p.next {
+			if t.Before(now) {
+				delete(p.next, id)
+			}
+		}
+	}
+	p.mu.Unlock()
+	time.Sleep(at.Sub(now))
+}
+
+// messageCounter - число отправленных сообщений по типам раскладки ("failed" - не отправленные)
+type messageCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *messageCounter) add(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[kind]++
+}
+
+func (c *messageCounter) snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.counts))
+	for k, v := range c.counts {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// pagedMessage - страницы длинного сообщения и постоянные кнопки под ним, хранятся в кэше
+type pagedMessage struct {
+	Pages   []string                          `json:"pages"`
+	Actions [][]telegram.InlineKeyboardButton `json:"actions,omitempty"`
+}
+
+func pagesKey(chatID int64, messageID int) string {
+	return cache.PagesKey("telegram", strconv.FormatInt(chatID, 10), strconv.Itoa(messageID))
+}
+
+// sendPages отправляет первую часть длинного сообщения с кнопками листания; остальные части
+// хранятся в кэше и подставляются в то же сообщение по нажатию "Показать ещё".
+// Ряды actions выводятся под кнопками листания на каждой странице; из opts берутся ответ на сообщение
+// и защита от пересылки.
+func (a *App) sendPages(chatID int64, opts telegram.SendOptions, pages []string, actions ...[]telegram.InlineKeyboardButton) *telegram.Message {
+	if len(pages) == 0 {
+		return nil
+	}
+	paged := pagedMessage{Pages: pages, Actions: actions}
+	sent := a.sendPage(chatID, opts, pages[0], paged.keyboard(0))
+	opts.MessageEffectID = "" // эффект показывается только на первой части
+	if sent == nil || len(pages) == 1 {
+		return sent
+	}
+	data, err := json.Marshal(paged)
+	if err == nil {
+		err = a.cache.Set(context.Background(), pagesKey(chatID, sent.MessageID), string(data), a.cfg.CacheTTL)
+	}
+	if err != nil {
+		// без кэша листать нечего: досылаем остальные части отдельными сообщениями
+		log.Printf("Ошибка сохранения страниц сообщения %d: %v", sent.MessageID, err)
+		_ = a.tele.EditMessageText(chatID, sent.MessageID, pages[0], telegram.SendOptions{ParseMode: "HTML"})
+		for i, p := range pages[1:] {
+			var keyboard *telegram.InlineKeyboardMarkup
+			if i == len(pages)-2 && len(actions) > 0 {
+				keyboard = &telegram.InlineKeyboardMarkup{InlineKeyboard: actions}
+			}
+			a.sendPage(chatID, opts, p, keyboard)
+		}
+	}
+	return sent
+}
+
+func (a *App) sendPage(chatID int64, opts telegram.SendOptions, text string, keyboard *telegram.InlineKeyboardMarkup) *telegram.Message {
+	opts.ParseMode, opts.ReplyMarkup = "HTML", keyboard
+	sent, err := a.tele.Send(chatID, text, opts)
+	if err != nil {
+		log.Printf("Ошибка отправки части сообщения в чат %d: %v", chatID, err)
+		opts.ParseMode = ""
+		sent, err = a.tele.Send(chatID, format.StripHTML(text), opts)
+		if err != nil {
+			return nil
+		}
+	}
+	return sent
+}
+
+// keyboard - кнопки листания для страницы page и постоянные кнопки; nil, если кнопок нет
+func (p pagedMessage) keyboard(page int) *telegram.InlineKeyboardMarkup {
+	var rows [][]telegram.InlineKeyboardButton
+	if total := len(p.Pages); total > 1 {
+		var row []telegram.InlineKeyboardButton
+		if page > 0 {
+			row = append(row, telegram.InlineKeyboardButton{Text: "◀ Назад", CallbackData: fmt.Sprintf("page:%d", page-1)})
+		}
+		if page < total-1 {
+			row = append(row, telegram.InlineKeyboardButton{Text: fmt.Sprintf("Показать ещё (%d/%d)", page+2, total), CallbackData: fmt.Sprintf("page:%d", page+1)})
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, p.Actions...)
+	if len(rows) == 0 {
+		return nil
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// onPageCallback показывает в сообщении страницу с номером из payload
+func (a *App) onPageCallback(q *telegram.CallbackQuery, payload string) {
+	chatID, messageID := q.Message.Chat.ID, q.Message.MessageID
+	page, err := strconv.Atoi(payload)
+	var paged pagedMessage
+	if err == nil {
+		data, found, cacheErr := a.cache.Get(context.Background(), pagesKey(chatID, messageID))
+		if cacheErr != nil {
+			log.Printf("Ошибка чтения страниц сообщения %d: %v", messageID, cacheErr)
+		}
+		if found {
+			err = json.Unmarshal([]byte(data), &paged)
+		}
+	}
+	if err != nil || page < 0 || page >= len(paged.Pages) {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Продолжение больше недоступно")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "")
+	keyboard := paged.keyboard(page)
+	if err := a.tele.EditMessageText(chatID, messageID, paged.Pages[page], telegram.SendOptions{ParseMode: "HTML", ReplyMarkup: keyboard}); err != nil {
+		log.Printf("Ошибка показа страницы %d сообщения %d: %v", page, messageID, err)
+		_ = a.tele.EditMessageText(chatID, messageID, format.StripHTML(paged.Pages[page]), telegram.SendOptions{ReplyMarkup: keyboard})
+	}
+}
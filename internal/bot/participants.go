@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const (
+	// maxParticipantNames - сколько самых частых имен подсказывать распознаванию
+	maxParticipantNames = 10
+	// maxTrackedParticipants - сколько разных имен запоминать в одном чате
+	maxTrackedParticipants = 200
+)
+
+// chatParticipants считает, сколько сообщений в каждом чате отправил каждый участник (по имени).
+// Частые имена подсказываются модели при распознавании, чтобы она не искажала их.
+type chatParticipants struct {
+	mu     sync.Mutex
+	counts map[int64]map[string]int
+}
+
+// noteParticipant учитывает отправителя сообщения; боты и сообщения без отправителя не учитываются
+func (a *App) noteParticipant(msg *telegram.Message) {
+	if msg.From == nil || msg.From.IsBot {
+		return
+	}
+	name := strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName)
+	if name == "" {
+		return
+	}
+	a.participants.mu.Lock()
+	defer a.participants.mu.Unlock()
+	if a.participants.counts == nil {
+		a.participants.counts = make(map[int64]map[string]int)
+	}
+	counts := a.participants.counts[msg.Chat.ID]
+	if counts == nil {
+		counts = make(map[string]int)
+		a.participants.counts[msg.Chat.ID] = counts
+	}
+	if _, known := counts[name]; !known && len(counts) >= maxTrackedParticipants {
+		return
+	}
+	counts[name]++
+}
+
+// frequentNames возвращает до maxParticipantNames самых активных участников чата
+func (a *App) frequentNames(chatID int64) []string {
+	a.participants.mu.Lock()
+	defer a.participants.mu.Unlock()
+	counts := a.participants.counts[chatID]
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxParticipantNames {
+		names = names[:maxParticipantNames]
+	}
+	return names
+}
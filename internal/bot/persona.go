@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+type summaryPersona struct {
+	id      string
+	label   string
+	aliases []string // варианты имени для /persona
+}
+
+var summaryPersonas = []summaryPersona{
+	{config.PersonaAnalyst, "Строгий аналитик", []string{"аналитик", "строгий"}},
+	{config.PersonaFriendly, "Дружелюбный с эмодзи", []string{"дружелюбный", "неформальный", "эмодзи"}},
+	{config.PersonaTelegraph, "Телеграфный стиль", []string{"телеграф", "телеграфный"}},
+	{config.PersonaTech, "Техническая встреча", []string{"техническая", "разработка", "митинг"}},
+}
+
+func findPersona(name string) (summaryPersona, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range summaryPersonas {
+		if p.id == name {
+			return p, true
+		}
+		for _, alias := range p.aliases {
+			if alias == name {
+				return p, true
+			}
+		}
+	}
+	return summaryPersona{}, false
+}
+
+func personaLabel(id string) string {
+	if p, found := findPersona(id); found {
+		return p.label
+	}
+	return "по умолчанию"
+}
+
+// promptContext подставляет в ctx системный промпт персоны, выбранной в чате (теме) сообщения in,
+// или варианта эксперимента, в котором участвует чат,
+// словарь терминов, частые имена участников и сам чат, чтобы запросы шли в закрепленную за ним модель
+func (a *App) promptContext(ctx context.Context, in *telegram.Message) context.Context {
+	settings := a.chatSettings(ctx, in)
+	ctx = ai.WithChat(ctx, "telegram", strconv.FormatInt(in.Chat.ID, 10))
+	prompt := a.cfg.PersonaPrompts[settings.Persona]
+	if _, variant := a.chatVariant(ctx, in); variant != nil {
+		prompt = variant.SystemPrompt
+	}
+	ctx = ai.WithNames(ctx, a.frequentNames(in.Chat.ID))
+	if settings.Persona == config.PersonaTech {
+		ctx = ai.WithTranscribeHint(ctx, a.cfg.TechTranscribeHint)
+	}
+	return ai.WithGlossary(ai.WithSystemPrompt(ctx, prompt), settings.Glossary)
+}
+
+func (a *App) cmdPersona(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	var names []string
+	for _, p := range summaryPersonas {
+		names = append(names, fmt.Sprintf("%s — %s", p.id, strings.ToLower(p.label)))
+	}
+	usage := "Использование: /persona <персона>, /persona default — обычные резюме. Персоны: " + strings.Join(names, ", ") +
+		". В теме форума команда меняет персону только этой темы."
+	if args == "" {
+		current := settings.ForTopic(threadOf(msg)).Persona
+		_ = a.tele.SendMessage(msg.Chat.ID, "Персона резюме"+scopeName(msg)+": "+personaLabel(current)+".\n\n"+usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять персону резюме могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	prefs := scopePreferences(&settings, msg)
+	if strings.ToLower(strings.TrimSpace(args)) == "default" {
+		prefs.Persona = ""
+	} else if p, found := findPersona(args); found {
+		prefs.Persona = p.id
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	current := settings.ForTopic(threadOf(msg)).Persona
+	_ = a.tele.SendMessage(msg.Chat.ID, "Персона резюме"+scopeName(msg)+": "+personaLabel(current)+".", msg.MessageID, "")
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+func (a *App) cmdProtect(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	const usage = "Использование: /protect on|off. При включенной защите расшифровки и резюме нельзя переслать, скопировать или сохранить."
+	var protect bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "выключена"
+		if settings.ProtectContent {
+			state = "включена"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Защита от пересылки "+state+".\n\n"+usage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		protect = true
+	case "off", "выкл", "нет":
+		protect = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять защиту от пересылки могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.ProtectContent = protect
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if protect {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Защита от пересылки включена: новые расшифровки и резюме нельзя будет переслать или сохранить.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Защита от пересылки выключена.", msg.MessageID, "")
+	}
+}
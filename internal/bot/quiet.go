@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Реакции, которыми бот сообщает о ходе обработки в тихом режиме
+const (
+	reactionProcessing = "👀"
+	reactionDone       = "👌"
+	reactionFailed     = "🤷"
+)
+
+func (a *App) isQuiet(ctx context.Context, in *telegram.Message) bool {
+	return a.chatSettings(ctx, in).Quiet
+}
+
+// react ставит реакцию на сообщение пользователя; используется только в тихом режиме
+func (a *App) react(msg *telegram.Message, emoji string) {
+	if err := a.tele.SetMessageReaction(msg.Chat.ID, msg.MessageID, emoji); err != nil {
+		log.Printf("Ошибка установки реакции на сообщение %d: %v", msg.MessageID, err)
+	}
+}
+
+// notifyError сообщает пользователю об ошибке обработки его сообщения (с кнопками buttons, если они
+// заданы) и возвращает отправленное сообщение; в тихом режиме вместо текста ставится реакция,
+// а подробности остаются в логе
+func (a *App) notifyError(msg *telegram.Message, text string, buttons ...[]telegram.InlineKeyboardButton) *telegram.Message {
+	if a.isQuiet(context.Background(), msg) {
+		log.Printf("Тихий режим, ошибка для сообщения %d не отправлена: %s", msg.MessageID, text)
+		a.react(msg, reactionFailed)
+		return nil
+	}
+	opts := telegram.SendOptions{ReplyTo: msg.MessageID, DisableNotification: a.chatSettings(context.Background(), msg).SilentFor(false)}
+	if len(buttons) > 0 {
+		opts.ReplyMarkup = &telegram.InlineKeyboardMarkup{InlineKeyboard: buttons}
+	}
+	sent, _ := a.tele.Send(msg.Chat.ID, text, opts)
+	return sent
+}
+
+func (a *App) cmdQuiet(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	const usage = "Использование: /quiet on|off. В тихом режиме бот не пишет «Обрабатываю...» и сообщения об ошибках, а отмечает ход обработки реакциями: " +
+		reactionProcessing + " — в работе, " + reactionDone + " — готово, " + reactionFailed + " — не получилось."
+	var quiet bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "выключен"
+		if settings.Quiet {
+			state = "включен"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Тихий режим "+state+".\n\n"+usage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		quiet = true
+	case "off", "выкл", "нет":
+		quiet = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять режим могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.Quiet = quiet
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if quiet {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Тихий режим включен.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Тихий режим выключен.", msg.MessageID, "")
+	}
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// onQuotesCallback присылает ключевые дословные цитаты из расшифровки в виде блоков цитат
+func (a *App) onQuotesCallback(q *telegram.CallbackQuery, payload string) {
+	messageID, err := strconv.Atoi(payload)
+	if err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	ctx := context.Background()
+	chatID := q.Message.Chat.ID
+	transcript, found := a.cachedTranscript(ctx, chatID, messageID)
+	if !found {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Расшифровка больше недоступна")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Ищу ключевые цитаты...")
+	quotes, err := a.ai.ExtractQuotes(ai.WithChat(ctx, "telegram", strconv.FormatInt(chatID, 10)), transcript, a.cfg.QuotesPrompt)
+	if err != nil {
+		log.Printf("Ошибка выбора цитат для сообщения %d: %v", messageID, err)
+		_ = a.tele.SendMessage(chatID, fmt.Sprintf("Не удалось выбрать цитаты: %v", err), messageID, "")
+		return
+	}
+	if len(quotes) == 0 {
+		_ = a.tele.SendMessage(chatID, "Ключевых цитат в записи не нашлось.", messageID, "")
+		return
+	}
+	var blocks []string
+	for _, quote := range quotes {
+		blocks = append(blocks, "<blockquote>"+html.EscapeString(quote)+"</blockquote>")
+	}
+	a.sendFormattedMessage(q.Message, messageID, format.LayoutData{Kind: format.KindQuotes, Title: "Цитаты", Body: strings.Join(blocks, "\n")})
+}
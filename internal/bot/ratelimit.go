@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// rateWindow - окно, в котором считаются задания пользователя для USER_RATE_LIMIT
+const rateWindow = time.Hour
+
+// userRates - время последних заданий каждого пользователя в пределах rateWindow
+type userRates struct {
+	mu   sync.Mutex
+	jobs map[int64][]time.Time
+}
+
+// allowJob учитывает новое задание пользователя и сообщает, укладывается ли оно в лимит;
+// если нет, возвращает, через сколько освободится место
+func (a *App) allowJob(userID int64) (bool, time.Duration) {
+	a.rates.mu.Lock()
+	defer a.rates.mu.Unlock()
+	now := time.Now()
+	recent := a.rates.jobs[userID][:0]
+	for _, t := range a.rates.jobs[userID] {
+		if now.Sub(t) < rateWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= a.cfg.UserRateLimit {
+		a.rates.jobs[userID] = recent
+		return false, rateWindow - now.Sub(recent[0])
+	}
+	if a.rates.jobs == nil {
+		a.rates.jobs = make(map[int64][]time.Time)
+	}
+	a.rates.jobs[userID] = append(recent, now)
+	return true, 0
+}
+
+// retryAfter возвращает, через сколько пользователь сможет отправить новое задание (0 - уже может)
+func (a *App) retryAfter(userID int64) time.Duration {
+	a.rates.mu.Lock()
+	defer a.rates.mu.Unlock()
+	jobs := a.rates.jobs[userID]
+	if len(jobs) < a.cfg.UserRateLimit {
+		return 0
+	}
+	return max(rateWindow-time.Since(jobs[len(jobs)-a.cfg.UserRateLimit]), 0)
+}
+
+// rejectRateLimited отклоняет задание пользователя сверх USER_RATE_LIMIT с оценкой ожидания
+// и кнопкой "Уведомить, когда можно"; администраторы бота лимитом не ограничены
+func (a *App) rejectRateLimited(msg *telegram.Message) bool {
+	if a.cfg.UserRateLimit <= 0 || msg.From == nil || a.isBotAdmin(msg) {
+		return false
+	}
+	ok, wait := a.allowJob(msg.From.ID)
+	if ok {
+		return false
+	}
+	log.Printf("Сообщение %d в чате %d отклонено: пользователь %d превысил лимит заданий", msg.MessageID, msg.Chat.ID, msg.From.ID)
+	lang := a.uiLanguage(msg)
+	text := i18n.T(lang, i18n.ErrRateLimited, a.cfg.UserRateLimit, waitMinutes(wait))
+	if a.isQuiet(context.Background(), msg) {
+		a.notifyError(msg, text)
+		return true
+	}
+	markup := &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: i18n.T(lang, i18n.ButtonNotifyLimit), CallbackData: "notify:limit"},
+	}}}
+	silent := a.chatSettings(context.Background(), msg).SilentFor(false)
+	_, _ = a.tele.Send(msg.Chat.ID, text, telegram.SendOptions{ReplyTo: msg.MessageID, ReplyMarkup: markup, DisableNotification: silent})
+	return true
+}
+
+// waitMinutes округляет ожидание вверх до минут, не меньше одной
+func waitMinutes(d time.Duration) int {
+	return max(int((d+time.Minute-1)/time.Minute), 1)
+}
+
+// readyWatchers - пользователи, которые попросили написать им, когда обработка записи закончится;
+// ключ - "чат:сообщение"
+type readyWatchers struct {
+	mu     sync.Mutex
+	users  map[string][]int64
+	limits map[int64]bool
+}
+
+func watchKey(chatID int64, messageID int) string {
+	return strconv.FormatInt(chatID, 10) + ":" + strconv.Itoa(messageID)
+}
+
+// announceQueue сообщает, что задание ждет очереди, с оценкой ожидания и кнопкой "Уведомить, когда готово"
+func (a *App) announceQueue(msg *telegram.Message, wait time.Duration, ahead int) {
+	settings := a.chatSettings(context.Background(), msg)
+	if msg.BusinessConnectionID != "" || settings.Quiet || settings.Verbosity == store.VerbosityQuiet {
+		return
+	}
+	lang := a.uiLanguage(msg)
+	markup := &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: i18n.T(lang, i18n.ButtonNotifyReady), CallbackData: fmt.Sprintf("notify:job:%d", msg.MessageID)},
+	}}}
+	_, _ = a.tele.Send(msg.Chat.ID, i18n.T(lang, i18n.StatusQueued, ahead, waitMinutes(wait)),
+		telegram.SendOptions{ReplyTo: msg.MessageID, ReplyMarkup: markup, DisableNotification: settings.SilentFor(false)})
+}
+
+// onNotifyCallback подписывает нажавшего на уведомление в личный чат: "job:<id сообщения>" - когда
+// запись обработана, "limit" - когда снова можно отправлять записи
+func (a *App) onNotifyCallback(q *telegram.CallbackQuery, payload string) {
+	userID := q.From.ID
+	lang := i18n.Resolve(q.From.LanguageCode, a.cfg.SummaryLanguage)
+	if payload == "limit" {
+		wait := a.retryAfter(userID)
+		if wait == 0 {
+			_ = a.tele.AnswerCallbackQuery(q.ID, i18n.T(lang, i18n.NotifyLimit))
+			return
+		}
+		a.watchers.mu.Lock()
+		if a.watchers.limits == nil {
+			a.watchers.limits = make(map[int64]bool)
+		}
+		pending := a.watchers.limits[userID]
+		a.watchers.limits[userID] = true
+		a.watchers.mu.Unlock()
+		if !pending {
+			time.AfterFunc(wait, func() {
+				a.watchers.mu.Lock()
+				delete(a.watchers.limits, userID)
+				a.watchers.mu.Unlock()
+				a.sendPrivate(userID, i18n.T(lang, i18n.NotifyLimit))
+			})
+		}
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Пришлю сообщение в личный чат с ботом")
+		return
+	}
+	rawID, found := strings.CutPrefix(payload, "job:")
+	messageID, err := strconv.Atoi(rawID)
+	if !found || err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	key := watchKey(q.Message.Chat.ID, messageID)
+	a.watchers.mu.Lock()
+	if a.watchers.users == nil {
+		a.watchers.users = make(map[string][]int64)
+	}
+	if !containsInt64(a.watchers.users[key], userID) {
+		a.watchers.users[key] = append(a.watchers.users[key], userID)
+	}
+	a.watchers.mu.Unlock()
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Пришлю сообщение в личный чат с ботом, когда будет готово")
+}
+
+// notifyWatchers пишет в личный чат подписавшимся на окончание обработки сообщения msg
+func (a *App) notifyWatchers(msg *telegram.Message) {
+	key := watchKey(msg.Chat.ID, msg.MessageID)
+	a.watchers.mu.Lock()
+	users := a.watchers.users[key]
+	delete(a.watchers.users, key)
+	a.watchers.mu.Unlock()
+	for _, userID := range users {
+		a.sendPrivate(userID, i18n.T(a.uiLanguage(msg), i18n.NotifyReady, msg.Chat.Name()))
+	}
+}
+
+// sendPrivate пишет пользователю в личный чат; если пользователь не начинал диалог с ботом,
+// Telegram вернет ошибку, и она только записывается в журнал
+func (a *App) sendPrivate(userID int64, text string) {
+	if err := a.tele.SendMessage(userID, text, 0, ""); err != nil {
+		log.Printf("Не удалось отправить уведомление пользователю %d: %v", userID, err)
+	}
+}
+
+func containsInt64(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+func (a *App) cmdRedact(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	const usage = "Использование: /redact on|off. В режиме скрытия персональных данных телефоны, номера карт, адреса почты, адреса и полные имена " +
+		"в расшифровках заменяются метками ([телефон], [карта], [адрес], [имя]) еще до суммирования и сохранения."
+	var redact bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "выключено"
+		if settings.RedactPII {
+			state = "включено"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Скрытие персональных данных "+state+".\n\n"+usage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		redact = true
+	case "off", "выкл", "нет":
+		redact = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, usage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять режим скрытия персональных данных могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.RedactPII = redact
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	if redact {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Скрытие персональных данных включено. Уже сохраненные расшифровки не меняются.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Скрытие персональных данных выключено.", msg.MessageID, "")
+	}
+}
@@ -0,0 +1,261 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// reminderPreset - вариант времени напоминания на кнопке
+type reminderPreset struct {
+	id    string
+	label string
+	due   func(now time.Time) time.Time
+}
+
+var reminderPresets = []reminderPreset{
+	{id: "1h", label: "Через час", due: func(now time.Time) time.Time { return now.Add(time.Hour) }},
+	{id: "3h", label: "Через 3 часа", due: func(now time.Time) time.Time { return now.Add(3 * time.Hour) }},
+	{id: "tomorrow", label: "Завтра в 9:00", due: func(now time.Time) time.Time {
+		y, m, d := now.AddDate(0, 0, 1).Date()
+		return time.Date(y, m, d, 9, 0, 0, 0, now.Location())
+	}},
+	{id: "week", label: "Через неделю", due: func(now time.Time) time.Time { return now.AddDate(0, 0, 7) }},
+}
+
+// Сколько напоминаний отправлять за один проход и сколько пытаться доставить просроченное
+const (
+	reminderBatch    = 100
+	reminderGiveUpIn = 24 * time.Hour
+)
+
+func actionsKey(chatID int64, messageID int) string {
+	return cache.ActionsKey("telegram", strconv.FormatInt(chatID, 10), strconv.Itoa(messageID))
+}
+
+// extractButtons - кнопки под резюме: "Задачи" выделяет из расшифровки задачи с кнопками "Напомнить",
+// "Цитаты" - ключевые дословные цитаты
+func extractButtons(messageID int) [][]telegram.InlineKeyboardButton {
+	return [][]telegram.InlineKeyboardButton{{
+		{Text: "📌 Задачи", CallbackData: fmt.Sprintf("todo:%d", messageID)},
+		{Text: "💬 Цитаты", CallbackData: fmt.Sprintf("quotes:%d", messageID)},
+	}}
+}
+
+// actionItems возвращает задачи из расшифровки сообщения messageID; выделенные раньше берутся из кэша,
+// чтобы номера задач на кнопках оставались прежними
+func (a *App) actionItems(ctx context.Context, chatID int64, messageID int) ([]string, bool, error) {
+	if data, found, err := a.cache.Get(ctx, actionsKey(chatID, messageID)); err != nil {
+		log.Printf("Ошибка чтения задач сообщения %d из кэша: %v", messageID, err)
+	} else if found {
+		var items []string
+		if err := json.Unmarshal([]byte(data), &items); err == nil {
+			return items, true, nil
+		}
+	}
+	transcript, found := a.cachedTranscript(ctx, chatID, messageID)
+	if !found {
+		return nil, false, nil
+	}
+	items, err := a.ai.ExtractActionItems(ai.WithChat(ctx, "telegram", strconv.FormatInt(chatID, 10)), transcript, a.cfg.ActionItemsPrompt)
+	if err != nil {
+		return nil, true, err
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, true, fmt.Errorf("ошибка маршалинга задач: %w", err)
+	}
+	if err := a.cache.Set(ctx, actionsKey(chatID, messageID), string(data), a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка записи задач сообщения %d в кэш: %v", messageID, err)
+	}
+	return items, true, nil
+}
+
+// onTodoCallback присылает список задач из расшифровки с кнопкой "Напомнить" у каждой
+func (a *App) onTodoCallback(q *telegram.CallbackQuery, payload string) {
+	messageID, err := strconv.Atoi(payload)
+	if err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	chatID := q.Message.Chat.ID
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Ищу задачи в записи...")
+	items, found, err := a.actionItems(context.Background(), chatID, messageID)
+	switch {
+	case !found:
+		_ = a.tele.SendMessage(chatID, "Расшифровка больше недоступна.", messageID, "")
+		return
+	case err != nil:
+		log.Printf("Ошибка выделения задач для сообщения %d: %v", messageID, err)
+		_ = a.tele.SendMessage(chatID, fmt.Sprintf("Не удалось выделить задачи: %v", err), messageID, "")
+		return
+	case len(items) == 0:
+		_ = a.tele.SendMessage(chatID, "Задач в записи не нашлось.", messageID, "")
+		return
+	}
+	var b strings.Builder
+	b.WriteString("<b>Задачи</b>\n")
+	var rows [][]telegram.InlineKeyboardButton
+	for i, item := range items {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, html.EscapeString(item))
+		rows = append(rows, []telegram.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("⏰ %d. %s", i+1, format.FirstLine(item, 40)),
+			CallbackData: fmt.Sprintf("remind:%d:%d", messageID, i),
+		}})
+	}
+	_, err = a.tele.Send(chatID, b.String(), telegram.SendOptions{ReplyTo: messageID, ParseMode: "HTML",
+		ReplyMarkup: &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}})
+	if err != nil {
+		log.Printf("Ошибка отправки задач для сообщения %d: %v", messageID, err)
+	}
+}
+
+// onRemindCallback обрабатывает кнопки напоминаний: "remind:<сообщение>:<задача>" предлагает выбрать время,
+// "remind:<сообщение>:<задача>:<вариант>" планирует напоминание
+func (a *App) onRemindCallback(q *telegram.CallbackQuery, payload string) {
+	parts := strings.Split(payload, ":")
+	if len(parts) < 2 {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	messageID, err1 := strconv.Atoi(parts[0])
+	index, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	ctx := context.Background()
+	chatID := q.Message.Chat.ID
+	items, _, err := a.actionItems(ctx, chatID, messageID)
+	if err != nil || index < 0 || index >= len(items) {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Задача больше недоступна")
+		return
+	}
+	item := items[index]
+
+	if len(parts) == 2 {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		var row []telegram.InlineKeyboardButton
+		var rows [][]telegram.InlineKeyboardButton
+		for _, p := range reminderPresets {
+			row = append(row, telegram.InlineKeyboardButton{Text: p.label, CallbackData: fmt.Sprintf("remind:%d:%d:%s", messageID, index, p.id)})
+			if len(row) == 2 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		_, err := a.tele.Send(chatID, "Когда напомнить: «"+item+"»?", telegram.SendOptions{ReplyTo: q.Message.MessageID,
+			ReplyMarkup: &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}})
+		if err != nil {
+			log.Printf("Ошибка отправки выбора времени напоминания в чате %d: %v", chatID, err)
+		}
+		return
+	}
+
+	var preset *reminderPreset
+	for i := range reminderPresets {
+		if reminderPresets[i].id == parts[2] {
+			preset = &reminderPresets[i]
+		}
+	}
+	if preset == nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	// "Завтра в 9:00" - по часовому поясу чата
+	loc := a.chatLocation(ctx, q.Message)
+	reminder := store.Reminder{
+		Platform:  "telegram",
+		ChatID:    strconv.FormatInt(chatID, 10),
+		UserID:    strconv.FormatInt(q.From.ID, 10),
+		MessageID: strconv.Itoa(messageID),
+		Text:      item,
+		DueAt:     preset.due(time.Now().In(loc)),
+	}
+	if err := a.reminders.AddReminder(ctx, &reminder); err != nil {
+		log.Printf("Ошибка сохранения напоминания в чате %d: %v", chatID, err)
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Не удалось сохранить напоминание")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Напоминание сохранено")
+	text := fmt.Sprintf("⏰ Напомню %s: «%s».", reminder.DueAt.In(loc).Format("02.01.2006 в 15:04"), item)
+	if err := a.tele.EditMessageText(chatID, q.Message.MessageID, text, telegram.SendOptions{}); err != nil {
+		log.Printf("Ошибка изменения сообщения %d в чате %d: %v", q.Message.MessageID, chatID, err)
+	}
+}
+
+// RunReminders отправляет наступившие напоминания, проверяя их каждые ReminderPollInterval до отмены ctx
+func (a *App) RunReminders(ctx context.Context) {
+	interval := a.cfg.ReminderPollInterval
+	if interval <= 0 {
+		interval = config.DefaultReminderPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		a.deliverReminders(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) deliverReminders(ctx context.Context) {
+	now := time.Now()
+	due, err := a.reminders.DueReminders(ctx, now, reminderBatch)
+	if err != nil {
+		log.Printf("Ошибка чтения напоминаний: %v", err)
+		return
+	}
+	for _, r := range due {
+		if r.Platform != "telegram" {
+			continue
+		}
+		if err := a.sendReminder(r); err != nil {
+			log.Printf("Ошибка отправки напоминания %d в чат %s: %v", r.ID, r.ChatID, err)
+			// Не доставленное за сутки напоминание (бота удалили из чата и т.п.) больше не пытаемся отправить
+			if now.Sub(r.DueAt) < reminderGiveUpIn {
+				continue
+			}
+		}
+		if err := a.reminders.DeleteReminder(ctx, r.ID); err != nil {
+			log.Printf("Ошибка удаления напоминания %d: %v", r.ID, err)
+		}
+	}
+}
+
+// sendReminder отправляет напоминание r или, для ReminderUnpin, открепляет сообщение
+func (a *App) sendReminder(r store.Reminder) error {
+	chatID, err := strconv.ParseInt(r.ChatID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("некорректный ID чата: %w", err)
+	}
+	replyTo, _ := strconv.Atoi(r.MessageID)
+	if r.Kind == store.ReminderUnpin {
+		return a.tele.UnpinChatMessage(chatID, replyTo)
+	}
+	text := "⏰ Напоминание: " + r.Text
+	err = a.tele.SendMessage(chatID, text, replyTo, "")
+	if err != nil && replyTo != 0 {
+		// Исходное сообщение могли удалить - тогда напоминаем без ответа на него
+		err = a.tele.SendMessage(chatID, text, 0, "")
+	}
+	return err
+}
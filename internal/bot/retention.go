@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const retentionUsage = "Использование: /retention <срок расшифровок> <срок резюме> (например, /retention 30d 1y; 0 — бессрочно), /retention none — ничего не сохранять, /retention default — политика по умолчанию."
+
+// parseRetentionDays разбирает срок вида "30", "30d", "4w", "1y" или "0" (бессрочно) в дни
+func parseRetentionDays(s string) (int, bool) {
+	s = strings.ToLower(s)
+	mult := 1
+	switch {
+	case strings.HasSuffix(s, "d"):
+		s = strings.TrimSuffix(s, "d")
+	case strings.HasSuffix(s, "w"):
+		s, mult = strings.TrimSuffix(s, "w"), 7
+	case strings.HasSuffix(s, "y"):
+		s, mult = strings.TrimSuffix(s, "y"), 365
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n * mult, true
+}
+
+func describeDays(days int) string {
+	if days == 0 {
+		return "бессрочно"
+	}
+	return fmt.Sprintf("%d дн.", days)
+}
+
+func describeRetention(policy store.RetentionPolicy, isDefault bool) string {
+	if policy.Disabled {
+		return "Расшифровки и резюме этого чата не сохраняются."
+	}
+	text := fmt.Sprintf("Расшифровки хранятся: %s, резюме: %s.", describeDays(policy.TranscriptDays), describeDays(policy.SummaryDays))
+	if isDefault {
+		text += " Действует политика по умолчанию."
+	}
+	return text
+}
+
+func (a *App) defaultRetention() store.RetentionPolicy {
+	return store.RetentionPolicy{TranscriptDays: a.cfg.RetentionTranscriptDays, SummaryDays: a.cfg.RetentionSummaryDays}
+}
+
+func (a *App) cmdRetention(msg *telegram.Message, args string) {
+	if a.records == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Бот не хранит расшифровки: постоянное хранилище не настроено.", msg.MessageID, "")
+		return
+	}
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	current := func() string {
+		if settings.Retention == nil {
+			return describeRetention(a.defaultRetention(), true)
+		}
+		return describeRetention(*settings.Retention, false)
+	}
+	if args == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, current()+"\n\n"+retentionUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять сроки хранения могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 1 && strings.EqualFold(fields[0], "default"):
+		settings.Retention = nil
+	case len(fields) == 1 && strings.EqualFold(fields[0], "none"):
+		settings.Retention = &store.RetentionPolicy{Disabled: true}
+	case len(fields) == 2:
+		transcriptDays, ok1 := parseRetentionDays(fields[0])
+		summaryDays, ok2 := parseRetentionDays(fields[1])
+		if !ok1 || !ok2 {
+			_ = a.tele.SendMessage(msg.Chat.ID, retentionUsage, msg.MessageID, "")
+			return
+		}
+		settings.Retention = &store.RetentionPolicy{TranscriptDays: transcriptDays, SummaryDays: summaryDays}
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, retentionUsage, msg.MessageID, "")
+		return
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, current()+" Уже сохраненные данные будут очищены при следующем проходе очистки.", msg.MessageID, "")
+}
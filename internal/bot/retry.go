@@ -0,0 +1,223 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// failedJobTTL - сколько неудавшееся задание можно повторить; потом сохраненный звук удаляется
+const failedJobTTL = time.Hour
+
+// failedJobs - неудавшиеся задания по ключу "чат:сообщение" и сообщения об ошибках, ответ на которые
+// тоже повторяет задание
+type failedJobs struct {
+	mu      sync.Mutex
+	jobs    map[string]*failedJob
+	aliases map[string]string
+}
+
+type failedJob struct {
+	msg *telegram.Message
+	job pipeline.Job
+	// Временные копии файлов задания, которые удаляются вместе с записью
+	files []string
+}
+
+// rememberFailed сохраняет неудавшееся задание для повтора. Если расшифровка уже готова, повтор
+// начнется с суммирования; иначе сохраняются копии сконвертированного и исходного файлов (исходный
+// нужен архиву), чтобы не скачивать их заново.
+func (a *App) rememberFailed(msg *telegram.Message, job pipeline.Job, res *pipeline.Result) {
+	rec := &failedJob{msg: msg, job: job}
+	switch {
+	case res != nil && res.Transcript != "":
+		rec.job.Transcript = res.Transcript
+		// Расшифровка уже показана, повторно ее не отправляем
+		rec.job.InputPath, rec.job.AudioPath = "", ""
+	case job.Transcript != "":
+	case job.AudioPath != "":
+		path, err := keepCopy(job.AudioPath)
+		if err != nil {
+			log.Printf("Не удалось сохранить звук сообщения %d для повтора: %v", msg.MessageID, err)
+			return
+		}
+		rec.job.AudioPath, rec.files = path, []string{path}
+		// Исходный файл нужен архиву, а оригинал удаляется вместе с заданием
+		switch job.InputPath {
+		case "":
+		case job.AudioPath:
+			rec.job.InputPath = path
+		default:
+			input, err := keepCopy(job.InputPath)
+			if err != nil {
+				log.Printf("Не удалось сохранить файл сообщения %d для повтора: %v", msg.MessageID, err)
+				removeFiles(rec.files)
+				return
+			}
+			rec.job.InputPath, rec.files = input, append(rec.files, input)
+		}
+	case job.InputPath != "":
+		path, err := keepCopy(job.InputPath)
+		if err != nil {
+			log.Printf("Не удалось сохранить файл сообщения %d для повтора: %v", msg.MessageID, err)
+			return
+		}
+		rec.job.InputPath, rec.files = path, []string{path}
+	default:
+		return
+	}
+	key := watchKey(msg.Chat.ID, msg.MessageID)
+	a.failed.mu.Lock()
+	if a.failed.jobs == nil {
+		a.failed.jobs = make(map[string]*failedJob)
+		a.failed.aliases = make(map[string]string)
+	}
+	old := a.failed.jobs[key]
+	a.failed.jobs[key] = rec
+	a.failed.mu.Unlock()
+	if old != nil {
+		removeFiles(old.files)
+	}
+	time.AfterFunc(failedJobTTL, func() { a.forgetFailed(key, rec) })
+}
+
+// forgetFailed удаляет запись rec о неудавшемся задании, если ее еще не заменили или не забрали
+func (a *App) forgetFailed(key string, rec *failedJob) {
+	a.failed.mu.Lock()
+	current := a.failed.jobs[key]
+	if current == rec {
+		delete(a.failed.jobs, key)
+	}
+	if current == rec || current == nil {
+		for alias, target := range a.failed.aliases {
+			if target == key {
+				delete(a.failed.aliases, alias)
+			}
+		}
+	}
+	a.failed.mu.Unlock()
+	if current == rec {
+		removeFiles(rec.files)
+	}
+}
+
+// takeFailed забирает неудавшееся задание по сообщению пользователя или сообщению об ошибке
+func (a *App) takeFailed(chatID int64, messageID int) *failedJob {
+	key := watchKey(chatID, messageID)
+	a.failed.mu.Lock()
+	defer a.failed.mu.Unlock()
+	if target, ok := a.failed.aliases[key]; ok {
+		key = target
+	}
+	rec := a.failed.jobs[key]
+	delete(a.failed.jobs, key)
+	return rec
+}
+
+// retryButton - кнопка "Повторить" под сообщением об ошибке, если задание сохранено для повтора
+func (a *App) retryButton(msg *telegram.Message) [][]telegram.InlineKeyboardButton {
+	a.failed.mu.Lock()
+	_, found := a.failed.jobs[watchKey(msg.Chat.ID, msg.MessageID)]
+	a.failed.mu.Unlock()
+	if !found {
+		return nil
+	}
+	return [][]telegram.InlineKeyboardButton{{
+		{Text: i18n.T(a.uiLanguage(msg), i18n.ButtonRetry), CallbackData: fmt.Sprintf("retry:%d", msg.MessageID)},
+	}}
+}
+
+// noteRetryMessage запоминает сообщение об ошибке, чтобы "повтори" в ответ на него тоже повторяло задание
+func (a *App) noteRetryMessage(msg, sent *telegram.Message) {
+	if sent == nil {
+		return
+	}
+	key := watchKey(msg.Chat.ID, msg.MessageID)
+	a.failed.mu.Lock()
+	defer a.failed.mu.Unlock()
+	if _, found := a.failed.jobs[key]; found {
+		a.failed.aliases[watchKey(sent.Chat.ID, sent.MessageID)] = key
+	}
+}
+
+// retryReply повторяет задание по ответу "повтори" на исходное сообщение или сообщение об ошибке;
+// false, если повторять нечего
+func (a *App) retryReply(msg *telegram.Message) bool {
+	rec := a.takeFailed(msg.Chat.ID, msg.ReplyToMessage.MessageID)
+	if rec == nil {
+		return false
+	}
+	a.retry(rec)
+	return true
+}
+
+// onRetryCallback обрабатывает кнопку "Повторить": "<id исходного сообщения>"
+func (a *App) onRetryCallback(q *telegram.CallbackQuery, payload string) {
+	messageID, err := strconv.Atoi(payload)
+	if err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	rec := a.takeFailed(q.Message.Chat.ID, messageID)
+	if rec == nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Повторить уже нельзя: отправьте запись заново")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Повторяю обработку...")
+	a.retry(rec)
+}
+
+// retry заново прогоняет сохраненное задание через конвейер, не скачивая файл повторно
+func (a *App) retry(rec *failedJob) {
+	defer removeFiles(rec.files)
+	msg := rec.msg
+	if a.rejectOverBudget(msg) || a.rejectRateLimited(msg) {
+		return
+	}
+	log.Printf("Повтор обработки сообщения %d в чате %d", msg.MessageID, msg.Chat.ID)
+	a.sendStatus(msg, i18n.T(a.uiLanguage(msg), i18n.StatusProcessing))
+	release := a.scheduleJob(msg)
+	defer release()
+	defer a.notifyWatchers(msg)
+	a.runJob(msg, rec.job)
+}
+
+// keepCopy копирует временный файл задания, который удалится после обработки, для повтора
+func keepCopy(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp("", "retry-*-"+filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+func removeFiles(paths []string) {
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Printf("Не удалось удалить временный файл %s: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// jobPriority - приоритет задания обработки; меньше - важнее
+type jobPriority int
+
+const (
+	priorityHigh       jobPriority = iota // личные чаты и администраторы
+	priorityNormal                        // обычные группы
+	priorityLargeGroup                    // автообработка в больших группах
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{"высокий", "обычный", "большая группа"}
+
+// Сколько хранить число участников группы
+const memberCountTTL = 6 * time.Hour
+
+// defaultJobEstimate - оценка длительности задания, пока ни одно задание не завершилось
+const defaultJobEstimate = time.Minute
+
+// jobScheduler ограничивает число одновременных заданий и раздает места по приоритетам: задание
+// начинается, если есть свободное место, его приоритет не превысил свою долю и нет ждущих
+// заданий важнее. Так одна загруженная группа не отнимает все места у личных чатов.
+type jobScheduler struct {
+	mu      sync.Mutex
+	limit   int
+	shares  [numPriorities]int
+	running [numPriorities]int
+	total   int
+	waiting [numPriorities][]chan struct{}
+	// Скользящее среднее длительности заданий для оценки ожидания
+	avgJob time.Duration
+}
+
+// newJobScheduler создает планировщик на limit заданий; groupShare и largeGroupShare - доли мест
+// (в процентах), которые могут занять задания обычных и больших групп
+func newJobScheduler(limit, groupShare, largeGroupShare int) *jobScheduler {
+	share := func(percent int) int { return max(limit*percent/100, 1) }
+	return &jobScheduler{limit: limit, shares: [numPriorities]int{limit, share(groupShare), share(largeGroupShare)}}
+}
+
+// acquire ждет места для задания с приоритетом p и возвращает функцию, освобождающую его.
+// Если заданию приходится ждать, onQueued (если задан) получает оценку ожидания и число заданий впереди.
+func (s *jobScheduler) acquire(p jobPriority, onQueued func(wait time.Duration, ahead int)) func() {
+	s.mu.Lock()
+	if s.canStart(p) {
+		s.start(p)
+		s.mu.Unlock()
+		return s.releaser(p)
+	}
+	ahead := 0
+	for q := jobPriority(0); q <= p; q++ {
+		ahead += len(s.waiting[q])
+	}
+	wait := s.estimateWait(ahead)
+	ready := make(chan struct{})
+	s.waiting[p] = append(s.waiting[p], ready)
+	log.Printf("Задание с приоритетом «%s» ждет очереди (выполняется %d из %d)", priorityNames[p], s.total, s.limit)
+	s.mu.Unlock()
+	if onQueued != nil {
+		onQueued(wait, ahead)
+	}
+	<-ready
+	return s.releaser(p)
+}
+
+func (s *jobScheduler) releaser(p jobPriority) func() {
+	started := time.Now()
+	return func() { s.release(p, time.Since(started)) }
+}
+
+// estimateWait оценивает ожидание задания, перед которым в очереди ahead заданий: места освобождаются
+// в среднем раз в avgJob, и за это время стартует limit заданий
+func (s *jobScheduler) estimateWait(ahead int) time.Duration {
+	avg := s.avgJob
+	if avg == 0 {
+		avg = defaultJobEstimate
+	}
+	return avg * time.Duration(ahead/s.limit+1)
+}
+
+// queueState - состояние очереди для веб-панели
+type queueState struct {
+	Limit   int          `json:"limit"`
+	Running int          `json:"running"`
+	Waiting int          `json:"waiting"`
+	AvgJob  string       `json:"avg_job"`
+	Levels  []queueLevel `json:"levels"`
+}
+
+type queueLevel struct {
+	Name    string `json:"name"`
+	Share   int    `json:"share"`
+	Running int    `json:"running"`
+	Waiting int    `json:"waiting"`
+}
+
+func (s *jobScheduler) state() queueState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := queueState{Limit: s.limit, Running: s.total, AvgJob: s.avgJob.Round(time.Second).String()}
+	for p := jobPriority(0); p < numPriorities; p++ {
+		st.Waiting += len(s.waiting[p])
+		st.Levels = append(st.Levels, queueLevel{Name: priorityNames[p], Share: s.shares[p], Running: s.running[p], Waiting: len(s.waiting[p])})
+	}
+	return st
+}
+
+func (s *jobScheduler) canStart(p jobPriority) bool {
+	if s.total >= s.limit || s.running[p] >= s.shares[p] {
+		return false
+	}
+	for higher := jobPriority(0); higher < p; higher++ {
+		if len(s.waiting[higher]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *jobScheduler) start(p jobPriority) {
+	s.running[p]++
+	s.total++
+}
+
+func (s *jobScheduler) release(p jobPriority, took time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[p]--
+	s.total--
+	if s.avgJob == 0 {
+		s.avgJob = took
+	} else {
+		s.avgJob = (s.avgJob*4 + took) / 5
+	}
+	// Будим ждущих, начиная с самых важных
+	for q := jobPriority(0); q < numPriorities; q++ {
+		for len(s.waiting[q]) > 0 && s.total < s.limit && s.running[q] < s.shares[q] {
+			ready := s.waiting[q][0]
+			s.waiting[q] = s.waiting[q][1:]
+			s.start(q)
+			close(ready)
+		}
+	}
+}
+
+// scheduleJob ждет места для обработки сообщения msg и возвращает функцию, освобождающую его
+func (a *App) scheduleJob(msg *telegram.Message) func() {
+	if a.scheduler == nil {
+		return func() {}
+	}
+	return a.scheduler.acquire(a.jobPriority(msg), func(wait time.Duration, ahead int) { a.announceQueue(msg, wait, ahead) })
+}
+
+func (a *App) jobPriority(msg *telegram.Message) jobPriority {
+	if msg.Chat.IsPrivate() || (msg.From != nil && a.isChatAdmin(msg)) {
+		return priorityHigh
+	}
+	if a.cfg.LargeGroupMembers > 0 && a.memberCount(msg.Chat.ID) >= a.cfg.LargeGroupMembers {
+		return priorityLargeGroup
+	}
+	return priorityNormal
+}
+
+// memberCount возвращает число участников чата (из кэша, если оно уже известно) или 0 при ошибке
+func (a *App) memberCount(chatID int64) int {
+	ctx := context.Background()
+	key := cache.MemberCountKey("telegram", strconv.FormatInt(chatID, 10))
+	if v, found, err := a.cache.Get(ctx, key); err == nil && found {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	n, err := a.tele.GetChatMemberCount(chatID)
+	if err != nil {
+		log.Printf("Не удалось получить число участников чата %d: %v", chatID, err)
+		return 0
+	}
+	if err := a.cache.Set(ctx, key, strconv.Itoa(n), memberCountTTL); err != nil {
+		log.Printf("Ошибка записи в кэш числа участников чата %d: %v", chatID, err)
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const searchLimit = 10
+
+// highlightSnippet экранирует фрагмент и превращает маркеры совпадений в жирный шрифт
+func highlightSnippet(snippet string) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, store.SnippetMatchStart, "<b>")
+	return strings.ReplaceAll(snippet, store.SnippetMatchEnd, "</b>")
+}
+
+func (a *App) cmdSearch(msg *telegram.Message, args string) {
+	if a.records == nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Поиск недоступен: постоянное хранилище не настроено.", msg.MessageID, "")
+		return
+	}
+	if args == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /search <запрос>", msg.MessageID, "")
+		return
+	}
+	hits, err := a.records.Search(context.Background(), "telegram", strconv.FormatInt(msg.Chat.ID, 10), args, searchLimit)
+	if err != nil {
+		log.Printf("Ошибка поиска в чате %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось выполнить поиск.", msg.MessageID, "")
+		return
+	}
+	if len(hits) == 0 {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Ничего не найдено.", msg.MessageID, "")
+		return
+	}
+	var b strings.Builder
+	for i, hit := range hits {
+		header := hit.CreatedAt.Format("02.01.2006 15:04")
+		if messageID, err := strconv.Atoi(hit.MessageID); err == nil {
+			if link := msg.Chat.MessageLink(messageID); link != "" {
+				header = fmt.Sprintf(`<a href="%s">%s</a>`, link, header)
+			}
+		}
+		fmt.Fprintf(&b, "%d. %s\n%s\n\n", i+1, header, highlightSnippet(hit.Snippet))
+	}
+	a.sendFormattedMessage(msg, msg.MessageID, format.LayoutData{Kind: format.KindSearch, Title: "Результаты поиска", Body: strings.TrimSpace(b.String())})
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// sharePrefix начинает inline-запрос кнопки "Поделиться"; за ним следует случайный токен резюме
+const sharePrefix = "share:"
+
+// shareButtons сохраняет резюме под случайным токеном и возвращает кнопку "Поделиться", которая
+// открывает выбор чата и подставляет запрос с этим токеном. Токен не раскрывает ни чат, ни сообщение.
+// В чатах с /protect on кнопки нет: она позволила бы переслать резюме в обход запрета.
+func (a *App) shareButtons(in *telegram.Message, summary string) [][]telegram.InlineKeyboardButton {
+	if a.chatSettings(context.Background(), in).ProtectContent {
+		return nil
+	}
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("Ошибка генерации токена для кнопки \"Поделиться\": %v", err)
+		return nil
+	}
+	token := hex.EncodeToString(buf)
+	if err := a.cache.Set(context.Background(), cache.ShareKey(token), summary, a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка сохранения резюме для кнопки \"Поделиться\": %v", err)
+		return nil
+	}
+	return [][]telegram.InlineKeyboardButton{{{Text: "Поделиться", SwitchInlineQuery: sharePrefix + token}}}
+}
+
+// shareResults отвечает на inline-запрос кнопки "Поделиться" готовым сообщением с резюме
+func (a *App) shareResults(q *telegram.InlineQuery) []telegram.InlineQueryResultArticle {
+	token := strings.TrimSpace(strings.TrimPrefix(q.Query, sharePrefix))
+	summary, found, err := a.cache.Get(context.Background(), cache.ShareKey(token))
+	if err != nil {
+		log.Printf("Ошибка чтения резюме для inline-запроса пользователя %d: %v", q.From.ID, err)
+	}
+	if !found {
+		return nil
+	}
+	// В inline-результат помещается только одно сообщение
+	text := format.SplitHTML(format.SanitizeHTML("<b>Резюме</b>\n\n"+format.FormatHTML(summary)), a.cfg.MaxMessageLength)[0]
+	return []telegram.InlineQueryResultArticle{{
+		ID:                  "share-" + token,
+		Title:               "Отправить резюме",
+		Description:         format.FirstLine(summary, 100),
+		InputMessageContent: telegram.InputTextMessageContent{MessageText: text, ParseMode: "HTML"},
+	}}
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const silentUsage = "Использование: /silent off | status | all. status — статусы, ошибки и расшифровки приходят без звука, со звуком только резюме; all — без звука все сообщения; off — все со звуком."
+
+var silentNames = map[string]string{
+	"off": "", "выкл": "",
+	"status": store.SilentStatus, "статусы": store.SilentStatus,
+	"all": store.SilentAll, "все": store.SilentAll, "всё": store.SilentAll,
+}
+
+var silentTitles = map[string]string{
+	"":                 "все сообщения со звуком",
+	store.SilentStatus: "со звуком только резюме",
+	store.SilentAll:    "все сообщения без звука",
+}
+
+func (a *App) cmdSilent(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(args))
+	if name == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Уведомления: "+silentTitles[settings.Silent]+".\n\n"+silentUsage, msg.MessageID, "")
+		return
+	}
+	mode, ok := silentNames[name]
+	if !ok {
+		_ = a.tele.SendMessage(msg.Chat.ID, silentUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять режим уведомлений могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	settings.Silent = mode
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	_, _ = a.tele.Send(msg.Chat.ID, "Уведомления: "+silentTitles[mode]+".", telegram.SendOptions{ReplyTo: msg.MessageID, DisableNotification: settings.SilentFor(false)})
+}
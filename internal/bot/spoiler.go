@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const spoilerUsage = "Использование: /spoiler transcript|summary on|off (или /spoiler расшифровка|резюме вкл|выкл). Например, /spoiler transcript on скрывает расшифровку под спойлер, а /spoiler summary off показывает резюме открыто."
+
+func spoilerState(on bool) string {
+	if on {
+		return "под спойлером"
+	}
+	return "открыто"
+}
+
+func (a *App) cmdSpoiler(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	fields := strings.Fields(strings.ToLower(args))
+	if len(fields) == 0 {
+		transcript, summary := settings.Spoilers()
+		_ = a.tele.SendMessage(msg.Chat.ID, "Расшифровка: "+spoilerState(transcript)+", резюме: "+spoilerState(summary)+".\n\n"+spoilerUsage, msg.MessageID, "")
+		return
+	}
+	if len(fields) != 2 {
+		_ = a.tele.SendMessage(msg.Chat.ID, spoilerUsage, msg.MessageID, "")
+		return
+	}
+	var on bool
+	switch fields[1] {
+	case "on", "вкл", "да":
+		on = true
+	case "off", "выкл", "нет":
+		on = false
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, spoilerUsage, msg.MessageID, "")
+		return
+	}
+	var target **bool
+	switch fields[0] {
+	case "transcript", "расшифровка":
+		target = &settings.SpoilerTranscript
+	case "summary", "резюме":
+		target = &settings.SpoilerSummary
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, spoilerUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять спойлеры могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	*target = &on
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	transcript, summary := settings.Spoilers()
+	_ = a.tele.SendMessage(msg.Chat.ID, "Расшифровка: "+spoilerState(transcript)+", резюме: "+spoilerState(summary)+".", msg.MessageID, "")
+}
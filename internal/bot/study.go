@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// studyButton - кнопка "Конспект" под резюме записей не короче StudyMinDuration (лекции, вебинары)
+func (a *App) studyButton(msg *telegram.Message) [][]telegram.InlineKeyboardButton {
+	if a.cfg.StudyMinDuration <= 0 || time.Duration(mediaDuration(msg))*time.Second < a.cfg.StudyMinDuration {
+		return nil
+	}
+	return [][]telegram.InlineKeyboardButton{{
+		{Text: "🎓 Создать конспект/вопросы", CallbackData: fmt.Sprintf("study:%d", msg.MessageID)},
+	}}
+}
+
+// onStudyCallback присылает учебный конспект и вопросы для самопроверки по сохраненной расшифровке
+func (a *App) onStudyCallback(q *telegram.CallbackQuery, payload string) {
+	messageID, err := strconv.Atoi(payload)
+	if err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	chatID := q.Message.Chat.ID
+	transcript, found := a.cachedTranscript(context.Background(), chatID, messageID)
+	if !found {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Расшифровка больше недоступна")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Составляю конспект и вопросы...")
+	ctx := a.promptContext(context.Background(), q.Message)
+	notes, err := a.ai.SummarizeTextIn(ctx, transcript, a.cfg.StudyPrompt, a.chatLanguage(ctx, q.Message))
+	if err != nil {
+		log.Printf("Ошибка составления конспекта для сообщения %d: %v", messageID, err)
+		_ = a.tele.SendMessage(chatID, fmt.Sprintf("Не удалось составить конспект: %v", err), messageID, "")
+		return
+	}
+	a.sendFormattedMessage(q.Message, messageID, format.LayoutData{Kind: format.KindStudy, Title: "Конспект", Body: format.FormatHTML(notes)},
+		a.shareButtons(q.Message, notes)...)
+}
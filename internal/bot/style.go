@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+type summaryStyle struct {
+	id      string
+	label   string
+	aliases []string // варианты имени для /style
+}
+
+var summaryStyles = []summaryStyle{
+	{config.StyleBullets, "Маркированный список", []string{"список", "списком", "пункты"}},
+	{config.StyleProse, "Связный текст", []string{"текст", "текстом", "связно"}},
+	{config.StyleELI5, "Объясни просто", []string{"просто", "проще", "простыми словами", "объясни"}},
+	{config.StyleReport, "Формальный отчёт", []string{"отчет", "отчёт", "отчетом"}},
+}
+
+func findStyle(name string) (summaryStyle, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, s := range summaryStyles {
+		if s.id == name {
+			return s, true
+		}
+		for _, alias := range s.aliases {
+			if alias == name {
+				return s, true
+			}
+		}
+	}
+	return summaryStyle{}, false
+}
+
+// styleButtons - ряды кнопок повторного резюме для исходного сообщения messageID
+func styleButtons(messageID int) [][]telegram.InlineKeyboardButton {
+	var rows [][]telegram.InlineKeyboardButton
+	for i := 0; i < len(summaryStyles); i += 2 {
+		var row []telegram.InlineKeyboardButton
+		for _, s := range summaryStyles[i:min(i+2, len(summaryStyles))] {
+			row = append(row, telegram.InlineKeyboardButton{Text: s.label, CallbackData: fmt.Sprintf("style:%s:%d", s.id, messageID)})
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// cmdStyle пересобирает резюме в выбранном стиле: /style <стиль> в ответ на голосовое сообщение
+// или для последнего обработанного в чате
+func (a *App) cmdStyle(msg *telegram.Message, args string) {
+	style, found := findStyle(args)
+	if !found {
+		var names []string
+		for _, s := range summaryStyles {
+			names = append(names, fmt.Sprintf("%s (%s)", s.id, strings.Join(s.aliases, ", ")))
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /style <стиль> в ответ на голосовое сообщение (без ответа - для последнего обработанного).\nСтили: "+strings.Join(names, "; "), msg.MessageID, "")
+		return
+	}
+	messageID, transcript, found := a.targetTranscript(msg)
+	if !found {
+		_ = a.tele.SendMessage(msg.Chat.ID, noTranscriptText, msg.MessageID, "")
+		return
+	}
+	a.restyle(msg, messageID, transcript, style)
+}
+
+// onStyleCallback обрабатывает кнопку стиля под резюме: "<стиль>:<id исходного сообщения>"
+func (a *App) onStyleCallback(q *telegram.CallbackQuery, payload string) {
+	id, rawMessageID, _ := strings.Cut(payload, ":")
+	style, found := findStyle(id)
+	messageID, err := strconv.Atoi(rawMessageID)
+	if !found || err != nil {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "")
+		return
+	}
+	chatID := q.Message.Chat.ID
+	transcript, found := a.cachedTranscript(context.Background(), chatID, messageID)
+	if !found {
+		_ = a.tele.AnswerCallbackQuery(q.ID, "Расшифровка больше недоступна")
+		return
+	}
+	_ = a.tele.AnswerCallbackQuery(q.ID, "Переписываю резюме: "+style.label)
+	a.restyle(q.Message, messageID, transcript, style)
+}
+
+func (a *App) restyle(in *telegram.Message, messageID int, transcript string, style summaryStyle) {
+	chatID := in.Chat.ID
+	ctx := a.promptContext(context.Background(), in)
+	summary, err := a.ai.SummarizeTextIn(ctx, transcript, a.cfg.StylePrompts[style.id], a.chatLanguage(ctx, in))
+	if err != nil {
+		log.Printf("Ошибка резюме в стиле %s для сообщения %d: %v", style.id, messageID, err)
+		_ = a.tele.SendMessage(chatID, fmt.Sprintf("Ошибка при создании резюме: %v", err), messageID, "")
+		return
+	}
+	a.sendFormattedMessage(in, messageID, format.LayoutData{Kind: format.KindStyle, Title: style.label, Body: format.FormatHTML(summary)},
+		append(styleButtons(messageID), a.shareButtons(in, summary)...)...)
+}
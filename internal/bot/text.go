@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// isLongText сообщает, нужно ли резюмировать текст сообщения: достаточно длинный текст в личке
+// или пересланный в группу ("простыни", которые лень читать)
+func (a *App) isLongText(msg *telegram.Message) bool {
+	if a.cfg.LongTextMinLength <= 0 || msg.Text == "" {
+		return false
+	}
+	if !msg.Chat.IsPrivate() && msg.ForwardOrigin == nil {
+		return false
+	}
+	return utf8.RuneCountInString(msg.Text) >= a.cfg.LongTextMinLength
+}
+
+func isTextDocument(doc *telegram.Document) bool {
+	return strings.HasSuffix(strings.ToLower(doc.FileName), ".txt") || strings.HasPrefix(strings.ToLower(doc.MimeType), "text/plain")
+}
+
+// processTextDocument скачивает текстовый файл и резюмирует его как длинный текст
+func (a *App) processTextDocument(msg *telegram.Message) {
+	if msg.Document.FileSize > a.cfg.MaxFileSize {
+		a.notifyError(msg, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Ваш файл слишком большой.", a.cfg.MaxFileSize/(1024*1024)))
+		return
+	}
+	fileInfo, err := a.tele.GetFile(msg.Document.FileID)
+	if err != nil {
+		log.Printf("Ошибка получения текстового файла для сообщения %d: %v", msg.MessageID, err)
+		a.notifyError(msg, fmt.Sprintf("Не удалось скачать файл: %v", err))
+		return
+	}
+	data, err := a.tele.Download(fileInfo)
+	if errors.Is(err, telegram.ErrFileTooLarge) {
+		a.notifyError(msg, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Ваш файл слишком большой.", a.cfg.MaxFileSize/(1024*1024)))
+		return
+	}
+	if err != nil {
+		log.Printf("Ошибка скачивания текстового файла для сообщения %d: %v", msg.MessageID, err)
+		a.notifyError(msg, fmt.Sprintf("Не удалось скачать файл: %v", err))
+		return
+	}
+	if !utf8.Valid(data) {
+		a.notifyError(msg, "Извините, текстовый файл должен быть в кодировке UTF-8.")
+		return
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(string(data), "\uFEFF"))
+	if text == "" {
+		a.notifyError(msg, "Файл пустой.")
+		return
+	}
+	a.processText(msg, text)
+}
+
+// processText резюмирует длинный текст тем же конвейером, что и расшифровки: текст кэшируется
+// как расшифровка, поэтому для резюме работают кнопки стилей, "кратко", задачи и цитаты
+func (a *App) processText(msg *telegram.Message, text string) {
+	if a.rejectOverBudget(msg) || a.rejectRateLimited(msg) {
+		return
+	}
+	a.sendStatus(msg, i18n.T(a.uiLanguage(msg), i18n.StatusReadingText))
+	release := a.scheduleJob(msg)
+	defer release()
+	defer a.notifyWatchers(msg)
+	ctx := context.Background()
+	a.runJob(msg, pipeline.Job{
+		Source: pipeline.Source{
+			Platform:  "telegram",
+			ChatID:    strconv.FormatInt(msg.Chat.ID, 10),
+			UserID:    strconv.FormatInt(senderID(msg), 10),
+			MessageID: strconv.Itoa(msg.MessageID),
+		},
+		Transcript: text,
+		Language:   a.chatLanguage(ctx, msg),
+		Redact:     a.chatSettings(ctx, msg).RedactPII,
+	})
+}
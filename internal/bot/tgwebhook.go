@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// webhookSecretRe - секрет, который принимает setWebhook
+var webhookSecretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// RunWebhook регистрирует вебхук в Telegram и принимает обновления на TELEGRAM_WEBHOOK_LISTEN_ADDR.
+// Запросы без верного секрета в заголовке X-Telegram-Bot-Api-Secret-Token и (если задан
+// TELEGRAM_WEBHOOK_ALLOWED_IPS) с адресов вне разрешенных подсетей отклоняются.
+func (a *App) RunWebhook() error {
+	allowed, err := parseNetworks(a.cfg.TelegramWebhookAllowedIPs)
+	if err != nil {
+		return err
+	}
+	// Секрет общий для всех реплик: случайный секрет каждой реплики перезаписывал бы вебхук,
+	// и остальные отклоняли бы обновления
+	secret := a.cfg.TelegramWebhookSecret
+	if !webhookSecretRe.MatchString(secret) {
+		return fmt.Errorf("с %s нужен %s: от 1 до 256 символов A-Z, a-z, 0-9, _ и -", config.EnvTelegramWebhookURL, config.EnvTelegramWebhookSecret)
+	}
+	if err := a.tele.SetWebhook(a.cfg.TelegramWebhookURL, secret); err != nil {
+		return fmt.Errorf("не удалось установить вебхук: %w", err)
+	}
+	log.Printf("Вебхук Telegram установлен, прием обновлений на %s", a.cfg.TelegramWebhookListenAddr)
+	return http.ListenAndServe(a.cfg.TelegramWebhookListenAddr, &webhookHandler{app: a, secret: secret, allowed: allowed})
+}
+
+// parseNetworks разбирает список подсетей (CIDR) или отдельных адресов через запятую
+func parseNetworks(list string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная подсеть %q в списке разрешенных адресов вебхука: %w", s, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+type webhookHandler struct {
+	app     *App
+	secret  string
+	allowed []*net.IPNet
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.allowedSource(r.RemoteAddr) {
+		log.Printf("Вебхук: отклонен запрос с адреса %s вне разрешенных подсетей", r.RemoteAddr)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(h.secret)) != 1 {
+		log.Printf("Вебхук: отклонен запрос с адреса %s с неверным секретом", r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var update telegram.Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// Пока очередь обработки заполнена, ответ задерживается, и Telegram не присылает новые обновления
+	h.app.acquireUpdateSlot()
+	go func() {
+		defer h.app.releaseUpdateSlot()
+		h.app.handleUpdate(update)
+	}()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *webhookHandler) allowedSource(remoteAddr string) bool {
+	if len(h.allowed) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range h.allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
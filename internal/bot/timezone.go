@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const timezoneUsage = "Использование: /timezone <пояс> — часовой пояс чата в формате IANA, например /timezone Europe/Moscow; " +
+	"/timezone default — часовой пояс по умолчанию."
+
+// defaultLocation возвращает часовой пояс из TIMEZONE или часовой пояс сервера, если он не задан или некорректен
+func defaultLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Некорректный часовой пояс %q, используется часовой пояс сервера: %v", name, err)
+		return time.Local
+	}
+	return loc
+}
+
+// chatLocation возвращает часовой пояс чата сообщения msg: выбранный через /timezone или по умолчанию
+func (a *App) chatLocation(ctx context.Context, msg *telegram.Message) *time.Location {
+	if name := a.chatSettings(ctx, msg).Timezone; name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return a.location
+}
+
+func (a *App) cmdTimezone(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	name := strings.TrimSpace(args)
+	if name == "" {
+		current := "по умолчанию (" + a.location.String() + ")"
+		if settings.Timezone != "" {
+			current = settings.Timezone
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Часовой пояс чата: "+current+".\n\n"+timezoneUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять часовой пояс могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	if strings.EqualFold(name, "default") {
+		settings.Timezone = ""
+	} else {
+		loc, err := time.LoadLocation(name)
+		if err != nil || strings.EqualFold(name, "local") {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Неизвестный часовой пояс «"+name+"».\n\n"+timezoneUsage, msg.MessageID, "")
+			return
+		}
+		settings.Timezone = loc.String()
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	text := "Часовой пояс чата: по умолчанию (" + a.location.String() + ")."
+	if settings.Timezone != "" {
+		text = "Часовой пояс чата: " + settings.Timezone + ". Сейчас там " + time.Now().In(a.chatLocation(ctx, msg)).Format("15:04") + "."
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, text, msg.MessageID, "")
+}
@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const autoUsage = "Использование: /auto on|off|default. При выключенной автообработке бот не трогает голосовые и аудио, пока его не попросят командой /transcribe в ответ на сообщение. В теме форума команда меняет настройку только этой темы, default — возвращает настройку чата."
+
+// /transcribe запускает полную обработку медиа, которая сама разбирает голосовые команды через
+// commands, поэтому регистрируется в init, чтобы не было цикла инициализации
+func init() {
+	commands["transcribe"] = (*App).cmdTranscribe
+}
+
+// threadOf возвращает ID темы форума, в которой отправлено сообщение, или 0
+func threadOf(msg *telegram.Message) int {
+	if !msg.IsTopicMessage {
+		return 0
+	}
+	return msg.MessageThreadID
+}
+
+// scopePreferences возвращает изменяемые настройки для команды msg: темы форума, если команда
+// отправлена в тему, иначе всего чата
+func scopePreferences(settings *store.ChatSettings, msg *telegram.Message) *store.Preferences {
+	thread := threadOf(msg)
+	if thread == 0 {
+		return &settings.Preferences
+	}
+	key := strconv.Itoa(thread)
+	if settings.Topics == nil {
+		settings.Topics = make(map[string]*store.Preferences)
+	}
+	if settings.Topics[key] == nil {
+		settings.Topics[key] = &store.Preferences{}
+	}
+	return settings.Topics[key]
+}
+
+// scopeName описывает, к чему относится настройка: к теме форума или ко всему чату
+func scopeName(msg *telegram.Message) string {
+	if threadOf(msg) != 0 {
+		return " в этой теме"
+	}
+	return ""
+}
+
+// autoProcess сообщает, нужно ли обрабатывать медиа в чате (теме) сообщения без явной команды
+func (a *App) autoProcess(msg *telegram.Message) bool {
+	if msg.Chat.Type == "private" {
+		return true
+	}
+	auto := a.chatSettings(context.Background(), msg).Auto
+	return auto == nil || *auto
+}
+
+func (a *App) cmdAuto(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	var auto *bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		state := "включена"
+		if !a.autoProcess(msg) {
+			state = "выключена"
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Автообработка"+scopeName(msg)+" "+state+".\n\n"+autoUsage, msg.MessageID, "")
+		return
+	case "on", "вкл", "да":
+		on := true
+		auto = &on
+	case "off", "выкл", "нет":
+		off := false
+		auto = &off
+	case "default":
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, autoUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять автообработку могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	scopePreferences(&settings, msg).Auto = auto
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	effective := settings.ForTopic(threadOf(msg)).Auto
+	if effective == nil || *effective {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Автообработка"+scopeName(msg)+" включена.", msg.MessageID, "")
+	} else {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Автообработка"+scopeName(msg)+" выключена. Чтобы обработать запись, ответьте на нее командой /transcribe.", msg.MessageID, "")
+	}
+}
+
+// cmdTranscribe обрабатывает медиа из сообщения, на которое ответили командой
+func (a *App) cmdTranscribe(msg *telegram.Message, _ string) {
+	target := msg.ReplyToMessage
+	if target == nil || (target.Voice == nil && target.Audio == nil && target.Video == nil && target.VideoNote == nil && target.Document == nil) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Ответьте командой /transcribe на голосовое, видео или аудиофайл.", msg.MessageID, "")
+		return
+	}
+	a.dispatchMedia(target)
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/logging"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// defaultTraceDuration - сколько длится трассировка чата, если срок не указан
+const defaultTraceDuration = 30 * time.Minute
+
+// chatTraces - временные трассировки чатов: тайминги заданий отправляются администратору
+type chatTraces struct {
+	mu     sync.Mutex
+	active map[int64]chatTrace
+}
+
+type chatTrace struct {
+	admin int64
+	until time.Time
+}
+
+// traceFor возвращает трассировку чата, если она включена и не истекла
+func (a *App) traceFor(chatID int64) (chatTrace, bool) {
+	a.traces.mu.Lock()
+	defer a.traces.mu.Unlock()
+	t, ok := a.traces.active[chatID]
+	if ok && time.Now().After(t.until) {
+		delete(a.traces.active, chatID)
+		return chatTrace{}, false
+	}
+	return t, ok
+}
+
+// jobTrace собирает тайминги этапов одного задания
+type jobTrace struct {
+	mu     sync.Mutex
+	stages []string
+}
+
+func (t *jobTrace) add(stage string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stages = append(t.stages, fmt.Sprintf("%s %s", stage, d.Round(time.Millisecond)))
+}
+
+// sendTrace отправляет администратору тайминги задания из трассируемого чата
+func (a *App) sendTrace(trace chatTrace, msg *telegram.Message, jt *jobTrace, total time.Duration, jobErr error) {
+	jt.mu.Lock()
+	stages := strings.Join(jt.stages, ", ")
+	jt.mu.Unlock()
+	outcome := "ok"
+	if jobErr != nil {
+		outcome = "ошибка: " + jobErr.Error()
+	}
+	text := fmt.Sprintf("Трассировка чата %d, сообщение %d (%s):\n%s\nвсего %s, %s",
+		msg.Chat.ID, msg.MessageID, mediaKind(msg), stages, total.Round(time.Millisecond), outcome)
+	if err := a.tele.SendMessage(trace.admin, text, 0, ""); err != nil {
+		log.Printf("Не удалось отправить трассировку администратору %d: %v", trace.admin, err)
+	}
+}
+
+func (a *App) adminLogLevel(msg *telegram.Message, args string) {
+	name := strings.TrimSpace(args)
+	if name == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Уровень журнала: "+logging.Level()+".\n\nИспользование: /admin loglevel debug|info|warn", msg.MessageID, "")
+		return
+	}
+	if err := logging.SetLevel(name); err != nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, err.Error(), msg.MessageID, "")
+		return
+	}
+	log.Printf("Администратор %d установил уровень журнала %s", msg.From.ID, logging.Level())
+	_ = a.tele.SendMessage(msg.Chat.ID, "Уровень журнала: "+logging.Level()+".", msg.MessageID, "")
+}
+
+const traceUsage = "Использование: /admin trace <ID чата> [срок, например 30m] - присылать вам тайминги этапов обработки в чате; /admin trace <ID чата> off - выключить"
+
+func (a *App) adminTrace(msg *telegram.Message, args string) {
+	chat, rest := cutWord(args)
+	chatID, err := strconv.ParseInt(chat, 10, 64)
+	if err != nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, traceUsage, msg.MessageID, "")
+		return
+	}
+	a.traces.mu.Lock()
+	defer a.traces.mu.Unlock()
+	if strings.EqualFold(rest, "off") {
+		delete(a.traces.active, chatID)
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Трассировка чата %d выключена.", chatID), msg.MessageID, "")
+		return
+	}
+	d := defaultTraceDuration
+	if rest != "" {
+		if d, err = time.ParseDuration(rest); err != nil || d <= 0 {
+			_ = a.tele.SendMessage(msg.Chat.ID, traceUsage, msg.MessageID, "")
+			return
+		}
+	}
+	if a.traces.active == nil {
+		a.traces.active = make(map[int64]chatTrace)
+	}
+	a.traces.active[chatID] = chatTrace{admin: msg.From.ID, until: time.Now().Add(d)}
+	log.Printf("Администратор %d включил трассировку чата %d на %s", msg.From.ID, chatID, d)
+	_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Трассировка чата %d включена на %s: тайминги заданий будут приходить вам в личные сообщения.", chatID, d), msg.MessageID, "")
+}
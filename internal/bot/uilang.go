@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const (
+	// minLanguageSamples - сколько наблюдений нужно, прежде чем менять язык интерфейса чата
+	minLanguageSamples = 5
+	// maxLanguageSamples - при таком числе наблюдений счетчики делятся пополам, чтобы старые
+	// наблюдения постепенно теряли вес и язык мог смениться
+	maxLanguageSamples = 40
+	// dominantShare - доля наблюдений (в процентах), при которой язык считается преобладающим
+	dominantShare = 60
+)
+
+const uiLangUsage = "Использование: /uilang <код> — язык сообщений бота в чате, например /uilang en; " +
+	"/uilang auto — выбирать по языку записей и сообщений чата."
+
+// chatLanguages считает языки записей и сообщений по чатам; преобладающий язык становится языком интерфейса
+type chatLanguages struct {
+	mu     sync.Mutex
+	counts map[int64]map[string]int
+}
+
+// noteLanguage учитывает язык, на котором говорят или пишут в чате; неподдерживаемые интерфейсом
+// языки тоже учитываются, чтобы не переключаться на второстепенный язык
+func (a *App) noteLanguage(chatID int64, code string) {
+	code = i18n.Normalize(code)
+	if code == "" {
+		return
+	}
+	a.chatLanguages.mu.Lock()
+	defer a.chatLanguages.mu.Unlock()
+	if a.chatLanguages.counts == nil {
+		a.chatLanguages.counts = make(map[int64]map[string]int)
+	}
+	counts := a.chatLanguages.counts[chatID]
+	if counts == nil {
+		counts = make(map[string]int)
+		a.chatLanguages.counts[chatID] = counts
+	}
+	counts[code]++
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total >= maxLanguageSamples {
+		for lang, n := range counts {
+			if n /= 2; n == 0 {
+				delete(counts, lang)
+			} else {
+				counts[lang] = n
+			}
+		}
+	}
+}
+
+// dominantLanguage возвращает язык, преобладающий в чате, или пустую строку, если наблюдений мало
+// или явного лидера нет
+func (a *App) dominantLanguage(chatID int64) string {
+	a.chatLanguages.mu.Lock()
+	defer a.chatLanguages.mu.Unlock()
+	total, best, bestCount := 0, "", 0
+	for lang, n := range a.chatLanguages.counts[chatID] {
+		total += n
+		if n > bestCount || (n == bestCount && lang < best) {
+			best, bestCount = lang, n
+		}
+	}
+	if total < minLanguageSamples || bestCount*100 < total*dominantShare {
+		return ""
+	}
+	return best
+}
+
+// cmdUILang выбирает язык сообщений бота в чате: /uilang <код> или /uilang auto
+func (a *App) cmdUILang(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	code := i18n.Normalize(args)
+	if code == "" {
+		current := "автоматически: " + a.describeLanguage(a.uiLanguage(msg))
+		if settings.UILanguage != "" {
+			current = a.describeLanguage(settings.UILanguage)
+		}
+		_ = a.tele.SendMessage(msg.Chat.ID, "Язык сообщений бота: "+current+".\n\n"+uiLangUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять язык сообщений бота могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	switch {
+	case code == "auto" || code == "default":
+		settings.UILanguage = ""
+	case i18n.Supported(code):
+		settings.UILanguage = code
+	default:
+		_ = a.tele.SendMessage(msg.Chat.ID, "Этот язык не поддерживается, доступны: "+strings.Join(i18n.Languages(), ", ")+".\n\n"+uiLangUsage, msg.MessageID, "")
+		return
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	text := "Язык сообщений бота теперь выбирается автоматически по языку чата."
+	if settings.UILanguage != "" {
+		text = "Язык сообщений бота: " + strings.ToLower(a.describeLanguage(settings.UILanguage)) + "."
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, text, msg.MessageID, "")
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// usagePeriods - периоды, за которые /usage показывает потребление
+var usagePeriods = []struct {
+	name   string
+	period func(time.Time) string
+}{
+	{"Сегодня", store.DayPeriod},
+	{"В этом месяце", store.MonthPeriod},
+}
+
+// cmdUsage показывает потребление автора команды и чата за сутки и месяц (UTC) и лимиты чата
+func (a *App) cmdUsage(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	now := time.Now()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	limits := map[store.Quota]int64{}
+	for _, l := range a.budgetLimits("telegram", chatID, now) {
+		if l.quota.Subject != store.GlobalSubject {
+			limits[l.quota] = l.limit
+		}
+	}
+
+	type scope struct {
+		title   string
+		subject string
+	}
+	var scopes []scope
+	if msg.From != nil {
+		scopes = append(scopes, scope{"Вы", strconv.FormatInt(msg.From.ID, 10)})
+	}
+	if msg.Chat.Type != "private" {
+		scopes = append(scopes, scope{"Чат", store.ChatSubject(chatID)})
+	}
+
+	var b strings.Builder
+	for i, p := range usagePeriods {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s (UTC):\n", p.name)
+		for _, s := range scopes {
+			q := store.Quota{Platform: "telegram", Subject: s.subject, Period: p.period(now)}
+			var values [3]int64
+			for j, metric := range []string{store.MetricRequests, store.MetricAudioSeconds, store.MetricTokens} {
+				q.Metric = metric
+				v, err := a.quotas.GetUsage(ctx, q)
+				if err != nil {
+					log.Printf("Ошибка чтения потребления %s в чате %d: %v", s.subject, msg.Chat.ID, err)
+					_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать статистику потребления.", msg.MessageID, "")
+					return
+				}
+				values[j] = v
+			}
+			fmt.Fprintf(&b, "%s: записей %d, аудио %s, токенов %d", s.title, values[0], format.Duration(time.Duration(values[1])*time.Second), values[2])
+			if msg.Chat.Type == "private" {
+				// В личном чате лимит чата - это лимит самого пользователя
+				q.Subject = store.ChatSubject(chatID)
+			}
+			if limit := limits[q]; limit > 0 {
+				fmt.Fprintf(&b, " из %d", limit)
+			}
+			b.WriteString("\n")
+		}
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, b.String(), msg.MessageID, "")
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+const verbosityUsage = "Использование: /verbosity тихий | обычный | подробный. Тихий режим присылает только резюме, обычный — еще статусы и расшифровку, подробный — еще и сведения об обработке (модель, токены, время). В теме форума команда меняет режим только этой темы, default — возвращает режим чата."
+
+var verbosityNames = map[string]string{
+	"тихий": store.VerbosityQuiet, "quiet": store.VerbosityQuiet,
+	"обычный": store.VerbosityNormal, "normal": store.VerbosityNormal,
+	"подробный": store.VerbosityVerbose, "verbose": store.VerbosityVerbose,
+}
+
+var verbosityTitles = map[string]string{
+	store.VerbosityQuiet:   "тихий",
+	store.VerbosityNormal:  "обычный",
+	store.VerbosityVerbose: "подробный",
+}
+
+// chatSettings читает настройки чата сообщения in с учетом переопределений его темы форума;
+// при ошибке возвращает настройки по умолчанию
+func (a *App) chatSettings(ctx context.Context, in *telegram.Message) store.ChatSettings {
+	settings, err := a.settings.GetSettings(ctx, "telegram", strconv.FormatInt(in.Chat.ID, 10))
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", in.Chat.ID, err)
+	}
+	return settings.ForTopic(threadOf(in))
+}
+
+func (a *App) chatVerbosity(ctx context.Context, in *telegram.Message) string {
+	if v := a.chatSettings(ctx, in).Verbosity; v != "" {
+		return v
+	}
+	return store.VerbosityNormal
+}
+
+// sendStatus отправляет служебное сообщение о ходе обработки. При тихой подробности ничего не отправляет,
+// а в тихом режиме /quiet вместо сообщения ставит реакцию "в работе".
+func (a *App) sendStatus(msg *telegram.Message, text string) {
+	settings := a.chatSettings(context.Background(), msg)
+	if settings.Quiet {
+		a.react(msg, reactionProcessing)
+		return
+	}
+	if settings.Verbosity == store.VerbosityQuiet {
+		return
+	}
+	_, _ = a.tele.Send(msg.Chat.ID, text, telegram.SendOptions{ReplyTo: msg.MessageID, DisableNotification: settings.SilentFor(false)})
+}
+
+func (a *App) cmdVerbosity(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	settings, err := a.settings.GetSettings(ctx, "telegram", chatID)
+	if err != nil {
+		log.Printf("Ошибка чтения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось прочитать настройки чата.", msg.MessageID, "")
+		return
+	}
+	if args == "" {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Режим ответов"+scopeName(msg)+": "+verbosityTitles[a.chatVerbosity(ctx, msg)]+".\n\n"+verbosityUsage, msg.MessageID, "")
+		return
+	}
+	if !a.isChatAdmin(msg) {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Менять режим ответов могут только администраторы чата.", msg.MessageID, "")
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(args))
+	level, ok := verbosityNames[name]
+	if !ok && name != "default" {
+		_ = a.tele.SendMessage(msg.Chat.ID, verbosityUsage, msg.MessageID, "")
+		return
+	}
+	prefs := scopePreferences(&settings, msg)
+	prefs.Verbosity = level
+	// Обычный режим чата хранится пустым значением, а в теме - явно, чтобы перекрыть режим чата
+	if threadOf(msg) == 0 && level == store.VerbosityNormal {
+		prefs.Verbosity = ""
+	}
+	if err := a.settings.SaveSettings(ctx, "telegram", chatID, settings); err != nil {
+		log.Printf("Ошибка сохранения настроек чата %d: %v", msg.Chat.ID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, "Не удалось сохранить настройки чата.", msg.MessageID, "")
+		return
+	}
+	level = settings.ForTopic(threadOf(msg)).Verbosity
+	if level == "" {
+		level = store.VerbosityNormal
+	}
+	_ = a.tele.SendMessage(msg.Chat.ID, "Режим ответов"+scopeName(msg)+": "+verbosityTitles[level]+".", msg.MessageID, "")
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/i18n"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// voiceCommands сопоставляет начало произнесенной фразы с текстовой командой;
+// остаток фразы передается команде как аргументы
+var voiceCommands = []struct {
+	phrases []string
+	command string
+}{
+	{[]string{"сделай кратко", "кратко", "покороче", "короче"}, "/short"},
+	{[]string{"переведи последнее", "переведи"}, "/translate"},
+	{[]string{"перескажи", "стиль"}, "/style"},
+	{[]string{"покажи историю", "история"}, "/history"},
+	{[]string{"найди", "поиск"}, "/search"},
+}
+
+// parseVoiceCommand превращает расшифровку короткого голосового в текстовую команду
+func parseVoiceCommand(transcript string) (string, bool) {
+	words := strings.FieldsFunc(strings.ToLower(transcript), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	text := strings.Join(words, " ")
+	for _, vc := range voiceCommands {
+		for _, phrase := range vc.phrases {
+			if text == phrase || strings.HasPrefix(text, phrase+" ") {
+				args := strings.TrimSpace(strings.TrimPrefix(text, phrase))
+				// "переведи на английский" - предлог к аргументам не относится
+				args = strings.TrimSpace(strings.TrimPrefix(args+" ", "на "))
+				return strings.TrimSpace(vc.command + " " + args), true
+			}
+		}
+	}
+	return "", false
+}
+
+// voiceCommandCandidate сообщает, нужно ли проверить голосовое на команду: только короткие голосовые в личке
+func (a *App) voiceCommandCandidate(msg *telegram.Message) bool {
+	return a.cfg.VoiceCommandMaxDuration > 0 && msg.Voice != nil && msg.Chat.IsPrivate() &&
+		time.Duration(msg.Voice.Duration)*time.Second <= a.cfg.VoiceCommandMaxDuration &&
+		a.featureEnabled(flagVoiceCommands, msg.Chat.ID)
+}
+
+// runVoiceCommand распознает голосовое и, если это команда, выполняет ее. Иначе расшифровка
+// сохраняется в job, чтобы не распознавать запись повторно.
+func (a *App) runVoiceCommand(msg *telegram.Message, job *pipeline.Job) bool {
+	transcript, err := a.pipe.Transcribe(context.Background(), *job)
+	if err != nil {
+		log.Printf("Ошибка распознавания голосовой команды в сообщении %d: %v", msg.MessageID, err)
+		return false
+	}
+	command, ok := parseVoiceCommand(transcript)
+	if !ok {
+		job.Transcript = transcript
+		return false
+	}
+	log.Printf("Голосовая команда %q в сообщении %d", command, msg.MessageID)
+	cmdMsg := *msg
+	cmdMsg.Text = command
+	return a.handleCommand(&cmdMsg)
+}
+
+func (a *App) rememberLast(chatID int64, messageID int) {
+	if err := a.cache.Set(context.Background(), cache.LastKey("telegram", strconv.FormatInt(chatID, 10)), strconv.Itoa(messageID), a.cfg.CacheTTL); err != nil {
+		log.Printf("Ошибка записи в кэш последнего сообщения чата %d: %v", chatID, err)
+	}
+}
+
+// targetTranscript находит расшифровку, к которой относится команда: сообщения, на которое ответили,
+// или последнего обработанного в чате. Возвращает ID этого сообщения.
+func (a *App) targetTranscript(msg *telegram.Message) (int, string, bool) {
+	ctx := context.Background()
+	messageID := 0
+	if msg.ReplyToMessage != nil {
+		messageID = msg.ReplyToMessage.MessageID
+	} else {
+		last, found, err := a.cache.Get(ctx, cache.LastKey("telegram", strconv.FormatInt(msg.Chat.ID, 10)))
+		if err != nil {
+			log.Printf("Ошибка чтения последнего сообщения чата %d: %v", msg.Chat.ID, err)
+		}
+		if !found {
+			return 0, "", false
+		}
+		messageID, _ = strconv.Atoi(last)
+	}
+	transcript, found := a.cachedTranscript(ctx, msg.Chat.ID, messageID)
+	return messageID, transcript, found
+}
+
+const noTranscriptText = "Не нашел расшифровку: ответьте командой на голосовое сообщение или сначала отправьте запись."
+
+// shortSummary делает очень краткое резюме расшифровки или, если target - уже краткое резюме, сжимает его еще сильнее
+func (a *App) shortSummary(msg *telegram.Message, target compressible) {
+	if target.Depth >= maxCompressDepth {
+		_ = a.tele.SendMessage(msg.Chat.ID, "Короче уже некуда.", msg.MessageID, "")
+		return
+	}
+	ctx := a.promptContext(context.Background(), msg)
+	a.sendStatus(msg, i18n.T(a.uiLanguage(msg), i18n.StatusShorter))
+	prompt, title := a.ai.Prompts().Short, "Краткое резюме"
+	if target.Depth > 0 {
+		prompt, title = a.cfg.CompressPromptTemplate, "Еще короче"
+	}
+	language := a.chatLanguage(ctx, msg)
+	// Несколько "кратко" на одно сообщение одновременно делают одно обращение к модели
+	key := fmt.Sprintf("%d:%d:%d:%d:%s", msg.Chat.ID, threadOf(msg), target.Source, target.Depth, language)
+	if target.Depth > 0 {
+		key += ":" + target.Text
+	}
+	shortSummary, err, _ := a.shortFlights.do(key, func() (string, error) {
+		return a.ai.SummarizeTextIn(ctx, target.Text, prompt, language)
+	})
+	if err != nil {
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка при создании краткого резюме: %v", err), msg.MessageID, "")
+		return
+	}
+	sent := a.sendFormattedMessage(msg, msg.MessageID, format.LayoutData{Kind: format.KindShort, Title: title, Body: format.FormatHTML(shortSummary)})
+	a.rememberCompressible(msg.Chat.ID, sent, compressible{Source: target.Source, Depth: target.Depth + 1, Text: shortSummary})
+}
+
+// cmdShort делает очень краткое резюме сообщения, на которое ответили, или последнего обработанного;
+// в ответ на краткое резюме бота сжимает его еще сильнее
+func (a *App) cmdShort(msg *telegram.Message, _ string) {
+	if msg.ReplyToMessage != nil {
+		if target, found := a.compressTarget(context.Background(), msg.Chat.ID, msg.ReplyToMessage.MessageID); found {
+			a.shortSummary(msg, target)
+			return
+		}
+	}
+	messageID, transcript, found := a.targetTranscript(msg)
+	if !found {
+		_ = a.tele.SendMessage(msg.Chat.ID, noTranscriptText, msg.MessageID, "")
+		return
+	}
+	a.shortSummary(msg, compressible{Source: messageID, Text: transcript})
+}
+
+// cmdTranslate пересобирает резюме на указанном языке: /translate [язык]; без языка - на языке чата
+func (a *App) cmdTranslate(msg *telegram.Message, args string) {
+	ctx := context.Background()
+	language := a.chatLanguage(ctx, msg)
+	if args != "" {
+		code, ok := ai.LanguageCode(args)
+		if !ok {
+			_ = a.tele.SendMessage(msg.Chat.ID, "Использование: /translate [язык], например /translate английский или /translate en", msg.MessageID, "")
+			return
+		}
+		language = code
+	}
+	messageID, transcript, found := a.targetTranscript(msg)
+	if !found {
+		_ = a.tele.SendMessage(msg.Chat.ID, noTranscriptText, msg.MessageID, "")
+		return
+	}
+	summary, err := a.ai.SummarizeTextIn(a.promptContext(ctx, msg), transcript, a.ai.Prompts().User, language)
+	if err != nil {
+		log.Printf("Ошибка перевода резюме сообщения %d: %v", messageID, err)
+		_ = a.tele.SendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка при создании резюме: %v", err), msg.MessageID, "")
+		return
+	}
+	a.sendFormattedMessage(msg, messageID, format.LayoutData{Kind: format.KindSummary, Title: "Резюме: " + ai.LanguageName(language),
+		Body: format.FormatHTML(summary)}, a.shareButtons(msg, summary)...)
+}
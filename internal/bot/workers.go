@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/queue"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+)
+
+// Сколько держится отметка о том, кто обрабатывает попытку задания: подтверждение обработчика
+// может прийти позже JOB_QUEUE_ACK_TIMEOUT, но не на час
+const jobClaimTTL = time.Hour
+
+var errJobClaimed = errors.New("задание уже обрабатывается")
+
+// queuedJob - задание в очереди: сообщение с медиа и номер попытки (растет, когда задание возобновляется)
+type queuedJob struct {
+	Message *telegram.Message `json:"message"`
+	Attempt int               `json:"attempt"`
+}
+
+// SetJobQueue передает обработку медиа внешней очереди (JOB_QUEUE_URL): процесс с long polling
+// отправляет задания в q, а обрабатывают их процессы, запущенные с RunWorker
+func (a *App) SetJobQueue(q queue.Queue) { a.jobQueue = q }
+
+// claimJob занимает попытку attempt обработки сообщения msg в общем кэше. Задание, которое не удалось
+// передать в очередь, могло все же дойти до обработчика (подтверждение опоздало): кто занял попытку
+// первым, тот ее и выполняет.
+func (a *App) claimJob(msg *telegram.Message, attempt int) (bool, error) {
+	key := cache.JobClaimKey("telegram", strconv.FormatInt(msg.Chat.ID, 10), strconv.Itoa(msg.MessageID), attempt)
+	return a.cache.SetNX(context.Background(), key, a.replicaID, jobClaimTTL)
+}
+
+// dispatchMedia обрабатывает медиа из сообщения msg: с внешней очередью передает его обработчикам,
+// а если ни один не подтвердил задание за JOB_QUEUE_ACK_TIMEOUT, обрабатывает сам
+func (a *App) dispatchMedia(msg *telegram.Message) {
+	if a.jobQueue == nil {
+		a.processMedia(msg)
+		return
+	}
+	attempt := a.resumed.get(watchKey(msg.Chat.ID, msg.MessageID))
+	payload, err := json.Marshal(queuedJob{Message: msg, Attempt: attempt})
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), a.cfg.JobQueueAckTimeout)
+		err = a.jobQueue.Submit(ctx, payload)
+		cancel()
+	}
+	if err == nil {
+		// Возобновленное задание дальше продлевает обработчик
+		a.releaseJob(msg)
+		log.Printf("Сообщение %d передано в очередь заданий", msg.MessageID)
+		return
+	}
+	claimed, claimErr := a.claimJob(msg, attempt)
+	if claimErr != nil {
+		// Без общего кэша лучше рискнуть повтором, чем потерять запись
+		log.Printf("Ошибка отметки задания для сообщения %d: %v", msg.MessageID, claimErr)
+	} else if !claimed {
+		a.releaseJob(msg)
+		log.Printf("Сообщение %d уже принял обработчик из очереди (подтверждение опоздало: %v)", msg.MessageID, err)
+		return
+	}
+	log.Printf("Сообщение %d не передано в очередь заданий, обрабатывается здесь: %v", msg.MessageID, err)
+	a.processMedia(msg)
+}
+
+// RunWorker принимает задания из очереди q и обрабатывает их, пока ctx не отменен (подкоманда worker).
+// Одновременно обрабатывается не больше WORKER_CONCURRENCY заданий: лишние передаются другим
+// обработчикам, а если заняты все, их обрабатывает отправитель. Принятое задание сохраняется до
+// подтверждения, поэтому, если обработчик упадет, его возобновит ведущая реплика (см. ResumeJobs).
+func (a *App) RunWorker(ctx context.Context, q queue.Queue) error {
+	slots := make(chan struct{}, max(a.cfg.WorkerConcurrency, 1))
+	return q.Consume(ctx, func(data []byte) error {
+		var job queuedJob
+		if err := json.Unmarshal(data, &job); err != nil || job.Message == nil || job.Message.Chat == nil {
+			return fmt.Errorf("не удалось разобрать задание: %v", err)
+		}
+		msg := job.Message
+		select {
+		case slots <- struct{}{}:
+		default:
+			return queue.ErrBusy
+		}
+		claimed, err := a.claimJob(msg, job.Attempt)
+		if err != nil || !claimed {
+			<-slots
+			if err != nil {
+				return fmt.Errorf("не удалось отметить задание: %w", err)
+			}
+			return errJobClaimed
+		}
+		log.Printf("Получено задание из очереди: сообщение %d в чате %d", msg.MessageID, msg.Chat.ID)
+		a.resumed.set(watchKey(msg.Chat.ID, msg.MessageID), job.Attempt)
+		a.persistJob(msg, jobQueued)
+		go func() {
+			defer func() { <-slots }()
+			// processMedia удаляет задание сам, но может отклонить запись раньше, чем начнет его вести
+			defer a.finishJob(msg)
+			a.processMedia(msg)
+		}()
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache - разделяемое состояние сессий (кэшированные расшифровки и т.п.).
+// Реализация в памяти подходит для одной реплики, Redis - для нескольких реплик с одним токеном.
+type Cache interface {
+	// Get возвращает значение и признак его наличия
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Set сохраняет значение; ttl <= 0 - без срока жизни
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX сохраняет значение, только если ключа еще нет, и сообщает, сохранено ли оно
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// TranscriptKey - ключ кэша для расшифровки сообщения в чате
+func TranscriptKey(platform, chatID string, messageID string) string {
+	return fmt.Sprintf("transcript:%s:%s:%s", platform, chatID, messageID)
+}
+
+// SummaryKey - ключ кэша для резюме сообщения в чате
+func SummaryKey(platform, chatID string, messageID string) string {
+	return fmt.Sprintf("summary:%s:%s:%s", platform, chatID, messageID)
+}
+
+// ShareKey - ключ кэша для резюме, которым можно поделиться через inline-режим
+func ShareKey(token string) string {
+	return "share:" + token
+}
+
+// LastKey - ключ кэша для ID последнего обработанного медиа-сообщения в чате
+func LastKey(platform, chatID string) string {
+	return fmt.Sprintf("last:%s:%s", platform, chatID)
+}
+
+// PagesKey - ключ кэша для страниц длинного сообщения, отправленного ботом
+func PagesKey(platform, chatID string, messageID string) string {
+	return fmt.Sprintf("pages:%s:%s:%s", platform, chatID, messageID)
+}
+
+// ActionsKey - ключ кэша для задач, выделенных из расшифровки сообщения
+func ActionsKey(platform, chatID string, messageID string) string {
+	return fmt.Sprintf("actions:%s:%s:%s", platform, chatID, messageID)
+}
+
+// CompressKey - ключ кэша для сообщения бота, которое можно сжать ответом "кратко"
+func CompressKey(platform, chatID string, messageID string) string {
+	return fmt.Sprintf("compress:%s:%s:%s", platform, chatID, messageID)
+}
+
+// SeriesKey - ключ кэша для серии голосовых подряд от пользователя в чате
+func SeriesKey(platform, chatID, userID string) string {
+	return fmt.Sprintf("series:%s:%s:%s", platform, chatID, userID)
+}
+
+// MergeKey - ключ кэша для серии голосовых, которую предлагается объединить под резюме сообщения
+func MergeKey(platform, chatID string, messageID string) string {
+	return fmt.Sprintf("merge:%s:%s:%s", platform, chatID, messageID)
+}
+
+// MemberCountKey - ключ кэша для числа участников чата
+func MemberCountKey(platform, chatID string) string {
+	return fmt.Sprintf("members:%s:%s", platform, chatID)
+}
+
+// BusinessKey - ключ кэша для подключения к бизнес-аккаунту
+func BusinessKey(platform, connectionID string) string {
+	return fmt.Sprintf("business:%s:%s", platform, connectionID)
+}
+
+// VoteKey - ключ кэша для оценки резюме пользователем (одна оценка на резюме)
+func VoteKey(platform, chatID, messageID, userID string) string {
+	return fmt.Sprintf("vote:%s:%s:%s:%s", platform, chatID, messageID, userID)
+}
+
+// JobClaimKey - ключ кэша, которым процесс занимает попытку attempt обработки сообщения,
+// чтобы ее не выполнили и обработчик из очереди, и процесс с long polling
+func JobClaimKey(platform, chatID, messageID string, attempt int) string {
+	return fmt.Sprintf("claim:%s:%s:%s:%d", platform, chatID, messageID, attempt)
+}
+
+// SummaryResponseKey - ключ кэша для готового суммирования по хешу текста и промптов
+func SummaryResponseKey(hash string) string {
+	return "summary_response:" + hash
+}
+
+// DailyNotesKey - ключ кэша для закрепленного сообщения "Итоги дня" чата
+func DailyNotesKey(platform, chatID string) string {
+	return fmt.Sprintf("daily_notes:%s:%s", platform, chatID)
+}
+
+// PollOffsetKey - ключ кэша для offset long polling: по нему новая ведущая реплика продолжает
+// с того места, где остановилась прежняя
+func PollOffsetKey(platform string) string {
+	return "poll_offset:" + platform
+}
+
+// WithPrefix возвращает кэш, который добавляет prefix ко всем ключам c: так несколько ботов
+// (тенантов MULTI_TENANT) делят один кэш, не видя ключей друг друга
+func WithPrefix(c Cache, prefix string) Cache {
+	return prefixed{c: c, prefix: prefix}
+}
+
+type prefixed struct {
+	c      Cache
+	prefix string
+}
+
+func (p prefixed) Get(ctx context.Context, key string) (string, bool, error) {
+	return p.c.Get(ctx, p.prefix+key)
+}
+
+func (p prefixed) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return p.c.Set(ctx, p.prefix+key, value, ttl)
+}
+
+func (p prefixed) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return p.c.SetNX(ctx, p.prefix+key, value, ttl)
+}
+
+func (p prefixed) Delete(ctx context.Context, key string) error {
+	return p.c.Delete(ctx, p.prefix+key)
+}
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+}
+
+func NewMemory() *Memory {
+	m := &Memory{items: make(map[string]memoryItem)}
+	go m.janitor(10 * time.Minute)
+	return m
+}
+
+func (m *Memory) Get(_ context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if !ok {
+		return "", false, nil
+	}
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		delete(m.items, key)
+		return "", false, nil
+	}
+	return item.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	m.items[key] = item
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) SetNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev, ok := m.items[key]; ok && (prev.expiresAt.IsZero() || time.Now().Before(prev.expiresAt)) {
+		return false, nil
+	}
+	m.items[key] = item
+	return true, nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.items, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// janitor периодически удаляет просроченные записи, чтобы кэш не рос бесконечно
+func (m *Memory) janitor(interval time.Duration) {
+	for range time.Tick(interval) {
+		now := time.Now()
+		m.mu.Lock()
+		for k, item := range m.items {
+			if !item.expiresAt.IsZero() && now.After(item.expiresAt) {
+				delete(m.items, k)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
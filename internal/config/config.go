@@ -1,7 +1,11 @@
 package config
 
 import (
+	"log"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,12 +18,163 @@ const (
 	EnvSystemPrompt = "SYSTEM_PROMPT"
 	EnvUserPromptTemplate = "USER_PROMPT_TEMPLATE"
 	EnvShortPromptTemplate = "SHORT_PROMPT_TEMPLATE"
+	EnvCompressPromptTemplate = "COMPRESS_PROMPT_TEMPLATE"
+	EnvSlackBotToken = "SLACK_BOT_TOKEN"
+	EnvSlackSigningSecret = "SLACK_SIGNING_SECRET"
+	EnvSlackListenAddr = "SLACK_LISTEN_ADDR"
+	EnvPlatform = "PLATFORM"
+	EnvMatrixHomeserver = "MATRIX_HOMESERVER"
+	EnvMatrixAccessToken = "MATRIX_ACCESS_TOKEN"
+	EnvResultWebhookURL = "RESULT_WEBHOOK_URL"
+	EnvDatabasePath = "DATABASE_PATH"
+	EnvDatabaseURL = "DATABASE_URL"
+	EnvStorageBackend = "STORAGE_BACKEND"
+	EnvNotionToken = "NOTION_TOKEN"
+	EnvNotionAllowedPages = "NOTION_ALLOWED_PAGES"
+	EnvNotesVaultDir = "NOTES_VAULT_DIR"
+	EnvNotesWebDAVURL = "NOTES_WEBDAV_URL"
+	EnvNotesWebDAVUser = "NOTES_WEBDAV_USER"
+	EnvNotesWebDAVPassword = "NOTES_WEBDAV_PASSWORD"
+	EnvRedisURL = "REDIS_URL"
+	EnvCacheTTL = "CACHE_TTL"
+	EnvArchiveS3Endpoint = "ARCHIVE_S3_ENDPOINT"
+	EnvArchiveS3AccessKey = "ARCHIVE_S3_ACCESS_KEY"
+	EnvArchiveS3SecretKey = "ARCHIVE_S3_SECRET_KEY"
+	EnvArchiveS3Bucket = "ARCHIVE_S3_BUCKET"
+	EnvArchiveS3Region = "ARCHIVE_S3_REGION"
+	EnvArchiveS3UseSSL = "ARCHIVE_S3_USE_SSL"
+	EnvArchivePrefix = "ARCHIVE_PREFIX"
+	EnvArchiveRetentionDays = "ARCHIVE_RETENTION_DAYS"
+	EnvRetentionTranscriptDays = "RETENTION_TRANSCRIPT_DAYS"
+	EnvRetentionSummaryDays = "RETENTION_SUMMARY_DAYS"
+	EnvRetentionPurgeInterval = "RETENTION_PURGE_INTERVAL"
+	EnvOutputTemplateFile = "OUTPUT_TEMPLATE_FILE"
+	EnvStylePromptBullets = "STYLE_PROMPT_BULLETS"
+	EnvStylePromptProse = "STYLE_PROMPT_PROSE"
+	EnvStylePromptELI5 = "STYLE_PROMPT_ELI5"
+	EnvStylePromptReport = "STYLE_PROMPT_REPORT"
+	EnvSummaryLanguage = "SUMMARY_LANGUAGE"
+	EnvTimezone = "TIMEZONE"
+	EnvVoiceCommandMaxDuration = "VOICE_COMMAND_MAX_DURATION"
+	EnvActionItemsPrompt = "ACTION_ITEMS_PROMPT"
+	EnvReminderPollInterval = "REMINDER_POLL_INTERVAL"
+	EnvMergeWindow = "MERGE_WINDOW"
+	EnvLongTextMinLength = "LONG_TEXT_MIN_LENGTH"
+	EnvDownloadAttempts = "DOWNLOAD_ATTEMPTS"
+	EnvHTTPTimeout = "HTTP_TIMEOUT"
+	EnvHTTPDialTimeout = "HTTP_DIAL_TIMEOUT"
+	EnvDownloadTimeout = "DOWNLOAD_TIMEOUT"
+	EnvDownloadMaxConns = "DOWNLOAD_MAX_CONNS"
+	EnvCoalesceThreshold = "COALESCE_THRESHOLD"
+	EnvCoalesceMaxBatch = "COALESCE_MAX_BATCH"
+	EnvCoalesceMaxChars = "COALESCE_MAX_CHARS"
+	EnvCoalesceWait = "COALESCE_WAIT"
+	EnvMemoryBudgetMB = "MEMORY_BUDGET_MB"
+	EnvMaxDurationMinutes = "MAX_DURATION_MINUTES"
+	EnvMaxPendingUpdates = "MAX_PENDING_UPDATES"
+	EnvMaxConcurrentJobs = "MAX_CONCURRENT_JOBS"
+	EnvUserRateLimit = "USER_RATE_LIMIT"
+	EnvTelegramWebhookURL = "TELEGRAM_WEBHOOK_URL"
+	EnvPollTimeout = "POLL_TIMEOUT"
+	EnvMessageEffectID = "MESSAGE_EFFECT_ID"
+	EnvBotAdminIDs = "BOT_ADMIN_IDS"
+	EnvBroadcastInterval = "BROADCAST_INTERVAL"
+	EnvBudgetDaily = "BUDGET_DAILY"
+	EnvBudgetMonthly = "BUDGET_MONTHLY"
+	EnvBudgetChatDaily = "BUDGET_CHAT_DAILY"
+	EnvBudgetChatMonthly = "BUDGET_CHAT_MONTHLY"
+	EnvTokenPricePerMillion = "TOKEN_PRICE_PER_MILLION"
+	EnvFeatureFlags = "FEATURE_FLAGS"
+	EnvLogLevel = "LOG_LEVEL"
+	EnvVerifySummary = "VERIFY_SUMMARY"
+	EnvVerifySummaryPrompt = "VERIFY_SUMMARY_PROMPT"
+	EnvRefineTranscript = "REFINE_TRANSCRIPT"
+	EnvPunctuateTranscript = "PUNCTUATE_TRANSCRIPT"
+	EnvTranscriptTimestamps = "TRANSCRIPT_TIMESTAMPS"
+	EnvChaptersMinDuration = "CHAPTERS_MIN_DURATION"
+	EnvStudyMinDuration = "STUDY_MIN_DURATION"
+	EnvStudyPrompt = "STUDY_PROMPT"
+	EnvPollBackoffMin = "POLL_BACKOFF_MIN"
+	EnvPollBackoffMax = "POLL_BACKOFF_MAX"
+	EnvTelegramWebhookListenAddr = "TELEGRAM_WEBHOOK_LISTEN_ADDR"
+	EnvTelegramWebhookSecret = "TELEGRAM_WEBHOOK_SECRET"
+	EnvTelegramWebhookAllowedIPs = "TELEGRAM_WEBHOOK_ALLOWED_IPS"
+	EnvDashboardListenAddr = "DASHBOARD_LISTEN_ADDR"
+	EnvDashboardToken = "DASHBOARD_TOKEN"
+	EnvJobShareGroup = "JOB_SHARE_GROUP"
+	EnvJobShareLargeGroup = "JOB_SHARE_LARGE_GROUP"
+	EnvLargeGroupMembers = "LARGE_GROUP_MEMBERS"
+	EnvQuotesPrompt = "QUOTES_PROMPT"
+	EnvSpeechThreshold = "SPEECH_THRESHOLD"
+	EnvFlagUnclear = "FLAG_UNCLEAR"
+	EnvPersonaPromptAnalyst = "PERSONA_PROMPT_ANALYST"
+	EnvPersonaPromptFriendly = "PERSONA_PROMPT_FRIENDLY"
+	EnvPersonaPromptTelegraph = "PERSONA_PROMPT_TELEGRAPH"
+	EnvPersonaPromptTech = "PERSONA_PROMPT_TECH"
+	EnvTechTranscribeHint = "TECH_TRANSCRIBE_HINT"
+	EnvMultiTenant = "MULTI_TENANT"
+	EnvPollLock = "POLL_LOCK"
+	EnvPollLockTTL = "POLL_LOCK_TTL"
+	EnvJobQueueURL = "JOB_QUEUE_URL"
+	EnvJobQueueSubject = "JOB_QUEUE_SUBJECT"
+	EnvJobQueueAckTimeout = "JOB_QUEUE_ACK_TIMEOUT"
+	EnvWorkerConcurrency = "WORKER_CONCURRENCY"
+	EnvAutoPinPrompt = "AUTO_PIN_PROMPT"
+	EnvAutoPinThreshold = "AUTO_PIN_THRESHOLD"
+	EnvAutoPinDuration = "AUTO_PIN_DURATION"
+	EnvSummaryCacheTTL = "SUMMARY_CACHE_TTL"
+)
+
+// Поддерживаемые платформы
+const (
+	PlatformTelegram = "telegram"
+	PlatformSlack    = "slack"
+	PlatformMatrix   = "matrix"
+)
+
+// Реализации хранилища для STORAGE_BACKEND
+const (
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+	StorageRedis    = "redis"
+)
+
+// Блокировки для POLL_LOCK
+const (
+	PollLockRedis    = "redis"
+	PollLockPostgres = "postgres"
+)
+
+// Стили повторного резюме
+const (
+	StyleBullets = "bullets"
+	StyleProse   = "prose"
+	StyleELI5    = "eli5"
+	StyleReport  = "report"
+)
+
+// Персоны резюме: заменяют системный промпт в чатах, которые их выбрали
+const (
+	PersonaAnalyst   = "analyst"
+	PersonaFriendly  = "friendly"
+	PersonaTelegraph = "telegraph"
+	PersonaTech      = "tech"
 )
 
 // Значения по умолчанию
 const (
 	DefaultPrimaryModel  = "gemini-2.5-flash"
 	DefaultFallbackModel = "gemini-2.0-flash"
+	DefaultSlackListenAddr = ":8080"
+	DefaultCacheTTL = 48 * time.Hour
+	DefaultArchivePrefix = "voice/"
+	DefaultRetentionPurgeInterval = time.Hour
+	DefaultSummaryLanguage = "ru"
+	DefaultVoiceCommandMaxDuration = 5 * time.Second
+	DefaultReminderPollInterval = 30 * time.Second
+	DefaultMergeWindow = 3 * time.Minute
+	DefaultSpeechThreshold = 0.5
 )
 
 var (
@@ -41,10 +196,62 @@ var (
 6. Если в тексте есть какие-либо действия или рекомендации, выделите их в отдельный маркированный список.
 7. В конце резюме добавьте короткий параграф (2-3 предложения) с аналитическим заключением или выводом на основе содержания сообщения.`
 
+	// Профили промптов для резюме на других языках (см. LanguageProfiles)
+	DefaultSystemPromptEN = `You are a highly skilled assistant for text processing and analysis who writes concise, informative summaries of voice messages. Always answer in English. Avoid emoji, emoticons and phrases like 'the speaker'. Format text as follows:
+* **bold text** for key concepts
+* *italics* for important but secondary details
+* ` + "```python" + ` to mark the beginning and end of code blocks
+* * at the start of a line for bulleted lists.
+Your task is to write short but meaningful summaries that highlight the most important information and key points of the text. Aim for clarity and brevity while keeping the main meaning and context of the original message.`
+	DefaultUserPromptTemplateEN = `Your goal is to process and analyze the following text transcribed from a voice message:
+%s
+Please write a short summary following these rules:
+1. Start the summary with a horizontal rule (---) for visual separation.
+2. Limit the abstract summary to at most six sentences.
+3. Put the key words and phrases of each sentence in bold.
+4. If the text contains numbers or statistics, include them in the summary in italics.
+5. Identify the main topic or topics of the message and state them at the beginning of the summary.
+6. If the text contains any actions or recommendations, put them in a separate bulleted list.
+7. End the summary with a short paragraph (2-3 sentences) with an analytical conclusion based on the content of the message.`
+	DefaultSystemPromptUK = `Ви - висококваліфікований асистент з обробки та аналізу тексту, що спеціалізується на створенні стислих та інформативних резюме голосових повідомлень. Ваші відповіді завжди мають бути українською мовою. Уникайте емодзі, смайликів і розмовних виразів на кшталт 'мовець'. Для форматування тексту використовуйте такі позначення:
+* **жирний текст** для виділення ключових понять
+* *курсив* для важливих, але другорядних деталей
+* ` + "```python" + ` для позначення початку і кінця блоків коду
+* * на початку рядка для маркованих списків.
+Ваше завдання - створювати стислі, але змістовні резюме, виділяючи найважливішу інформацію та ключові моменти наданого тексту. Прагніть до ясності й лаконічності, зберігаючи основний зміст і контекст вихідного повідомлення.`
+	DefaultUserPromptTemplateUK = `Ваша мета - обробити та проаналізувати наступний текст, отриманий з розшифровки голосового повідомлення:
+%s
+Будь ласка, створіть стисле резюме, дотримуючись таких правил:
+1. Почніть резюме з горизонтальної лінії (---) для візуального розділення.
+2. Обмежте резюме щонайбільше шістьма реченнями.
+3. Виділіть жирним ключові слова та фрази в кожному реченні.
+4. Якщо в тексті є числові дані чи статистика, включіть їх у резюме, виділивши курсивом.
+5. Визначте основну тему або теми повідомлення і вкажіть їх на початку резюме.
+6. Якщо в тексті є якісь дії чи рекомендації, винесіть їх в окремий маркований список.
+7. Наприкінці резюме додайте короткий абзац (2-3 речення) з аналітичним висновком на основі змісту повідомлення.`
+
 	DefaultShortPromptTemplate = `Сделай очень краткое резюме (1-2 предложения) на основе этого текста, выделив только самую главную мысль: %s`
+	DefaultCompressPromptTemplate = `Сократи это краткое резюме еще сильнее: оставь одну короткую фразу с самой главной мыслью, без вводных слов: %s`
+
+	DefaultStylePromptBullets = `Перескажи содержание этого текста в виде маркированного списка: по одному пункту на каждую мысль, факт или договоренность, без вступления и заключения. Ключевые слова выдели жирным: %s`
+	DefaultStylePromptProse = `Перескажи содержание этого текста связным текстом в 1-3 абзаца, без списков и заголовков, сохранив логику рассуждения и все важные детали: %s`
+	DefaultStylePromptELI5 = `Объясни, о чем этот текст, так, чтобы понял человек без какой-либо подготовки: простыми словами, короткими предложениями, без терминов, а где без них не обойтись - с пояснением: %s`
+	DefaultPersonaPromptAnalyst = `Вы - строгий аналитик. Пишите резюме голосовых сообщений сухо и точно: только факты, цифры, выводы и риски, без оценочных суждений, эмоций, эмодзи и разговорных выражений. Если в тексте есть противоречия или недостающие данные, отметьте их. Пишите на русском языке, если не указано иное. Форматирование: **жирный текст** для ключевых понятий, *курсив* для второстепенных деталей, * в начале строки для маркированных списков.`
+	DefaultPersonaPromptFriendly = `Вы - дружелюбный собеседник, который пересказывает голосовые сообщения друзьям. Пишите тепло и неформально, простыми словами, на «ты». Эмодзи разрешены и желательны: ставьте 1-2 уместных эмодзи на абзац или пункт списка. Пишите на русском языке, если не указано иное. Форматирование: **жирный текст** для главного, * в начале строки для списков.`
+	DefaultPersonaPromptTelegraph = `Пишите резюме голосовых сообщений телеграфным стилем: предельно коротко, без вводных слов, связок и вежливых оборотов, обрывками фраз через точку, цифры - цифрами. Без эмодзи. Пишите на русском языке, если не указано иное. Форматирование: * в начале строки для списков, **жирный текст** только для самого важного.`
+	DefaultPersonaPromptTech = `Вы составляете резюме встреч и обсуждений разработчиков. Стройте резюме из разделов **Решения**, **Техдолг** и **Задачи**, каждый - маркированный список; у задачи укажите исполнителя и срок, если они названы; разделы без данных пропускайте. Идентификаторы кода, названия сервисов, библиотек, функций, таблиц, команд и флагов пишите в исходном написании и оформляйте как ` + "`код`" + `, продиктованный многострочный код и команды - блоками ` + "```" + `. Без эмодзи и оценочных суждений. Пишите на русском языке, если не указано иное.`
+	DefaultTechTranscribeHint = `Это техническая встреча разработчиков: идентификаторы кода, названия сервисов, библиотек, функций, переменных, команд и флагов пишите в исходном написании латиницей (например, "кубер" - Kubernetes, "гет юзер бай айди" - getUserById), а продиктованный код и консольные команды оформляйте блоками ` + "```" + `.`
+
+	DefaultQuotesPrompt = `Выбери из этого текста 2-3 ключевые цитаты, в которых важна точная формулировка: обещания, договоренности, цены, суммы, сроки. Каждую цитату приведи дословно, как в тексте, без изменений и сокращений, по одной в строке, без кавычек, нумерации и пояснений: %s`
+	DefaultAutoPinPrompt = `Оцени по шкале от 0 до 10, насколько важно закрепить это резюме в групповом чате, чтобы участники его не пропустили: 10 - в нем есть принятые решения, договоренности или сроки (дедлайны, даты встреч), 0 - обычный разговор без решений и сроков. Ответь одним числом: %s`
+	DefaultActionItemsPrompt = `Выпиши из этого текста конкретные задачи, поручения и договоренности о действиях: по одной в строке, коротко, в повелительном наклонении, с исполнителем и сроком, если они названы. Не добавляй нумерацию, пояснения и другие строки. Если задач в тексте нет, ответь одним словом НЕТ: %s`
+	DefaultVerifySummaryPrompt = `Сверь резюме с расшифровкой записи. Запись может быть шумной, поэтому в резюме могли попасть утверждения, которых в расшифровке нет: имена, цифры, даты, решения и выводы. Неподтвержденные утверждения исправь по расшифровке, а если исправить нельзя - убери или пометь в конце пометкой "(не подтверждено записью)". Сохрани язык, стиль и форматирование резюме, ничего не добавляй от себя. Верни только исправленное резюме, без комментариев.`
+	DefaultStudyPrompt = `Составь по этому тексту учебный конспект: раздел "Конспект" - главные понятия, определения, факты и выводы маркированным списком, ключевые термины выдели жирным; затем раздел "Вопросы для самопроверки" - 5-7 вопросов по материалу, после каждого вопроса с новой строки краткий ответ, начинающийся со слова "Ответ:". Используй только то, что есть в тексте: %s`
+	DefaultStylePromptReport = `Составь по этому тексту формальный отчет в деловом стиле с разделами "Тема", "Основные положения", "Решения и договоренности" и "Дальнейшие действия"; пропусти разделы, для которых в тексте нет данных: %s`
 )
 
 type Config struct {
+	Platform            string
 	BotToken            string
 	GoogleAPIKey        string
 	PrimaryModel        string
@@ -52,13 +259,216 @@ type Config struct {
 	SystemPrompt        string
 	UserPromptTemplate  string
 	ShortPromptTemplate string
+	// Шаблон повторного сжатия краткого резюме (ответ "кратко" на краткое резюме)
+	CompressPromptTemplate string
 
 	MaxMessageLength    int
 	MaxFileSize         int64
+	// Максимальная длительность записи (MAX_DURATION_MINUTES); 0 - без ограничения
+	MaxDuration time.Duration
 
 	PrimaryModelRetries  int
 	FallbackModelRetries int
 	RetryDelay           time.Duration
+
+	SlackBotToken      string
+	SlackSigningSecret string
+	SlackListenAddr    string
+
+	MatrixHomeserver  string
+	MatrixAccessToken string
+
+	ResultWebhookURL string
+	DatabasePath     string
+	DatabaseURL      string
+	StorageBackend   string
+
+	NotionToken         string
+	NotionAllowedPages  []string
+	NotesVaultDir       string
+	NotesWebDAVURL      string
+	NotesWebDAVUser     string
+	NotesWebDAVPassword string
+
+	RedisURL string
+	CacheTTL time.Duration
+	// Сколько хранить готовые резюме по хешу текста и промптов, чтобы повторное "кратко" или тот же
+	// стиль не обращались к модели; 0 - не кэшировать
+	SummaryCacheTTL time.Duration
+
+	ArchiveS3Endpoint      string
+	ArchiveS3AccessKey     string
+	ArchiveS3SecretKey     string
+	ArchiveS3Bucket        string
+	ArchiveS3Region        string
+	ArchiveS3UseSSL        bool
+	ArchivePrefix          string
+	ArchiveRetentionDays   int
+
+	// Срок хранения по умолчанию для чатов без собственной политики; 0 - бессрочно
+	RetentionTranscriptDays int
+	RetentionSummaryDays    int
+	RetentionPurgeInterval  time.Duration
+
+	// Файл с шаблоном раскладки итоговых сообщений (text/template); пусто - раскладка по умолчанию
+	OutputTemplateFile string
+
+	// Шаблоны промптов для повторного резюме в выбранном стиле, ключ - Style*
+	StylePrompts map[string]string
+
+	// Язык резюме по умолчанию (ISO 639-1) для чатов, где он не выбран через /language
+	SummaryLanguage string
+
+	// Часовой пояс по умолчанию (IANA, например Europe/Moscow) для чатов, где он не выбран через /timezone;
+	// пусто - часовой пояс сервера
+	Timezone string
+
+	// Голосовые в личке не длиннее этого проверяются на голосовые команды; 0 - выключено
+	VoiceCommandMaxDuration time.Duration
+
+	// Шаблон промпта для выделения задач из расшифровки (кнопка "Задачи")
+	ActionItemsPrompt string
+	// Шаблон промпта для выбора ключевых цитат (кнопка "Цитаты")
+	QuotesPrompt string
+	// Автозакрепление важных резюме (/autopin): шаблон промпта оценки важности от 0 до 10, оценка, с которой
+	// резюме закрепляется, и через сколько открепить его, если в чате не задан свой срок (0 - не откреплять)
+	AutoPinPrompt    string
+	AutoPinThreshold int
+	AutoPinDuration  time.Duration
+	// Как часто проверять, не пора ли отправить напоминания
+	ReminderPollInterval time.Duration
+	// Голосовые одного пользователя, отправленные с паузой не больше MergeWindow, можно объединить
+	// в одно резюме; 0 - не предлагать объединение
+	MergeWindow time.Duration
+	// Минимальная длина текста (в символах), который бот резюмирует как расшифровку; 0 - не резюмировать тексты
+	LongTextMinLength int
+	// Сколько попыток дается на скачивание одного файла из Telegram; после обрыва файл докачивается с места обрыва
+	DownloadAttempts int
+
+	// HTTP-клиенты: HTTPTimeout - общий таймаут запросов к API, HTTPDialTimeout - таймаут соединения и
+	// TLS для всех клиентов (у long polling других таймаутов нет), DownloadTimeout и DownloadMaxConns -
+	// таймаут и размер пула соединений клиента для скачивания файлов
+	HTTPTimeout      time.Duration
+	HTTPDialTimeout  time.Duration
+	DownloadTimeout  time.Duration
+	DownloadMaxConns int
+
+	// Объединение коротких суммирований в один запрос к Gemini при всплесках нагрузки: после
+	// CoalesceThreshold одновременных суммирований (0 - не объединять) тексты до CoalesceMaxChars
+	// символов собираются в течение CoalesceWait в пакеты до CoalesceMaxBatch текстов
+	CoalesceThreshold int
+	CoalesceMaxBatch  int
+	CoalesceMaxChars  int
+	CoalesceWait      time.Duration
+
+	// Сколько памяти (в байтах) могут одновременно занимать распознаваемые записи; 0 - без ограничения
+	MemoryBudget int64
+	// Сколько обновлений Telegram может обрабатываться одновременно; при заполненной очереди бот
+	// перестает запрашивать новые обновления. 0 - без ограничения
+	MaxPendingUpdates int
+
+	// Очередь заданий с приоритетами: одновременно обрабатывается не больше MaxConcurrentJobs записей
+	// (0 - без ограничения). Личные чаты и администраторы идут первыми и могут занять все места, обычные
+	// группы - не больше JobShareGroup процентов мест, группы от LargeGroupMembers участников - не больше
+	// JobShareLargeGroup процентов
+	MaxConcurrentJobs  int
+	JobShareGroup      int
+	JobShareLargeGroup int
+	LargeGroupMembers  int
+
+	// Вебхук Telegram вместо long polling: публичный URL (пусто - long polling), адрес, на котором
+	// слушать, секрет для заголовка X-Telegram-Bot-Api-Secret-Token (пусто - случайный при каждом
+	// запуске) и разрешенные подсети отправителей через запятую (пусто - без проверки)
+	TelegramWebhookURL        string
+	TelegramWebhookListenAddr string
+	TelegramWebhookSecret     string
+	TelegramWebhookAllowedIPs string
+
+	// Веб-панель операторов: адрес, на котором слушать (пусто - панель выключена), и токен доступа
+	DashboardListenAddr string
+	DashboardToken      string
+
+	// Long polling: сколько getUpdates ждет обновлений и в каких пределах растет пауза между повторами
+	// после сетевых ошибок
+	PollTimeout    time.Duration
+	PollBackoffMin time.Duration
+	PollBackoffMax time.Duration
+	// Несколько реплик для отказоустойчивости: long polling, напоминания и возобновление заданий ведет
+	// только реплика, захватившая блокировку PollLock (redis или postgres; пусто - без блокировки).
+	// PollLockTTL - срок блокировки Redis или интервал проверки соединения PostgreSQL
+	PollLock    string
+	PollLockTTL time.Duration
+
+	// Внешняя очередь заданий NATS: процесс с long polling передает обработку медиа процессам
+	// "worker" через тему JobQueueSubject; задание, которое никто не подтвердил за JobQueueAckTimeout,
+	// обрабатывается на месте. Пусто - медиа обрабатывается в процессе с long polling.
+	// Обработчик берет не больше WorkerConcurrency заданий сразу, остальные отклоняет
+	JobQueueURL        string
+	JobQueueSubject    string
+	JobQueueAckTimeout time.Duration
+	WorkerConcurrency  int
+
+	// Эффект (message_effect_id) для резюме в личных чатах; пусто - без эффекта
+	MessageEffectID string
+
+	// ID пользователей Telegram - администраторов бота (команда /admin)
+	BotAdminIDs []int64
+	// Пауза между сообщениями рассылки /admin broadcast, чтобы не упереться в лимиты Telegram
+	BroadcastInterval time.Duration
+
+	// Лимиты расходов на модели: на весь бот и на каждый чат, за сутки и за месяц (UTC).
+	// Долларовые лимиты переводятся в токены по цене TokenPricePerMillion за миллион токенов
+	BudgetDaily          Budget
+	BudgetMonthly        Budget
+	BudgetChatDaily      Budget
+	BudgetChatMonthly    Budget
+	TokenPricePerMillion float64
+
+	// Состояние флагов функций по умолчанию: "имя=on,имя=off,имя=25%" (см. /admin flags)
+	FeatureFlags string
+
+	// Уровень журнала при запуске: debug, info или warn (меняется командой /admin loglevel)
+	LogLevel string
+
+	// Сверять резюме с расшифровкой отдельным запросом к модели и исправлять неподтвержденные утверждения
+	// Промпты резюме для отдельных языков (код ISO 639-1 -> промпты); используются, когда резюме
+	// пишется на этом языке, вместо русских промптов по умолчанию
+	LanguageProfiles map[string]LanguageProfile
+
+	VerifySummary       bool
+	VerifySummaryPrompt string
+
+	// Второй проход распознавания: запись и черновая расшифровка отправляются модели для исправления ошибок
+	RefineTranscript bool
+	// Восстанавливать пунктуацию и абзацы в расшифровке отдельным запросом к модели (иначе - правилами)
+	PunctuateTranscript bool
+	// Метки времени [ММ:СС] в начале абзацев расшифровки и ссылки на них в резюме
+	TranscriptTimestamps bool
+	// Записи не короче этого значения получают оглавление (нужны метки времени); 0 - без оглавления
+	ChaptersMinDuration time.Duration
+	// Под резюме записей не короче этого значения есть кнопка "Конспект": конспект и вопросы
+	// для самопроверки по расшифровке; 0 - без кнопки
+	StudyMinDuration time.Duration
+	StudyPrompt      string
+
+	// Сколько записей и текстов один пользователь может отправить на обработку за час; 0 - без ограничения
+	UserRateLimit int
+
+	// Минимальная доля речи в записи (0-1) по оценке модели; ниже - запись считается музыкой или шумом.
+	// 0 - не классифицировать записи
+	SpeechThreshold float64
+
+	// Помечать неразборчивые фрагменты в расшифровке ([неразборчиво 02:13]) вместо того, чтобы модель их угадывала
+	FlagUnclear bool
+
+	// Системные промпты персон резюме, ключ - Persona*
+	PersonaPrompts map[string]string
+	// Подсказка распознаванию для чатов с персоной PersonaTech
+	TechTranscribeHint string
+
+	// Обслуживать несколько ботов (тенантов) из таблицы tenants в базе вместо BOT_TOKEN; у каждого
+	// тенанта свои токен, ключ Gemini, настройки, квоты и данные в хранилище
+	MultiTenant bool
 }
 
 func getEnvOrDefault(key, def string) string {
@@ -68,8 +478,164 @@ func getEnvOrDefault(key, def string) string {
 	return def
 }
 
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+func getEnvInt(key string, def int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+func getEnvBool(key string, def bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %t", key, v, def)
+		return def
+	}
+	return b
+}
+
+// getEnvList читает список строк через запятую, пропуская пустые элементы
+func getEnvList(key string) []string {
+	var list []string
+	for _, s := range strings.Split(os.Getenv(key), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// getEnvInt64List читает список чисел через запятую; некорректные элементы пропускаются с предупреждением
+func getEnvInt64List(key string) []int64 {
+	var list []int64
+	for _, s := range strings.Split(os.Getenv(key), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			log.Printf("Некорректный элемент %q в %s, пропускается", s, key)
+			continue
+		}
+		list = append(list, v)
+	}
+	return list
+}
+
+// Budget - лимит расходов в токенах или долларах; нулевое значение - без лимита
+type Budget struct {
+	Tokens int64
+	USD    float64
+}
+
+// Limit возвращает лимит в токенах при цене pricePerMillion долларов за миллион токенов;
+// 0 - без лимита. Долларовый лимит без цены не действует.
+func (b Budget) Limit(pricePerMillion float64) int64 {
+	if b.Tokens > 0 {
+		return b.Tokens
+	}
+	if b.USD > 0 && pricePerMillion > 0 {
+		return int64(b.USD / pricePerMillion * 1e6)
+	}
+	return 0
+}
+
+// getEnvBudget читает лимит: число токенов ("2000000") или сумму в долларах ("$5")
+func getEnvBudget(key string) Budget {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return Budget{}
+	}
+	if usd, ok := strings.CutPrefix(v, "$"); ok {
+		f, err := strconv.ParseFloat(usd, 64)
+		if err != nil || f < 0 {
+			log.Printf("Некорректное значение %s=%q, лимит не задан", key, v)
+			return Budget{}
+		}
+		return Budget{USD: f}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("Некорректное значение %s=%q, лимит не задан", key, v)
+		return Budget{}
+	}
+	return Budget{Tokens: n}
+}
+
+// LanguageProfile - системный промпт и шаблон резюме для одного языка; пустое поле - промпт по умолчанию
+type LanguageProfile struct {
+	System string
+	User   string
+}
+
+// reLanguagePromptEnv находит переменные вида SYSTEM_PROMPT_EN и USER_PROMPT_TEMPLATE_EN
+var reLanguagePromptEnv = regexp.MustCompile(`^(` + EnvSystemPrompt + `|` + EnvUserPromptTemplate + `)_([A-Z]{2,3})$`)
+
+// getEnvLanguageProfiles собирает профили промптов по языкам: встроенные для en и uk, поверх них -
+// переменные SYSTEM_PROMPT_<ЯЗЫК> и USER_PROMPT_TEMPLATE_<ЯЗЫК>
+func getEnvLanguageProfiles() map[string]LanguageProfile {
+	profiles := map[string]LanguageProfile{
+		"en": {System: DefaultSystemPromptEN, User: DefaultUserPromptTemplateEN},
+		"uk": {System: DefaultSystemPromptUK, User: DefaultUserPromptTemplateUK},
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		m := reLanguagePromptEnv.FindStringSubmatch(key)
+		if m == nil || value == "" {
+			continue
+		}
+		language := strings.ToLower(m[2])
+		profile := profiles[language]
+		if m[1] == EnvSystemPrompt {
+			profile.System = value
+		} else {
+			profile.User = value
+		}
+		profiles[language] = profile
+	}
+	return profiles
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %g", key, v, def)
+		return def
+	}
+	return f
+}
+
 func LoadFromEnv() Config {
 	return Config{
+		Platform:            strings.ToLower(getEnvOrDefault(EnvPlatform, PlatformTelegram)),
 		BotToken:            os.Getenv(EnvBotToken),
 		GoogleAPIKey:        os.Getenv(EnvGoogleAPIKey),
 		PrimaryModel:        getEnvOrDefault(EnvPrimaryModel, DefaultPrimaryModel),
@@ -77,11 +643,120 @@ func LoadFromEnv() Config {
 		SystemPrompt:        getEnvOrDefault(EnvSystemPrompt, DefaultSystemPrompt),
 		UserPromptTemplate:  getEnvOrDefault(EnvUserPromptTemplate, DefaultUserPromptTemplate),
 		ShortPromptTemplate: getEnvOrDefault(EnvShortPromptTemplate, DefaultShortPromptTemplate),
+		CompressPromptTemplate: getEnvOrDefault(EnvCompressPromptTemplate, DefaultCompressPromptTemplate),
 		MaxMessageLength:    4096,
 		MaxFileSize:         20 * 1024 * 1024,
+		MaxDuration:         time.Duration(getEnvInt(EnvMaxDurationMinutes, 0)) * time.Minute,
 		PrimaryModelRetries:  3,
 		FallbackModelRetries: 5,
 		RetryDelay:           3 * time.Second,
+		SlackBotToken:        os.Getenv(EnvSlackBotToken),
+		SlackSigningSecret:   os.Getenv(EnvSlackSigningSecret),
+		SlackListenAddr:      getEnvOrDefault(EnvSlackListenAddr, DefaultSlackListenAddr),
+		MatrixHomeserver:     os.Getenv(EnvMatrixHomeserver),
+		MatrixAccessToken:    os.Getenv(EnvMatrixAccessToken),
+		ResultWebhookURL:     os.Getenv(EnvResultWebhookURL),
+		DatabasePath:         os.Getenv(EnvDatabasePath),
+		DatabaseURL:          os.Getenv(EnvDatabaseURL),
+		StorageBackend:       os.Getenv(EnvStorageBackend),
+		NotionToken:          os.Getenv(EnvNotionToken),
+		NotionAllowedPages:   getEnvList(EnvNotionAllowedPages),
+		NotesVaultDir:        os.Getenv(EnvNotesVaultDir),
+		NotesWebDAVURL:       os.Getenv(EnvNotesWebDAVURL),
+		NotesWebDAVUser:      os.Getenv(EnvNotesWebDAVUser),
+		NotesWebDAVPassword:  os.Getenv(EnvNotesWebDAVPassword),
+		RedisURL:             os.Getenv(EnvRedisURL),
+		CacheTTL:             getEnvDuration(EnvCacheTTL, DefaultCacheTTL),
+		SummaryCacheTTL:      getEnvDuration(EnvSummaryCacheTTL, 24*time.Hour),
+		ArchiveS3Endpoint:    os.Getenv(EnvArchiveS3Endpoint),
+		ArchiveS3AccessKey:   os.Getenv(EnvArchiveS3AccessKey),
+		ArchiveS3SecretKey:   os.Getenv(EnvArchiveS3SecretKey),
+		ArchiveS3Bucket:      os.Getenv(EnvArchiveS3Bucket),
+		ArchiveS3Region:      os.Getenv(EnvArchiveS3Region),
+		ArchiveS3UseSSL:      getEnvBool(EnvArchiveS3UseSSL, true),
+		ArchivePrefix:        getEnvOrDefault(EnvArchivePrefix, DefaultArchivePrefix),
+		ArchiveRetentionDays: getEnvInt(EnvArchiveRetentionDays, 0),
+		RetentionTranscriptDays: getEnvInt(EnvRetentionTranscriptDays, 0),
+		RetentionSummaryDays:    getEnvInt(EnvRetentionSummaryDays, 0),
+		RetentionPurgeInterval:  getEnvDuration(EnvRetentionPurgeInterval, DefaultRetentionPurgeInterval),
+		OutputTemplateFile:      os.Getenv(EnvOutputTemplateFile),
+		SummaryLanguage:         strings.ToLower(getEnvOrDefault(EnvSummaryLanguage, DefaultSummaryLanguage)),
+		Timezone:                os.Getenv(EnvTimezone),
+		VoiceCommandMaxDuration: getEnvDuration(EnvVoiceCommandMaxDuration, DefaultVoiceCommandMaxDuration),
+		ActionItemsPrompt:       getEnvOrDefault(EnvActionItemsPrompt, DefaultActionItemsPrompt),
+		ReminderPollInterval:    getEnvDuration(EnvReminderPollInterval, DefaultReminderPollInterval),
+		MergeWindow:             getEnvDuration(EnvMergeWindow, DefaultMergeWindow),
+		LongTextMinLength:       getEnvInt(EnvLongTextMinLength, 1000),
+		DownloadAttempts:        getEnvInt(EnvDownloadAttempts, 3),
+		HTTPTimeout:             getEnvDuration(EnvHTTPTimeout, 65*time.Second),
+		HTTPDialTimeout:         getEnvDuration(EnvHTTPDialTimeout, 10*time.Second),
+		DownloadTimeout:         getEnvDuration(EnvDownloadTimeout, 10*time.Minute),
+		DownloadMaxConns:        getEnvInt(EnvDownloadMaxConns, 16),
+		CoalesceThreshold:       getEnvInt(EnvCoalesceThreshold, 0),
+		CoalesceMaxBatch:        getEnvInt(EnvCoalesceMaxBatch, 5),
+		CoalesceMaxChars:        getEnvInt(EnvCoalesceMaxChars, 4000),
+		CoalesceWait:            getEnvDuration(EnvCoalesceWait, 500*time.Millisecond),
+		MemoryBudget:            int64(getEnvInt(EnvMemoryBudgetMB, 0)) * 1024 * 1024,
+		MaxPendingUpdates:       getEnvInt(EnvMaxPendingUpdates, 100),
+		MaxConcurrentJobs:       getEnvInt(EnvMaxConcurrentJobs, 0),
+		JobShareGroup:           getEnvInt(EnvJobShareGroup, 70),
+		JobShareLargeGroup:      getEnvInt(EnvJobShareLargeGroup, 30),
+		LargeGroupMembers:       getEnvInt(EnvLargeGroupMembers, 200),
+		TelegramWebhookURL:        os.Getenv(EnvTelegramWebhookURL),
+		TelegramWebhookListenAddr: getEnvOrDefault(EnvTelegramWebhookListenAddr, ":8443"),
+		TelegramWebhookSecret:     os.Getenv(EnvTelegramWebhookSecret),
+		TelegramWebhookAllowedIPs: os.Getenv(EnvTelegramWebhookAllowedIPs),
+		DashboardListenAddr:       os.Getenv(EnvDashboardListenAddr),
+		DashboardToken:            os.Getenv(EnvDashboardToken),
+		PollTimeout:               getEnvDuration(EnvPollTimeout, 60*time.Second),
+		PollBackoffMin:            getEnvDuration(EnvPollBackoffMin, time.Second),
+		PollBackoffMax:            getEnvDuration(EnvPollBackoffMax, time.Minute),
+		PollLock:                  strings.ToLower(os.Getenv(EnvPollLock)),
+		PollLockTTL:               getEnvDuration(EnvPollLockTTL, 15*time.Second),
+		JobQueueURL:               os.Getenv(EnvJobQueueURL),
+		JobQueueSubject:           getEnvOrDefault(EnvJobQueueSubject, "voice-shut-up.jobs"),
+		JobQueueAckTimeout:        getEnvDuration(EnvJobQueueAckTimeout, 10*time.Second),
+		WorkerConcurrency:         getEnvInt(EnvWorkerConcurrency, 4),
+		MessageEffectID:           os.Getenv(EnvMessageEffectID),
+		BotAdminIDs:               getEnvInt64List(EnvBotAdminIDs),
+		BroadcastInterval:         getEnvDuration(EnvBroadcastInterval, 50*time.Millisecond),
+		BudgetDaily:               getEnvBudget(EnvBudgetDaily),
+		BudgetMonthly:             getEnvBudget(EnvBudgetMonthly),
+		BudgetChatDaily:           getEnvBudget(EnvBudgetChatDaily),
+		BudgetChatMonthly:         getEnvBudget(EnvBudgetChatMonthly),
+		TokenPricePerMillion:      getEnvFloat(EnvTokenPricePerMillion, 0),
+		FeatureFlags:              os.Getenv(EnvFeatureFlags),
+		LogLevel:                  getEnvOrDefault(EnvLogLevel, "info"),
+		LanguageProfiles:          getEnvLanguageProfiles(),
+		VerifySummary:             getEnvBool(EnvVerifySummary, false),
+		VerifySummaryPrompt:       getEnvOrDefault(EnvVerifySummaryPrompt, DefaultVerifySummaryPrompt),
+		RefineTranscript:          getEnvBool(EnvRefineTranscript, false),
+		PunctuateTranscript:       getEnvBool(EnvPunctuateTranscript, false),
+		TranscriptTimestamps:      getEnvBool(EnvTranscriptTimestamps, false),
+		ChaptersMinDuration:       getEnvDuration(EnvChaptersMinDuration, 15*time.Minute),
+		StudyMinDuration:          getEnvDuration(EnvStudyMinDuration, 10*time.Minute),
+		StudyPrompt:               getEnvOrDefault(EnvStudyPrompt, DefaultStudyPrompt),
+		UserRateLimit:             getEnvInt(EnvUserRateLimit, 0),
+		QuotesPrompt:            getEnvOrDefault(EnvQuotesPrompt, DefaultQuotesPrompt),
+		AutoPinPrompt:           getEnvOrDefault(EnvAutoPinPrompt, DefaultAutoPinPrompt),
+		AutoPinThreshold:        getEnvInt(EnvAutoPinThreshold, 7),
+		AutoPinDuration:         getEnvDuration(EnvAutoPinDuration, 72*time.Hour),
+		SpeechThreshold:         getEnvFloat(EnvSpeechThreshold, DefaultSpeechThreshold),
+		FlagUnclear:             getEnvBool(EnvFlagUnclear, true),
+		PersonaPrompts: map[string]string{
+			PersonaAnalyst:   getEnvOrDefault(EnvPersonaPromptAnalyst, DefaultPersonaPromptAnalyst),
+			PersonaFriendly:  getEnvOrDefault(EnvPersonaPromptFriendly, DefaultPersonaPromptFriendly),
+			PersonaTelegraph: getEnvOrDefault(EnvPersonaPromptTelegraph, DefaultPersonaPromptTelegraph),
+			PersonaTech:      getEnvOrDefault(EnvPersonaPromptTech, DefaultPersonaPromptTech),
+		},
+		TechTranscribeHint: getEnvOrDefault(EnvTechTranscribeHint, DefaultTechTranscribeHint),
+		MultiTenant:        getEnvBool(EnvMultiTenant, false),
+		StylePrompts: map[string]string{
+			StyleBullets: getEnvOrDefault(EnvStylePromptBullets, DefaultStylePromptBullets),
+			StyleProse:   getEnvOrDefault(EnvStylePromptProse, DefaultStylePromptProse),
+			StyleELI5:    getEnvOrDefault(EnvStylePromptELI5, DefaultStylePromptELI5),
+			StyleReport:  getEnvOrDefault(EnvStylePromptReport, DefaultStylePromptReport),
+		},
 	}
 }
 
@@ -0,0 +1,147 @@
+// Package flags - флаги функций: включение рискованных возможностей для доли чатов или отдельных
+// чатов. Значения по умолчанию задаются кодом и переменной окружения FEATURE_FLAGS, администраторы
+// бота переопределяют их во время работы.
+package flags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+)
+
+// Set - набор флагов с правилами по умолчанию и переопределениями
+type Set struct {
+	mu        sync.RWMutex
+	defaults  map[string]store.FlagRule
+	overrides map[string]store.FlagRule
+}
+
+// New создает набор флагов с правилами по умолчанию defaults
+func New(defaults map[string]store.FlagRule) *Set {
+	return &Set{defaults: defaults}
+}
+
+// SetOverrides заменяет переопределения, заданные во время работы
+func (s *Set) SetOverrides(overrides map[string]store.FlagRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides = overrides
+}
+
+// Rule возвращает действующее правило флага и признак того, что оно переопределено
+func (s *Set) Rule(name string) (store.FlagRule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if rule, ok := s.overrides[name]; ok {
+		return rule, true
+	}
+	return s.defaults[name], false
+}
+
+// Known сообщает, известен ли флаг (есть ли у него правило по умолчанию)
+func (s *Set) Known(name string) bool {
+	_, ok := s.defaults[name]
+	return ok
+}
+
+// Names возвращает имена известных флагов по алфавиту
+func (s *Set) Names() []string { return slices.Sorted(maps.Keys(s.defaults)) }
+
+// Enabled сообщает, включен ли флаг name для чата chatID
+func (s *Set) Enabled(name, chatID string) bool {
+	rule, _ := s.Rule(name)
+	return Match(rule, name, chatID)
+}
+
+// Match применяет правило: явное решение для чата, иначе попадание чата в долю Percent.
+// Попадание определяется хешем имени флага и чата, поэтому не меняется между запросами,
+// а при увеличении доли включенные чаты остаются включенными.
+func Match(rule store.FlagRule, name, chatID string) bool {
+	if on, ok := rule.Chats[chatID]; ok {
+		return on
+	}
+	if rule.Percent <= 0 {
+		return false
+	}
+	if rule.Percent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + chatID))
+	return int(h.Sum32()%100) < rule.Percent
+}
+
+// ParsePercent разбирает состояние флага: on, off или доля чатов в процентах ("25%")
+func ParsePercent(s string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "on", "true", "1":
+		return 100, nil
+	case "off", "false", "0":
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("некорректное состояние флага %q: нужно on, off или доля от 0%% до 100%%", s)
+	}
+	return n, nil
+}
+
+// ApplyEnv переопределяет доли флагов в defaults значением переменной окружения вида
+// "имя=on,имя=off,имя=25%"; неизвестные флаги и некорректные значения возвращаются ошибкой
+func ApplyEnv(defaults map[string]store.FlagRule, env string) error {
+	for _, item := range strings.Split(env, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		rule, ok := defaults[name]
+		if !ok {
+			return fmt.Errorf("неизвестный флаг %q", name)
+		}
+		percent, err := ParsePercent(value)
+		if err != nil {
+			return err
+		}
+		rule.Percent = percent
+		defaults[name] = rule
+	}
+	return nil
+}
+
+// Describe описывает правило для людей: "включен", "выключен", "25% чатов" и исключения
+func Describe(rule store.FlagRule) string {
+	var state string
+	switch {
+	case rule.Percent >= 100:
+		state = "включен"
+	case rule.Percent <= 0:
+		state = "выключен"
+	default:
+		state = fmt.Sprintf("%d%% чатов", rule.Percent)
+	}
+	var on, off []string
+	for chat, enabled := range rule.Chats {
+		if enabled {
+			on = append(on, chat)
+		} else {
+			off = append(off, chat)
+		}
+	}
+	slices.Sort(on)
+	slices.Sort(off)
+	if len(on) > 0 {
+		state += "; включен в " + strings.Join(on, ", ")
+	}
+	if len(off) > 0 {
+		state += "; выключен в " + strings.Join(off, ", ")
+	}
+	return state
+}
@@ -2,53 +2,52 @@ package format
 
 import (
 	"fmt"
-	"html"
 	"regexp"
 	"strings"
+	"time"
 )
 
-func FormatHTML(text string) string {
-	reCodeBlock := regexp.MustCompile("(?s)```(\\w+)?\n(.*?)\n```")
-	text = reCodeBlock.ReplaceAllStringFunc(text, func(match string) string {
-		parts := reCodeBlock.FindStringSubmatch(match)
-		lang, code := "", parts[2]
-		if len(parts) > 1 {
-			lang = parts[1]
-		}
-		return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, lang, html.EscapeString(strings.TrimSpace(code)))
-	})
-	reBold := regexp.MustCompile(`\*\*(.*?)\*\*`)
-	text = reBold.ReplaceAllString(text, `<b>$1</b>`)
-	reItalic := regexp.MustCompile(`\*(.*?)\*`)
-	text = reItalic.ReplaceAllString(text, `<i>$1</i>`)
-	reCode := regexp.MustCompile("`([^`]+)`")
-	text = reCode.ReplaceAllString(text, `<code>$1</code>`)
+// mrkdwnEscaper экранирует управляющие символы Slack: без этого <!channel> или <https://...|текст>
+// из расшифровки превратятся в упоминание или ссылку
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EscapeMrkdwn экранирует текст для Slack mrkdwn, чтобы отправить его как есть
+func EscapeMrkdwn(text string) string { return mrkdwnEscaper.Replace(text) }
+
+// FormatMrkdwn переводит markdown модели в разметку Slack mrkdwn
+func FormatMrkdwn(text string) string {
+	text = EscapeMrkdwn(text)
 	reListItem := regexp.MustCompile(`(?m)^\* `)
 	text = reListItem.ReplaceAllString(text, "• ")
-	return text
+	reBold := regexp.MustCompile(`\*\*(.*?)\*\*`)
+	text = reBold.ReplaceAllString(text, "\x00$1\x00")
+	reItalic := regexp.MustCompile(`\*([^*\n]+?)\*`)
+	text = reItalic.ReplaceAllString(text, `_${1}_`)
+	return strings.ReplaceAll(text, "\x00", "*")
 }
 
-func SplitMessage(message string, maxLen int) []string {
-	if len(message) <= maxLen {
-		return []string{message}
-	}
-	var parts []string
-	for len(message) > 0 {
-		if len(message) <= maxLen {
-			parts = append(parts, message)
-			break
-		}
-		splitPos := strings.LastIndex(message[:maxLen], "\n")
-		if splitPos == -1 {
-			splitPos = strings.LastIndex(message[:maxLen], " ")
+// FirstLine возвращает первую содержательную строку текста без markdown-разметки,
+// обрезанную до maxRunes символов
+func FirstLine(text string, maxRunes int) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "*#-_ ")
+		if line == "" {
+			continue
 		}
-		if splitPos == -1 {
-			splitPos = maxLen
+		if r := []rune(line); len(r) > maxRunes {
+			line = string(r[:maxRunes]) + "…"
 		}
-		parts = append(parts, message[:splitPos])
-		message = strings.TrimSpace(message[splitPos:])
+		return line
 	}
-	return parts
+	return ""
 }
 
-
+// Duration форматирует длительность как м:сс или ч:мм:сс
+func Duration(d time.Duration) string {
+	total := int(d.Round(time.Second).Seconds())
+	h, m, s := total/3600, total%3600/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,496 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"hello", 5},
+		{"привет", 6},
+		{"😀", 2},
+		{"е́", 2},
+		{"👨‍👩‍👧", 8},
+		{"🇷🇺", 4},
+	}
+	for _, tt := range tests {
+		if got := UTF16Len(tt.in); got != tt.want {
+			t.Errorf("UTF16Len(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+// checkParts проверяет лимит каждой части и то, что при разбиении не потерялся текст
+func checkParts(t *testing.T, message string, parts []string, maxLen int) {
+	t.Helper()
+	for i, p := range parts {
+		if n := UTF16Len(p); n > maxLen {
+			t.Errorf("part %d has %d UTF-16 units, limit %d", i, n, maxLen)
+		}
+		if p == "" {
+			t.Errorf("part %d is empty", i)
+		}
+	}
+	if got, want := strings.Join(strings.Fields(strings.Join(parts, " ")), ""), strings.Join(strings.Fields(message), ""); got != want {
+		t.Errorf("content changed after split:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSplitMessageCyrillicFitsByUTF16(t *testing.T) {
+	// 3000 кириллических символов - это 6000 байт, но только 3000 единиц UTF-16
+	message := strings.Repeat("ж", 3000)
+	parts := SplitMessage(message, 4096)
+	if len(parts) != 1 {
+		t.Fatalf("got %d parts, want 1", len(parts))
+	}
+}
+
+func TestSplitMessagePrefersNewlines(t *testing.T) {
+	message := strings.Repeat("а", 6) + "\n" + strings.Repeat("б", 6) + " " + strings.Repeat("в", 3)
+	parts := SplitMessage(message, 12)
+	want := []string{strings.Repeat("а", 6), strings.Repeat("б", 6) + " " + strings.Repeat("в", 3)}
+	if len(parts) != len(want) {
+		t.Fatalf("got %q, want %q", parts, want)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %q, want %q", i, parts[i], want[i])
+		}
+	}
+}
+
+func TestSplitMessageEmojiNotBroken(t *testing.T) {
+	// Эмодзи занимают по 2 единицы; лимит 5 не должен резать суррогатную пару
+	message := strings.Repeat("😀", 10)
+	parts := SplitMessage(message, 5)
+	checkParts(t, message, parts, 5)
+	for i, p := range parts {
+		if p != strings.Repeat("😀", 2) {
+			t.Errorf("part %d = %q, want two whole emoji", i, p)
+		}
+	}
+}
+
+func TestSplitMessageZWJSequenceKeptWhole(t *testing.T) {
+	family := "👨‍👩‍👧"
+	message := "ab" + family + family
+	parts := SplitMessage(message, 10)
+	checkParts(t, message, parts, 10)
+	want := []string{"ab" + family, family}
+	if len(parts) != 2 || parts[0] != want[0] || parts[1] != want[1] {
+		t.Errorf("got %q, want %q", parts, want)
+	}
+}
+
+func TestSplitMessageCombiningCharacters(t *testing.T) {
+	// "е" + комбинируемое ударение: знак не должен уйти в начало следующей части
+	word := strings.Repeat("е́", 5)
+	parts := SplitMessage(word, 3)
+	checkParts(t, word, parts, 3)
+	for i, p := range parts {
+		if strings.HasPrefix(p, "́") {
+			t.Errorf("part %d starts with a combining mark: %q", i, p)
+		}
+	}
+}
+
+func TestSplitMessageFlagsKeptWhole(t *testing.T) {
+	message := strings.Repeat("🇷🇺", 3)
+	parts := SplitMessage(message, 6)
+	checkParts(t, message, parts, 6)
+	for i, p := range parts {
+		if p != "🇷🇺" {
+			t.Errorf("part %d = %q, want a whole flag", i, p)
+		}
+	}
+}
+
+func TestSplitMessageShortUnchanged(t *testing.T) {
+	if parts := SplitMessage("короткое", 4096); len(parts) != 1 || parts[0] != "короткое" {
+		t.Errorf("got %q", parts)
+	}
+}
+
+// checkBalanced проверяет, что все теги части закрыты в правильном порядке
+func checkBalanced(t *testing.T, part string) {
+	t.Helper()
+	var stack []string
+	for _, tok := range tokenizeHTML(part) {
+		if !tok.tag {
+			continue
+		}
+		name, closing := tagName(tok.raw)
+		if !closing {
+			stack = append(stack, name)
+			continue
+		}
+		if len(stack) == 0 || stack[len(stack)-1] != name {
+			t.Errorf("unbalanced </%s> in %q", name, part)
+			return
+		}
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) > 0 {
+		t.Errorf("unclosed tags %v in %q", stack, part)
+	}
+}
+
+func visibleLen(part string) int {
+	n := 0
+	for _, tok := range tokenizeHTML(part) {
+		n += tok.units
+	}
+	return n
+}
+
+func TestSplitHTMLReopensTags(t *testing.T) {
+	message := "<b>Итоги</b>\n\n<tg-spoiler>" + strings.Repeat("слово <i>курсив</i> ", 20) + "</tg-spoiler>"
+	parts := SplitHTML(message, 50)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for i, p := range parts {
+		checkBalanced(t, p)
+		if n := visibleLen(p); n > 50 {
+			t.Errorf("part %d has %d visible units", i, n)
+		}
+		if i > 0 && !strings.HasPrefix(p, "<tg-spoiler>") {
+			t.Errorf("part %d does not reopen spoiler: %q", i, p)
+		}
+	}
+}
+
+func TestSplitHTMLKeepsEntitiesWhole(t *testing.T) {
+	message := strings.Repeat("a&amp;b&lt;c ", 30)
+	parts := SplitHTML(message, 7)
+	for i, p := range parts {
+		if strings.Count(p, "&") != strings.Count(p, ";") {
+			t.Errorf("part %d breaks an entity: %q", i, p)
+		}
+	}
+}
+
+func TestSplitHTMLCountsVisibleText(t *testing.T) {
+	// Разметка не входит в лимит Telegram: сообщение с длинной ссылкой помещается целиком
+	message := `<a href="https://example.com/` + strings.Repeat("x", 100) + `">ссылка</a>`
+	if parts := SplitHTML(message, 10); len(parts) != 1 || parts[0] != message {
+		t.Errorf("got %q", parts)
+	}
+}
+
+func TestSplitHTMLInsideTagWithoutSpaces(t *testing.T) {
+	message := "<b>" + strings.Repeat("ж", 25) + "</b>"
+	parts := SplitHTML(message, 10)
+	if len(parts) != 3 {
+		t.Fatalf("got %d parts: %q", len(parts), parts)
+	}
+	for _, p := range parts {
+		checkBalanced(t, p)
+	}
+}
+
+func TestSplitHTMLCodeBlock(t *testing.T) {
+	var code strings.Builder
+	for i := 0; i < 40; i++ {
+		code.WriteString("    fmt.Println(i &lt; 10)\n")
+	}
+	message := "Пример:\n\n<pre><code class=\"language-go\">" + code.String() + "</code></pre>\n\nКонец"
+	parts := SplitHTML(message, 300)
+	if len(parts) < 3 {
+		t.Fatalf("expected the code block to be split, got %d parts", len(parts))
+	}
+	for i, p := range parts {
+		checkBalanced(t, p)
+		if n := visibleLen(p); n > 300 {
+			t.Errorf("part %d has %d visible units", i, n)
+		}
+		if !strings.Contains(p, "<pre>") {
+			continue
+		}
+		if i > 0 && !strings.HasPrefix(p, codeContinuedAbove+"\n<pre><code class=\"language-go\">    fmt") {
+			t.Errorf("part %d does not reopen the code block with indentation: %q", i, p)
+		}
+		if i < len(parts)-1 && !strings.HasSuffix(p, "</code></pre>\n"+codeContinuedBelow) {
+			t.Errorf("part %d has no continuation marker: %q", i, p)
+		}
+		// Строки кода не должны разрываться посередине
+		for _, line := range strings.Split(p, "\n") {
+			if strings.Contains(line, "fmt") && !strings.HasSuffix(line, "10)") && !strings.HasSuffix(line, "10)</code></pre>") {
+				t.Errorf("part %d breaks a code line: %q", i, line)
+			}
+		}
+	}
+}
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	in := `_*[]()~` + "`" + `>#+-=|{}.!\`
+	want := `\_\*\[\]\(\)\~\` + "`" + `\>\#\+\-\=\|\{\}\.\!\\`
+	if got := EscapeMarkdownV2(in); got != want {
+		t.Errorf("EscapeMarkdownV2(%q) = %q, want %q", in, got, want)
+	}
+	if got := EscapeMarkdownV2("привет, мир"); got != "привет, мир" {
+		t.Errorf("plain text changed: %q", got)
+	}
+}
+
+func TestFormatHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"## Итоги", "<b>Итоги</b>"},
+		{"**важно** и *курсив*", "<b>важно</b> и <i>курсив</i>"},
+		{"~~нет~~", "<s>нет</s>"},
+		{"[док](https://example.com/a?b=1&c=2)", `<a href="https://example.com/a?b=1&amp;c=2">док</a>`},
+		{"[a < b](https://example.com)", `<a href="https://example.com">a &lt; b</a>`},
+		{"<https://example.com>", `<a href="https://example.com">https://example.com</a>`},
+		{"[плохо](javascript:alert(1))", "плохо"},
+		{"[данные](data:text/html,<b>x</b>)", "данные"},
+		{"- пункт\n  - вложенный\n    - глубже\n- второй", "• пункт\n    ◦ вложенный\n        ◦ глубже\n• второй"},
+		{"1. первый\n2. второй\n   1. вложенный", "1. первый\n2. второй\n    1. вложенный"},
+		{"3. третий\n4. четвертый", "3. третий\n4. четвертый"},
+		{"> цитата\n> > вложенная", "<blockquote>цитата\n\nвложенная</blockquote>"},
+		{"| Имя | Срок |\n|---|---|\n| Аня | 1.10 |\n| Борис | завтра |", "<pre>Имя   | Срок\n------+-------\nАня   | 1.10\nБорис | завтра</pre>"},
+		{"a < b & c > d", "a &lt; b &amp; c &gt; d"},
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"`x < y && z`", "<code>x &lt; y &amp;&amp; z</code>"},
+		{"```go\nif a < b && c {\n}\n```", `<pre><code class="language-go">if a &lt; b &amp;&amp; c {` + "\n" + `}</code></pre>`},
+	}
+	for _, tt := range tests {
+		if got := FormatHTML(tt.in); got != tt.want {
+			t.Errorf("FormatHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMrkdwn(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"**важно**: срок", "*важно*: срок"},
+		{"* пункт *a*", "• пункт _a_"},
+		{"<!channel> a & b > c", "&lt;!channel&gt; a &amp; b &gt; c"},
+		{"<https://example.com|ссылка>", "&lt;https://example.com|ссылка&gt;"},
+	}
+	for _, tt := range tests {
+		if got := FormatMrkdwn(tt.in); got != tt.want {
+			t.Errorf("FormatMrkdwn(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMarkdownV2(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"## Итоги", "*Итоги*"},
+		{"**важно**: срок 1.10!", "*важно*: срок 1\\.10\\!"},
+		{"- пункт (a)\n- пункт b", "• пункт \\(a\\)\n• пункт b"},
+		{"1. первый", "1\\. первый"},
+		{"`a_b`", "`a_b`"},
+		{"```go\nx := `y` \\ 1.0\n```", "```go\nx := \\`y\\` \\\\ 1.0\n```"},
+		{"[док](https://example.com/a_(b))", "[док](https://example.com/a_(b\\))"},
+		{"> цитата", ">цитата"},
+		{"~~нет~~", "~нет~"},
+	}
+	for _, tt := range tests {
+		if got := FormatMarkdownV2(tt.in); got != tt.want {
+			t.Errorf("FormatMarkdownV2(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripFormatting(t *testing.T) {
+	in := "## Итоги\n\n**Важно**: см. [док](https://example.com)\n\n- пункт `a<b`\n    - вложенный\n\n> цитата"
+	want := "Итоги\n\nВажно: см. док (https://example.com)\n\n• пункт a<b\n    ◦ вложенный\n\n│ цитата"
+	if got := StripFormatting(in); got != want {
+		t.Errorf("StripFormatting() = %q, want %q", got, want)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	in := `<b>Итоги</b>` + "\n\n" + `<tg-spoiler>a &lt; b &amp; <a href="https://example.com/?a=1&amp;b=2">ссылка</a></tg-spoiler>`
+	want := "Итоги\n\na < b & ссылка (https://example.com/?a=1&b=2)"
+	if got := StripHTML(in); got != want {
+		t.Errorf("StripHTML() = %q, want %q", got, want)
+	}
+}
+
+func TestLayoutDefault(t *testing.T) {
+	l, err := ParseLayout("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.Render(LayoutData{Title: "Summary", Body: "<b>a</b>\n\nb", Spoiler: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<b>Summary</b>\n\n<tg-spoiler><b>a</b>\n\nb</tg-spoiler>"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestLayoutCustom(t *testing.T) {
+	l, err := ParseLayout(`{{.Body}}{{if eq .Kind "summary"}}
+—
+{{.Title}} · {{.Duration}} · {{.Model}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.Render(LayoutData{Kind: KindSummary, Title: "A&B", Body: "текст", Duration: "1:05", Model: "gemini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "текст\n—\nA&amp;B · 1:05 · gemini"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if _, err := ParseLayout("{{.Unknown}}"); err == nil {
+		t.Error("ParseLayout accepted unknown field")
+	}
+}
+
+func TestSplitHTMLNumbered(t *testing.T) {
+	message := "<b>Заголовок</b>\n\n" + strings.Repeat("слово ", 200)
+	parts := SplitHTMLNumbered(message, "Заголовок", 300)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for i, p := range parts {
+		if n := visibleLen(p); n > 300 {
+			t.Errorf("part %d has %d visible units, limit 300", i, n)
+		}
+		want := fmt.Sprintf("<i>Часть %d/%d</i>\n\n", i+1, len(parts))
+		if i > 0 {
+			want = "<b>Заголовок</b>\n" + want
+		}
+		if !strings.HasPrefix(p, want) {
+			t.Errorf("part %d starts with %q, want prefix %q", i, p[:min(len(p), 60)], want)
+		}
+	}
+	if got := SplitHTMLNumbered("короткий", "Заголовок", 300); len(got) != 1 || got[0] != "короткий" {
+		t.Errorf("short message changed: %q", got)
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"<b>ok</b>", "<b>ok</b>"},
+		{"<div>текст</div><br/>дальше", "текст\nдальше"},
+		{"<b>жирный <i>курсив</b> хвост</i>", "<b>жирный <i>курсив</i></b><i> хвост</i>"},
+		{"<b>не закрыт", "<b>не закрыт</b>"},
+		{"лишний</i> тег", "лишний тег"},
+		{"a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+		{"1 <2> 3", "1 &lt;2&gt; 3"},
+		{"&nbsp;&amp;&#128512;&bogus;", "\u00a0&amp;&#128512;&amp;bogus;"},
+		{`<a href="javascript:alert(1)">x</a>`, "x"},
+		{`<a href="https://e.com/?a=1&amp;b=2" onclick="x">y</a>`, `<a href="https://e.com/?a=1&amp;b=2">y</a>`},
+		{`<span class="tg-spoiler" style="x">s</span><span>t</span>`, `<span class="tg-spoiler">s</span>t`},
+		{`<pre><code class="language-go">x <b>y</b></code></pre>`, `<pre><code class="language-go">x y</code></pre>`},
+		{`<blockquote expandable>q</blockquote>`, `<blockquote expandable>q</blockquote>`},
+	}
+	for _, tt := range tests {
+		if got := SanitizeHTML(tt.in); got != tt.want {
+			t.Errorf("SanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaskProfanity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Ну это пиздец, блять", "Ну это п***, б***"},
+		{"Заебал уже, сука!", "З*** уже, с***!"},
+		{"небо, страхуй, ребенок, сук дерева, себя", "небо, страхуй, ребенок, сук дерева, себя"},
+		{"What the fuck, shit happens", "What the f***, s*** happens"},
+		{`<a href="https://e.com/fuck">хуйня</a>`, `<a href="https://e.com/fuck">х***</a>`},
+	}
+	for _, tt := range tests {
+		if got := MaskProfanityHTML(tt.in); got != tt.want {
+			t.Errorf("MaskProfanityHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Звоните +7 (912) 345-67-89 или 8 912 345 67 89", "Звоните [телефон] или [телефон]"},
+		{"Карта 4111 1111 1111 1111, заказ 1234 5678 9012 3456", "Карта [карта], заказ 1234 5678 9012 3456"},
+		{"Пишите на ivan.petrov@mail.ru.", "Пишите на [email]."},
+		{"Встреча в 15:30, бюджет 120000 рублей", "Встреча в 15:30, бюджет 120000 рублей"},
+	}
+	for _, tt := range tests {
+		if got := RedactPII(tt.in); got != tt.want {
+			t.Errorf("RedactPII(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightUnclear(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Встречаемся в [неразборчиво 02:13] у входа", "Встречаемся в <i>[неразборчиво 02:13]</i> у входа"},
+		{"[неразборчиво] и [неразборчиво 1:02:03]", "<i>[неразборчиво]</i> и <i>[неразборчиво 1:02:03]</i>"},
+		{"[разборчиво 02:13]", "[разборчиво 02:13]"},
+	}
+	for _, tt := range tests {
+		if got := HighlightUnclear(tt.in); got != tt.want {
+			t.Errorf("HighlightUnclear(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParagraphs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"привет. как дела? всё хорошо", "Привет. Как дела? Всё хорошо"},
+		{"это т.е. пример. и А. Пушкин тоже", "Это т.е. пример. И А. Пушкин тоже"},
+		{"первая строка.\nвторая строка", "Первая строка.\nВторая строка"},
+		{"[неразборчиво 00:05] дальше", "[неразборчиво 00:05] дальше"},
+	}
+	for _, tt := range tests {
+		if got := Paragraphs(tt.in); got != tt.want {
+			t.Errorf("Paragraphs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	sentence := strings.Repeat("слово ", 30) + "конец."
+	long := strings.TrimSpace(strings.Repeat(sentence+" ", 6))
+	got := Paragraphs(long)
+	paragraphs := strings.Split(got, "\n\n")
+	if len(paragraphs) < 2 {
+		t.Fatalf("Paragraphs не разделил длинный текст на абзацы: %q", got)
+	}
+	for _, p := range paragraphs {
+		if !strings.HasPrefix(p, "Слово") || !strings.HasSuffix(p, "конец.") {
+			t.Errorf("абзац разрезан не по границе предложения: %q", p)
+		}
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	in := "[00:00] Привет.\n[04:10] Про бюджет, см. [неразборчиво 04:20].\nбез метки\n[1:02:03] Итоги"
+	got := Timestamps(in)
+	want := []string{"00:00", "04:10", "1:02:03"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Timestamps() = %v, want %v", got, want)
+	}
+}
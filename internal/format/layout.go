@@ -0,0 +1,80 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"text/template"
+)
+
+// Виды итоговых сообщений, по которым шаблон может менять раскладку
+const (
+	KindSummary    = "summary"
+	KindTranscript = "transcript"
+	KindShort      = "short"
+	KindHistory    = "history"
+	KindSearch     = "search"
+	KindStyle      = "style"
+	KindQuotes     = "quotes"
+	KindStudy      = "study"
+)
+
+// DefaultLayout повторяет раскладку по умолчанию: жирный заголовок, пустая строка и текст,
+// резюме скрыто под спойлером; в подробном режиме внизу добавляются сведения об обработке
+const DefaultLayout = `{{with .Title}}<b>{{.}}</b>
+
+{{end}}{{with .Origin}}<i>Переслано: {{.}}</i>
+
+{{end}}{{if .Spoiler}}<tg-spoiler>{{.Body}}</tg-spoiler>{{else}}{{.Body}}{{end}}{{if and .Verbose .Model}}
+
+<i>Длительность: {{or .Duration "—"}} · модель: {{.Model}} · токены: {{.Tokens}} · обработка: {{.Latency}}</i>{{end}}`
+
+// LayoutData - данные, доступные шаблону итогового сообщения. Title, Model и Origin уже экранированы,
+// Body - готовый HTML.
+type LayoutData struct {
+	Kind     string
+	Title    string
+	Body     string
+	Spoiler  bool
+	Duration string // длительность медиа в виде м:сс, пусто если неизвестна
+	Model    string // модель, подготовившая текст, пусто если не применимо
+	Tokens   int    // израсходованные токены
+	Latency  string // время обработки
+	Verbose  bool   // чат выбрал подробные ответы
+	Origin   string // источник пересланного сообщения, пусто если сообщение не переслано
+}
+
+// Layout - шаблон раскладки итогового сообщения Telegram (text/template, результат - HTML)
+type Layout struct {
+	tmpl *template.Template
+}
+
+// ParseLayout разбирает шаблон раскладки; пустой текст означает DefaultLayout
+func ParseLayout(text string) (*Layout, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultLayout
+	}
+	tmpl, err := template.New("layout").Funcs(template.FuncMap{
+		"escape": html.EscapeString,
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора шаблона сообщения: %w", err)
+	}
+	// пробный рендер ловит обращения к несуществующим полям до первого сообщения
+	if err := tmpl.Execute(&strings.Builder{}, LayoutData{}); err != nil {
+		return nil, fmt.Errorf("ошибка в шаблоне сообщения: %w", err)
+	}
+	return &Layout{tmpl: tmpl}, nil
+}
+
+// Render собирает сообщение по шаблону
+func (l *Layout) Render(d LayoutData) (string, error) {
+	d.Title = html.EscapeString(d.Title)
+	d.Model = html.EscapeString(d.Model)
+	d.Origin = html.EscapeString(d.Origin)
+	var b strings.Builder
+	if err := l.tmpl.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("ошибка заполнения шаблона сообщения: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,284 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+var markdown = goldmark.New(goldmark.WithExtensions(extension.Table, extension.Strikethrough))
+
+// FormatHTML переводит markdown модели в HTML из набора тегов, который понимает Telegram.
+// Заголовки становятся жирным текстом, списки - строками с маркерами и отступами,
+// таблицы - моноширинным блоком; весь остальной текст экранируется.
+func FormatHTML(md string) string { return render(md, htmlMarkup{}) }
+
+// markup - целевая разметка Telegram, в которую переводится дерево markdown
+type markup interface {
+	text(s string) string
+	// wrap оформляет уже размеченный inner: "b" - жирный, "i" - курсив, "s" - зачеркнутый
+	wrap(style, inner string) string
+	code(s string) string
+	pre(lang, code string) string
+	link(url, label string) string
+	quote(inner string) string
+}
+
+func render(md string, m markup) string {
+	src := []byte(md)
+	doc := markdown.Parser().Parse(text.NewReader(src))
+	r := &renderer{src: src, m: m}
+	return r.blocks(doc, 0, "\n\n")
+}
+
+type renderer struct {
+	src        []byte
+	m          markup
+	quoteDepth int
+}
+
+type htmlMarkup struct{}
+
+func (htmlMarkup) text(s string) string { return html.EscapeString(s) }
+
+func (htmlMarkup) wrap(style, inner string) string {
+	return "<" + style + ">" + inner + "</" + style + ">"
+}
+
+func (htmlMarkup) code(s string) string { return "<code>" + html.EscapeString(s) + "</code>" }
+
+func (htmlMarkup) pre(lang, code string) string {
+	code = html.EscapeString(code)
+	if lang == "" {
+		return "<pre>" + code + "</pre>"
+	}
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, html.EscapeString(lang), code)
+}
+
+func (htmlMarkup) link(url, label string) string {
+	return `<a href="` + html.EscapeString(url) + `">` + label + `</a>`
+}
+
+func (htmlMarkup) quote(inner string) string { return "<blockquote>" + inner + "</blockquote>" }
+
+func (r *renderer) blocks(parent ast.Node, indent int, sep string) string {
+	var parts []string
+	for c := parent.FirstChild(); c != nil; c = c.NextSibling() {
+		if s := r.block(c, indent); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func (r *renderer) block(n ast.Node, indent int) string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		return r.m.wrap("b", r.inlines(n))
+	case *ast.Paragraph, *ast.TextBlock:
+		return r.inlines(n)
+	case *ast.List:
+		return r.list(n, indent)
+	case *ast.Blockquote:
+		// Telegram не поддерживает вложенные цитаты
+		if r.quoteDepth > 0 {
+			return r.blocks(n, indent, "\n\n")
+		}
+		r.quoteDepth++
+		inner := r.blocks(n, indent, "\n\n")
+		r.quoteDepth--
+		return r.m.quote(inner)
+	case *ast.FencedCodeBlock:
+		return r.m.pre(string(n.Language(r.src)), strings.TrimRight(r.lines(n), "\n"))
+	case *ast.CodeBlock:
+		return r.m.pre("", strings.TrimRight(r.lines(n), "\n"))
+	case *ast.ThematicBreak:
+		return r.m.text("——————")
+	case *ast.HTMLBlock:
+		return r.m.text(strings.TrimRight(r.lines(n), "\n"))
+	case *extast.Table:
+		return r.table(n)
+	default:
+		return r.blocks(n, indent, "\n\n")
+	}
+}
+
+func (r *renderer) lines(n ast.Node) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(r.src))
+	}
+	return b.String()
+}
+
+const listIndent = "    "
+
+func (r *renderer) list(n *ast.List, indent int) string {
+	var items []string
+	num := n.Start
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "• "
+		if indent > 0 {
+			marker = "◦ "
+		}
+		if n.IsOrdered() {
+			marker = fmt.Sprintf("%d. ", num)
+			num++
+		}
+		var parts []string
+		for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+			part := r.block(c, indent+1)
+			if part == "" {
+				continue
+			}
+			// Вложенные списки сами добавляют отступ, остальные блоки выравниваются под текст пункта
+			if _, nested := c.(*ast.List); !nested && len(parts) > 0 {
+				part = strings.Repeat(listIndent, indent+1) + part
+			}
+			parts = append(parts, part)
+		}
+		items = append(items, strings.Repeat(listIndent, indent)+r.m.text(marker)+strings.Join(parts, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table выводит таблицу моноширинным блоком с выровненными столбцами
+func (r *renderer) table(n *extast.Table) string {
+	var rows [][]string
+	var widths []int
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			value := strings.TrimSpace(r.plain(cell))
+			if i := len(cells); i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[len(cells)] = max(widths[len(cells)], utf8.RuneCountInString(value))
+			cells = append(cells, value)
+		}
+		rows = append(rows, cells)
+	}
+	var b strings.Builder
+	for i, cells := range rows {
+		for j, cell := range cells {
+			if j > 0 {
+				b.WriteString(" | ")
+			}
+			b.WriteString(cell)
+			if j < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+			}
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			for j, w := range widths {
+				if j > 0 {
+					b.WriteString("-+-")
+				}
+				b.WriteString(strings.Repeat("-", w))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return r.m.pre("", strings.TrimRight(b.String(), "\n"))
+}
+
+// plain возвращает текст узла без разметки
+func (r *renderer) plain(n ast.Node) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(r.src))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteString(" ")
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.AutoLink:
+			b.Write(c.Label(r.src))
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+func (r *renderer) inlines(n ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		b.WriteString(r.inline(c))
+	}
+	return b.String()
+}
+
+// safeLink пропускает только ссылки со схемами, которые Telegram принимает в href
+func safeLink(url string) bool {
+	lower := strings.ToLower(url)
+	for _, scheme := range []string{"http://", "https://", "tg://", "mailto:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *renderer) link(url, label string) string {
+	if !safeLink(url) {
+		return label
+	}
+	return r.m.link(url, label)
+}
+
+func (r *renderer) inline(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Text:
+		s := r.m.text(string(n.Segment.Value(r.src)))
+		if n.SoftLineBreak() || n.HardLineBreak() {
+			s += "\n"
+		}
+		return s
+	case *ast.String:
+		return r.m.text(string(n.Value))
+	case *ast.CodeSpan:
+		return r.m.code(r.plain(n))
+	case *ast.Emphasis:
+		style := "i"
+		if n.Level >= 2 {
+			style = "b"
+		}
+		return r.m.wrap(style, r.inlines(n))
+	case *extast.Strikethrough:
+		return r.m.wrap("s", r.inlines(n))
+	case *ast.Link:
+		return r.link(string(n.Destination), r.inlines(n))
+	case *ast.Image:
+		return r.link(string(n.Destination), r.inlines(n))
+	case *ast.AutoLink:
+		url := string(n.URL(r.src))
+		if n.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(url), "mailto:") {
+			url = "mailto:" + url
+		}
+		return r.link(url, r.m.text(string(n.Label(r.src))))
+	case *ast.RawHTML:
+		var b strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			b.Write(seg.Value(r.src))
+		}
+		return r.m.text(b.String())
+	default:
+		return r.inlines(n)
+	}
+}
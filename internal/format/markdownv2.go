@@ -0,0 +1,68 @@
+package format
+
+import "strings"
+
+// markdownV2Special - символы, которые parse_mode MarkdownV2 требует экранировать в обычном тексте
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// EscapeMarkdownV2 экранирует текст для parse_mode MarkdownV2, чтобы его можно было
+// отправить как есть: расшифровки и любые строки, не прошедшие через FormatMarkdownV2.
+func EscapeMarkdownV2(text string) string {
+	return escapeChars(text, markdownV2Special)
+}
+
+// FormatMarkdownV2 переводит markdown модели в MarkdownV2 Telegram по тем же правилам,
+// что и FormatHTML: заголовки жирным, списки маркерами, таблицы моноширинным блоком.
+func FormatMarkdownV2(md string) string { return render(md, markdownV2Markup{}) }
+
+func escapeChars(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type markdownV2Markup struct{}
+
+func (markdownV2Markup) text(s string) string { return EscapeMarkdownV2(s) }
+
+func (markdownV2Markup) wrap(style, inner string) string {
+	switch style {
+	case "b":
+		return "*" + inner + "*"
+	case "i":
+		// \r отделяет курсив от соседнего "_", иначе Telegram прочитает "__" как подчеркивание
+		return "_" + inner + "_\r"
+	case "s":
+		return "~" + inner + "~"
+	}
+	return inner
+}
+
+// внутри code и pre экранируются только ` и \
+func (markdownV2Markup) code(s string) string { return "`" + escapeChars(s, "`\\") + "`" }
+
+func (markdownV2Markup) pre(lang, code string) string {
+	return "```" + lang + "\n" + escapeChars(code, "`\\") + "\n```"
+}
+
+// в адресе ссылки экранируются только ) и \
+func (markdownV2Markup) link(url, label string) string {
+	return "[" + label + "](" + escapeChars(url, ")\\") + ")"
+}
+
+func (markdownV2Markup) quote(inner string) string {
+	lines := strings.Split(inner, "\n")
+	for i, l := range lines {
+		lines[i] = ">" + l
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package format
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// paragraphRunes - примерная длина абзаца, на которые делятся длинные строки расшифровки
+const paragraphRunes = 400
+
+// Paragraphs приводит расшифровку к читаемому виду: начинает предложения с заглавной буквы
+// и делит строки длиннее paragraphRunes на абзацы по границам предложений
+func Paragraphs(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		sentences := splitSentences(line)
+		for j, s := range sentences {
+			sentences[j] = capitalize(s)
+		}
+		if utf8.RuneCountInString(line) <= paragraphRunes {
+			lines[i] = strings.Join(sentences, " ")
+			continue
+		}
+		var paragraphs []string
+		start, size := 0, 0
+		for j, s := range sentences {
+			size += utf8.RuneCountInString(s)
+			if size >= paragraphRunes || j == len(sentences)-1 {
+				paragraphs = append(paragraphs, strings.Join(sentences[start:j+1], " "))
+				start, size = j+1, 0
+			}
+		}
+		lines[i] = strings.Join(paragraphs, "\n\n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitSentences делит строку на предложения по знакам .!?…, за которыми следует пробел.
+// Сокращения с точкой внутри ("т.е.") и инициалы концом предложения не считаются.
+func splitSentences(s string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range s {
+		if !strings.ContainsRune(".!?…", r) {
+			continue
+		}
+		end := i + utf8.RuneLen(r)
+		if end >= len(s) || s[end] != ' ' || (r == '.' && !sentenceEnd(s[start:i])) {
+			continue
+		}
+		if sentence := strings.TrimSpace(s[start:end]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+func sentenceEnd(head string) bool {
+	word := head[strings.LastIndexByte(head, ' ')+1:]
+	return utf8.RuneCountInString(word) > 1 && !strings.Contains(word, ".")
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if !unicode.IsLower(r) {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
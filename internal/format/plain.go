@@ -0,0 +1,64 @@
+package format
+
+import (
+	"html"
+	"strings"
+)
+
+// StripFormatting переводит markdown модели в читаемый простой текст без разметки:
+// списки сохраняют маркеры и отступы, ссылки превращаются в "текст (адрес)".
+func StripFormatting(md string) string { return render(md, plainMarkup{}) }
+
+// StripHTML убирает теги из HTML-сообщения Telegram и раскрывает сущности. Нужен для повторной
+// отправки без parse_mode, когда Telegram отверг разметку части сообщения.
+func StripHTML(s string) string {
+	var b strings.Builder
+	var href string
+	for _, t := range tokenizeHTML(s) {
+		switch {
+		case !t.tag:
+			b.WriteString(html.UnescapeString(t.raw))
+		case strings.HasPrefix(strings.ToLower(t.raw), "<a "):
+			href = tagAttr(t.raw, "href")
+		default:
+			if name, closing := tagName(t.raw); name == "a" && closing && href != "" {
+				b.WriteString(" (" + href + ")")
+				href = ""
+			}
+		}
+	}
+	return b.String()
+}
+
+func tagAttr(tag, attr string) string {
+	i := strings.Index(tag, attr+`="`)
+	if i < 0 {
+		return ""
+	}
+	v := tag[i+len(attr)+2:]
+	if end := strings.IndexByte(v, '"'); end >= 0 {
+		v = v[:end]
+	}
+	return html.UnescapeString(v)
+}
+
+type plainMarkup struct{}
+
+func (plainMarkup) text(s string) string        { return s }
+func (plainMarkup) wrap(_, inner string) string { return inner }
+func (plainMarkup) code(s string) string        { return s }
+func (plainMarkup) pre(_, code string) string   { return code }
+func (plainMarkup) link(url, label string) string {
+	if label == "" || label == url {
+		return url
+	}
+	return label + " (" + url + ")"
+}
+
+func (plainMarkup) quote(inner string) string {
+	lines := strings.Split(inner, "\n")
+	for i, l := range lines {
+		lines[i] = "│ " + l
+	}
+	return strings.Join(lines, "\n")
+}
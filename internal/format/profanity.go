@@ -0,0 +1,75 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// profanityPatterns - корни нецензурных слов; проверяются по слову целиком в нижнем регистре.
+// Приставки перечислены явно, чтобы не задевать обычные слова вроде "небо" или "страхуй".
+var profanityPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(за|на|по|от|вы|до|при|пере|раз|рас|у|ни|под|об|проху)?ху[йяеёию]`),
+	regexp.MustCompile(`пизд`),
+	regexp.MustCompile(`^бля`),
+	regexp.MustCompile(`^(за|на|по|от|вы|до|при|пере|раз|у|съ|объ|подъ|въ|из|взъ|отъ|долбо)?[её]б(а|у|л|н|и|ё|е|о|ы|ск|т)`),
+	regexp.MustCompile(`^долбо[её]б`),
+	regexp.MustCompile(`^(муда[кч]|мудил|мудозвон)`),
+	regexp.MustCompile(`^пид[оа]р`),
+	regexp.MustCompile(`^залуп`),
+	regexp.MustCompile(`^г[ао]ндон`),
+	regexp.MustCompile(`^шлюх`),
+	regexp.MustCompile(`^сук(а|и|у|ой|ам|ами)$`),
+	regexp.MustCompile(`fuck`),
+	regexp.MustCompile(`^(shit|bitch|cunt|asshole|dickhead|motherfuck)`),
+}
+
+var reWord = regexp.MustCompile(`\p{L}+`)
+
+// isProfanity сообщает, является ли слово нецензурным
+func isProfanity(word string) bool {
+	word = strings.ToLower(word)
+	for _, re := range profanityPatterns {
+		if re.MatchString(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskWord оставляет первую букву слова, остальное заменяет звездочками: "с***"
+func maskWord(word string) string {
+	_, size := utf8.DecodeRuneInString(word)
+	return word[:size] + "***"
+}
+
+// MaskProfanity заменяет нецензурные слова в тексте на первую букву со звездочками
+func MaskProfanity(text string) string {
+	return reWord.ReplaceAllStringFunc(text, func(word string) string {
+		if isProfanity(word) {
+			return maskWord(word)
+		}
+		return word
+	})
+}
+
+// MaskProfanityHTML маскирует нецензурные слова в HTML-сообщении, не трогая теги и их атрибуты
+func MaskProfanityHTML(s string) string {
+	var b strings.Builder
+	for s != "" {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			b.WriteString(MaskProfanity(s))
+			break
+		}
+		b.WriteString(MaskProfanity(s[:start]))
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			b.WriteString(s[start:])
+			break
+		}
+		b.WriteString(s[start : start+end+1])
+		s = s[start+end+1:]
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Метки, которыми заменяются персональные данные
+const (
+	RedactedPhone = "[телефон]"
+	RedactedCard  = "[карта]"
+	RedactedEmail = "[email]"
+)
+
+var (
+	reEmail = regexp.MustCompile(`[\p{L}\d._%+\-]+@[\p{L}\d.\-]+\.\p{L}{2,}`)
+	// Последовательность цифр, возможно с "+" в начале, скобками, пробелами и дефисами между ними
+	reDigitRun = regexp.MustCompile(`(?:\+|\b)\d(?:[ \-()]{0,2}\d)*\b`)
+)
+
+// RedactPII заменяет в тексте номера карт (проходящие проверку Луна), телефоны и адреса почты метками.
+// Имена и адреса регулярными выражениями надежно не найти - их скрывает модель (ai.RedactPII).
+func RedactPII(text string) string {
+	text = reEmail.ReplaceAllString(text, RedactedEmail)
+	return reDigitRun.ReplaceAllStringFunc(text, func(m string) string {
+		switch n := countDigits(m); {
+		case n >= 13 && n <= 19 && luhnValid(m):
+			return RedactedCard
+		case n >= 10 && n <= 15:
+			return RedactedPhone
+		}
+		return m
+	})
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// luhnValid проверяет контрольную сумму номера карты, пропуская разделители
+func luhnValid(number string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
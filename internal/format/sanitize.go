@@ -0,0 +1,158 @@
+package format
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// telegramTags - теги, которые понимает parse_mode HTML, и допустимые у них атрибуты
+var telegramTags = map[string][]string{
+	"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "ins": nil,
+	"s": nil, "strike": nil, "del": nil, "tg-spoiler": nil,
+	"span":       {"class"},
+	"a":          {"href"},
+	"code":       {"class"},
+	"pre":        nil,
+	"blockquote": {"expandable"},
+	"tg-emoji":   {"emoji-id"},
+}
+
+var (
+	reTagShape = regexp.MustCompile(`^</?[a-zA-Z][a-zA-Z0-9-]*(\s[^<>]*)?/?>$`)
+	reTagAttr  = regexp.MustCompile(`([a-zA-Z-]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+	reEntity   = regexp.MustCompile(`^&(lt|gt|amp|quot|#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6});$`)
+)
+
+// SanitizeHTML приводит HTML к виду, который гарантированно примет parse_mode HTML Telegram:
+// неизвестные теги и атрибуты убираются (текст внутри остается), небезопасные ссылки теряют адрес,
+// внутри pre и code разметка снимается, неверная вложенность и незакрытые теги исправляются,
+// а одиночные <, > и & экранируются.
+func SanitizeHTML(s string) string {
+	var b strings.Builder
+	var stack []string // имена открытых тегов
+	for _, t := range tokenizeHTML(s) {
+		if !t.tag {
+			switch {
+			case t.r == '<':
+				b.WriteString("&lt;")
+			case t.r == '>':
+				b.WriteString("&gt;")
+			case t.r == '&':
+				b.WriteString("&amp;")
+			case t.r == 0 && !reEntity.MatchString(t.raw):
+				// сущность, которую Telegram не знает (&nbsp; и т.п.), заменяем ее значением
+				b.WriteString(html.EscapeString(html.UnescapeString(t.raw)))
+			default:
+				b.WriteString(t.raw)
+			}
+			continue
+		}
+		if !reTagShape.MatchString(t.raw) {
+			b.WriteString(html.EscapeString(t.raw))
+			continue
+		}
+		name, closing := tagName(t.raw)
+		if name == "br" {
+			b.WriteString("\n")
+			continue
+		}
+		if _, ok := telegramTags[name]; !ok || strings.HasSuffix(t.raw, "/>") {
+			continue
+		}
+		// внутри pre допустим только code, внутри code - ничего
+		top := lastTag(stack)
+		if closing {
+			if (top == "code" || top == "pre") && name != top {
+				continue
+			}
+			stack = closeTag(&b, stack, name)
+			continue
+		}
+		if top == "code" || top == "pre" && name != "code" {
+			continue
+		}
+		open, ok := sanitizeTag(name, t.raw)
+		if !ok {
+			continue
+		}
+		b.WriteString(open)
+		stack = append(stack, name)
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteString("</" + stack[i] + ">")
+	}
+	return b.String()
+}
+
+func lastTag(stack []string) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[len(stack)-1]
+}
+
+// closeTag закрывает последний открытый тег name; теги, открытые внутри него, закрываются раньше
+// и открываются заново после, чтобы вложенность оставалась правильной. Лишний закрывающий тег пропускается.
+func closeTag(b *strings.Builder, stack []string, name string) []string {
+	i := len(stack) - 1
+	for i >= 0 && stack[i] != name {
+		i--
+	}
+	if i < 0 {
+		return stack
+	}
+	inner := stack[i+1:]
+	for j := len(inner) - 1; j >= 0; j-- {
+		b.WriteString("</" + inner[j] + ">")
+	}
+	b.WriteString("</" + name + ">")
+	// заново открываются только простые теги: атрибуты ссылок и классы к этому месту уже неизвестны
+	var reopened []string
+	for _, n := range inner {
+		if len(telegramTags[n]) == 0 {
+			b.WriteString("<" + n + ">")
+			reopened = append(reopened, n)
+		}
+	}
+	return append(stack[:i:i], reopened...)
+}
+
+// sanitizeTag оставляет в открывающем теге только допустимые атрибуты; false - тег нужно выбросить
+func sanitizeTag(name, raw string) (string, bool) {
+	attrs := map[string]string{}
+	body := strings.TrimSuffix(strings.TrimPrefix(raw, "<"+raw[1:1+len(name)]), ">")
+	for _, m := range reTagAttr.FindAllStringSubmatch(body, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	switch name {
+	case "a":
+		href := attrs["href"]
+		if !safeLink(href) {
+			return "", false
+		}
+		return `<a href="` + html.EscapeString(href) + `">`, true
+	case "span":
+		if attrs["class"] != "tg-spoiler" {
+			return "", false
+		}
+		return `<span class="tg-spoiler">`, true
+	case "code":
+		if class := attrs["class"]; strings.HasPrefix(class, "language-") && !strings.ContainsAny(class, " \"") {
+			return `<code class="` + html.EscapeString(class) + `">`, true
+		}
+		return "<code>", true
+	case "blockquote":
+		if _, ok := attrs["expandable"]; ok {
+			return "<blockquote expandable>", true
+		}
+		return "<blockquote>", true
+	case "tg-emoji":
+		id := attrs["emoji-id"]
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return "", false
+		}
+		return `<tg-emoji emoji-id="` + id + `">`, true
+	}
+	return "<" + name + ">", true
+}
@@ -0,0 +1,318 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UTF16Len возвращает длину текста в кодовых единицах UTF-16 - так длину сообщений считает Telegram
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeUTF16Len(r)
+	}
+	return n
+}
+
+func runeUTF16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// joinsPrevious сообщает, что руна продолжает предыдущий графический символ и отделять ее нельзя:
+// комбинируемые знаки, селекторы вариантов, модификаторы тона эмодзи, теги флагов и ZWJ
+func joinsPrevious(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return true
+	case r == 0x200D, r >= 0xFE00 && r <= 0xFE0F, r >= 0x1F3FB && r <= 0x1F3FF, r >= 0xE0020 && r <= 0xE007F:
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool { return r >= 0x1F1E6 && r <= 0x1F1FF }
+
+// safeCut возвращает наибольшую позицию в байтах, не превышающую maxLen единиц UTF-16,
+// на которой текст можно разрезать, не разрывая графический символ
+func safeCut(s string, maxLen int) int {
+	units, cut := 0, 0
+	var prev rune
+	regional := 0 // число региональных индикаторов подряд: флаг - это их пара
+	for i, r := range s {
+		if i > 0 {
+			boundary := !joinsPrevious(r) && prev != 0x200D && !(isRegionalIndicator(r) && regional%2 == 1)
+			if boundary {
+				cut = i
+			}
+		}
+		units += runeUTF16Len(r)
+		if units > maxLen {
+			return cut
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		} else {
+			regional = 0
+		}
+		prev = r
+	}
+	return len(s)
+}
+
+// SplitMessage делит сообщение на части не длиннее maxLen единиц UTF-16, предпочитая
+// границы строк, затем пробелы, и никогда не разрывая графические символы (эмодзи, буквы с диакритикой)
+func SplitMessage(message string, maxLen int) []string {
+	if UTF16Len(message) <= maxLen {
+		return []string{message}
+	}
+	var parts []string
+	for len(message) > 0 {
+		if UTF16Len(message) <= maxLen {
+			parts = append(parts, message)
+			break
+		}
+		limit := safeCut(message, maxLen)
+		splitPos := strings.LastIndex(message[:limit], "\n")
+		if splitPos <= 0 {
+			splitPos = strings.LastIndex(message[:limit], " ")
+		}
+		if splitPos <= 0 {
+			splitPos = limit
+		}
+		if splitPos == 0 {
+			// Один графический символ длиннее лимита: режем по границе руны, чтобы не зациклиться
+			_, size := utf8.DecodeRuneInString(message)
+			splitPos = size
+		}
+		parts = append(parts, message[:splitPos])
+		message = strings.TrimSpace(message[splitPos:])
+	}
+	return parts
+}
+
+type htmlToken struct {
+	raw   string
+	units int  // видимая длина в единицах UTF-16; у тегов 0
+	tag   bool // тег целиком
+	r     rune // руна для текстовых токенов, иначе 0
+}
+
+// tokenizeHTML разбивает HTML на неделимые токены: теги, сущности (&amp; и т.п.) и отдельные руны текста
+func tokenizeHTML(s string) []htmlToken {
+	var tokens []htmlToken
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				tokens = append(tokens, htmlToken{raw: s[i : i+end+1], tag: true})
+				i += end + 1
+				continue
+			}
+		case '&':
+			if end := strings.IndexByte(s[i:], ';'); end > 1 && end <= 10 && !strings.ContainsAny(s[i+1:i+end], " &<>") {
+				raw := s[i : i+end+1]
+				tokens = append(tokens, htmlToken{raw: raw, units: UTF16Len(html.UnescapeString(raw))})
+				i += end + 1
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		tokens = append(tokens, htmlToken{raw: s[i : i+size], units: runeUTF16Len(r), r: r})
+		i += size
+	}
+	return tokens
+}
+
+func tagName(tag string) (name string, closing bool) {
+	tag = strings.TrimSuffix(strings.TrimPrefix(tag, "<"), ">")
+	if strings.HasPrefix(tag, "/") {
+		closing, tag = true, tag[1:]
+	}
+	if i := strings.IndexAny(tag, " \t\n/"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag), closing
+}
+
+// openTags обновляет стек открытых тегов после тега tag
+func openTags(stack []string, tag string) []string {
+	if strings.HasSuffix(tag, "/>") {
+		return stack
+	}
+	name, closing := tagName(tag)
+	if !closing {
+		return append(stack, tag)
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if n, _ := tagName(stack[i]); n == name {
+			return append(stack[:i:i], stack[i+1:]...)
+		}
+	}
+	return stack
+}
+
+func closeTags(stack []string) string {
+	var b strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		name, _ := tagName(stack[i])
+		b.WriteString("</" + name + ">")
+	}
+	return b.String()
+}
+
+// Пометки, которые ставятся вокруг блока кода, разрезанного между сообщениями
+const (
+	codeContinuedBelow = "<i>⤵ код продолжается в следующем сообщении</i>"
+	codeContinuedAbove = "<i>⤴ продолжение кода</i>"
+)
+
+func inPre(stack []string) bool {
+	for _, tag := range stack {
+		if name, _ := tagName(tag); name == "pre" {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpaceToken(t htmlToken) bool { return t.r == ' ' || t.r == '\n' || t.r == '\t' }
+
+// SplitHTML делит HTML-сообщение Telegram на части, в каждой из которых не больше maxLen единиц UTF-16
+// видимого текста (Telegram считает длину после разбора разметки). Разрез никогда не попадает внутрь тега
+// или сущности: открытые теги закрываются в конце части и открываются заново в начале следующей.
+// Длинный блок <pre> делится по строкам на несколько самостоятельных блоков с пометками о продолжении.
+func SplitHTML(message string, maxLen int) []string {
+	tokens := tokenizeHTML(message)
+	budget := maxLen
+	if strings.Contains(message, "<pre") {
+		// Место под пометки о продолжении кода резервируется заранее: заранее неизвестно, где будет разрез
+		budget = max(1, maxLen-UTF16Len(codeContinuedBelow)-UTF16Len(codeContinuedAbove)-2)
+	}
+	var parts []string
+	var stack []string
+	for start := 0; start < len(tokens); {
+		startsInPre := inPre(stack)
+		if startsInPre {
+			// В коде отступы значимы: убираем только перевод строки, по которому прошел разрез
+			if start < len(tokens) && tokens[start].r == '\n' {
+				start++
+			}
+		} else {
+			// Пропускаем пробелы в начале части, как SplitMessage
+			for start < len(tokens) && isSpaceToken(tokens[start]) {
+				start++
+			}
+		}
+		if start == len(tokens) {
+			break
+		}
+		end, units := start, 0
+		lastNewline, lastSpace, lastSafe := -1, -1, -1
+		var prev rune
+		regional := 0
+		for ; end < len(tokens); end++ {
+			t := tokens[end]
+			if end > start && !t.tag {
+				if t.r == 0 || !joinsPrevious(t.r) && prev != 0x200D && !(isRegionalIndicator(t.r) && regional%2 == 1) {
+					lastSafe = end
+				}
+			}
+			if units+t.units > budget {
+				break
+			}
+			units += t.units
+			switch t.r {
+			case '\n':
+				lastNewline = end
+			case ' ':
+				lastSpace = end
+			}
+			if !t.tag {
+				if isRegionalIndicator(t.r) {
+					regional++
+				} else {
+					regional = 0
+				}
+				prev = t.r
+			}
+		}
+		if end < len(tokens) {
+			switch {
+			case lastNewline > start:
+				end = lastNewline
+			case lastSpace > start:
+				end = lastSpace
+			case lastSafe > start:
+				end = lastSafe
+			case end == start:
+				end = start + 1
+			}
+		}
+
+		var b strings.Builder
+		if startsInPre {
+			b.WriteString(codeContinuedAbove + "\n")
+		}
+		for _, tag := range stack {
+			b.WriteString(tag)
+		}
+		last := end
+		for last > start && isSpaceToken(tokens[last-1]) {
+			last--
+		}
+		for i := start; i < end; i++ {
+			if tokens[i].tag {
+				stack = openTags(stack, tokens[i].raw)
+			}
+			if i < last || tokens[i].tag {
+				b.WriteString(tokens[i].raw)
+			}
+		}
+		b.WriteString(closeTags(stack))
+		if inPre(stack) && end < len(tokens) {
+			b.WriteString("\n" + codeContinuedBelow)
+		}
+		parts = append(parts, b.String())
+		start = end
+	}
+	if len(parts) == 0 {
+		return []string{message}
+	}
+	return parts
+}
+
+// SplitHTMLNumbered делит сообщение как SplitHTML, но если частей несколько, начинает каждую
+// с "Часть k/n", а со второй части еще и повторяет жирный заголовок title.
+// Место под шапку вычитается из лимита заранее, так что части по-прежнему укладываются в maxLen.
+func SplitHTMLNumbered(message, title string, maxLen int) []string {
+	parts := SplitHTML(message, maxLen)
+	if len(parts) < 2 {
+		return parts
+	}
+	// Длина шапки зависит от числа частей, а оно - от длины шапки: повторяем, пока число частей не перестанет расти
+	for total := len(parts); ; total = len(parts) {
+		reserve := UTF16Len(StripHTML(partHeader(title, total, total)))
+		parts = SplitHTML(message, max(1, maxLen-reserve))
+		if len(parts) <= total {
+			break
+		}
+	}
+	for i := range parts {
+		parts[i] = partHeader(title, i+1, len(parts)) + parts[i]
+	}
+	return parts
+}
+
+func partHeader(title string, part, total int) string {
+	header := fmt.Sprintf("<i>Часть %d/%d</i>\n\n", part, total)
+	if part > 1 && title != "" {
+		header = "<b>" + html.EscapeString(title) + "</b>\n" + header
+	}
+	return header
+}
@@ -0,0 +1,15 @@
+package format
+
+import "regexp"
+
+// reSegmentStart находит метки времени в начале абзацев расшифровки: [04:10] или [1:02:03]
+var reSegmentStart = regexp.MustCompile(`(?m)^\[(\d{1,2}:\d{2}(?::\d{2})?)\]`)
+
+// Timestamps возвращает метки времени, с которых начинаются абзацы расшифровки, в порядке появления
+func Timestamps(transcript string) []string {
+	var stamps []string
+	for _, m := range reSegmentStart.FindAllStringSubmatch(transcript, -1) {
+		stamps = append(stamps, m[1])
+	}
+	return stamps
+}
@@ -0,0 +1,11 @@
+package format
+
+import "regexp"
+
+// reUnclear находит метки неразборчивых фрагментов, которые модель ставит в расшифровке: [неразборчиво 02:13]
+var reUnclear = regexp.MustCompile(`\[неразборчиво(?: \d{1,2}:\d{2}(?::\d{2})?)?\]`)
+
+// HighlightUnclear выделяет курсивом метки неразборчивых фрагментов в HTML-тексте
+func HighlightUnclear(s string) string {
+	return reUnclear.ReplaceAllString(s, "<i>$0</i>")
+}
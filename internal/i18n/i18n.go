@@ -0,0 +1,172 @@
+// Package i18n хранит тексты интерфейса бота на нескольких языках и выбирает язык по цепочке
+// предпочтений (язык пользователя → язык чата → язык по умолчанию).
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage - язык, на котором есть все тексты; используется, если ни один из языков цепочки не поддерживается
+const DefaultLanguage = "ru"
+
+// Ключи текстов
+const (
+	Welcome = "welcome"
+
+	StatusProcessing  = "status_processing"
+	StatusReadingText = "status_reading_text"
+	StatusShorter     = "status_shorter"
+	StatusQueued      = "status_queued"
+	ButtonNotifyReady = "button_notify_ready"
+	NotifyReady       = "notify_ready"
+	ErrRateLimited    = "err_rate_limited"
+	ButtonNotifyLimit = "button_notify_limit"
+	NotifyLimit       = "notify_limit"
+	ButtonRetry       = "button_retry"
+	StatusResumed     = "status_resumed"
+
+	ErrGeneric         = "err_generic"
+	ErrEmptyTranscript = "err_empty_transcript"
+	ErrMusic           = "err_music"
+	ErrNoise           = "err_noise"
+	ErrConvert         = "err_convert"
+	ErrTranscribe      = "err_transcribe"
+	ErrRedact          = "err_redact"
+	ErrSummarize       = "err_summarize"
+	ErrResumeFailed    = "err_resume_failed"
+)
+
+var catalog = map[string]map[string]string{
+	"ru": {
+		Welcome: "Привет! Я бот, который может транскрибировать и суммировать голосовые сообщения, видео и аудиофайлы.\n\n" +
+			"Просто отправь мне голосовое сообщение, видео или аудиофайл (mp3, wav, oga, ogg, opus), и я преобразую его в текст и создам краткое резюме.\n\n" +
+			"P.S Данный бот работает на мощностях Google Gemini AI, использует модели %s и %s для транскрипции и суммаризации\n\n" +
+			"Важно: максимальный размер файла для обработки - %d МБ.",
+
+		StatusProcessing:  "Обрабатываю ваш медиафайл, это может занять некоторое время...",
+		StatusReadingText: "Читаю текст, это может занять некоторое время...",
+		StatusShorter:     "Создаю еще более краткое резюме...",
+		StatusQueued:      "Сейчас много заданий: впереди в очереди %d, ожидание около %d мин.",
+		ButtonNotifyReady: "🔔 Уведомить, когда готово",
+		NotifyReady:       "Готово: запись в чате «%s» обработана.",
+		ErrRateLimited:    "Вы отправили %d записей за последний час, это лимит. Следующую можно будет отправить примерно через %d мин.",
+		ButtonNotifyLimit: "🔔 Уведомить, когда можно",
+		NotifyLimit:       "Лимит обновился: можно снова отправлять записи.",
+		ButtonRetry:       "🔁 Повторить",
+		StatusResumed:     "Бот перезапускался во время обработки этой записи, продолжаю с начала.",
+
+		ErrGeneric:         "Произошла ошибка: %v",
+		ErrEmptyTranscript: "Не удалось распознать речь в аудио.",
+		ErrMusic:           "Похоже, это музыка: речи в записи не слышно, поэтому расшифровки не будет.",
+		ErrNoise:           "Похоже, в записи только шум: речи не слышно, поэтому расшифровки не будет.",
+		ErrConvert:         "Произошла ошибка при обработке медиафайла: %v",
+		ErrTranscribe:      "Произошла ошибка при транскрипции аудио: %v",
+		ErrRedact:          "Не удалось скрыть персональные данные, расшифровка не показана: %v",
+		ErrSummarize:       "Произошла ошибка при создании резюме: %v",
+		ErrResumeFailed:    "Не удалось обработать запись: бот несколько раз перезапускался во время ее обработки. Пришлите ее еще раз.",
+	},
+	"en": {
+		Welcome: "Hi! I can transcribe and summarize voice messages, videos and audio files.\n\n" +
+			"Just send me a voice message, a video or an audio file (mp3, wav, oga, ogg, opus), and I will turn it into text and write a short summary.\n\n" +
+			"P.S. This bot runs on Google Gemini AI and uses the %s and %s models for transcription and summarization.\n\n" +
+			"Note: the maximum file size is %d MB.",
+
+		StatusProcessing:  "Processing your media file, this may take a while...",
+		StatusReadingText: "Reading the text, this may take a while...",
+		StatusShorter:     "Writing an even shorter summary...",
+		StatusQueued:      "The bot is busy right now: %d jobs ahead of yours, the wait is about %d min.",
+		ButtonNotifyReady: "🔔 Notify me when ready",
+		NotifyReady:       "Done: the recording in «%s» has been processed.",
+		ErrRateLimited:    "You have sent %d recordings in the last hour, which is the limit. You can send the next one in about %d min.",
+		ButtonNotifyLimit: "🔔 Notify me when I can",
+		NotifyLimit:       "The limit has been reset: you can send recordings again.",
+		ButtonRetry:       "🔁 Retry",
+		StatusResumed:     "The bot restarted while processing this recording, starting over.",
+
+		ErrGeneric:         "An error occurred: %v",
+		ErrEmptyTranscript: "Could not recognize any speech in the audio.",
+		ErrMusic:           "This looks like music: there is no speech in the recording, so there will be no transcript.",
+		ErrNoise:           "This recording seems to contain only noise: there is no speech, so there will be no transcript.",
+		ErrConvert:         "Failed to process the media file: %v",
+		ErrTranscribe:      "Failed to transcribe the audio: %v",
+		ErrRedact:          "Failed to hide personal data, so the transcript is not shown: %v",
+		ErrSummarize:       "Failed to write the summary: %v",
+		ErrResumeFailed:    "Could not process the recording: the bot restarted several times while processing it. Please send it again.",
+	},
+	"uk": {
+		Welcome: "Привіт! Я бот, який транскрибує та підсумовує голосові повідомлення, відео та аудіофайли.\n\n" +
+			"Просто надішли мені голосове повідомлення, відео або аудіофайл (mp3, wav, oga, ogg, opus), і я перетворю його на текст та складу короткий підсумок.\n\n" +
+			"P.S. Бот працює на Google Gemini AI і використовує моделі %s та %s для транскрипції та підсумовування.\n\n" +
+			"Важливо: максимальний розмір файлу - %d МБ.",
+
+		StatusProcessing:  "Обробляю ваш медіафайл, це може зайняти деякий час...",
+		StatusReadingText: "Читаю текст, це може зайняти деякий час...",
+		StatusShorter:     "Складаю ще коротший підсумок...",
+		StatusQueued:      "Зараз багато завдань: попереду в черзі %d, очікування близько %d хв.",
+		ButtonNotifyReady: "🔔 Сповістити, коли буде готово",
+		NotifyReady:       "Готово: запис у чаті «%s» оброблено.",
+		ErrRateLimited:    "Ви надіслали %d записів за останню годину, це ліміт. Наступний можна буде надіслати приблизно через %d хв.",
+		ButtonNotifyLimit: "🔔 Сповістити, коли можна",
+		NotifyLimit:       "Ліміт оновився: можна знову надсилати записи.",
+		ButtonRetry:       "🔁 Повторити",
+		StatusResumed:     "Бот перезапускався під час обробки цього запису, продовжую з початку.",
+
+		ErrGeneric:         "Сталася помилка: %v",
+		ErrEmptyTranscript: "Не вдалося розпізнати мову в аудіо.",
+		ErrMusic:           "Схоже, це музика: мовлення в записі не чути, тому розшифровки не буде.",
+		ErrNoise:           "Схоже, у записі лише шум: мовлення не чути, тому розшифровки не буде.",
+		ErrConvert:         "Сталася помилка під час обробки медіафайлу: %v",
+		ErrTranscribe:      "Сталася помилка під час транскрипції аудіо: %v",
+		ErrRedact:          "Не вдалося приховати персональні дані, розшифровку не показано: %v",
+		ErrSummarize:       "Сталася помилка під час створення підсумку: %v",
+		ErrResumeFailed:    "Не вдалося обробити запис: бот кілька разів перезапускався під час його обробки. Надішліть його ще раз.",
+	},
+}
+
+// Normalize приводит код языка к виду каталога: "en-US" → "en"
+func Normalize(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code, _, _ = strings.Cut(code, "-")
+	code, _, _ = strings.Cut(code, "_")
+	return code
+}
+
+// Supported сообщает, есть ли тексты на языке code
+func Supported(code string) bool {
+	_, ok := catalog[Normalize(code)]
+	return ok
+}
+
+// Languages возвращает коды языков, на которых есть тексты, по алфавиту
+func Languages() []string {
+	codes := make([]string, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Resolve возвращает первый поддерживаемый язык из candidates или DefaultLanguage
+func Resolve(candidates ...string) string {
+	for _, c := range candidates {
+		if _, ok := catalog[Normalize(c)]; ok {
+			return Normalize(c)
+		}
+	}
+	return DefaultLanguage
+}
+
+// T возвращает текст key на языке lang (или на DefaultLanguage, если перевода нет), подставляя args
+func T(lang, key string, args ...any) string {
+	text, ok := catalog[Normalize(lang)][key]
+	if !ok {
+		text = catalog[DefaultLanguage][key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,30 @@
+// Package leader - распределенные блокировки для выбора ведущей реплики: несколько реплик бота
+// работают одновременно, но long polling ведет только та, что держит блокировку.
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Lock - распределенная блокировка с автоматическим продлением
+type Lock interface {
+	// Acquire ждет, пока блокировка освободится, и захватывает ее. Возвращенный контекст отменяется,
+	// когда блокировка потеряна: ее не удалось продлить или оборвалось соединение с хранилищем
+	Acquire(ctx context.Context) (context.Context, error)
+	// Release освобождает блокировку, чтобы другая реплика могла захватить ее сразу, не дожидаясь срока
+	Release(ctx context.Context) error
+}
+
+// Locks создает блокировки по имени; реплики с одинаковым именем блокировки соревнуются за нее
+type Locks interface {
+	Lock(name string) Lock
+}
+
+// newToken возвращает случайный идентификатор владельца блокировки
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
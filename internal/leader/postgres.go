@@ -0,0 +1,129 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgresLocks - блокировки на advisory locks PostgreSQL. Блокировка принадлежит соединению, поэтому
+// PostgreSQL снимает ее сам, когда реплика умирает и соединение рвется; владелец проверяет соединение
+// каждые interval и считает блокировку потерянной, если оно не отвечает.
+type PostgresLocks struct {
+	db       *sql.DB
+	interval time.Duration
+}
+
+// OpenPostgres подключается к PostgreSQL по DSN для блокировок
+func OpenPostgres(dsn string, interval time.Duration) (*PostgresLocks, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть PostgreSQL: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось подключиться к PostgreSQL: %w", err)
+	}
+	return &PostgresLocks{db: db, interval: interval}, nil
+}
+
+func (p *PostgresLocks) Close() error { return p.db.Close() }
+
+func (p *PostgresLocks) Lock(name string) Lock {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &pgLock{db: p.db, name: name, key: int64(h.Sum64()), interval: p.interval}
+}
+
+type pgLock struct {
+	db       *sql.DB
+	name     string
+	key      int64
+	interval time.Duration
+
+	mu     sync.Mutex
+	conn   *sql.Conn
+	cancel context.CancelFunc
+}
+
+func (l *pgLock) Acquire(ctx context.Context) (context.Context, error) {
+	for {
+		conn, ok, err := l.tryAcquire(ctx)
+		if err != nil {
+			log.Printf("Ошибка захвата блокировки %s в PostgreSQL: %v", l.name, err)
+		}
+		if ok {
+			held, cancel := context.WithCancel(ctx)
+			l.mu.Lock()
+			l.conn, l.cancel = conn, cancel
+			l.mu.Unlock()
+			go l.watch(held, cancel, conn)
+			return held, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.interval):
+		}
+	}
+}
+
+// tryAcquire берет отдельное соединение из пула и пытается захватить на нем блокировку
+func (l *pgLock) tryAcquire(ctx context.Context) (*sql.Conn, bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	return conn, true, nil
+}
+
+// watch проверяет соединение, на котором держится блокировка, пока она не потеряна или не освобождена
+func (l *pgLock) watch(ctx context.Context, cancel context.CancelFunc, conn *sql.Conn) {
+	defer cancel()
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		pingCtx, stop := context.WithTimeout(ctx, l.interval)
+		err := conn.PingContext(pingCtx)
+		stop()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Соединение с блокировкой %s в PostgreSQL потеряно: %v", l.name, err)
+			return
+		}
+	}
+}
+
+func (l *pgLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	conn, cancel := l.conn, l.cancel
+	l.conn, l.cancel = nil, nil
+	l.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	cancel()
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		// Соединение с неснятой блокировкой нельзя возвращать в пул: закрытие соединения снимет ее
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		return fmt.Errorf("не удалось освободить блокировку %s в PostgreSQL: %w", l.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisLockPrefix = "vsu:lock:"
+
+// Продление и снятие блокировки только владельцем: ключ мог истечь и достаться другой реплике
+var (
+	renewScript   = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) end return 0`)
+	releaseScript = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) end return 0`)
+)
+
+// RedisLocks - блокировки на ключах Redis со сроком жизни ttl: владелец продлевает ключ каждую треть
+// срока, а если реплика умерла, ключ истекает и блокировку захватывает другая
+type RedisLocks struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisLocks(client *redis.Client, ttl time.Duration) *RedisLocks {
+	return &RedisLocks{client: client, ttl: ttl}
+}
+
+func (r *RedisLocks) Lock(name string) Lock {
+	return &redisLock{client: r.client, key: redisLockPrefix + name, ttl: r.ttl}
+}
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	token  string
+	cancel context.CancelFunc
+}
+
+func (l *redisLock) Acquire(ctx context.Context) (context.Context, error) {
+	token := newToken()
+	for {
+		ok, err := l.client.SetNX(ctx, l.key, token, l.ttl).Result()
+		if err != nil {
+			log.Printf("Ошибка захвата блокировки %s в Redis: %v", l.key, err)
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.ttl / 3):
+		}
+	}
+	held, cancel := context.WithCancel(ctx)
+	l.mu.Lock()
+	l.token, l.cancel = token, cancel
+	l.mu.Unlock()
+	go l.renew(held, cancel, token)
+	return held, nil
+}
+
+// renew продлевает блокировку, пока она не потеряна. Если Redis недоступен, блокировка считается
+// потерянной чуть раньше истечения ключа, чтобы две реплики не вели long polling одновременно.
+func (l *redisLock) renew(ctx context.Context, cancel context.CancelFunc, token string) {
+	defer cancel()
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := renewScript.Run(ctx, l.client, []string{l.key}, token, l.ttl.Milliseconds()).Int()
+		switch {
+		case err != nil:
+			log.Printf("Ошибка продления блокировки %s в Redis: %v", l.key, err)
+			if time.Since(renewed) >= l.ttl*2/3 {
+				return
+			}
+		case n == 0:
+			log.Printf("Блокировка %s истекла и перешла к другой реплике", l.key)
+			return
+		default:
+			renewed = time.Now()
+		}
+	}
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	token, cancel := l.token, l.cancel
+	l.token, l.cancel = "", nil
+	l.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	if err := releaseScript.Run(ctx, l.client, []string{l.key}, token).Err(); err != nil {
+		return fmt.Errorf("не удалось освободить блокировку %s в Redis: %w", l.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package loadtest прогоняет синтетические задания с образцами аудио через весь конвейер обработки
+// и считает пропускную способность и перцентили задержки. Нужен, чтобы подобрать число одновременных
+// заданий, бюджет памяти и ограничения ffmpeg под конкретную машину.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+)
+
+// Config - параметры прогона
+type Config struct {
+	// Образцы аудио и видео; пусто - сгенерировать синтетическую запись через ffmpeg
+	Fixtures []string
+	// Сколько заданий отправить и сколько из них выполнять одновременно
+	Jobs        int
+	Concurrency int
+	// Длительность синтетической записи, если образцы не заданы
+	FixtureDuration time.Duration
+}
+
+// Report - итоги прогона
+type Report struct {
+	Jobs        int
+	Concurrency int
+	Failed      int
+	// Число ошибок по этапам конвейера
+	Errors  map[string]int
+	Elapsed time.Duration
+	// Задержки успешных заданий, по возрастанию
+	Latencies []time.Duration
+}
+
+var videoExtensions = []string{".mp4", ".mov", ".mkv", ".webm", ".avi"}
+
+// Run отправляет cfg.Jobs заданий в pipe, выполняя не больше cfg.Concurrency одновременно.
+// Образцы используются по кругу; конвейер только читает их, поэтому копировать файлы не нужно.
+func Run(ctx context.Context, pipe *pipeline.Pipeline, cfg Config) (*Report, error) {
+	if cfg.Jobs <= 0 || cfg.Concurrency <= 0 {
+		return nil, errors.New("число заданий и одновременных заданий должно быть больше нуля")
+	}
+	fixtures := cfg.Fixtures
+	if len(fixtures) == 0 {
+		path, err := generateFixture(cfg.FixtureDuration)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(path)
+		fixtures = []string{path}
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < cfg.Jobs; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	report := &Report{Jobs: cfg.Jobs, Concurrency: cfg.Concurrency, Errors: make(map[string]int)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	started := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fixture := fixtures[i%len(fixtures)]
+				jobStarted := time.Now()
+				_, err := pipe.Run(ctx, pipeline.Job{
+					Source:    pipeline.Source{Platform: "loadtest", ChatID: "loadtest", UserID: strconv.Itoa(i), MessageID: strconv.Itoa(i)},
+					InputPath: fixture,
+					IsVideo:   slices.Contains(videoExtensions, strings.ToLower(filepath.Ext(fixture))),
+				}, nil)
+				latency := time.Since(jobStarted)
+				mu.Lock()
+				if err != nil {
+					report.Failed++
+					report.Errors[stageOf(err)]++
+				} else {
+					report.Latencies = append(report.Latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	report.Elapsed = time.Since(started)
+	slices.Sort(report.Latencies)
+	return report, ctx.Err()
+}
+
+func stageOf(err error) string {
+	var stageErr *pipeline.StageError
+	if errors.As(err, &stageErr) {
+		return stageErr.Stage
+	}
+	return "other"
+}
+
+// generateFixture создает синтетическую запись (тон 440 Гц) заданной длительности
+func generateFixture(duration time.Duration) (string, error) {
+	if duration <= 0 {
+		duration = time.Minute
+	}
+	f, err := os.CreateTemp("", "loadtest-*.mp3")
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать файл образца: %w", err)
+	}
+	f.Close()
+	cmd := exec.Command("ffmpeg", "-y", "-f", "lavfi", "-i", fmt.Sprintf("sine=frequency=440:duration=%.0f", duration.Seconds()),
+		"-c:a", "libmp3lame", "-q:a", "5", f.Name())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("не удалось сгенерировать образец ffmpeg: %w, вывод: %s", err, out)
+	}
+	return f.Name(), nil
+}
+
+// Percentile возвращает задержку, которую не превысили p процентов успешных заданий
+func (r *Report) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(r.Latencies)))) - 1
+	return r.Latencies[min(max(i, 0), len(r.Latencies)-1)]
+}
+
+// Throughput возвращает число успешно обработанных заданий в минуту
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(len(r.Latencies)) / r.Elapsed.Minutes()
+}
+
+// Print выводит итоги прогона в w
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Заданий: %d (одновременно %d), успешно: %d, с ошибкой: %d\n", r.Jobs, r.Concurrency, len(r.Latencies), r.Failed)
+	for _, stage := range slices.Sorted(maps.Keys(r.Errors)) {
+		fmt.Fprintf(w, "  ошибок на этапе %s: %d\n", stage, r.Errors[stage])
+	}
+	fmt.Fprintf(w, "Общее время: %s, пропускная способность: %.1f заданий/мин\n", r.Elapsed.Round(time.Millisecond), r.Throughput())
+	if len(r.Latencies) > 0 {
+		fmt.Fprintf(w, "Задержка: p50 %s, p90 %s, p99 %s, макс. %s\n",
+			r.Percentile(50).Round(time.Millisecond), r.Percentile(90).Round(time.Millisecond),
+			r.Percentile(99).Round(time.Millisecond), r.Latencies[len(r.Latencies)-1].Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,101 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var reBatchText = regexp.MustCompile(`(?m)^=== ТЕКСТ (\d+) ===$`)
+
+// NewMockGemini запускает локальный сервер, отвечающий на generateContent как Gemini, но без модели:
+// каждый ответ задерживается на latency и содержит синтетический текст. Адрес сервера передается
+// клиенту genai в HTTPOptions.BaseURL.
+func NewMockGemini(latency time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Contents []struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"contents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var prompt strings.Builder
+		for _, c := range req.Contents {
+			for _, p := range c.Parts {
+				prompt.WriteString(p.Text)
+			}
+		}
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+		text := mockAnswer(prompt.String())
+		resp := map[string]any{
+			"candidates": []any{map[string]any{
+				"content":      map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}},
+				"finishReason": "STOP",
+			}},
+			"usageMetadata": map[string]any{
+				"promptTokenCount":     prompt.Len() / 4,
+				"candidatesTokenCount": len(text) / 4,
+				"totalTokenCount":      (prompt.Len() + len(text)) / 4,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("Ошибка ответа имитации Gemini: %v", err)
+		}
+	}))
+}
+
+// mockAnswer подбирает ответ в формате, который ожидает сервис ai для данного промпта
+func mockAnswer(prompt string) string {
+	switch {
+	case strings.Contains(prompt, "speech=0.8"):
+		return "speech=1 music=0 noise=0"
+	case strings.Contains(prompt, "lang: xx"):
+		return "lang: ru\nСинтетическая расшифровка для нагрузочного теста. Говорящий обсуждает планы на неделю и сроки задач."
+	}
+	if batch := reBatchText.FindAllStringSubmatch(prompt, -1); len(batch) > 0 {
+		var sb strings.Builder
+		for _, m := range batch {
+			fmt.Fprintf(&sb, "=== ОТВЕТ %s ===\nСинтетическое краткое содержание.\n", m[1])
+		}
+		return sb.String()
+	}
+	return "**Синтетическое резюме**\n- Планы на неделю\n- Сроки задач"
+}
+
+// RateLimitedTransport пропускает не больше perSecond запросов в секунду; нужен, чтобы гонять
+// нагрузочный тест на настоящей модели, не упираясь в квоты Gemini
+func RateLimitedTransport(base http.RoundTripper, perSecond float64) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitedTransport{base: base, tick: time.NewTicker(time.Duration(float64(time.Second) / perSecond)).C}
+}
+
+type rateLimitedTransport struct {
+	base http.RoundTripper
+	tick <-chan time.Time
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-t.tick:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	return t.base.RoundTrip(req)
+}
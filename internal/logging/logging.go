@@ -0,0 +1,56 @@
+// Package logging направляет стандартный log в slog с уровнем, который можно менять во время работы.
+// Сообщения log.Printf получают уровень INFO: уровень warn их скрывает и оставляет только предупреждения
+// slog, а debug добавляет подробности slog.Debug (трассировка запросов и отправки).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+var level slog.LevelVar
+
+// Setup подключает обработчик slog и направляет в него стандартный log
+func Setup(name string) {
+	if err := SetLevel(name); err != nil {
+		slog.Warn(err.Error())
+	}
+	slog.SetDefault(slog.New(&handler{Handler: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})}))
+}
+
+// SetLevel меняет уровень журнала: debug, info или warn
+func SetLevel(name string) error {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		level.Set(slog.LevelDebug)
+	case "info", "":
+		level.Set(slog.LevelInfo)
+	case "warn", "warning":
+		level.Set(slog.LevelWarn)
+	default:
+		return fmt.Errorf("неизвестный уровень журнала %q: нужно debug, info или warn", name)
+	}
+	return nil
+}
+
+// Level возвращает текущий уровень журнала
+func Level() string { return strings.ToLower(level.Level().String()) }
+
+type handler struct {
+	slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{Handler: h.Handler.WithGroup(name)}
+}
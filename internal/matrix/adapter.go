@@ -0,0 +1,142 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+)
+
+const (
+	syncTimeout      = 30 * time.Second
+	maxMessageLength = 16000
+)
+
+// Adapter опрашивает /sync и прогоняет голосовые и аудиосообщения из комнат через общий конвейер
+type Adapter struct {
+	client      *Client
+	pipe        *pipeline.Pipeline
+	maxFileSize int64
+	retryDelay  time.Duration
+	userID      string
+}
+
+func NewAdapter(client *Client, pipe *pipeline.Pipeline, maxFileSize int64, retryDelay time.Duration) *Adapter {
+	return &Adapter{client: client, pipe: pipe, maxFileSize: maxFileSize, retryDelay: retryDelay}
+}
+
+// Run блокирует выполнение и обрабатывает события до завершения процесса
+func (a *Adapter) Run() error {
+	userID, err := a.client.WhoAmI()
+	if err != nil {
+		return fmt.Errorf("не удалось определить пользователя Matrix: %w", err)
+	}
+	a.userID = userID
+	// Первичная синхронизация нужна только для получения токена: историю комнат не обрабатываем
+	initial, err := a.client.Sync("", 0)
+	if err != nil {
+		return fmt.Errorf("ошибка первичной синхронизации Matrix: %w", err)
+	}
+	since := initial.NextBatch
+	log.Printf("Matrix: подключен как %s", userID)
+	for {
+		resp, err := a.client.Sync(since, syncTimeout)
+		if err != nil {
+			log.Printf("Matrix: ошибка синхронизации: %v. Повтор через %s.", err, a.retryDelay)
+			<-time.After(a.retryDelay)
+			continue
+		}
+		since = resp.NextBatch
+		for roomID := range resp.Rooms.Invite {
+			if err := a.client.JoinRoom(roomID); err != nil {
+				log.Printf("Matrix: не удалось войти в комнату %s: %v", roomID, err)
+			}
+		}
+		for roomID, room := range resp.Rooms.Join {
+			for _, ev := range room.Timeline.Events {
+				if ev.Type != "m.room.message" || ev.Sender == a.userID {
+					continue
+				}
+				go a.handleEvent(roomID, ev)
+			}
+		}
+	}
+}
+
+func isMediaContent(c MessageContent) bool {
+	switch c.MsgType {
+	case "m.audio", "m.video":
+		return true
+	case "m.file":
+		return strings.HasPrefix(c.Info.Mimetype, "audio/") || strings.HasPrefix(c.Info.Mimetype, "video/")
+	}
+	return false
+}
+
+func (a *Adapter) handleEvent(roomID string, ev Event) {
+	var content MessageContent
+	if err := json.Unmarshal(ev.Content, &content); err != nil || !isMediaContent(content) || content.URL == "" {
+		return
+	}
+	if content.Info.Size > a.maxFileSize {
+		a.send(roomID, ev.EventID, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Ваш файл слишком большой.", a.maxFileSize/(1024*1024)), "")
+		return
+	}
+	log.Printf("Matrix: получено медиа %s в комнате %s", ev.EventID, roomID)
+	data, err := a.client.Download(content.URL)
+	if err != nil {
+		log.Printf("Matrix: ошибка скачивания %s: %v", content.URL, err)
+		a.send(roomID, ev.EventID, fmt.Sprintf("Произошла ошибка при скачивании файла: %v", err), "")
+		return
+	}
+	fileName := content.FileName
+	if fileName == "" {
+		fileName = content.Body
+	}
+	inputPath, err := media.SaveToTemp(data, fileName)
+	if err != nil {
+		a.send(roomID, ev.EventID, fmt.Sprintf("Произошла ошибка при обработке медиафайла: %v", err), "")
+		return
+	}
+	defer os.Remove(inputPath)
+
+	job := pipeline.Job{
+		Source:    pipeline.Source{Platform: "matrix", ChatID: roomID, UserID: ev.Sender, MessageID: ev.EventID},
+		InputPath: inputPath,
+		IsVideo:   content.MsgType == "m.video" || strings.HasPrefix(content.Info.Mimetype, "video/"),
+		Duration:  time.Duration(content.Info.Duration) * time.Millisecond,
+	}
+	res, err := a.pipe.Run(context.Background(), job, func(transcript string) {
+		a.send(roomID, ev.EventID, "Transcription\n\n"+transcript, "<b>Transcription</b><br><br>"+html.EscapeString(transcript))
+	})
+	if err != nil {
+		log.Printf("Matrix: ошибка обработки события %s: %v", ev.EventID, err)
+		a.send(roomID, ev.EventID, fmt.Sprintf("Произошла ошибка при обработке: %v", err), "")
+		return
+	}
+	a.send(roomID, ev.EventID, "Summary\n\n"+format.StripFormatting(res.Summary), "<b>Summary</b><br><br><span data-mx-spoiler>"+format.FormatHTML(res.Summary)+"</span>")
+	log.Printf("Matrix: обработка события %s успешно завершена", ev.EventID)
+}
+
+func (a *Adapter) send(roomID, replyTo, body, formattedBody string) {
+	if len(body) > maxMessageLength || len(formattedBody) > maxMessageLength {
+		// Длинный HTML делить небезопасно, поэтому части уходят простым текстом
+		for _, part := range format.SplitMessage(body, maxMessageLength) {
+			if err := a.client.SendMessage(roomID, part, "", replyTo); err != nil {
+				log.Printf("Matrix: ошибка отправки сообщения в комнату %s: %v", roomID, err)
+			}
+		}
+		return
+	}
+	if err := a.client.SendMessage(roomID, body, strings.ReplaceAll(formattedBody, "\n", "<br>"), replyTo); err != nil {
+		log.Printf("Matrix: ошибка отправки сообщения в комнату %s: %v", roomID, err)
+	}
+}
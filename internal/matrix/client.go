@@ -0,0 +1,124 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Client struct {
+	homeserver  string
+	accessToken string
+	http        *http.Client
+	txnCounter  atomic.Int64
+}
+
+func NewClient(homeserver, accessToken string, httpClient *http.Client) *Client {
+	return &Client{homeserver: strings.TrimRight(homeserver, "/"), accessToken: accessToken, http: httpClient}
+}
+
+func (c *Client) do(method, path string, payload any, out any) error {
+	var body io.Reader
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("ошибка маршалинга payload для %s: %w", path, err)
+		}
+		body = bytes.NewReader(payloadBytes)
+	}
+	req, err := http.NewRequest(method, c.homeserver+path, body)
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса %s: %w", path, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при запросе %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return fmt.Errorf("запрос %s завершился со статусом %s: %s %s", path, resp.Status, errResp.ErrCode, errResp.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ошибка декодирования ответа %s: %w", path, err)
+	}
+	return nil
+}
+
+// WhoAmI возвращает идентификатор пользователя, которому принадлежит токен
+func (c *Client) WhoAmI() (string, error) {
+	var resp whoAmIResponse
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.UserID, nil
+}
+
+// Sync выполняет long-poll запрос /sync начиная с токена since
+func (c *Client) Sync(since string, timeout time.Duration) (*SyncResponse, error) {
+	q := url.Values{}
+	q.Set("timeout", fmt.Sprint(timeout.Milliseconds()))
+	if since != "" {
+		q.Set("since", since)
+	}
+	var resp SyncResponse
+	if err := c.do(http.MethodGet, "/_matrix/client/v3/sync?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) JoinRoom(roomID string) error {
+	return c.do(http.MethodPost, "/_matrix/client/v3/join/"+url.PathEscape(roomID), struct{}{}, nil)
+}
+
+// Download скачивает медиа по mxc:// URI через аутентифицированный media API
+func (c *Client) Download(mxcURI string) ([]byte, error) {
+	serverAndID, ok := strings.CutPrefix(mxcURI, "mxc://")
+	if !ok {
+		return nil, fmt.Errorf("некорректный mxc URI: %s", mxcURI)
+	}
+	req, err := http.NewRequest(http.MethodGet, c.homeserver+"/_matrix/client/v1/media/download/"+serverAndID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса на скачивание файла: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при скачивании файла: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("не удалось скачать файл, статус: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// SendMessage отправляет m.notice в комнату; formattedBody (HTML) и replyTo необязательны
+func (c *Client) SendMessage(roomID, body, formattedBody, replyTo string) error {
+	msg := outgoingMessage{MsgType: "m.notice", Body: body}
+	if formattedBody != "" {
+		msg.Format, msg.FormattedBody = "org.matrix.custom.html", formattedBody
+	}
+	if replyTo != "" {
+		msg.RelatesTo = &relatesTo{}
+		msg.RelatesTo.InReplyTo.EventID = replyTo
+	}
+	txnID := fmt.Sprintf("vsu-%d-%d", time.Now().UnixNano(), c.txnCounter.Add(1))
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), txnID)
+	return c.do(http.MethodPut, path, msg, nil)
+}
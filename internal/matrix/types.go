@@ -0,0 +1,61 @@
+package matrix
+
+import "encoding/json"
+
+type SyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join   map[string]JoinedRoom `json:"join"`
+		Invite map[string]struct{}   `json:"invite"`
+	} `json:"rooms"`
+}
+
+type JoinedRoom struct {
+	Timeline struct {
+		Events []Event `json:"events"`
+	} `json:"timeline"`
+}
+
+type Event struct {
+	Type    string          `json:"type"`
+	EventID string          `json:"event_id"`
+	Sender  string          `json:"sender"`
+	Content json.RawMessage `json:"content"`
+}
+
+type MessageContent struct {
+	MsgType  string    `json:"msgtype"`
+	Body     string    `json:"body"`
+	FileName string    `json:"filename"`
+	URL      string    `json:"url"`
+	Info     MediaInfo `json:"info"`
+}
+
+type MediaInfo struct {
+	Mimetype string `json:"mimetype"`
+	Size     int64  `json:"size"`
+	Duration int64  `json:"duration"` // миллисекунды
+}
+
+type outgoingMessage struct {
+	MsgType       string     `json:"msgtype"`
+	Body          string     `json:"body"`
+	Format        string     `json:"format,omitempty"`
+	FormattedBody string     `json:"formatted_body,omitempty"`
+	RelatesTo     *relatesTo `json:"m.relates_to,omitempty"`
+}
+
+type relatesTo struct {
+	InReplyTo struct {
+		EventID string `json:"event_id"`
+	} `json:"m.in_reply_to"`
+}
+
+type whoAmIResponse struct {
+	UserID string `json:"user_id"`
+}
+
+type errorResponse struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
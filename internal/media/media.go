@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,6 +33,24 @@ func (p *Processor) runFFmpeg(args ...string) error {
 	return nil
 }
 
+// Duration определяет длительность медиафайла через ffprobe
+func Duration(path string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка выполнения ffprobe: %w, вывод: %s", err, stderr.String())
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe не определил длительность %q: %w", strings.TrimSpace(string(out)), err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
 func (p *Processor) convertToMp3(inputPath, outputPath string) error {
 	return p.runFFmpeg("-y", "-i", inputPath, "-c:a", "libmp3lame", "-q:a", "3", "-ac", "1", "-ar", "22050", outputPath)
 }
@@ -39,62 +59,154 @@ func (p *Processor) extractAudioFromVideo(inputPath, outputPath string) error {
 	return p.runFFmpeg("-y", "-i", inputPath, "-vn", "-acodec", "libmp3lame", "-q:a", "2", outputPath)
 }
 
-// SaveAndProcessMedia сохраняет файл из Telegram и конвертирует его в mp3, возвращая путь к временному mp3
-func (p *Processor) SaveAndProcessMedia(msg *telegram.Message, api *telegram.Client) (string, error) {
+// Convert приводит входной файл к mono mp3 для распознавания и возвращает путь к временному mp3
+func (p *Processor) Convert(inputPath string, isVideo bool) (string, error) {
+	tempOutputFile, err := os.CreateTemp("", "output-*.mp3")
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать временный выходной файл: %w", err)
+	}
+	tempOutputFile.Close()
+	log.Printf("Конвертация файла: %s -> %s", inputPath, tempOutputFile.Name())
+	if isVideo {
+		err = p.extractAudioFromVideo(inputPath, tempOutputFile.Name())
+	} else {
+		err = p.convertToMp3(inputPath, tempOutputFile.Name())
+	}
+	if err != nil {
+		os.Remove(tempOutputFile.Name())
+		return "", fmt.Errorf("ошибка конвертации медиа: %w", err)
+	}
+	log.Printf("Файл успешно сконвертирован в MP3: %s", tempOutputFile.Name())
+	return tempOutputFile.Name(), nil
+}
+
+// speechExtensions - расширения файлов Ogg/Opus (голосовые Telegram, WhatsApp, Signal): кодек уже
+// рассчитан на речь, поэтому такие файлы распознаются как есть, без перекодирования в mp3
+var speechExtensions = map[string]bool{".oga": true, ".ogg": true, ".opus": true}
+
+// PassThrough сообщает, можно ли отправить аудиофайл на распознавание без конвертации
+func PassThrough(fileName string) bool {
+	return speechExtensions[strings.ToLower(filepath.Ext(fileName))]
+}
+
+// AudioMIME возвращает MIME-тип подготовленного для распознавания файла: Ogg для файлов без
+// конвертации, иначе mp3
+func AudioMIME(path string) string {
+	if PassThrough(path) {
+		return "audio/ogg"
+	}
+	return "audio/mpeg"
+}
+
+// SaveToTemp записывает содержимое во временный файл с расширением исходного имени
+func SaveToTemp(content []byte, originalFileName string) (string, error) {
+	tempInputFile, err := os.CreateTemp("", "input-*"+filepath.Ext(originalFileName))
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать временный входной файл: %w", err)
+	}
+	defer tempInputFile.Close()
+	if _, err := tempInputFile.Write(content); err != nil {
+		os.Remove(tempInputFile.Name())
+		return "", fmt.Errorf("не удалось записать во временный входной файл: %w", err)
+	}
+	return tempInputFile.Name(), nil
+}
+
+// SaveTelegramMedia скачивает медиа из сообщения Telegram во временный файл и одновременно конвертирует
+// скачиваемый поток в mp3: ffmpeg работает, пока файл еще качается. Возвращает пути к исходному файлу и
+// к mp3 и признак видео. Если поток сконвертировать не удалось (например, у mp4 индекс в конце файла),
+// mp3 собирается из скачанного файла обычным Convert.
+// Ogg/Opus (PassThrough) не конвертируется: оба пути указывают на скачанный файл.
+// Отдельной загрузки в Gemini нет: звук уходит в запросе распознавания целиком (файлы до MaxFileSize
+// помещаются в запрос), поэтому с ней совмещаются только скачивание и конвертация.
+func (p *Processor) SaveTelegramMedia(msg *telegram.Message, api *telegram.Client) (inputPath, audioPath string, isVideo bool, err error) {
 	var fileID, originalFileName string
-	var isVideo bool
 	switch {
 	case msg.Voice != nil:
 		fileID, originalFileName = msg.Voice.FileID, "voice.oga"
 	case msg.Audio != nil:
-		fileID, originalFileName = msg.Audio.FileID, msg.Audio.FileName
+		fileID, originalFileName = msg.Audio.FileID, fileNameOrMime(msg.Audio.FileName, msg.Audio.MimeType)
 	case msg.Video != nil:
 		fileID, originalFileName, isVideo = msg.Video.FileID, msg.Video.FileName, true
 	case msg.VideoNote != nil:
 		fileID, originalFileName, isVideo = msg.VideoNote.FileID, "video_note.mp4", true
 	case msg.Document != nil:
-		fileID, originalFileName = msg.Document.FileID, msg.Document.FileName
+		fileID, originalFileName = msg.Document.FileID, fileNameOrMime(msg.Document.FileName, msg.Document.MimeType)
+		isVideo = strings.HasPrefix(strings.ToLower(msg.Document.MimeType), "video/")
 	default:
-		return "", fmt.Errorf("сообщение не содержит поддерживаемого медиафайла")
+		return "", "", false, fmt.Errorf("сообщение не содержит поддерживаемого медиафайла")
 	}
 
 	log.Printf("Получение информации о файле ID: %s", fileID)
 	fileInfo, err := api.GetFile(fileID)
 	if err != nil {
-		return "", err
+		return "", "", false, err
 	}
-	log.Printf("Скачивание файла: %s", fileInfo.FilePath)
-	fileContent, err := api.DownloadFile(fileInfo.FilePath)
-	if err != nil {
-		return "", err
+	// У пересланных из каналов и от ботов файлов имени часто нет: тогда расширение берется из пути в Telegram
+	if filepath.Ext(originalFileName) == "" {
+		originalFileName += filepath.Ext(fileInfo.FilePath)
 	}
-	tempInputFile, err := os.CreateTemp("", "input-*"+filepath.Ext(originalFileName))
+	inputFile, err := os.CreateTemp("", "input-*"+filepath.Ext(originalFileName))
 	if err != nil {
-		return "", fmt.Errorf("не удалось создать временный входной файл: %w", err)
+		return "", "", false, fmt.Errorf("не удалось создать временный входной файл: %w", err)
 	}
-	defer os.Remove(tempInputFile.Name())
-	defer tempInputFile.Close()
-	if _, err := tempInputFile.Write(fileContent); err != nil {
-		return "", fmt.Errorf("не удалось записать во временный входной файл: %w", err)
-	}
-	tempInputFile.Close()
-	tempOutputFile, err := os.CreateTemp("", "output-*.mp3")
-	if err != nil {
-		return "", fmt.Errorf("не удалось создать временный выходной файл: %w", err)
+	inputPath = inputFile.Name()
+
+	log.Printf("Скачивание файла: %s", fileInfo.FilePath)
+	if !isVideo && PassThrough(originalFileName) {
+		err = api.DownloadTo(fileInfo, inputFile)
+		if closeErr := inputFile.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("не удалось записать во временный входной файл: %w", closeErr)
+		}
+		if err != nil {
+			os.Remove(inputPath)
+			return "", "", false, err
+		}
+		// Исходный файл и есть звук для распознавания
+		return inputPath, inputPath, false, nil
 	}
-	tempOutputFile.Close()
-	log.Printf("Конвертация файла: %s -> %s", tempInputFile.Name(), tempOutputFile.Name())
-	if isVideo {
-		err = p.extractAudioFromVideo(tempInputFile.Name(), tempOutputFile.Name())
-	} else {
-		err = p.convertToMp3(tempInputFile.Name(), tempOutputFile.Name())
+	stream := p.startStreamConvert(isVideo)
+	err = api.DownloadTo(fileInfo, io.MultiWriter(inputFile, stream))
+	if closeErr := inputFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("не удалось записать во временный входной файл: %w", closeErr)
 	}
+	audioPath, streamErr := stream.finish(err)
 	if err != nil {
-		os.Remove(tempOutputFile.Name())
-		return "", fmt.Errorf("ошибка конвертации медиа: %w", err)
+		os.Remove(inputPath)
+		return "", "", false, err
 	}
-	log.Printf("Файл успешно сконвертирован в MP3: %s", tempOutputFile.Name())
-	return tempOutputFile.Name(), nil
+	if streamErr != nil {
+		log.Printf("Потоковая конвертация не удалась, конвертирую скачанный файл: %v", streamErr)
+		if audioPath, err = p.Convert(inputPath, isVideo); err != nil {
+			os.Remove(inputPath)
+			return "", "", false, err
+		}
+	}
+	return inputPath, audioPath, isVideo, nil
 }
 
+// audioExtensions - расширения файлов по MIME-типу для медиа без имени файла (в том числе видео,
+// которые боты-мосты присылают документами)
+var audioExtensions = map[string]string{
+	"audio/ogg":    ".oga",
+	"audio/opus":   ".opus",
+	"audio/mpeg":   ".mp3",
+	"audio/mp3":    ".mp3",
+	"audio/mp4":    ".m4a",
+	"audio/x-m4a":  ".m4a",
+	"audio/aac":    ".aac",
+	"audio/wav":    ".wav",
+	"audio/x-wav":  ".wav",
+	"audio/flac":   ".flac",
+	"audio/x-flac": ".flac",
+	"video/mp4":    ".mp4",
+	"video/webm":   ".webm",
+}
 
+// fileNameOrMime возвращает имя файла, а если его нет - имя с расширением по MIME-типу
+func fileNameOrMime(name, mimeType string) string {
+	if name != "" {
+		return name
+	}
+	return "audio" + audioExtensions[strings.ToLower(mimeType)]
+}
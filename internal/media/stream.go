@@ -0,0 +1,91 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Потоковая конвертация идет, пока файл качается, поэтому ей нужно больше времени, чем runFFmpeg
+const streamConvertTimeout = 10 * time.Minute
+
+// streamConverter принимает скачиваемые данные и передает их ffmpeg через stdin. Если ffmpeg
+// завершился раньше (не смог разобрать поток), дальнейшие данные молча отбрасываются, чтобы не
+// мешать скачиванию в файл.
+type streamConverter struct {
+	pw         *io.PipeWriter
+	outputPath string
+	done       chan error
+	failed     bool
+}
+
+// startStreamConvert запускает ffmpeg, читающий исходный файл из потока и пишущий mp3 во временный файл
+func (p *Processor) startStreamConvert(isVideo bool) *streamConverter {
+	pr, pw := io.Pipe()
+	sc := &streamConverter{pw: pw, done: make(chan error, 1)}
+	outputFile, err := os.CreateTemp("", "output-*.mp3")
+	if err != nil {
+		sc.failed = true
+		sc.done <- fmt.Errorf("не удалось создать временный выходной файл: %w", err)
+		pr.Close()
+		return sc
+	}
+	outputFile.Close()
+	sc.outputPath = outputFile.Name()
+	args := []string{"-y", "-i", "pipe:0", "-c:a", "libmp3lame", "-q:a", "3", "-ac", "1", "-ar", "22050", sc.outputPath}
+	if isVideo {
+		args = []string{"-y", "-i", "pipe:0", "-vn", "-acodec", "libmp3lame", "-q:a", "2", sc.outputPath}
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), streamConvertTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+		cmd.Stdin = pr
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		// Разблокирует запись в pw, если ffmpeg перестал читать поток раньше времени
+		pr.CloseWithError(io.ErrClosedPipe)
+		if err != nil {
+			err = fmt.Errorf("ошибка выполнения ffmpeg: %w, вывод: %s", err, stderr.String())
+		}
+		sc.done <- err
+	}()
+	return sc
+}
+
+func (sc *streamConverter) Write(b []byte) (int, error) {
+	if !sc.failed {
+		if _, err := sc.pw.Write(b); err != nil {
+			sc.failed = true
+		}
+	}
+	return len(b), nil
+}
+
+// finish закрывает поток (с ошибкой скачивания downloadErr, если она есть), дожидается ffmpeg и
+// возвращает путь к mp3; при ошибке временный mp3 удаляется
+func (sc *streamConverter) finish(downloadErr error) (string, error) {
+	if downloadErr != nil {
+		sc.pw.CloseWithError(downloadErr)
+	} else {
+		sc.pw.Close()
+	}
+	err := <-sc.done
+	if err == nil && downloadErr != nil {
+		err = downloadErr
+	}
+	if err != nil {
+		if sc.outputPath != "" {
+			os.Remove(sc.outputPath)
+		}
+		return "", err
+	}
+	log.Printf("Файл сконвертирован в MP3 во время скачивания: %s", sc.outputPath)
+	return sc.outputPath, nil
+}
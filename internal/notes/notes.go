@@ -0,0 +1,89 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+)
+
+// Виды назначений для синхронизации заметок
+const (
+	KindNotion = "notion"
+	KindVault  = "vault"
+)
+
+// Note - одна заметка с резюме, независимая от конкретного назначения
+type Note struct {
+	Title      string
+	ChatID     string
+	MessageID  string
+	CreatedAt  time.Time
+	Duration   time.Duration
+	Summary    string
+	Transcript string
+}
+
+// Writer сохраняет заметку в назначение target (страница Notion, папка хранилища)
+type Writer interface {
+	Write(ctx context.Context, target string, note Note) error
+}
+
+// Syncer - приемник результатов конвейера, дублирующий резюме в настроенное для чата назначение
+type Syncer struct {
+	settings store.SettingsStore
+	writers  map[string]Writer
+}
+
+func NewSyncer(settings store.SettingsStore) *Syncer {
+	return &Syncer{settings: settings, writers: make(map[string]Writer)}
+}
+
+// Register подключает Writer для вида назначения kind
+func (s *Syncer) Register(kind string, w Writer) { s.writers[kind] = w }
+
+// Supports сообщает, настроен ли на уровне бота указанный вид назначения
+func (s *Syncer) Supports(kind string) bool {
+	_, ok := s.writers[kind]
+	return ok
+}
+
+func (s *Syncer) Deliver(ctx context.Context, res *pipeline.Result) error {
+	src := res.Job.Source
+	settings, err := s.settings.GetSettings(ctx, src.Platform, src.ChatID)
+	if err != nil {
+		return err
+	}
+	if settings.Notes == nil {
+		return nil
+	}
+	w, ok := s.writers[settings.Notes.Kind]
+	if !ok {
+		return fmt.Errorf("назначение заметок %q не настроено", settings.Notes.Kind)
+	}
+	target := settings.Notes.Target
+	if settings.Notes.Kind == KindVault {
+		target = chatFolder(src.ChatID) + "/" + target
+	}
+	now := time.Now()
+	return w.Write(ctx, target, Note{
+		Title:      noteTitle(res.Summary, now),
+		ChatID:     src.ChatID,
+		MessageID:  src.MessageID,
+		CreatedAt:  now,
+		Duration:   res.Job.Duration,
+		Summary:    res.Summary,
+		Transcript: res.Transcript,
+	})
+}
+
+// noteTitle строит заголовок из даты и первой содержательной строки резюме
+func noteTitle(summary string, at time.Time) string {
+	if line := format.FirstLine(summary, 80); line != "" {
+		return at.Format("2006-01-02 15:04") + " " + line
+	}
+	return at.Format("2006-01-02 15:04") + " Голосовое сообщение"
+}
@@ -0,0 +1,126 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	notionAPIURL  = "https://api.notion.com/v1/pages"
+	notionVersion = "2022-06-28"
+	// Ограничения Notion API на длину текста в блоке и число блоков в запросе
+	notionMaxText   = 2000
+	notionMaxBlocks = 100
+)
+
+// NotionWriter создает дочернюю страницу с резюме под страницей, указанной в настройках чата
+type NotionWriter struct {
+	token string
+	http  *http.Client
+}
+
+func NewNotionWriter(token string, httpClient *http.Client) *NotionWriter {
+	return &NotionWriter{token: token, http: httpClient}
+}
+
+type notionText struct {
+	Type string `json:"type"`
+	Text struct {
+		Content string `json:"content"`
+	} `json:"text"`
+}
+
+type notionBlock struct {
+	Object    string `json:"object"`
+	Type      string `json:"type"`
+	Paragraph *struct {
+		RichText []notionText `json:"rich_text"`
+	} `json:"paragraph,omitempty"`
+	Heading2 *struct {
+		RichText []notionText `json:"rich_text"`
+	} `json:"heading_2,omitempty"`
+}
+
+func richText(s string) []notionText {
+	var t notionText
+	t.Type = "text"
+	t.Text.Content = s
+	return []notionText{t}
+}
+
+func paragraphBlock(s string) notionBlock {
+	b := notionBlock{Object: "block", Type: "paragraph"}
+	b.Paragraph = &struct {
+		RichText []notionText `json:"rich_text"`
+	}{RichText: richText(s)}
+	return b
+}
+
+func headingBlock(s string) notionBlock {
+	b := notionBlock{Object: "block", Type: "heading_2"}
+	b.Heading2 = &struct {
+		RichText []notionText `json:"rich_text"`
+	}{RichText: richText(s)}
+	return b
+}
+
+// textBlocks режет текст на абзацы, укладывающиеся в ограничение Notion на длину блока
+func textBlocks(text string) []notionBlock {
+	var blocks []notionBlock
+	for _, para := range strings.Split(text, "\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		r := []rune(para)
+		for len(r) > notionMaxText {
+			blocks = append(blocks, paragraphBlock(string(r[:notionMaxText])))
+			r = r[notionMaxText:]
+		}
+		blocks = append(blocks, paragraphBlock(string(r)))
+	}
+	return blocks
+}
+
+func (w *NotionWriter) Write(ctx context.Context, target string, note Note) error {
+	blocks := []notionBlock{headingBlock("Резюме")}
+	blocks = append(blocks, textBlocks(note.Summary)...)
+	blocks = append(blocks, headingBlock("Транскрипция"))
+	blocks = append(blocks, textBlocks(note.Transcript)...)
+	if len(blocks) > notionMaxBlocks {
+		blocks = blocks[:notionMaxBlocks]
+	}
+	payload := map[string]any{
+		"parent": map[string]string{"page_id": target},
+		"properties": map[string]any{
+			"title": map[string]any{"title": richText(note.Title)},
+		},
+		"children": blocks,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга страницы Notion: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notionAPIURL, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса к Notion: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+w.token)
+	req.Header.Set("Notion-Version", notionVersion)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при запросе к Notion: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("Notion вернул статус %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[\\/:*?"<>|\x00-\x1f]+`)
+
+// SanitizeFolder проверяет папку назначения из настроек чата: только относительный путь без ".."
+func SanitizeFolder(folder string) (string, error) {
+	folder = strings.Trim(strings.TrimSpace(folder), "/")
+	if folder == "" {
+		return "", fmt.Errorf("папка не указана")
+	}
+	for _, part := range strings.Split(folder, "/") {
+		if part == "" || part == "." || part == ".." || unsafeFileChars.MatchString(part) {
+			return "", fmt.Errorf("недопустимое имя папки %q", folder)
+		}
+	}
+	return folder, nil
+}
+
+// chatFolder - папка чата в хранилище: папка из /notes задается внутри нее, поэтому чаты с общим
+// хранилищем не могут писать в заметки друг друга
+func chatFolder(chatID string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(chatID, "_"), ". ")
+}
+
+// RenderMarkdown формирует markdown-заметку с front matter, совместимую с Obsidian
+func RenderMarkdown(note Note) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\ncreated: %s\nchat: %q\nmessage: %q\n", note.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), note.ChatID, note.MessageID)
+	if note.Duration > 0 {
+		fmt.Fprintf(&b, "duration: %d\n", int(note.Duration.Seconds()))
+	}
+	b.WriteString("tags: [voice]\n---\n\n")
+	b.WriteString(strings.TrimSpace(note.Summary))
+	b.WriteString("\n\n> [!quote]- Транскрипция\n")
+	for _, line := range strings.Split(strings.TrimSpace(note.Transcript), "\n") {
+		b.WriteString("> " + line + "\n")
+	}
+	return b.Bytes()
+}
+
+// noteFileName строит имя файла из заголовка, чата и сообщения: заголовок точен до минуты, и записи
+// одного чата (или разных чатов с общей папкой) с одинаковым началом резюме иначе перезапишут друг друга
+func noteFileName(note Note) string {
+	name := note.Title + " " + note.ChatID + "-" + note.MessageID
+	return strings.TrimSpace(unsafeFileChars.ReplaceAllString(name, " ")) + ".md"
+}
+
+// DirWriter пишет заметки в локальную папку хранилища (например, git-копию Obsidian vault)
+type DirWriter struct {
+	root string
+}
+
+func NewDirWriter(root string) *DirWriter { return &DirWriter{root: root} }
+
+func (w *DirWriter) Write(_ context.Context, target string, note Note) error {
+	folder, err := SanitizeFolder(target)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(w.root, filepath.FromSlash(folder))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("не удалось создать папку заметок: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, noteFileName(note)), RenderMarkdown(note), 0o644); err != nil {
+		return fmt.Errorf("не удалось записать заметку: %w", err)
+	}
+	return nil
+}
+
+// WebDAVWriter загружает заметки в хранилище по WebDAV (Nextcloud, remotely-save и т.п.)
+type WebDAVWriter struct {
+	baseURL  string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func NewWebDAVWriter(baseURL, user, password string, httpClient *http.Client) *WebDAVWriter {
+	return &WebDAVWriter{baseURL: strings.TrimRight(baseURL, "/"), user: user, password: password, http: httpClient}
+}
+
+func (w *WebDAVWriter) do(ctx context.Context, method, p string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.baseURL+p, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания запроса WebDAV: %w", err)
+	}
+	if w.user != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запроса WebDAV %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func (w *WebDAVWriter) Write(ctx context.Context, target string, note Note) error {
+	folder, err := SanitizeFolder(target)
+	if err != nil {
+		return err
+	}
+	// Создаем папки по одной: MKCOL не создает промежуточные коллекции. 405 - папка уже существует.
+	dir := ""
+	for _, part := range strings.Split(folder, "/") {
+		dir = path.Join(dir, url.PathEscape(part))
+		status, err := w.do(ctx, "MKCOL", "/"+dir, nil)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusCreated && status != http.StatusMethodNotAllowed {
+			return fmt.Errorf("не удалось создать папку %s по WebDAV, статус %d", dir, status)
+		}
+	}
+	status, err := w.do(ctx, http.MethodPut, "/"+dir+"/"+url.PathEscape(noteFileName(note)), RenderMarkdown(note))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated && status != http.StatusNoContent && status != http.StatusOK {
+		return fmt.Errorf("не удалось загрузить заметку по WebDAV, статус %d", status)
+	}
+	return nil
+}
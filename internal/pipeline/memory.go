@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Во время распознавания запись держится в памяти несколько раз: прочитанный файл, base64 в теле
+// запроса к Gemini и буфер HTTP-клиента
+const audioMemoryFactor = 3
+
+// memoryBudget ограничивает суммарную память, которую одновременно занимают записи заданий.
+// Задание, которому не хватает бюджета, ждет, пока другие освободят память; задание больше
+// всего бюджета выполняется, когда других заданий нет.
+type memoryBudget struct {
+	mu    sync.Mutex
+	limit int64
+	used  int64
+	freed chan struct{} // закрывается при каждом освобождении памяти
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	return &memoryBudget{limit: limit, freed: make(chan struct{})}
+}
+
+// acquire резервирует n байт, дожидаясь освобождения памяти; возвращает функцию, освобождающую резерв
+func (b *memoryBudget) acquire(ctx context.Context, n int64) (func(), error) {
+	n = min(n, b.limit)
+	logged := false
+	for {
+		b.mu.Lock()
+		if b.used+n <= b.limit {
+			b.used += n
+			b.mu.Unlock()
+			return func() { b.release(n) }, nil
+		}
+		freed := b.freed
+		if !logged {
+			log.Printf("Бюджет памяти исчерпан (занято %d из %d байт, нужно %d), задание ждет очереди", b.used, b.limit, n)
+			logged = true
+		}
+		b.mu.Unlock()
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (b *memoryBudget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	close(b.freed)
+	b.freed = make(chan struct{})
+}
@@ -0,0 +1,424 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+)
+
+// Этапы обработки, на которых может произойти ошибка
+const (
+	StageConvert    = "convert"
+	StageClassify   = "classify"
+	StageTranscribe = "transcribe"
+	StageRedact     = "redact"
+	StageSummarize  = "summarize"
+	StageVerify     = "verify"
+)
+
+// ErrEmptyTranscript возвращается, если в аудио не удалось распознать речь
+var ErrEmptyTranscript = errors.New("не удалось распознать речь в аудио")
+
+// ErrMusic и ErrNoise возвращаются на этапе классификации, если речи в записи меньше порога
+var (
+	ErrMusic = errors.New("похоже, это музыка")
+	ErrNoise = errors.New("похоже, в записи нет речи")
+)
+
+// Source описывает происхождение медиа независимо от платформы
+type Source struct {
+	Platform  string
+	ChatID    string
+	UserID    string
+	MessageID string
+}
+
+type Job struct {
+	Source    Source
+	InputPath string
+	IsVideo   bool
+	// Уже сконвертированный mp3 (например, сконвертированный во время скачивания) или Ogg/Opus без
+	// конвертации; если задан, конвертация пропускается, а удаляет файл тот, кто создал задание
+	AudioPath string
+	Duration  time.Duration
+	// Язык резюме (ISO 639-1); пусто - язык из системного промпта
+	Language string
+	// Готовая расшифровка (например, полученная через Transcribe); если задана, распознавание пропускается
+	Transcript string
+	// Скрыть персональные данные в расшифровке до показа, суммирования и сохранения
+	Redact bool
+}
+
+type Result struct {
+	Job        Job
+	Transcript string
+	Summary    string
+	Usage      ai.Usage
+	Latency    time.Duration
+	// Ключи архивных копий исходного и сконвертированного файлов (если архивирование включено)
+	OriginalKey string
+	AudioKey    string
+	// Язык записи, определенный при транскрипции (ISO 639-1); пусто, если не определен
+	Language string
+}
+
+// Sink получает каждый успешно обработанный результат (вебхуки, хранилище и т.п.)
+type Sink interface {
+	Deliver(ctx context.Context, res *Result) error
+}
+
+// Archiver сохраняет исходный и сконвертированный файлы во внешнее хранилище и возвращает их ключи
+type Archiver interface {
+	Archive(ctx context.Context, src Source, inputPath, audioPath string) (originalKey, audioKey string, err error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// StageError оборачивает ошибку с указанием этапа, на котором она возникла
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return fmt.Sprintf("%s: %v", e.Stage, e.Err) }
+func (e *StageError) Unwrap() error { return e.Err }
+
+type Pipeline struct {
+	ai                 *ai.Service
+	media              *media.Processor
+	userPromptTemplate string
+	sinks              []Sink
+	archiver           Archiver
+	speechThreshold    float64
+	memory             *memoryBudget
+	verifyPrompt       string
+	refine             bool
+	punctuate          bool
+	timestamps         bool
+	chaptersMin        time.Duration
+}
+
+func New(aiSvc *ai.Service, mediaProc *media.Processor, userPromptTemplate string) *Pipeline {
+	return &Pipeline{ai: aiSvc, media: mediaProc, userPromptTemplate: userPromptTemplate}
+}
+
+// AddSink подключает получателя результатов. Вызывать до начала обработки.
+func (p *Pipeline) AddSink(s Sink) { p.sinks = append(p.sinks, s) }
+
+// SetArchiver включает архивирование файлов. Вызывать до начала обработки.
+func (p *Pipeline) SetArchiver(a Archiver) { p.archiver = a }
+
+// SetSpeechThreshold включает классификацию записи перед транскрипцией: если доля речи по оценке
+// модели меньше threshold, обработка прерывается с ErrMusic или ErrNoise; 0 - не классифицировать.
+// Вызывать до начала обработки.
+func (p *Pipeline) SetSpeechThreshold(threshold float64) { p.speechThreshold = threshold }
+
+// SetVerifyPrompt включает сверку резюме с расшифровкой: модель исправляет или помечает утверждения,
+// которых нет в записи; пустая строка - не сверять. Вызывать до начала обработки.
+func (p *Pipeline) SetVerifyPrompt(prompt string) { p.verifyPrompt = prompt }
+
+// SetRefineTranscript включает второй проход распознавания: запись отправляется модели еще раз вместе
+// с черновой расшифровкой, чтобы исправить имена, числа и термины. Вызывать до начала обработки.
+func (p *Pipeline) SetRefineTranscript(on bool) { p.refine = on }
+
+// SetPunctuateTranscript включает восстановление пунктуации и абзацев в расшифровке отдельным запросом
+// к модели; без него расшифровка делится на абзацы правилами (format.Paragraphs). Вызывать до начала обработки.
+func (p *Pipeline) SetPunctuateTranscript(on bool) { p.punctuate = on }
+
+// SetTimestamps включает ссылки резюме на расшифровку: если абзацы расшифровки начинаются с меток
+// времени, к резюме добавляется список пунктов с моментами записи, где о них говорится.
+// Вызывать до начала обработки.
+func (p *Pipeline) SetTimestamps(on bool) { p.timestamps = on }
+
+// SetChapters включает оглавление для записей не короче minDuration: если абзацы расшифровки начинаются
+// с меток времени, к резюме добавляется список глав; 0 - без оглавления. Вызывать до начала обработки.
+func (p *Pipeline) SetChapters(minDuration time.Duration) { p.chaptersMin = minDuration }
+
+// SetMemoryBudget ограничивает память (в байтах), которую одновременно занимают распознаваемые записи;
+// задания сверх бюджета ждут очереди. Скачанные файлы при этом лежат на диске. Вызывать до начала обработки.
+func (p *Pipeline) SetMemoryBudget(bytes int64) {
+	if bytes > 0 {
+		p.memory = newMemoryBudget(bytes)
+	}
+}
+
+// DeleteArchived удаляет архивные копии по ключам; без архива ничего не делает
+func (p *Pipeline) DeleteArchived(ctx context.Context, keys ...string) error {
+	if p.archiver == nil || len(keys) == 0 {
+		return nil
+	}
+	return p.archiver.Delete(ctx, keys...)
+}
+
+// Run конвертирует исходный файл, транскрибирует и суммирует его.
+// onTranscript (если задан) вызывается сразу после транскрипции, до суммирования.
+// При ошибке суммирования Result всё равно возвращается с заполненной транскрипцией.
+// Задание без InputPath (например, длинный текст) сразу суммирует job.Transcript.
+func (p *Pipeline) Run(ctx context.Context, job Job, onTranscript func(string)) (*Result, error) {
+	started := time.Now()
+	ctx = ai.WithUsage(ai.WithChat(ctx, job.Source.Platform, job.Source.ChatID))
+	audioPath := job.AudioPath
+	var err error
+	if audioPath == "" && job.InputPath != "" && !job.IsVideo && media.PassThrough(job.InputPath) {
+		audioPath = job.InputPath
+	}
+	if audioPath == "" && job.InputPath != "" {
+		stageStarted := time.Now()
+		if audioPath, err = p.media.Convert(job.InputPath, job.IsVideo); err != nil {
+			return nil, &StageError{Stage: StageConvert, Err: err}
+		}
+		defer os.Remove(audioPath)
+		traceStage(ctx, job, StageConvert, stageStarted)
+	}
+
+	// Архивирование идет параллельно с распознаванием; файлы удаляются только после его завершения
+	var originalKey, audioKey string
+	archived := make(chan struct{})
+	if p.archiver != nil && audioPath != "" {
+		go func() {
+			defer close(archived)
+			var err error
+			originalKey, audioKey, err = p.archiver.Archive(ctx, job.Source, job.InputPath, audioPath)
+			if err != nil {
+				log.Printf("Ошибка архивирования файлов сообщения %s: %v", job.Source.MessageID, err)
+			}
+		}()
+	} else {
+		close(archived)
+	}
+	defer func() { <-archived }()
+
+	transcript, language := job.Transcript, ""
+	if transcript == "" {
+		if audioPath == "" {
+			return nil, &StageError{Stage: StageTranscribe, Err: ErrEmptyTranscript}
+		}
+		stageStarted := time.Now()
+		transcript, language, err = p.transcribe(ctx, audioPath)
+		if err != nil {
+			return nil, err
+		}
+		traceStage(ctx, job, StageTranscribe, stageStarted)
+	}
+	if job.Redact {
+		stageStarted := time.Now()
+		if transcript, err = p.redact(ctx, transcript); err != nil {
+			return nil, err
+		}
+		traceStage(ctx, job, StageRedact, stageStarted)
+	}
+	res := &Result{Job: job, Transcript: transcript, Language: language}
+	if onTranscript != nil {
+		onTranscript(transcript)
+	}
+
+	stageStarted := time.Now()
+	summary, err := p.ai.SummarizeTextIn(ctx, transcript, p.ai.UserPromptTemplate(p.userPromptTemplate), job.Language)
+	if err != nil {
+		return res, &StageError{Stage: StageSummarize, Err: err}
+	}
+	traceStage(ctx, job, StageSummarize, stageStarted)
+	if p.verifyPrompt != "" {
+		summary = p.verify(ctx, job, transcript, summary)
+	}
+	if p.timestamps {
+		summary = p.linkSummary(ctx, transcript, summary)
+	}
+	if p.chaptersMin > 0 && job.Duration >= p.chaptersMin {
+		summary = p.addChapters(ctx, transcript, summary)
+	}
+	res.Summary = summary
+	res.Usage = ai.UsageFrom(ctx)
+	res.Latency = time.Since(started)
+	<-archived
+	res.OriginalKey, res.AudioKey = originalKey, audioKey
+	p.deliver(res)
+	return res, nil
+}
+
+// Transcribe только конвертирует и распознает файл задания, без суммирования, архивирования и доставки
+func (p *Pipeline) Transcribe(ctx context.Context, job Job) (string, error) {
+	ctx = ai.WithChat(ctx, job.Source.Platform, job.Source.ChatID)
+	audioPath := job.AudioPath
+	if audioPath == "" && !job.IsVideo && media.PassThrough(job.InputPath) {
+		audioPath = job.InputPath
+	}
+	if audioPath == "" {
+		var err error
+		if audioPath, err = p.media.Convert(job.InputPath, job.IsVideo); err != nil {
+			return "", &StageError{Stage: StageConvert, Err: err}
+		}
+		defer os.Remove(audioPath)
+	}
+	transcript, _, err := p.transcribe(ctx, audioPath)
+	return transcript, err
+}
+
+func (p *Pipeline) transcribe(ctx context.Context, audioPath string) (string, string, error) {
+	if p.memory != nil {
+		info, err := os.Stat(audioPath)
+		if err != nil {
+			return "", "", &StageError{Stage: StageTranscribe, Err: err}
+		}
+		release, err := p.memory.acquire(ctx, info.Size()*audioMemoryFactor)
+		if err != nil {
+			return "", "", &StageError{Stage: StageTranscribe, Err: err}
+		}
+		defer release()
+	}
+	if err := p.classify(ctx, audioPath); err != nil {
+		return "", "", err
+	}
+	transcript, language, err := p.ai.TranscribeDetect(ctx, audioPath, os.ReadFile)
+	if err != nil {
+		return "", "", &StageError{Stage: StageTranscribe, Err: err}
+	}
+	if transcript == "" {
+		return "", "", &StageError{Stage: StageTranscribe, Err: ErrEmptyTranscript}
+	}
+	if p.refine {
+		transcript = p.refineTranscript(ctx, audioPath, transcript)
+	}
+	if p.punctuate {
+		transcript = p.punctuateTranscript(ctx, transcript)
+	}
+	return format.Paragraphs(transcript), language, nil
+}
+
+// linkSummary добавляет к резюме ссылки на моменты записи. Если в расшифровке нет меток времени
+// или модель не справилась, резюме остается без ссылок.
+func (p *Pipeline) linkSummary(ctx context.Context, transcript, summary string) string {
+	stamps := format.Timestamps(transcript)
+	if len(stamps) == 0 {
+		return summary
+	}
+	links, err := p.ai.LinkSummary(ctx, transcript, summary, stamps)
+	if err != nil {
+		log.Printf("Не удалось связать резюме с расшифровкой: %v", err)
+		return summary
+	}
+	if len(links) == 0 {
+		return summary
+	}
+	var b strings.Builder
+	b.WriteString(summary + "\n\n**Где в записи:**")
+	for _, l := range links {
+		b.WriteString("\n* " + l.Point + " — " + l.Time)
+	}
+	return b.String()
+}
+
+// addChapters добавляет к резюме оглавление записи. Без меток времени в расшифровке или при сбое
+// модели резюме остается без оглавления.
+func (p *Pipeline) addChapters(ctx context.Context, transcript, summary string) string {
+	stamps := format.Timestamps(transcript)
+	if len(stamps) < 2 {
+		return summary
+	}
+	chapters, err := p.ai.Chapters(ctx, transcript, stamps)
+	if err != nil {
+		log.Printf("Не удалось составить оглавление записи: %v", err)
+		return summary
+	}
+	if len(chapters) == 0 {
+		return summary
+	}
+	var b strings.Builder
+	b.WriteString(summary + "\n\n**Главы:**")
+	for _, c := range chapters {
+		b.WriteString("\n* " + c.Time + " — " + c.Title)
+	}
+	return b.String()
+}
+
+// refineTranscript исправляет ошибки распознавания вторым проходом. Сбой второго прохода не мешает
+// обработке: остается черновая расшифровка.
+func (p *Pipeline) refineTranscript(ctx context.Context, audioPath, transcript string) string {
+	refined, err := p.ai.RefineTranscript(ctx, audioPath, os.ReadFile, transcript)
+	if err != nil {
+		log.Printf("Не удалось уточнить расшифровку: %v", err)
+		return transcript
+	}
+	if refined == "" {
+		return transcript
+	}
+	return refined
+}
+
+// punctuateTranscript восстанавливает пунктуацию и абзацы моделью. При сбое остается исходная расшифровка.
+func (p *Pipeline) punctuateTranscript(ctx context.Context, transcript string) string {
+	punctuated, err := p.ai.PunctuateTranscript(ctx, transcript)
+	if err != nil {
+		log.Printf("Не удалось восстановить пунктуацию в расшифровке: %v", err)
+		return transcript
+	}
+	if punctuated == "" {
+		return transcript
+	}
+	return punctuated
+}
+
+// redact скрывает персональные данные: сначала регулярными выражениями, затем моделью. Если модель
+// недоступна, обработка прерывается, чтобы не показать и не сохранить данные, которые просили скрыть.
+func (p *Pipeline) redact(ctx context.Context, transcript string) (string, error) {
+	redacted, err := p.ai.RedactPII(ctx, format.RedactPII(transcript))
+	if err != nil {
+		return "", &StageError{Stage: StageRedact, Err: err}
+	}
+	if redacted == "" {
+		return "", &StageError{Stage: StageRedact, Err: ErrEmptyTranscript}
+	}
+	return redacted, nil
+}
+
+// verify сверяет резюме с расшифровкой. Сбой сверки не мешает обработке: остается исходное резюме.
+func (p *Pipeline) verify(ctx context.Context, job Job, transcript, summary string) string {
+	stageStarted := time.Now()
+	verified, err := p.ai.VerifySummary(ctx, p.verifyPrompt, transcript, summary)
+	if err != nil {
+		log.Printf("Не удалось сверить резюме с расшифровкой: %v", err)
+		return summary
+	}
+	traceStage(ctx, job, StageVerify, stageStarted)
+	if verified == "" {
+		return summary
+	}
+	return verified
+}
+
+// classify проверяет, что в записи есть речь. Сбой самой классификации не мешает обработке:
+// запись в этом случае считается речью.
+func (p *Pipeline) classify(ctx context.Context, audioPath string) error {
+	if p.speechThreshold <= 0 {
+		return nil
+	}
+	class, err := p.ai.ClassifyAudio(ctx, audioPath, os.ReadFile)
+	if err != nil {
+		log.Printf("Ошибка классификации аудио %s, продолжаем без нее: %v", audioPath, err)
+		return nil
+	}
+	if class.Speech >= p.speechThreshold {
+		return nil
+	}
+	if class.Music >= class.Noise {
+		return &StageError{Stage: StageClassify, Err: ErrMusic}
+	}
+	return &StageError{Stage: StageClassify, Err: ErrNoise}
+}
+
+func (p *Pipeline) deliver(res *Result) {
+	for _, s := range p.sinks {
+		go func(s Sink) {
+			if err := s.Deliver(context.Background(), res); err != nil {
+				log.Printf("Ошибка доставки результата (%s %s/%s): %v", res.Job.Source.Platform, res.Job.Source.ChatID, res.Job.Source.MessageID, err)
+			}
+		}(s)
+	}
+}
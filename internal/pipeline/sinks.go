@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+)
+
+type storeSink struct {
+	records  store.TranscriptStore
+	settings store.SettingsStore
+}
+
+// NewStoreSink сохраняет каждый результат в хранилище расшифровок, если чат не отказался от хранения
+func NewStoreSink(records store.TranscriptStore, settings store.SettingsStore) Sink {
+	return storeSink{records: records, settings: settings}
+}
+
+func (s storeSink) Deliver(ctx context.Context, res *Result) error {
+	src := res.Job.Source
+	settings, err := s.settings.GetSettings(ctx, src.Platform, src.ChatID)
+	if err != nil {
+		return err
+	}
+	if settings.Retention != nil && settings.Retention.Disabled {
+		return nil
+	}
+	return s.records.SaveRecord(ctx, &store.Record{
+		Platform:    src.Platform,
+		ChatID:      src.ChatID,
+		UserID:      src.UserID,
+		MessageID:   src.MessageID,
+		Duration:    res.Job.Duration,
+		Transcript:  res.Transcript,
+		Summary:     res.Summary,
+		Tokens:      res.Usage.TotalTokens,
+		OriginalKey: res.OriginalKey,
+		AudioKey:    res.AudioKey,
+	})
+}
+
+type quotaSink struct {
+	quotas store.QuotaStore
+}
+
+// NewQuotaSink учитывает потребление пользователя и чата за сутки и месяц: запросы, секунды аудио
+// и токены, а также токены всего бота (для лимитов расходов и /usage)
+func NewQuotaSink(quotas store.QuotaStore) Sink { return quotaSink{quotas: quotas} }
+
+func (s quotaSink) Deliver(ctx context.Context, res *Result) error {
+	src := res.Job.Source
+	now := time.Now()
+	usage := map[string]int64{
+		store.MetricRequests:     1,
+		store.MetricAudioSeconds: int64(res.Job.Duration.Seconds()),
+		store.MetricTokens:       int64(res.Usage.TotalTokens),
+	}
+	scopes := []store.Quota{
+		{Platform: src.Platform, Subject: src.UserID},
+		{Platform: src.Platform, Subject: store.ChatSubject(src.ChatID)},
+		{Subject: store.GlobalSubject, Metric: store.MetricTokens},
+	}
+	for _, scope := range scopes {
+		for metric, delta := range usage {
+			if delta == 0 || (scope.Metric != "" && scope.Metric != metric) {
+				continue
+			}
+			for _, period := range []string{store.DayPeriod(now), store.MonthPeriod(now)} {
+				q := store.Quota{Platform: scope.Platform, Subject: scope.Subject, Metric: metric, Period: period}
+				if _, err := s.quotas.AddUsage(ctx, q, delta); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Trace получает длительность каждого этапа задания
+type Trace func(stage string, d time.Duration)
+
+type traceKey struct{}
+
+// WithTrace возвращает контекст, в котором Run сообщает trace длительность этапов (отладка)
+func WithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// traceStage отмечает завершение этапа, начатого в started: пишет его в журнал на уровне debug
+// и передает трассировке из ctx, если она есть
+func traceStage(ctx context.Context, job Job, stage string, started time.Time) {
+	d := time.Since(started)
+	slog.Debug("Этап конвейера", "stage", stage, "duration", d, "chat", job.Source.ChatID, "message", job.Source.MessageID)
+	if trace, ok := ctx.Value(traceKey{}).(Trace); ok {
+		trace(stage, d)
+	}
+}
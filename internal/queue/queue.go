@@ -0,0 +1,25 @@
+// Package queue - внешняя очередь заданий: процесс с long polling передает тяжелую обработку медиа
+// отдельным процессам-обработчикам (подкоманда worker), которые масштабируются независимо.
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoWorkers - ни один обработчик не подтвердил задание вовремя
+var ErrNoWorkers = errors.New("нет свободных обработчиков заданий")
+
+// ErrBusy возвращает handle, когда обработчик занят: задание достается другому обработчику, а если
+// заняты все - остается без ответа, и отправитель получает ErrNoWorkers
+var ErrBusy = errors.New("обработчик занят")
+
+// Queue - очередь заданий; задание получает ровно один из подключенных обработчиков
+type Queue interface {
+	// Submit передает задание одному из обработчиков и ждет подтверждения, что тот его принял
+	Submit(ctx context.Context, job []byte) error
+	// Consume принимает задания и передает их handle, пока ctx не отменен; подтверждение уходит
+	// отправителю, когда handle вернул nil, а с ErrBusy задание передается дальше
+	Consume(ctx context.Context, handle func(job []byte) error) error
+	Close() error
+}
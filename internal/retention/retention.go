@@ -0,0 +1,108 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+)
+
+// ArchiveDeleter удаляет архивные копии удаленных записей
+type ArchiveDeleter interface {
+	DeleteArchived(ctx context.Context, keys ...string) error
+}
+
+// Purger периодически применяет политики хранения чатов к постоянному хранилищу
+type Purger struct {
+	records  store.TranscriptStore
+	settings store.SettingsStore
+	archive  ArchiveDeleter
+	defaults store.RetentionPolicy
+	interval time.Duration
+}
+
+// defaultInterval - интервал очистки, если задан неположительный (time.NewTicker с ним паникует)
+const defaultInterval = time.Hour
+
+// NewPurger создает задачу очистки; defaults применяется к чатам без собственной политики
+func NewPurger(records store.TranscriptStore, settings store.SettingsStore, archive ArchiveDeleter, defaults store.RetentionPolicy, interval time.Duration) *Purger {
+	if interval <= 0 {
+		log.Printf("Интервал очистки %s некорректен, используется %s", interval, defaultInterval)
+		interval = defaultInterval
+	}
+	return &Purger{records: records, settings: settings, archive: archive, defaults: defaults, interval: interval}
+}
+
+// Run выполняет очистку сразу и затем каждые interval до отмены ctx
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.Purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge выполняет один проход очистки по всем чатам с записями
+func (p *Purger) Purge(ctx context.Context) {
+	chats, err := p.records.ChatsWithRecords(ctx)
+	if err != nil {
+		log.Printf("Ошибка очистки по срокам хранения: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, chat := range chats {
+		if err := p.purgeChat(ctx, chat, now); err != nil {
+			log.Printf("Ошибка очистки чата %s/%s по срокам хранения: %v", chat.Platform, chat.ChatID, err)
+		}
+	}
+}
+
+func (p *Purger) purgeChat(ctx context.Context, chat store.ChatRef, now time.Time) error {
+	settings, err := p.settings.GetSettings(ctx, chat.Platform, chat.ChatID)
+	if err != nil {
+		return err
+	}
+	policy := p.defaults
+	if settings.Retention != nil {
+		policy = *settings.Retention
+	}
+
+	var deleted []store.Record
+	switch {
+	case policy.Disabled:
+		// Чат отказался от хранения: удаляем всё, что успело сохраниться раньше
+		deleted, err = p.records.DeleteRecordsBefore(ctx, chat.Platform, chat.ChatID, now)
+	case policy.SummaryDays > 0:
+		deleted, err = p.records.DeleteRecordsBefore(ctx, chat.Platform, chat.ChatID, now.AddDate(0, 0, -policy.SummaryDays))
+	}
+	if err != nil {
+		return err
+	}
+	if len(deleted) > 0 {
+		log.Printf("Чат %s/%s: удалено записей по сроку хранения: %d", chat.Platform, chat.ChatID, len(deleted))
+		var keys []string
+		for _, r := range deleted {
+			keys = append(keys, r.OriginalKey, r.AudioKey)
+		}
+		if err := p.archive.DeleteArchived(ctx, keys...); err != nil {
+			log.Printf("Ошибка удаления архивных файлов чата %s/%s: %v", chat.Platform, chat.ChatID, err)
+		}
+	}
+
+	if policy.TranscriptDays > 0 && !policy.Disabled {
+		cleared, err := p.records.ClearTranscripts(ctx, chat.Platform, chat.ChatID, now.AddDate(0, 0, -policy.TranscriptDays))
+		if err != nil {
+			return err
+		}
+		if cleared > 0 {
+			log.Printf("Чат %s/%s: стерто расшифровок по сроку хранения: %d", chat.Platform, chat.ChatID, cleared)
+		}
+	}
+	return nil
+}
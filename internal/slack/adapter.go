@@ -0,0 +1,154 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+)
+
+// Максимальная длина одного сообщения, после которой текст делится на части
+const maxMessageLength = 3900
+
+// Adapter принимает события Slack Events API и прогоняет аудиофайлы через общий конвейер
+type Adapter struct {
+	client        *Client
+	pipe          *pipeline.Pipeline
+	signingSecret string
+	maxFileSize   int64
+}
+
+func NewAdapter(client *Client, pipe *pipeline.Pipeline, signingSecret string, maxFileSize int64) *Adapter {
+	return &Adapter{client: client, pipe: pipe, signingSecret: signingSecret, maxFileSize: maxFileSize}
+}
+
+func (a *Adapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := a.verifySignature(r.Header, body); err != nil {
+		log.Printf("Slack: отклонен запрос с неверной подписью: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var env EventEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if env.Type == "url_verification" {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(env.Challenge))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	// Повторные доставки Slack отправляет, если мы не ответили за 3 секунды; обработка уже запущена
+	if r.Header.Get("X-Slack-Retry-Num") != "" {
+		return
+	}
+	if env.Type == "event_callback" && env.Event != nil {
+		go a.handleEvent(env.Event)
+	}
+}
+
+func (a *Adapter) verifySignature(h http.Header, body []byte) error {
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("некорректная метка времени: %q", ts)
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > 5*time.Minute || d < -5*time.Minute {
+		return fmt.Errorf("метка времени устарела: %s", ts)
+	}
+	mac := hmac.New(sha256.New, []byte(a.signingSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(h.Get("X-Slack-Signature"))) {
+		return errors.New("подпись не совпадает")
+	}
+	return nil
+}
+
+func isMediaFile(f File) bool {
+	return strings.HasPrefix(f.Mimetype, "audio/") || strings.HasPrefix(f.Mimetype, "video/")
+}
+
+func (a *Adapter) handleEvent(ev *Event) {
+	if ev.Type != "message" || ev.BotID != "" || len(ev.Files) == 0 {
+		return
+	}
+	threadTS := ev.ThreadTS
+	if threadTS == "" {
+		threadTS = ev.TS
+	}
+	for _, f := range ev.Files {
+		if !isMediaFile(f) {
+			continue
+		}
+		if f.Size > a.maxFileSize {
+			a.post(ev.Channel, threadTS, fmt.Sprintf("Извините, максимальный размер файла - %d МБ. Файл %s слишком большой.", a.maxFileSize/(1024*1024), f.Name))
+			continue
+		}
+		a.processFile(ev, f, threadTS)
+	}
+}
+
+func (a *Adapter) processFile(ev *Event, f File, threadTS string) {
+	log.Printf("Slack: получен файл %s в канале %s", f.ID, ev.Channel)
+	content, err := a.client.DownloadFile(f.URLPrivateDownload)
+	if err != nil {
+		log.Printf("Slack: ошибка скачивания файла %s: %v", f.ID, err)
+		a.post(ev.Channel, threadTS, fmt.Sprintf("Произошла ошибка при скачивании файла: %v", err))
+		return
+	}
+	inputPath, err := media.SaveToTemp(content, f.Name)
+	if err != nil {
+		a.post(ev.Channel, threadTS, fmt.Sprintf("Произошла ошибка при обработке медиафайла: %v", err))
+		return
+	}
+	defer os.Remove(inputPath)
+
+	job := pipeline.Job{
+		Source:    pipeline.Source{Platform: "slack", ChatID: ev.Channel, UserID: ev.User, MessageID: ev.TS},
+		InputPath: inputPath,
+		IsVideo:   strings.HasPrefix(f.Mimetype, "video/"),
+	}
+	res, err := a.pipe.Run(context.Background(), job, func(transcript string) {
+		a.post(ev.Channel, threadTS, "*Transcription*\n\n"+format.EscapeMrkdwn(transcript))
+	})
+	if err != nil {
+		log.Printf("Slack: ошибка обработки файла %s: %v", f.ID, err)
+		a.post(ev.Channel, threadTS, fmt.Sprintf("Произошла ошибка при обработке: %v", err))
+		return
+	}
+	a.post(ev.Channel, threadTS, "*Summary*\n\n"+format.FormatMrkdwn(res.Summary))
+	log.Printf("Slack: обработка файла %s успешно завершена", f.ID)
+}
+
+func (a *Adapter) post(channel, threadTS, text string) {
+	for _, part := range format.SplitMessage(text, maxMessageLength) {
+		if err := a.client.PostMessage(channel, threadTS, part); err != nil {
+			log.Printf("Slack: ошибка отправки сообщения в канал %s: %v", channel, err)
+		}
+	}
+}
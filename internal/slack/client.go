@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const apiBaseURL = "https://slack.com/api"
+
+type Client struct {
+	http     *http.Client
+	botToken string
+}
+
+func NewClient(botToken string, httpClient *http.Client) *Client {
+	return &Client{http: httpClient, botToken: botToken}
+}
+
+type postMessagePayload struct {
+	Channel  string `json:"channel"`
+	Text     string `json:"text"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+}
+
+// PostMessage отправляет сообщение в канал, в тред threadTS (если задан)
+func (c *Client) PostMessage(channel, threadTS, text string) error {
+	payloadBytes, err := json.Marshal(postMessagePayload{Channel: channel, Text: text, ThreadTS: threadTS})
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для chat.postMessage: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, apiBaseURL+"/chat.postMessage", bytes.NewReader(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса chat.postMessage: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.botToken)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса chat.postMessage: %w", err)
+	}
+	defer resp.Body.Close()
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("ошибка декодирования ответа chat.postMessage: %w", err)
+	}
+	if !apiResp.Ok {
+		return fmt.Errorf("ответ от chat.postMessage не 'ok': %s", apiResp.Error)
+	}
+	return nil
+}
+
+// DownloadFile скачивает приватный файл Slack, используя токен бота
+func (c *Client) DownloadFile(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса на скачивание файла: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.botToken)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при скачивании файла: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("не удалось скачать файл, статус: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
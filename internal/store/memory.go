@@ -0,0 +1,362 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory - хранилище в памяти процесса: данные теряются при перезапуске.
+// Подходит для разработки и одиночного экземпляра без базы; реализует все интерфейсы хранилища.
+type Memory struct {
+	mu        sync.Mutex
+	nextID    int64
+	records   map[int64]Record
+	settings  map[ChatRef]ChatSettings
+	quotas    map[Quota]int64
+	audit     []AuditEntry
+	reminders map[int64]Reminder
+	bot       BotSettings
+	jobs      map[string]PendingJob
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		records:   make(map[int64]Record),
+		settings:  make(map[ChatRef]ChatSettings),
+		quotas:    make(map[Quota]int64),
+		reminders: make(map[int64]Reminder),
+		jobs:      make(map[string]PendingJob),
+	}
+}
+
+func (m *Memory) SaveRecord(_ context.Context, r *Record) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	// Точность как у базы: время хранится в секундах
+	r.CreatedAt = r.CreatedAt.Truncate(time.Second)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	r.ID = m.nextID
+	m.records[r.ID] = *r
+	return nil
+}
+
+// filter возвращает копии записей, подходящих под условие, в хронологическом порядке
+func (m *Memory) filter(match func(r Record) bool) []Record {
+	var out []Record
+	for _, r := range m.records {
+		if match(r) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func (m *Memory) remove(match func(r Record) bool) []Record {
+	deleted := m.filter(match)
+	for _, r := range deleted {
+		delete(m.records, r.ID)
+	}
+	return deleted
+}
+
+func (m *Memory) ListRecords(_ context.Context, platform, chatID string, since time.Time) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.filter(func(r Record) bool {
+		return r.Platform == platform && r.ChatID == chatID && !r.CreatedAt.Before(since.Truncate(time.Second))
+	}), nil
+}
+
+func (m *Memory) RecentRecords(_ context.Context, platform, chatID string, limit int) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := m.filter(func(r Record) bool { return r.Platform == platform && r.ChatID == chatID })
+	var out []Record
+	for i := len(all) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, all[i])
+	}
+	return out, nil
+}
+
+func (m *Memory) GetRecord(_ context.Context, id int64) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.records[id]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func (m *Memory) Search(_ context.Context, platform, chatID, query string, limit int) ([]SearchHit, error) {
+	m.mu.Lock()
+	records := m.filter(func(r Record) bool { return r.Platform == platform && r.ChatID == chatID })
+	m.mu.Unlock()
+	return searchRecords(records, query, limit), nil
+}
+
+func (m *Memory) SearchUser(_ context.Context, platform, userID, query string, limit int) ([]SearchHit, error) {
+	m.mu.Lock()
+	records := m.filter(func(r Record) bool { return r.Platform == platform && r.UserID == userID })
+	m.mu.Unlock()
+	if strings.TrimSpace(query) == "" {
+		return recentHits(records, limit), nil
+	}
+	return searchRecords(records, query, limit), nil
+}
+
+func (m *Memory) ChatsWithRecords(_ context.Context) ([]ChatRef, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[ChatRef]bool)
+	var chats []ChatRef
+	for _, r := range m.records {
+		c := ChatRef{Platform: r.Platform, ChatID: r.ChatID}
+		if !seen[c] {
+			seen[c] = true
+			chats = append(chats, c)
+		}
+	}
+	return chats, nil
+}
+
+func (m *Memory) ClearTranscripts(_ context.Context, platform, chatID string, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, r := range m.records {
+		if r.Platform == platform && r.ChatID == chatID && r.CreatedAt.Before(before) && r.Transcript != "" {
+			r.Transcript = ""
+			m.records[id] = r
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) DeleteRecordsBefore(_ context.Context, platform, chatID string, before time.Time) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(func(r Record) bool {
+		return r.Platform == platform && r.ChatID == chatID && r.CreatedAt.Before(before)
+	}), nil
+}
+
+func (m *Memory) DeleteUserData(_ context.Context, platform, userID string) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(func(r Record) bool { return r.Platform == platform && r.UserID == userID }), nil
+}
+
+func (m *Memory) DeleteChatRecords(_ context.Context, platform, chatID string) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(func(r Record) bool { return r.Platform == platform && r.ChatID == chatID }), nil
+}
+
+func (m *Memory) GetSettings(_ context.Context, platform, chatID string) (ChatSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.settings[ChatRef{Platform: platform, ChatID: chatID}], nil
+}
+
+func (m *Memory) SaveSettings(_ context.Context, platform, chatID string, settings ChatSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[ChatRef{Platform: platform, ChatID: chatID}] = settings
+	return nil
+}
+
+func (m *Memory) ChatsWithSettings(_ context.Context) ([]ChatRef, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	chats := make([]ChatRef, 0, len(m.settings))
+	for c := range m.settings {
+		chats = append(chats, c)
+	}
+	return chats, nil
+}
+
+func (m *Memory) GetBotSettings(_ context.Context) (BotSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bot, nil
+}
+
+func (m *Memory) SaveBotSettings(_ context.Context, settings BotSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bot = settings
+	return nil
+}
+
+func (m *Memory) DeleteSettings(_ context.Context, platform, chatID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.settings, ChatRef{Platform: platform, ChatID: chatID})
+	return nil
+}
+
+func (m *Memory) AddUsage(_ context.Context, q Quota, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotas[q] += delta
+	return m.quotas[q], nil
+}
+
+func (m *Memory) GetUsage(_ context.Context, q Quota) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.quotas[q], nil
+}
+
+func (m *Memory) DeleteUsage(_ context.Context, platform, subject string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for q := range m.quotas {
+		if q.Platform == platform && q.Subject == subject {
+			delete(m.quotas, q)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) AppendAudit(_ context.Context, e AuditEntry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.ID = int64(len(m.audit) + 1)
+	m.audit = append(m.audit, e)
+	return nil
+}
+
+func (m *Memory) ListAudit(_ context.Context, q AuditQuery) ([]AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filterAudit(m.audit, q), nil
+}
+
+func (m *Memory) AddReminder(_ context.Context, r *Reminder) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.CreatedAt = r.CreatedAt.Truncate(time.Second)
+	r.DueAt = r.DueAt.Truncate(time.Second)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	r.ID = m.nextID
+	m.reminders[r.ID] = *r
+	return nil
+}
+
+func (m *Memory) DueReminders(_ context.Context, now time.Time, limit int) ([]Reminder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []Reminder
+	for _, r := range m.reminders {
+		if !r.DueAt.After(now) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].DueAt.Equal(due[j].DueAt) {
+			return due[i].DueAt.Before(due[j].DueAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (m *Memory) DeleteReminder(_ context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reminders, id)
+	return nil
+}
+
+func (m *Memory) DeleteUserReminders(_ context.Context, platform, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, r := range m.reminders {
+		if r.Platform == platform && r.UserID == userID {
+			delete(m.reminders, id)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteChatReminders(_ context.Context, platform, chatID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, r := range m.reminders {
+		if r.Platform == platform && r.ChatID == chatID {
+			delete(m.reminders, id)
+		}
+	}
+	return nil
+}
+
+func jobID(platform, chatID, messageID string) string {
+	return platform + ":" + chatID + ":" + messageID
+}
+
+func (m *Memory) SaveJob(_ context.Context, j PendingJob) error {
+	now := time.Now().Truncate(time.Second)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := jobID(j.Platform, j.ChatID, j.MessageID)
+	if prev, ok := m.jobs[id]; ok {
+		j.CreatedAt = prev.CreatedAt
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = now
+	}
+	j.UpdatedAt = now
+	m.jobs[id] = j
+	return nil
+}
+
+func (m *Memory) DeleteJob(_ context.Context, platform, chatID, messageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.jobs, jobID(platform, chatID, messageID))
+	return nil
+}
+
+func (m *Memory) PendingJobs(_ context.Context) ([]PendingJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]PendingJob, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+func sortJobs(jobs []PendingJob) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobID(jobs[i].Platform, jobs[i].ChatID, jobs[i].MessageID) < jobID(jobs[j].Platform, jobs[j].ChatID, jobs[j].MessageID)
+	})
+}
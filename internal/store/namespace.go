@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Namespace возвращает хранилища тенанта tenant поверх общих хранилищ stores. Данные тенанта лежат
+// в тех же таблицах под платформой "платформа@тенант": обработчики по-прежнему работают с платформой
+// "telegram" и не видят чатов, записей и счетчиков других тенантов. Глобальные настройки бота хранятся
+// отдельным документом, если хранилище это поддерживает (SQLStore.NamedBotSettings).
+func Namespace(stores Stores, tenant string) Stores {
+	n := &namespaced{s: stores, suffix: "@" + tenant}
+	out := Stores{Settings: n, Quotas: n, Audit: n, Reminders: n, Bot: stores.Bot, Jobs: n}
+	if stores.Transcripts != nil {
+		out.Transcripts = n
+	}
+	if named, ok := stores.Bot.(interface{ NamedBotSettings(string) BotSettingsStore }); ok {
+		out.Bot = named.NamedBotSettings(botSettingsName + n.suffix)
+	}
+	return out
+}
+
+type namespaced struct {
+	s      Stores
+	suffix string
+}
+
+// in переводит платформу обработчиков в платформу хранилища
+func (n *namespaced) in(platform string) string { return platform + n.suffix }
+
+// out переводит платформу хранилища обратно; ok=false - данные другого тенанта или бота без тенантов
+func (n *namespaced) out(platform string) (string, bool) {
+	return strings.CutSuffix(platform, n.suffix)
+}
+
+func (n *namespaced) records(records []Record) []Record {
+	out := records[:0]
+	for _, r := range records {
+		if p, ok := n.out(r.Platform); ok {
+			r.Platform = p
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (n *namespaced) hits(hits []SearchHit) []SearchHit {
+	out := hits[:0]
+	for _, h := range hits {
+		if p, ok := n.out(h.Platform); ok {
+			h.Platform = p
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func (n *namespaced) chats(chats []ChatRef) []ChatRef {
+	out := chats[:0]
+	for _, c := range chats {
+		if p, ok := n.out(c.Platform); ok {
+			c.Platform = p
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (n *namespaced) SaveRecord(ctx context.Context, r *Record) error {
+	stored := *r
+	stored.Platform = n.in(r.Platform)
+	err := n.s.Transcripts.SaveRecord(ctx, &stored)
+	r.ID, r.CreatedAt = stored.ID, stored.CreatedAt
+	return err
+}
+
+func (n *namespaced) ListRecords(ctx context.Context, platform, chatID string, since time.Time) ([]Record, error) {
+	records, err := n.s.Transcripts.ListRecords(ctx, n.in(platform), chatID, since)
+	return n.records(records), err
+}
+
+func (n *namespaced) RecentRecords(ctx context.Context, platform, chatID string, limit int) ([]Record, error) {
+	records, err := n.s.Transcripts.RecentRecords(ctx, n.in(platform), chatID, limit)
+	return n.records(records), err
+}
+
+func (n *namespaced) GetRecord(ctx context.Context, id int64) (*Record, error) {
+	r, err := n.s.Transcripts.GetRecord(ctx, id)
+	if err != nil || r == nil {
+		return r, err
+	}
+	p, ok := n.out(r.Platform)
+	if !ok {
+		return nil, nil
+	}
+	r.Platform = p
+	return r, nil
+}
+
+func (n *namespaced) Search(ctx context.Context, platform, chatID, query string, limit int) ([]SearchHit, error) {
+	hits, err := n.s.Transcripts.Search(ctx, n.in(platform), chatID, query, limit)
+	return n.hits(hits), err
+}
+
+func (n *namespaced) SearchUser(ctx context.Context, platform, userID, query string, limit int) ([]SearchHit, error) {
+	hits, err := n.s.Transcripts.SearchUser(ctx, n.in(platform), userID, query, limit)
+	return n.hits(hits), err
+}
+
+func (n *namespaced) ChatsWithRecords(ctx context.Context) ([]ChatRef, error) {
+	chats, err := n.s.Transcripts.ChatsWithRecords(ctx)
+	return n.chats(chats), err
+}
+
+func (n *namespaced) ClearTranscripts(ctx context.Context, platform, chatID string, before time.Time) (int64, error) {
+	return n.s.Transcripts.ClearTranscripts(ctx, n.in(platform), chatID, before)
+}
+
+func (n *namespaced) DeleteRecordsBefore(ctx context.Context, platform, chatID string, before time.Time) ([]Record, error) {
+	records, err := n.s.Transcripts.DeleteRecordsBefore(ctx, n.in(platform), chatID, before)
+	return n.records(records), err
+}
+
+func (n *namespaced) DeleteUserData(ctx context.Context, platform, userID string) ([]Record, error) {
+	records, err := n.s.Transcripts.DeleteUserData(ctx, n.in(platform), userID)
+	return n.records(records), err
+}
+
+func (n *namespaced) DeleteChatRecords(ctx context.Context, platform, chatID string) ([]Record, error) {
+	records, err := n.s.Transcripts.DeleteChatRecords(ctx, n.in(platform), chatID)
+	return n.records(records), err
+}
+
+func (n *namespaced) GetSettings(ctx context.Context, platform, chatID string) (ChatSettings, error) {
+	return n.s.Settings.GetSettings(ctx, n.in(platform), chatID)
+}
+
+func (n *namespaced) SaveSettings(ctx context.Context, platform, chatID string, settings ChatSettings) error {
+	return n.s.Settings.SaveSettings(ctx, n.in(platform), chatID, settings)
+}
+
+func (n *namespaced) DeleteSettings(ctx context.Context, platform, chatID string) error {
+	return n.s.Settings.DeleteSettings(ctx, n.in(platform), chatID)
+}
+
+func (n *namespaced) ChatsWithSettings(ctx context.Context) ([]ChatRef, error) {
+	chats, err := n.s.Settings.ChatsWithSettings(ctx)
+	return n.chats(chats), err
+}
+
+func (n *namespaced) AddUsage(ctx context.Context, q Quota, delta int64) (int64, error) {
+	q.Platform = n.in(q.Platform)
+	return n.s.Quotas.AddUsage(ctx, q, delta)
+}
+
+func (n *namespaced) GetUsage(ctx context.Context, q Quota) (int64, error) {
+	q.Platform = n.in(q.Platform)
+	return n.s.Quotas.GetUsage(ctx, q)
+}
+
+func (n *namespaced) DeleteUsage(ctx context.Context, platform, subject string) error {
+	return n.s.Quotas.DeleteUsage(ctx, n.in(platform), subject)
+}
+
+func (n *namespaced) AppendAudit(ctx context.Context, e AuditEntry) error {
+	e.Platform = n.in(e.Platform)
+	return n.s.Audit.AppendAudit(ctx, e)
+}
+
+// ListAudit читает журнал без ограничения числа событий: журнал общий, и последние Limit событий
+// могут принадлежать другим тенантам
+func (n *namespaced) ListAudit(ctx context.Context, q AuditQuery) ([]AuditEntry, error) {
+	all, err := n.s.Audit.ListAudit(ctx, AuditQuery{Action: q.Action, Since: q.Since})
+	if err != nil {
+		return nil, err
+	}
+	var entries []AuditEntry
+	for _, e := range all {
+		if p, ok := n.out(e.Platform); ok {
+			e.Platform = p
+			entries = append(entries, e)
+		}
+	}
+	return filterAudit(entries, AuditQuery{Limit: q.Limit}), nil
+}
+
+func (n *namespaced) AddReminder(ctx context.Context, r *Reminder) error {
+	stored := *r
+	stored.Platform = n.in(r.Platform)
+	err := n.s.Reminders.AddReminder(ctx, &stored)
+	r.ID, r.CreatedAt = stored.ID, stored.CreatedAt
+	return err
+}
+
+// DueReminders возвращает наступившие напоминания тенанта среди первых limit наступивших напоминаний
+// всех тенантов; остальные достанутся следующим проверкам, когда другие тенанты отправят свои
+func (n *namespaced) DueReminders(ctx context.Context, now time.Time, limit int) ([]Reminder, error) {
+	due, err := n.s.Reminders.DueReminders(ctx, now, limit)
+	out := due[:0]
+	for _, r := range due {
+		if p, ok := n.out(r.Platform); ok {
+			r.Platform = p
+			out = append(out, r)
+		}
+	}
+	return out, err
+}
+
+func (n *namespaced) DeleteReminder(ctx context.Context, id int64) error {
+	return n.s.Reminders.DeleteReminder(ctx, id)
+}
+
+func (n *namespaced) DeleteUserReminders(ctx context.Context, platform, userID string) error {
+	return n.s.Reminders.DeleteUserReminders(ctx, n.in(platform), userID)
+}
+
+func (n *namespaced) DeleteChatReminders(ctx context.Context, platform, chatID string) error {
+	return n.s.Reminders.DeleteChatReminders(ctx, n.in(platform), chatID)
+}
+
+func (n *namespaced) SaveJob(ctx context.Context, j PendingJob) error {
+	j.Platform = n.in(j.Platform)
+	return n.s.Jobs.SaveJob(ctx, j)
+}
+
+func (n *namespaced) DeleteJob(ctx context.Context, platform, chatID, messageID string) error {
+	return n.s.Jobs.DeleteJob(ctx, n.in(platform), chatID, messageID)
+}
+
+func (n *namespaced) PendingJobs(ctx context.Context) ([]PendingJob, error) {
+	jobs, err := n.s.Jobs.PendingJobs(ctx)
+	out := jobs[:0]
+	for _, j := range jobs {
+		if p, ok := n.out(j.Platform); ok {
+			j.Platform = p
+			out = append(out, j)
+		}
+	}
+	return out, err
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	_ "github.com/lib/pq"
+)
+
+// recordsDocument - текст записи для полнотекстового поиска; выражение совпадает с выражением GIN-индекса
+const recordsDocument = `to_tsvector('simple', records.transcript || ' ' || records.summary)`
+
+// pgMigrations - схема PostgreSQL; номер последней примененной миграции хранится в schema_version
+var pgMigrations = []string{
+	`CREATE TABLE records (
+		id           BIGSERIAL PRIMARY KEY,
+		platform     TEXT    NOT NULL,
+		chat_id      TEXT    NOT NULL,
+		user_id      TEXT    NOT NULL,
+		message_id   TEXT    NOT NULL,
+		duration_ms  BIGINT  NOT NULL DEFAULT 0,
+		transcript   TEXT    NOT NULL,
+		summary      TEXT    NOT NULL,
+		tokens       INTEGER NOT NULL DEFAULT 0,
+		created_at   BIGINT  NOT NULL,
+		original_key TEXT    NOT NULL DEFAULT '',
+		audio_key    TEXT    NOT NULL DEFAULT ''
+	 );
+	 CREATE INDEX records_chat_created ON records(platform, chat_id, created_at);
+	 CREATE INDEX records_fts ON records USING GIN (` + recordsDocument + `);
+	 CREATE TABLE chat_settings (
+		platform TEXT NOT NULL,
+		chat_id  TEXT NOT NULL,
+		data     TEXT NOT NULL,
+		PRIMARY KEY (platform, chat_id)
+	 );
+	 CREATE TABLE audit_log (
+		id         BIGSERIAL PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		platform   TEXT   NOT NULL,
+		chat_id    TEXT   NOT NULL,
+		user_id    TEXT   NOT NULL,
+		action     TEXT   NOT NULL,
+		details    TEXT   NOT NULL DEFAULT ''
+	 );`,
+	`CREATE TABLE quotas (
+		platform TEXT   NOT NULL,
+		subject  TEXT   NOT NULL,
+		metric   TEXT   NOT NULL,
+		period   TEXT   NOT NULL,
+		value    BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (platform, subject, metric, period)
+	 );`,
+	`CREATE TABLE reminders (
+		id         BIGSERIAL PRIMARY KEY,
+		platform   TEXT   NOT NULL,
+		chat_id    TEXT   NOT NULL,
+		user_id    TEXT   NOT NULL,
+		message_id TEXT   NOT NULL,
+		text       TEXT   NOT NULL,
+		due_at     BIGINT NOT NULL,
+		created_at BIGINT NOT NULL
+	 );
+	 CREATE INDEX reminders_due ON reminders(due_at);`,
+	`CREATE TABLE bot_settings (
+		name TEXT PRIMARY KEY,
+		data TEXT NOT NULL
+	 );`,
+	`CREATE INDEX audit_log_action ON audit_log(action, created_at);`,
+	`CREATE TABLE pending_jobs (
+		platform   TEXT   NOT NULL,
+		chat_id    TEXT   NOT NULL,
+		message_id TEXT   NOT NULL,
+		user_id    TEXT   NOT NULL,
+		stage      TEXT   NOT NULL,
+		payload    TEXT   NOT NULL,
+		attempts   BIGINT NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		PRIMARY KEY (platform, chat_id, message_id)
+	 );`,
+	`CREATE TABLE tenants (
+		id             TEXT    PRIMARY KEY,
+		bot_token      TEXT    NOT NULL,
+		google_api_key TEXT    NOT NULL DEFAULT '',
+		config         TEXT    NOT NULL DEFAULT '{}',
+		disabled       INTEGER NOT NULL DEFAULT 0
+	 );`,
+	`ALTER TABLE reminders ADD COLUMN kind TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE pending_jobs ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+}
+
+// OpenPostgres подключается к PostgreSQL по DSN (postgres://...) и применяет схему.
+// Подходит для нескольких реплик бота с общим хранилищем.
+func OpenPostgres(dsn string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть PostgreSQL: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось подключиться к PostgreSQL: %w", err)
+	}
+	if err := migratePostgres(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{db: db, postgres: true}, nil
+}
+
+func migratePostgres(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("не удалось создать таблицу версии схемы: %w", err)
+	}
+	for {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("не удалось начать миграцию: %w", err)
+		}
+		// Блокировка не дает нескольким репликам применять миграции одновременно
+		if _, err := tx.Exec(`LOCK TABLE schema_version IN EXCLUSIVE MODE`); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("не удалось заблокировать таблицу версии схемы: %w", err)
+		}
+		var version int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("не удалось прочитать версию схемы: %w", err)
+		}
+		if version >= len(pgMigrations) {
+			return tx.Rollback()
+		}
+		if _, err := tx.Exec(pgMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка миграции %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM schema_version`); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка миграции %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_version (version) VALUES ($1)`, version+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка миграции %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("ошибка миграции %d: %w", version+1, err)
+		}
+	}
+}
+
+// pgTSQuery превращает пользовательский запрос в tsquery: каждое слово ищется по префиксу.
+// Оставляются только буквы и цифры, чтобы не допустить синтаксиса tsquery в запросе.
+func pgTSQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, word)
+		if word != "" {
+			terms = append(terms, word+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+func (s *SQLStore) searchPostgres(ctx context.Context, platform, scope, value, query string, limit int) ([]SearchHit, error) {
+	match := pgTSQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=16, MinWords=5, MaxFragments=1, FragmentDelimiter=…", SnippetMatchStart, SnippetMatchEnd)
+	rows, err := s.query(ctx,
+		`SELECT `+recordColumns+`, ts_headline('simple', records.transcript || ' ' || records.summary, q, ?)
+		 FROM records, to_tsquery('simple', ?) q
+		 WHERE `+recordsDocument+` @@ q AND records.platform = ? AND records.`+scope+` = ?
+		 ORDER BY ts_rank(`+recordsDocument+`, q) DESC LIMIT ?`,
+		options, match, platform, value, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка полнотекстового поиска: %w", err)
+	}
+	return scanHits(rows)
+}
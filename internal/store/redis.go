@@ -0,0 +1,552 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "vsu:store:"
+
+// Redis - хранилище в Redis для нескольких реплик без SQL-базы; реализует все интерфейсы хранилища.
+// Записи лежат JSON-документами, индексы чатов - в сортированных по времени множествах.
+// Поиск перебирает записи чата, поэтому рассчитан на умеренные объемы.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis { return &Redis{client: client} }
+
+func recordKey(id int64) string { return redisKeyPrefix + "rec:" + strconv.FormatInt(id, 10) }
+
+func chatIndexKey(platform, chatID string) string {
+	return redisKeyPrefix + "chat:" + platform + ":" + chatID
+}
+
+func userIndexKey(platform, userID string) string {
+	return redisKeyPrefix + "user:" + platform + ":" + userID
+}
+
+func settingsKey(platform, chatID string) string {
+	return redisKeyPrefix + "settings:" + platform + ":" + chatID
+}
+
+func botSettingsKey() string {
+	return redisKeyPrefix + "bot_settings"
+}
+
+func quotaKey(platform, subject string) string {
+	return redisKeyPrefix + "quota:" + platform + ":" + subject
+}
+
+func reminderKey(id int64) string { return redisKeyPrefix + "reminder:" + strconv.FormatInt(id, 10) }
+
+const (
+	redisChatsKey  = redisKeyPrefix + "chats"
+	redisRecordSeq = redisKeyPrefix + "rec:seq"
+	redisAuditKey  = redisKeyPrefix + "audit"
+	// Напоминания: JSON-документы и множество их ID, отсортированное по сроку
+	redisReminderSeq  = redisKeyPrefix + "reminder:seq"
+	redisRemindersKey = redisKeyPrefix + "reminders"
+	// Незавершенные задания: хеш "платформа:чат:сообщение" -> JSON
+	redisJobsKey = redisKeyPrefix + "jobs"
+)
+
+func (s *Redis) SaveRecord(ctx context.Context, r *Record) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.CreatedAt = r.CreatedAt.Truncate(time.Second)
+	id, err := s.client.Incr(ctx, redisRecordSeq).Result()
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить запись: %w", err)
+	}
+	r.ID = id
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга записи: %w", err)
+	}
+	member := strconv.FormatInt(id, 10)
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, recordKey(id), data, 0)
+		p.ZAdd(ctx, chatIndexKey(r.Platform, r.ChatID), redis.Z{Score: float64(r.CreatedAt.Unix()), Member: member})
+		p.SAdd(ctx, userIndexKey(r.Platform, r.UserID), member)
+		p.SAdd(ctx, redisChatsKey, r.Platform+"\n"+r.ChatID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить запись: %w", err)
+	}
+	return nil
+}
+
+// load читает записи по ID в переданном порядке, пропуская уже удаленные
+func (s *Redis) load(ctx context.Context, ids []string) ([]Record, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisKeyPrefix + "rec:" + id
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	records := make([]Record, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать запись: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (s *Redis) chatRange(ctx context.Context, platform, chatID string, lo, hi string) ([]Record, error) {
+	ids, err := s.client.ZRangeByScore(ctx, chatIndexKey(platform, chatID), &redis.ZRangeBy{Min: lo, Max: hi}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	return s.load(ctx, ids)
+}
+
+func (s *Redis) ListRecords(ctx context.Context, platform, chatID string, since time.Time) ([]Record, error) {
+	return s.chatRange(ctx, platform, chatID, strconv.FormatInt(since.Unix(), 10), "+inf")
+}
+
+func (s *Redis) RecentRecords(ctx context.Context, platform, chatID string, limit int) ([]Record, error) {
+	ids, err := s.client.ZRevRange(ctx, chatIndexKey(platform, chatID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	return s.load(ctx, ids)
+}
+
+func (s *Redis) GetRecord(ctx context.Context, id int64) (*Record, error) {
+	records, err := s.load(ctx, []string{strconv.FormatInt(id, 10)})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+func (s *Redis) Search(ctx context.Context, platform, chatID, query string, limit int) ([]SearchHit, error) {
+	records, err := s.chatRange(ctx, platform, chatID, "-inf", "+inf")
+	if err != nil {
+		return nil, err
+	}
+	return searchRecords(records, query, limit), nil
+}
+
+func (s *Redis) SearchUser(ctx context.Context, platform, userID, query string, limit int) ([]SearchHit, error) {
+	ids, err := s.client.SMembers(ctx, userIndexKey(platform, userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	records, err := s.load(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(query) == "" {
+		return recentHits(records, limit), nil
+	}
+	return searchRecords(records, query, limit), nil
+}
+
+func (s *Redis) ChatsWithRecords(ctx context.Context) ([]ChatRef, error) {
+	members, err := s.client.SMembers(ctx, redisChatsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список чатов: %w", err)
+	}
+	chats := make([]ChatRef, 0, len(members))
+	for _, m := range members {
+		platform, chatID, _ := strings.Cut(m, "\n")
+		chats = append(chats, ChatRef{Platform: platform, ChatID: chatID})
+	}
+	return chats, nil
+}
+
+// before возвращает верхнюю границу диапазона ZRANGEBYSCORE "строго раньше t"
+func before(t time.Time) string { return "(" + strconv.FormatInt(t.Unix(), 10) }
+
+func (s *Redis) ClearTranscripts(ctx context.Context, platform, chatID string, beforeTime time.Time) (int64, error) {
+	records, err := s.chatRange(ctx, platform, chatID, "-inf", before(beforeTime))
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, r := range records {
+		if r.Transcript == "" {
+			continue
+		}
+		r.Transcript = ""
+		data, err := json.Marshal(r)
+		if err != nil {
+			return n, fmt.Errorf("ошибка маршалинга записи: %w", err)
+		}
+		// XX: запись могла быть удалена параллельно, не воскрешаем ее
+		if err := s.client.SetXX(ctx, recordKey(r.ID), data, redis.KeepTTL).Err(); err != nil {
+			return n, fmt.Errorf("не удалось стереть расшифровку: %w", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (s *Redis) remove(ctx context.Context, records []Record) ([]Record, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	chats := make(map[ChatRef]bool)
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, r := range records {
+			member := strconv.FormatInt(r.ID, 10)
+			p.Del(ctx, recordKey(r.ID))
+			p.ZRem(ctx, chatIndexKey(r.Platform, r.ChatID), member)
+			p.SRem(ctx, userIndexKey(r.Platform, r.UserID), member)
+			chats[ChatRef{Platform: r.Platform, ChatID: r.ChatID}] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось удалить записи: %w", err)
+	}
+	for c := range chats {
+		if n, err := s.client.ZCard(ctx, chatIndexKey(c.Platform, c.ChatID)).Result(); err == nil && n == 0 {
+			s.client.SRem(ctx, redisChatsKey, c.Platform+"\n"+c.ChatID)
+		}
+	}
+	return records, nil
+}
+
+func (s *Redis) DeleteRecordsBefore(ctx context.Context, platform, chatID string, beforeTime time.Time) ([]Record, error) {
+	records, err := s.chatRange(ctx, platform, chatID, "-inf", before(beforeTime))
+	if err != nil {
+		return nil, err
+	}
+	return s.remove(ctx, records)
+}
+
+func (s *Redis) DeleteUserData(ctx context.Context, platform, userID string) ([]Record, error) {
+	ids, err := s.client.SMembers(ctx, userIndexKey(platform, userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	records, err := s.load(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return s.remove(ctx, records)
+}
+
+func (s *Redis) DeleteChatRecords(ctx context.Context, platform, chatID string) ([]Record, error) {
+	records, err := s.chatRange(ctx, platform, chatID, "-inf", "+inf")
+	if err != nil {
+		return nil, err
+	}
+	return s.remove(ctx, records)
+}
+
+func (s *Redis) GetSettings(ctx context.Context, platform, chatID string) (ChatSettings, error) {
+	var settings ChatSettings
+	data, err := s.client.Get(ctx, settingsKey(platform, chatID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("не удалось прочитать настройки чата: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return settings, fmt.Errorf("не удалось разобрать настройки чата: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Redis) SaveSettings(ctx context.Context, platform, chatID string, settings ChatSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга настроек чата: %w", err)
+	}
+	if err := s.client.Set(ctx, settingsKey(platform, chatID), data, 0).Err(); err != nil {
+		return fmt.Errorf("не удалось сохранить настройки чата: %w", err)
+	}
+	return nil
+}
+
+func (s *Redis) DeleteSettings(ctx context.Context, platform, chatID string) error {
+	if err := s.client.Del(ctx, settingsKey(platform, chatID)).Err(); err != nil {
+		return fmt.Errorf("не удалось удалить настройки чата: %w", err)
+	}
+	return nil
+}
+
+// ChatsWithSettings перебирает ключи настроек через SCAN, не блокируя Redis
+func (s *Redis) ChatsWithSettings(ctx context.Context) ([]ChatRef, error) {
+	prefix := redisKeyPrefix + "settings:"
+	var chats []ChatRef
+	iter := s.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		platform, chatID, ok := strings.Cut(strings.TrimPrefix(iter.Val(), prefix), ":")
+		if !ok {
+			continue
+		}
+		chats = append(chats, ChatRef{Platform: platform, ChatID: chatID})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось получить список чатов: %w", err)
+	}
+	return chats, nil
+}
+
+func (s *Redis) GetBotSettings(ctx context.Context) (BotSettings, error) {
+	var settings BotSettings
+	data, err := s.client.Get(ctx, botSettingsKey()).Result()
+	if errors.Is(err, redis.Nil) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("не удалось прочитать настройки бота: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return settings, fmt.Errorf("не удалось разобрать настройки бота: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Redis) SaveBotSettings(ctx context.Context, settings BotSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга настроек бота: %w", err)
+	}
+	if err := s.client.Set(ctx, botSettingsKey(), data, 0).Err(); err != nil {
+		return fmt.Errorf("не удалось сохранить настройки бота: %w", err)
+	}
+	return nil
+}
+
+// Счетчики субъекта хранятся в одном хеше с полями "метрика|период"
+func (s *Redis) AddUsage(ctx context.Context, q Quota, delta int64) (int64, error) {
+	value, err := s.client.HIncrBy(ctx, quotaKey(q.Platform, q.Subject), q.Metric+"|"+q.Period, delta).Result()
+	if err != nil {
+		return 0, fmt.Errorf("не удалось обновить счетчик %s: %w", q.Metric, err)
+	}
+	return value, nil
+}
+
+func (s *Redis) GetUsage(ctx context.Context, q Quota) (int64, error) {
+	value, err := s.client.HGet(ctx, quotaKey(q.Platform, q.Subject), q.Metric+"|"+q.Period).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать счетчик %s: %w", q.Metric, err)
+	}
+	return value, nil
+}
+
+func (s *Redis) DeleteUsage(ctx context.Context, platform, subject string) error {
+	if err := s.client.Del(ctx, quotaKey(platform, subject)).Err(); err != nil {
+		return fmt.Errorf("не удалось удалить счетчики: %w", err)
+	}
+	return nil
+}
+
+func (s *Redis) AppendAudit(ctx context.Context, e AuditEntry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга события аудита: %w", err)
+	}
+	if err := s.client.RPush(ctx, redisAuditKey, data).Err(); err != nil {
+		return fmt.Errorf("не удалось записать событие аудита: %w", err)
+	}
+	return nil
+}
+
+func (s *Redis) ListAudit(ctx context.Context, q AuditQuery) ([]AuditEntry, error) {
+	items, err := s.client.LRange(ctx, redisAuditKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать журнал аудита: %w", err)
+	}
+	entries := make([]AuditEntry, 0, len(items))
+	for i, item := range items {
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(item), &e); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать событие аудита: %w", err)
+		}
+		e.ID = int64(i + 1)
+		entries = append(entries, e)
+	}
+	return filterAudit(entries, q), nil
+}
+
+func (s *Redis) AddReminder(ctx context.Context, r *Reminder) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.CreatedAt = r.CreatedAt.Truncate(time.Second)
+	r.DueAt = r.DueAt.Truncate(time.Second)
+	id, err := s.client.Incr(ctx, redisReminderSeq).Result()
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить напоминание: %w", err)
+	}
+	r.ID = id
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга напоминания: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, reminderKey(id), data, 0)
+		p.ZAdd(ctx, redisRemindersKey, redis.Z{Score: float64(r.DueAt.Unix()), Member: strconv.FormatInt(id, 10)})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить напоминание: %w", err)
+	}
+	return nil
+}
+
+// loadReminders читает напоминания по ID в переданном порядке, пропуская уже удаленные
+func (s *Redis) loadReminders(ctx context.Context, ids []string) ([]Reminder, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisKeyPrefix + "reminder:" + id
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать напоминания: %w", err)
+	}
+	reminders := make([]Reminder, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var r Reminder
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать напоминание: %w", err)
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, nil
+}
+
+func (s *Redis) DueReminders(ctx context.Context, now time.Time, limit int) ([]Reminder, error) {
+	ids, err := s.client.ZRangeByScore(ctx, redisRemindersKey, &redis.ZRangeBy{
+		Min: "-inf", Max: strconv.FormatInt(now.Unix(), 10), Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать напоминания: %w", err)
+	}
+	return s.loadReminders(ctx, ids)
+}
+
+func (s *Redis) DeleteReminder(ctx context.Context, id int64) error {
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, reminderKey(id))
+		p.ZRem(ctx, redisRemindersKey, strconv.FormatInt(id, 10))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось удалить напоминание: %w", err)
+	}
+	return nil
+}
+
+// deleteRemindersWhere перебирает все напоминания: их немного, отдельные индексы по чатам и пользователям не нужны
+func (s *Redis) deleteRemindersWhere(ctx context.Context, match func(r Reminder) bool) error {
+	ids, err := s.client.ZRange(ctx, redisRemindersKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать напоминания: %w", err)
+	}
+	reminders, err := s.loadReminders(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		if !match(r) {
+			continue
+		}
+		if err := s.DeleteReminder(ctx, r.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Redis) DeleteUserReminders(ctx context.Context, platform, userID string) error {
+	return s.deleteRemindersWhere(ctx, func(r Reminder) bool { return r.Platform == platform && r.UserID == userID })
+}
+
+func (s *Redis) DeleteChatReminders(ctx context.Context, platform, chatID string) error {
+	return s.deleteRemindersWhere(ctx, func(r Reminder) bool { return r.Platform == platform && r.ChatID == chatID })
+}
+
+// SaveJob сохраняет задание; время создания берется из уже сохраненного задания, если оно есть
+func (s *Redis) SaveJob(ctx context.Context, j PendingJob) error {
+	id := jobID(j.Platform, j.ChatID, j.MessageID)
+	now := time.Now().Truncate(time.Second)
+	prev, err := s.client.HGet(ctx, redisJobsKey, id).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("не удалось сохранить задание: %w", err)
+	}
+	if prev != "" {
+		var old PendingJob
+		if err := json.Unmarshal([]byte(prev), &old); err == nil {
+			j.CreatedAt = old.CreatedAt
+		}
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = now
+	}
+	j.UpdatedAt = now
+	data, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга задания: %w", err)
+	}
+	if err := s.client.HSet(ctx, redisJobsKey, id, data).Err(); err != nil {
+		return fmt.Errorf("не удалось сохранить задание: %w", err)
+	}
+	return nil
+}
+
+func (s *Redis) DeleteJob(ctx context.Context, platform, chatID, messageID string) error {
+	if err := s.client.HDel(ctx, redisJobsKey, jobID(platform, chatID, messageID)).Err(); err != nil {
+		return fmt.Errorf("не удалось удалить задание: %w", err)
+	}
+	return nil
+}
+
+func (s *Redis) PendingJobs(ctx context.Context) ([]PendingJob, error) {
+	values, err := s.client.HGetAll(ctx, redisJobsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать задания: %w", err)
+	}
+	jobs := make([]PendingJob, 0, len(values))
+	for _, data := range values {
+		var j PendingJob
+		if err := json.Unmarshal([]byte(data), &j); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать задание: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
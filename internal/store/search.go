@@ -0,0 +1,105 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const snippetWords = 16
+
+func normalizeWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }))
+}
+
+// searchRecords - простой поиск для хранилищ без полнотекстового индекса (память, Redis):
+// запись подходит, если каждое слово запроса является префиксом какого-либо слова ее текста
+func searchRecords(records []Record, query string, limit int) []SearchHit {
+	var terms []string
+	for _, w := range strings.Fields(query) {
+		if w = normalizeWord(w); w != "" {
+			terms = append(terms, w)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return matchRecords(records, terms, limit)
+}
+
+// recentHits возвращает последние limit записей без фрагментов
+func recentHits(records []Record, limit int) []SearchHit {
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.After(records[j].CreatedAt) })
+	hits := make([]SearchHit, 0, min(limit, len(records)))
+	for i := 0; i < len(records) && i < limit; i++ {
+		hits = append(hits, SearchHit{Record: records[i]})
+	}
+	return hits
+}
+
+func matchRecords(records []Record, terms []string, limit int) []SearchHit {
+	type scored struct {
+		hit   SearchHit
+		score int
+	}
+	var found []scored
+	for _, r := range records {
+		words := strings.Fields(r.Transcript + " " + r.Summary)
+		matched := make([]bool, len(words))
+		seen := make(map[string]bool, len(terms))
+		score, first := 0, -1
+		for i, w := range words {
+			nw := normalizeWord(w)
+			for _, t := range terms {
+				if strings.HasPrefix(nw, t) {
+					matched[i] = true
+					seen[t] = true
+					score++
+					if first < 0 {
+						first = i
+					}
+					break
+				}
+			}
+		}
+		if len(seen) < len(terms) {
+			continue
+		}
+		found = append(found, scored{hit: SearchHit{Record: r, Snippet: snippet(words, matched, first)}, score: score})
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+		return found[i].hit.CreatedAt.After(found[j].hit.CreatedAt)
+	})
+	hits := make([]SearchHit, 0, min(limit, len(found)))
+	for i := 0; i < len(found) && i < limit; i++ {
+		hits = append(hits, found[i].hit)
+	}
+	return hits
+}
+
+// snippet вырезает окно слов вокруг первого совпадения и отмечает совпавшие слова маркерами
+func snippet(words []string, matched []bool, first int) string {
+	start := max(0, first-snippetWords/4)
+	end := min(len(words), start+snippetWords)
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		if matched[i] {
+			b.WriteString(SnippetMatchStart + words[i] + SnippetMatchEnd)
+		} else {
+			b.WriteString(words[i])
+		}
+	}
+	if end < len(words) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
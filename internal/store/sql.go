@@ -0,0 +1,750 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLStore - постоянное хранилище на SQLite или PostgreSQL; реализует все интерфейсы хранилища
+type SQLStore struct {
+	db       *sql.DB
+	postgres bool
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS records (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	platform    TEXT    NOT NULL,
+	chat_id     TEXT    NOT NULL,
+	user_id     TEXT    NOT NULL,
+	message_id  TEXT    NOT NULL,
+	duration_ms INTEGER NOT NULL DEFAULT 0,
+	transcript  TEXT    NOT NULL,
+	summary     TEXT    NOT NULL,
+	tokens      INTEGER NOT NULL DEFAULT 0,
+	created_at  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS records_chat_created ON records(platform, chat_id, created_at);
+CREATE TABLE IF NOT EXISTS chat_settings (
+	platform TEXT NOT NULL,
+	chat_id  TEXT NOT NULL,
+	data     TEXT NOT NULL,
+	PRIMARY KEY (platform, chat_id)
+);
+`
+
+// migrations применяются по порядку поверх schema; номер последней примененной хранится в PRAGMA user_version
+var migrations = []string{
+	`ALTER TABLE records ADD COLUMN original_key TEXT NOT NULL DEFAULT '';
+	 ALTER TABLE records ADD COLUMN audio_key TEXT NOT NULL DEFAULT '';`,
+	`CREATE VIRTUAL TABLE records_fts USING fts5(transcript, summary, content='records', content_rowid='id', tokenize='unicode61 remove_diacritics 2');
+	 CREATE TRIGGER records_fts_ai AFTER INSERT ON records BEGIN
+		INSERT INTO records_fts(rowid, transcript, summary) VALUES (new.id, new.transcript, new.summary);
+	 END;
+	 CREATE TRIGGER records_fts_ad AFTER DELETE ON records BEGIN
+		INSERT INTO records_fts(records_fts, rowid, transcript, summary) VALUES ('delete', old.id, old.transcript, old.summary);
+	 END;
+	 CREATE TRIGGER records_fts_au AFTER UPDATE ON records BEGIN
+		INSERT INTO records_fts(records_fts, rowid, transcript, summary) VALUES ('delete', old.id, old.transcript, old.summary);
+		INSERT INTO records_fts(rowid, transcript, summary) VALUES (new.id, new.transcript, new.summary);
+	 END;
+	 INSERT INTO records_fts(records_fts) VALUES ('rebuild');`,
+	`CREATE TABLE audit_log (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at INTEGER NOT NULL,
+		platform   TEXT    NOT NULL,
+		chat_id    TEXT    NOT NULL,
+		user_id    TEXT    NOT NULL,
+		action     TEXT    NOT NULL,
+		details    TEXT    NOT NULL DEFAULT ''
+	 );`,
+	`CREATE TABLE quotas (
+		platform TEXT    NOT NULL,
+		subject  TEXT    NOT NULL,
+		metric   TEXT    NOT NULL,
+		period   TEXT    NOT NULL,
+		value    INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (platform, subject, metric, period)
+	 );`,
+	`CREATE TABLE reminders (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		platform   TEXT    NOT NULL,
+		chat_id    TEXT    NOT NULL,
+		user_id    TEXT    NOT NULL,
+		message_id TEXT    NOT NULL,
+		text       TEXT    NOT NULL,
+		due_at     INTEGER NOT NULL,
+		created_at INTEGER NOT NULL
+	 );
+	 CREATE INDEX reminders_due ON reminders(due_at);`,
+	`CREATE TABLE bot_settings (
+		name TEXT PRIMARY KEY,
+		data TEXT NOT NULL
+	 );`,
+	`CREATE INDEX audit_log_action ON audit_log(action, created_at);`,
+	`CREATE TABLE pending_jobs (
+		platform   TEXT    NOT NULL,
+		chat_id    TEXT    NOT NULL,
+		message_id TEXT    NOT NULL,
+		user_id    TEXT    NOT NULL,
+		stage      TEXT    NOT NULL,
+		payload    TEXT    NOT NULL,
+		attempts   INTEGER NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (platform, chat_id, message_id)
+	 );`,
+	`CREATE TABLE tenants (
+		id             TEXT    PRIMARY KEY,
+		bot_token      TEXT    NOT NULL,
+		google_api_key TEXT    NOT NULL DEFAULT '',
+		config         TEXT    NOT NULL DEFAULT '{}',
+		disabled       INTEGER NOT NULL DEFAULT 0
+	 );`,
+	`ALTER TABLE reminders ADD COLUMN kind TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE pending_jobs ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+}
+
+// Open открывает (или создает) базу по пути path и применяет схему
+func Open(path string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("не удалось применить схему базы: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("не удалось прочитать версию схемы: %w", err)
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("не удалось начать миграцию %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка миграции %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("ошибка миграции %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("ошибка миграции %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) Close() error { return s.db.Close() }
+
+// rebind заменяет плейсхолдеры "?" на "$1", "$2"... для PostgreSQL
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.db.QueryRowContext(ctx, s.rebind(query), args...)
+}
+
+func (s *SQLStore) SaveRecord(ctx context.Context, r *Record) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	err := s.queryRow(ctx,
+		`INSERT INTO records (platform, chat_id, user_id, message_id, duration_ms, transcript, summary, tokens, created_at, original_key, audio_key)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		r.Platform, r.ChatID, r.UserID, r.MessageID, r.Duration.Milliseconds(), r.Transcript, r.Summary, r.Tokens, r.CreatedAt.Unix(), r.OriginalKey, r.AudioKey).Scan(&r.ID)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить запись: %w", err)
+	}
+	return nil
+}
+
+// ListRecords возвращает записи чата, созданные не раньше since, в хронологическом порядке
+func (s *SQLStore) ListRecords(ctx context.Context, platform, chatID string, since time.Time) ([]Record, error) {
+	rows, err := s.query(ctx,
+		`SELECT `+recordColumns+`
+		 FROM records WHERE platform = ? AND chat_id = ? AND created_at >= ? ORDER BY created_at, id`,
+		platform, chatID, since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// RecentRecords возвращает последние limit записей чата, новые первыми
+func (s *SQLStore) RecentRecords(ctx context.Context, platform, chatID string, limit int) ([]Record, error) {
+	rows, err := s.query(ctx,
+		`SELECT `+recordColumns+`
+		 FROM records WHERE platform = ? AND chat_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		platform, chatID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// GetRecord возвращает запись по ID; (nil, nil), если записи нет
+func (s *SQLStore) GetRecord(ctx context.Context, id int64) (*Record, error) {
+	rows, err := s.query(ctx, `SELECT `+recordColumns+` FROM records WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать запись: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	r, err := scanRecord(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+const recordColumns = `records.id, records.platform, records.chat_id, records.user_id, records.message_id, records.duration_ms,
+	records.transcript, records.summary, records.tokens, records.created_at, records.original_key, records.audio_key`
+
+func scanRecord(rows *sql.Rows, extra ...any) (Record, error) {
+	var r Record
+	var durationMs, createdAt int64
+	dest := []any{&r.ID, &r.Platform, &r.ChatID, &r.UserID, &r.MessageID, &durationMs, &r.Transcript, &r.Summary, &r.Tokens, &createdAt, &r.OriginalKey, &r.AudioKey}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return r, fmt.Errorf("не удалось прочитать запись: %w", err)
+	}
+	r.Duration = time.Duration(durationMs) * time.Millisecond
+	r.CreatedAt = time.Unix(createdAt, 0)
+	return r, nil
+}
+
+// ftsQuery превращает пользовательский запрос в запрос FTS5: каждое слово ищется по префиксу,
+// спецсимволы синтаксиса FTS экранируются кавычками
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// Search ищет по расшифровкам и резюме чата, возвращая лучшие совпадения первыми
+func (s *SQLStore) Search(ctx context.Context, platform, chatID, query string, limit int) ([]SearchHit, error) {
+	return s.search(ctx, platform, "chat_id", chatID, query, limit)
+}
+
+// SearchUser ищет по записям пользователя во всех чатах; при пустом запросе возвращает его последние записи
+func (s *SQLStore) SearchUser(ctx context.Context, platform, userID, query string, limit int) ([]SearchHit, error) {
+	if strings.TrimSpace(query) == "" {
+		rows, err := s.query(ctx,
+			`SELECT `+recordColumns+`, ''
+			 FROM records WHERE platform = ? AND user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+			platform, userID, limit)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+		}
+		return scanHits(rows)
+	}
+	return s.search(ctx, platform, "user_id", userID, query, limit)
+}
+
+// search выполняет полнотекстовый поиск по записям, отобранным по столбцу scope ("chat_id" или "user_id")
+func (s *SQLStore) search(ctx context.Context, platform, scope, value, query string, limit int) ([]SearchHit, error) {
+	if s.postgres {
+		return s.searchPostgres(ctx, platform, scope, value, query, limit)
+	}
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	rows, err := s.query(ctx,
+		`SELECT `+recordColumns+`, snippet(records_fts, -1, ?, ?, '…', 16)
+		 FROM records_fts JOIN records ON records.id = records_fts.rowid
+		 WHERE records_fts MATCH ? AND records.platform = ? AND records.`+scope+` = ?
+		 ORDER BY rank LIMIT ?`,
+		SnippetMatchStart, SnippetMatchEnd, match, platform, value, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка полнотекстового поиска: %w", err)
+	}
+	return scanHits(rows)
+}
+
+// scanHits читает результаты поиска: колонки записи и фрагмент
+func scanHits(rows *sql.Rows) ([]SearchHit, error) {
+	defer rows.Close()
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		r, err := scanRecord(rows, &hit.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		hit.Record = r
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// GetSettings возвращает настройки чата; для чата без настроек - нулевое значение
+func (s *SQLStore) GetSettings(ctx context.Context, platform, chatID string) (ChatSettings, error) {
+	var settings ChatSettings
+	var data string
+	err := s.queryRow(ctx, `SELECT data FROM chat_settings WHERE platform = ? AND chat_id = ?`, platform, chatID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("не удалось прочитать настройки чата: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return settings, fmt.Errorf("не удалось разобрать настройки чата: %w", err)
+	}
+	return settings, nil
+}
+
+// DeleteSettings удаляет настройки чата
+func (s *SQLStore) DeleteSettings(ctx context.Context, platform, chatID string) error {
+	if _, err := s.exec(ctx, `DELETE FROM chat_settings WHERE platform = ? AND chat_id = ?`, platform, chatID); err != nil {
+		return fmt.Errorf("не удалось удалить настройки чата: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) SaveSettings(ctx context.Context, platform, chatID string, settings ChatSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга настроек чата: %w", err)
+	}
+	_, err = s.exec(ctx,
+		`INSERT INTO chat_settings (platform, chat_id, data) VALUES (?, ?, ?)
+		 ON CONFLICT (platform, chat_id) DO UPDATE SET data = excluded.data`,
+		platform, chatID, string(data))
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить настройки чата: %w", err)
+	}
+	return nil
+}
+
+// ChatsWithSettings возвращает все чаты, у которых есть сохраненные настройки
+func (s *SQLStore) ChatsWithSettings(ctx context.Context) ([]ChatRef, error) {
+	rows, err := s.query(ctx, `SELECT platform, chat_id FROM chat_settings`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список чатов: %w", err)
+	}
+	defer rows.Close()
+	var chats []ChatRef
+	for rows.Next() {
+		var c ChatRef
+		if err := rows.Scan(&c.Platform, &c.ChatID); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать чат: %w", err)
+		}
+		chats = append(chats, c)
+	}
+	return chats, rows.Err()
+}
+
+// Настройки бота хранятся одним JSON-документом
+const botSettingsName = "bot"
+
+func (s *SQLStore) GetBotSettings(ctx context.Context) (BotSettings, error) {
+	return s.getBotSettings(ctx, botSettingsName)
+}
+
+func (s *SQLStore) SaveBotSettings(ctx context.Context, settings BotSettings) error {
+	return s.saveBotSettings(ctx, botSettingsName, settings)
+}
+
+// NamedBotSettings возвращает хранилище отдельного документа настроек бота name (например, тенанта)
+func (s *SQLStore) NamedBotSettings(name string) BotSettingsStore {
+	return namedBotSettings{s: s, name: name}
+}
+
+type namedBotSettings struct {
+	s    *SQLStore
+	name string
+}
+
+func (n namedBotSettings) GetBotSettings(ctx context.Context) (BotSettings, error) {
+	return n.s.getBotSettings(ctx, n.name)
+}
+
+func (n namedBotSettings) SaveBotSettings(ctx context.Context, settings BotSettings) error {
+	return n.s.saveBotSettings(ctx, n.name, settings)
+}
+
+func (s *SQLStore) getBotSettings(ctx context.Context, name string) (BotSettings, error) {
+	var settings BotSettings
+	var data string
+	err := s.queryRow(ctx, `SELECT data FROM bot_settings WHERE name = ?`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("не удалось прочитать настройки бота: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &settings); err != nil {
+		return settings, fmt.Errorf("не удалось разобрать настройки бота: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *SQLStore) saveBotSettings(ctx context.Context, name string, settings BotSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга настроек бота: %w", err)
+	}
+	_, err = s.exec(ctx,
+		`INSERT INTO bot_settings (name, data) VALUES (?, ?)
+		 ON CONFLICT (name) DO UPDATE SET data = excluded.data`,
+		name, string(data))
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить настройки бота: %w", err)
+	}
+	return nil
+}
+
+// AppendAudit добавляет запись в журнал аудита; журнал только пополняется
+func (s *SQLStore) AppendAudit(ctx context.Context, e AuditEntry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	_, err := s.exec(ctx,
+		`INSERT INTO audit_log (created_at, platform, chat_id, user_id, action, details) VALUES (?, ?, ?, ?, ?, ?)`,
+		e.CreatedAt.Unix(), e.Platform, e.ChatID, e.UserID, e.Action, e.Details)
+	if err != nil {
+		return fmt.Errorf("не удалось записать событие аудита: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) ListAudit(ctx context.Context, q AuditQuery) ([]AuditEntry, error) {
+	query := `SELECT id, created_at, platform, chat_id, user_id, action, details FROM audit_log WHERE created_at >= ?`
+	args := []any{q.Since.Unix()}
+	if q.Action != "" {
+		query += ` AND action = ?`
+		args = append(args, q.Action)
+	}
+	query += ` ORDER BY id DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать журнал аудита: %w", err)
+	}
+	defer rows.Close()
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var created int64
+		if err := rows.Scan(&e.ID, &created, &e.Platform, &e.ChatID, &e.UserID, &e.Action, &e.Details); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать событие аудита: %w", err)
+		}
+		e.CreatedAt = time.Unix(created, 0)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось прочитать журнал аудита: %w", err)
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// DeleteUserData удаляет все записи пользователя во всех чатах платформы и возвращает удаленные записи
+func (s *SQLStore) DeleteUserData(ctx context.Context, platform, userID string) ([]Record, error) {
+	return s.deleteRecords(ctx, `platform = ? AND user_id = ?`, platform, userID)
+}
+
+// DeleteChatRecords удаляет все записи чата и возвращает удаленные записи
+func (s *SQLStore) DeleteChatRecords(ctx context.Context, platform, chatID string) ([]Record, error) {
+	return s.deleteRecords(ctx, `platform = ? AND chat_id = ?`, platform, chatID)
+}
+
+func (s *SQLStore) deleteRecords(ctx context.Context, where string, args ...any) ([]Record, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, s.rebind(`SELECT `+recordColumns+` FROM records WHERE `+where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать записи: %w", err)
+	}
+	var deleted []Record
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deleted = append(deleted, r)
+	}
+	rows.Close()
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM records WHERE `+where), args...); err != nil {
+		return nil, fmt.Errorf("не удалось удалить записи: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось удалить записи: %w", err)
+	}
+	return deleted, nil
+}
+
+// ChatsWithRecords возвращает все чаты, у которых есть сохраненные записи
+func (s *SQLStore) ChatsWithRecords(ctx context.Context) ([]ChatRef, error) {
+	rows, err := s.query(ctx, `SELECT DISTINCT platform, chat_id FROM records`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список чатов: %w", err)
+	}
+	defer rows.Close()
+	var chats []ChatRef
+	for rows.Next() {
+		var c ChatRef
+		if err := rows.Scan(&c.Platform, &c.ChatID); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать чат: %w", err)
+		}
+		chats = append(chats, c)
+	}
+	return chats, rows.Err()
+}
+
+// ClearTranscripts стирает расшифровки записей чата, созданных раньше before, и возвращает их число
+func (s *SQLStore) ClearTranscripts(ctx context.Context, platform, chatID string, before time.Time) (int64, error) {
+	res, err := s.exec(ctx,
+		`UPDATE records SET transcript = '' WHERE platform = ? AND chat_id = ? AND created_at < ? AND transcript != ''`,
+		platform, chatID, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("не удалось стереть расшифровки: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// DeleteRecordsBefore удаляет записи чата, созданные раньше before, и возвращает удаленные записи
+func (s *SQLStore) DeleteRecordsBefore(ctx context.Context, platform, chatID string, before time.Time) ([]Record, error) {
+	return s.deleteRecords(ctx, `platform = ? AND chat_id = ? AND created_at < ?`, platform, chatID, before.Unix())
+}
+
+func (s *SQLStore) AddUsage(ctx context.Context, q Quota, delta int64) (int64, error) {
+	var value int64
+	err := s.queryRow(ctx,
+		`INSERT INTO quotas (platform, subject, metric, period, value) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (platform, subject, metric, period) DO UPDATE SET value = quotas.value + excluded.value
+		 RETURNING value`,
+		q.Platform, q.Subject, q.Metric, q.Period, delta).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось обновить счетчик %s: %w", q.Metric, err)
+	}
+	return value, nil
+}
+
+func (s *SQLStore) GetUsage(ctx context.Context, q Quota) (int64, error) {
+	var value int64
+	err := s.queryRow(ctx,
+		`SELECT value FROM quotas WHERE platform = ? AND subject = ? AND metric = ? AND period = ?`,
+		q.Platform, q.Subject, q.Metric, q.Period).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать счетчик %s: %w", q.Metric, err)
+	}
+	return value, nil
+}
+
+func (s *SQLStore) DeleteUsage(ctx context.Context, platform, subject string) error {
+	if _, err := s.exec(ctx, `DELETE FROM quotas WHERE platform = ? AND subject = ?`, platform, subject); err != nil {
+		return fmt.Errorf("не удалось удалить счетчики: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) AddReminder(ctx context.Context, r *Reminder) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	err := s.queryRow(ctx,
+		`INSERT INTO reminders (kind, platform, chat_id, user_id, message_id, text, due_at, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+		r.Kind, r.Platform, r.ChatID, r.UserID, r.MessageID, r.Text, r.DueAt.Unix(), r.CreatedAt.Unix()).Scan(&r.ID)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить напоминание: %w", err)
+	}
+	return nil
+}
+
+// DueReminders возвращает напоминания со сроком не позже now, самые ранние первыми
+func (s *SQLStore) DueReminders(ctx context.Context, now time.Time, limit int) ([]Reminder, error) {
+	rows, err := s.query(ctx,
+		`SELECT id, kind, platform, chat_id, user_id, message_id, text, due_at, created_at
+		 FROM reminders WHERE due_at <= ? ORDER BY due_at, id LIMIT ?`,
+		now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать напоминания: %w", err)
+	}
+	defer rows.Close()
+	var reminders []Reminder
+	for rows.Next() {
+		var r Reminder
+		var dueAt, createdAt int64
+		if err := rows.Scan(&r.ID, &r.Kind, &r.Platform, &r.ChatID, &r.UserID, &r.MessageID, &r.Text, &dueAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать напоминание: %w", err)
+		}
+		r.DueAt = time.Unix(dueAt, 0)
+		r.CreatedAt = time.Unix(createdAt, 0)
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
+
+func (s *SQLStore) DeleteReminder(ctx context.Context, id int64) error {
+	return s.deleteReminders(ctx, `id = ?`, id)
+}
+
+func (s *SQLStore) DeleteUserReminders(ctx context.Context, platform, userID string) error {
+	return s.deleteReminders(ctx, `platform = ? AND user_id = ?`, platform, userID)
+}
+
+func (s *SQLStore) DeleteChatReminders(ctx context.Context, platform, chatID string) error {
+	return s.deleteReminders(ctx, `platform = ? AND chat_id = ?`, platform, chatID)
+}
+
+func (s *SQLStore) deleteReminders(ctx context.Context, where string, args ...any) error {
+	if _, err := s.exec(ctx, `DELETE FROM reminders WHERE `+where, args...); err != nil {
+		return fmt.Errorf("не удалось удалить напоминания: %w", err)
+	}
+	return nil
+}
+
+// SaveJob создает задание или обновляет стадию, число попыток и владельца у существующего
+func (s *SQLStore) SaveJob(ctx context.Context, j PendingJob) error {
+	now := time.Now()
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = now
+	}
+	_, err := s.exec(ctx,
+		`INSERT INTO pending_jobs (platform, chat_id, message_id, user_id, stage, payload, attempts, owner, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (platform, chat_id, message_id) DO UPDATE SET
+			user_id = excluded.user_id, stage = excluded.stage, payload = excluded.payload,
+			attempts = excluded.attempts, owner = excluded.owner, updated_at = excluded.updated_at`,
+		j.Platform, j.ChatID, j.MessageID, j.UserID, j.Stage, j.Payload, j.Attempts, j.Owner, j.CreatedAt.Unix(), now.Unix())
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить задание: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteJob(ctx context.Context, platform, chatID, messageID string) error {
+	_, err := s.exec(ctx, `DELETE FROM pending_jobs WHERE platform = ? AND chat_id = ? AND message_id = ?`, platform, chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить задание: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) PendingJobs(ctx context.Context) ([]PendingJob, error) {
+	rows, err := s.query(ctx,
+		`SELECT platform, chat_id, message_id, user_id, stage, payload, attempts, owner, created_at, updated_at
+		 FROM pending_jobs ORDER BY created_at, platform, chat_id, message_id`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать задания: %w", err)
+	}
+	defer rows.Close()
+	var jobs []PendingJob
+	for rows.Next() {
+		var j PendingJob
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&j.Platform, &j.ChatID, &j.MessageID, &j.UserID, &j.Stage, &j.Payload, &j.Attempts, &j.Owner, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать задание: %w", err)
+		}
+		j.CreatedAt = time.Unix(createdAt, 0)
+		j.UpdatedAt = time.Unix(updatedAt, 0)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// ListTenants возвращает тенантов по возрастанию ID; тенанты добавляются в таблицу tenants оператором
+func (s *SQLStore) ListTenants(ctx context.Context) ([]Tenant, error) {
+	rows, err := s.query(ctx, `SELECT id, bot_token, google_api_key, config, disabled FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать тенантов: %w", err)
+	}
+	defer rows.Close()
+	var tenants []Tenant
+	for rows.Next() {
+		var t Tenant
+		var data string
+		var disabled int
+		if err := rows.Scan(&t.ID, &t.BotToken, &t.GoogleAPIKey, &data, &disabled); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать тенанта: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &t.Config); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать настройки тенанта %s: %w", t.ID, err)
+		}
+		t.Disabled = disabled != 0
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
@@ -0,0 +1,451 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// TranscriptStore хранит расшифровки и резюме обработанных сообщений
+type TranscriptStore interface {
+	SaveRecord(ctx context.Context, r *Record) error
+	// ListRecords возвращает записи чата, созданные не раньше since, в хронологическом порядке
+	ListRecords(ctx context.Context, platform, chatID string, since time.Time) ([]Record, error)
+	// RecentRecords возвращает последние limit записей чата, новые первыми
+	RecentRecords(ctx context.Context, platform, chatID string, limit int) ([]Record, error)
+	// GetRecord возвращает запись по ID; (nil, nil), если записи нет
+	GetRecord(ctx context.Context, id int64) (*Record, error)
+	// Search ищет по расшифровкам и резюме чата, возвращая лучшие совпадения первыми
+	Search(ctx context.Context, platform, chatID, query string, limit int) ([]SearchHit, error)
+	// SearchUser ищет по записям пользователя во всех чатах; при пустом запросе возвращает его последние записи
+	SearchUser(ctx context.Context, platform, userID, query string, limit int) ([]SearchHit, error)
+	ChatsWithRecords(ctx context.Context) ([]ChatRef, error)
+	// ClearTranscripts стирает расшифровки записей чата, созданных раньше before, и возвращает их число
+	ClearTranscripts(ctx context.Context, platform, chatID string, before time.Time) (int64, error)
+	// Методы удаления возвращают удаленные записи, чтобы можно было убрать их копии из кэша и архива
+	DeleteRecordsBefore(ctx context.Context, platform, chatID string, before time.Time) ([]Record, error)
+	DeleteUserData(ctx context.Context, platform, userID string) ([]Record, error)
+	DeleteChatRecords(ctx context.Context, platform, chatID string) ([]Record, error)
+}
+
+// SettingsStore хранит настройки чатов
+type SettingsStore interface {
+	// GetSettings возвращает настройки чата; для чата без настроек - нулевое значение
+	GetSettings(ctx context.Context, platform, chatID string) (ChatSettings, error)
+	SaveSettings(ctx context.Context, platform, chatID string, settings ChatSettings) error
+	DeleteSettings(ctx context.Context, platform, chatID string) error
+	// ChatsWithSettings возвращает все чаты, у которых есть сохраненные настройки
+	ChatsWithSettings(ctx context.Context) ([]ChatRef, error)
+}
+
+// QuotaStore хранит счетчики потребления (запросы, секунды аудио, токены) по периодам
+type QuotaStore interface {
+	// AddUsage увеличивает счетчик на delta и возвращает новое значение
+	AddUsage(ctx context.Context, q Quota, delta int64) (int64, error)
+	GetUsage(ctx context.Context, q Quota) (int64, error)
+	// DeleteUsage удаляет все счетчики субъекта
+	DeleteUsage(ctx context.Context, platform, subject string) error
+}
+
+// AuditLog - журнал аудита, только пополняется
+type AuditLog interface {
+	AppendAudit(ctx context.Context, e AuditEntry) error
+	// ListAudit возвращает события, подходящие под q, в хронологическом порядке
+	ListAudit(ctx context.Context, q AuditQuery) ([]AuditEntry, error)
+}
+
+// ReminderStore хранит запланированные напоминания
+type ReminderStore interface {
+	AddReminder(ctx context.Context, r *Reminder) error
+	// DueReminders возвращает напоминания со сроком не позже now, самые ранние первыми
+	DueReminders(ctx context.Context, now time.Time, limit int) ([]Reminder, error)
+	DeleteReminder(ctx context.Context, id int64) error
+	DeleteUserReminders(ctx context.Context, platform, userID string) error
+	DeleteChatReminders(ctx context.Context, platform, chatID string) error
+}
+
+// BotSettingsStore хранит глобальные настройки бота, которые меняются во время работы
+type BotSettingsStore interface {
+	// GetBotSettings возвращает настройки бота; если они не сохранялись - нулевое значение
+	GetBotSettings(ctx context.Context) (BotSettings, error)
+	SaveBotSettings(ctx context.Context, settings BotSettings) error
+}
+
+// JobStore хранит задания, которые поставлены в очередь или обрабатываются,
+// чтобы после перезапуска бот мог их возобновить
+type JobStore interface {
+	// SaveJob создает задание или обновляет существующее с тем же сообщением
+	SaveJob(ctx context.Context, j PendingJob) error
+	DeleteJob(ctx context.Context, platform, chatID, messageID string) error
+	// PendingJobs возвращает все незавершенные задания, самые старые первыми
+	PendingJobs(ctx context.Context) ([]PendingJob, error)
+}
+
+// TenantStore хранит тенантов - независимых ботов, которые обслуживает один процесс в режиме MULTI_TENANT
+type TenantStore interface {
+	// ListTenants возвращает всех тенантов, включая отключенных, по возрастанию ID
+	ListTenants(ctx context.Context) ([]Tenant, error)
+}
+
+// Stores объединяет хранилища, с которыми работают обработчики.
+// Transcripts может быть nil, если хранение расшифровок не настроено.
+type Stores struct {
+	Transcripts TranscriptStore
+	Settings    SettingsStore
+	Quotas      QuotaStore
+	Audit       AuditLog
+	Reminders   ReminderStore
+	Bot         BotSettingsStore
+	Jobs        JobStore
+	// Тенанты есть только у хранилищ SQLite и PostgreSQL; nil - режим MULTI_TENANT недоступен
+	Tenants TenantStore
+}
+
+// Все реализации поддерживают полный набор интерфейсов
+var (
+	_ TranscriptStore = (*SQLStore)(nil)
+	_ SettingsStore   = (*SQLStore)(nil)
+	_ QuotaStore      = (*SQLStore)(nil)
+	_ AuditLog        = (*SQLStore)(nil)
+	_ ReminderStore   = (*SQLStore)(nil)
+	_ TranscriptStore = (*Memory)(nil)
+	_ SettingsStore   = (*Memory)(nil)
+	_ QuotaStore      = (*Memory)(nil)
+	_ AuditLog        = (*Memory)(nil)
+	_ ReminderStore   = (*Memory)(nil)
+	_ TranscriptStore = (*Redis)(nil)
+	_ SettingsStore   = (*Redis)(nil)
+	_ QuotaStore      = (*Redis)(nil)
+	_ AuditLog        = (*Redis)(nil)
+	_ ReminderStore   = (*Redis)(nil)
+	_ TranscriptStore = (*namespaced)(nil)
+	_ SettingsStore   = (*namespaced)(nil)
+	_ QuotaStore      = (*namespaced)(nil)
+	_ AuditLog        = (*namespaced)(nil)
+	_ ReminderStore   = (*namespaced)(nil)
+)
+
+var (
+	_ BotSettingsStore = (*SQLStore)(nil)
+	_ BotSettingsStore = (*Memory)(nil)
+	_ BotSettingsStore = (*Redis)(nil)
+	_ JobStore         = (*SQLStore)(nil)
+	_ JobStore         = (*Memory)(nil)
+	_ JobStore         = (*Redis)(nil)
+	_ JobStore         = (*namespaced)(nil)
+	_ TenantStore      = (*SQLStore)(nil)
+)
+
+// Quota - ключ счетчика потребления
+type Quota struct {
+	Platform string
+	Subject  string // ID пользователя
+	Metric   string
+	Period   string // например, день "2006-01-02"
+}
+
+// Метрики потребления
+const (
+	MetricRequests     = "requests"
+	MetricAudioSeconds = "audio_seconds"
+	MetricTokens       = "tokens"
+)
+
+// DayPeriod возвращает период-сутки (UTC) для момента t
+func DayPeriod(t time.Time) string { return t.UTC().Format("2006-01-02") }
+
+// MonthPeriod возвращает период-месяц (UTC) для момента t
+func MonthPeriod(t time.Time) string { return t.UTC().Format("2006-01") }
+
+// GlobalSubject - субъект счетчиков расходов всего бота
+const GlobalSubject = "*"
+
+// ChatSubject - субъект счетчиков расходов чата chatID
+func ChatSubject(chatID string) string { return "chat:" + chatID }
+
+// Record - сохраненный результат обработки одного медиафайла
+type Record struct {
+	ID         int64
+	Platform   string
+	ChatID     string
+	UserID     string
+	MessageID  string
+	Duration   time.Duration
+	Transcript string
+	Summary    string
+	Tokens     int
+	CreatedAt  time.Time
+	// Ключи объектов в архиве S3, если архивирование включено
+	OriginalKey string
+	AudioKey    string
+}
+
+// ChatSettings - настройки чата, хранятся одним JSON-документом
+type ChatSettings struct {
+	Notes     *NotesDestination `json:"notes,omitempty"`
+	Retention *RetentionPolicy  `json:"retention,omitempty"`
+	Preferences
+	// Тихий режим: вместо статусов и сообщений об ошибках бот ставит реакции
+	Quiet bool `json:"quiet,omitempty"`
+	// Скрывать под спойлер расшифровку и резюме; nil - по умолчанию (см. Spoilers)
+	SpoilerTranscript *bool `json:"spoiler_transcript,omitempty"`
+	SpoilerSummary    *bool `json:"spoiler_summary,omitempty"`
+	// Маскировать нецензурные слова в расшифровках и резюме ("с***")
+	MaskProfanity bool `json:"mask_profanity,omitempty"`
+	// Скрывать персональные данные (телефоны, карты, адреса, имена) до показа и сохранения расшифровки
+	RedactPII bool `json:"redact_pii,omitempty"`
+	// Переопределения Preferences для тем форума, ключ - ID темы (message_thread_id)
+	Topics map[string]*Preferences `json:"topics,omitempty"`
+	// Словарь терминов чата: как слышится → как писать ("Кубернетес" → "Kubernetes")
+	Glossary map[string]string `json:"glossary,omitempty"`
+	// Отправлять расшифровки и резюме с защитой от пересылки и сохранения (protect_content)
+	ProtectContent bool `json:"protect_content,omitempty"`
+	// Какие сообщения отправлять без звука: SilentStatus или SilentAll; пусто - все со звуком
+	Silent string `json:"silent,omitempty"`
+	// Не присылать в чат объявления администраторов бота (/admin broadcast)
+	NoAnnouncements bool `json:"no_announcements,omitempty"`
+	// Язык интерфейса, выбранный через /uilang; пусто - определяется по языку записей и сообщений чата
+	UILanguage string `json:"ui_language,omitempty"`
+	// Часовой пояс чата (IANA), выбранный через /timezone; пусто - TIMEZONE
+	Timezone string `json:"timezone,omitempty"`
+	// Дописывать резюме за день в одно закрепленное сообщение "Итоги дня" вместо отдельных сообщений
+	DailyNotes bool `json:"daily_notes,omitempty"`
+	// Закреплять резюме с решениями, договоренностями и сроками (/autopin) и через сколько их откреплять:
+	// "12h", "3d", "0" - не откреплять; пусто - AUTO_PIN_DURATION
+	AutoPin    bool   `json:"auto_pin,omitempty"`
+	AutoPinFor string `json:"auto_pin_for,omitempty"`
+	// Для личного чата с пользователем: присылать результаты его записей из групп сюда (/dm)
+	DeliverToDM bool `json:"deliver_to_dm,omitempty"`
+}
+
+// Spoilers сообщает, скрывать ли под спойлер расшифровку и резюме. По умолчанию расшифровка
+// открыта, а резюме скрыто.
+func (s ChatSettings) Spoilers() (transcript, summary bool) {
+	transcript, summary = false, true
+	if s.SpoilerTranscript != nil {
+		transcript = *s.SpoilerTranscript
+	}
+	if s.SpoilerSummary != nil {
+		summary = *s.SpoilerSummary
+	}
+	return transcript, summary
+}
+
+// Preferences - настройки, которые тема форума может переопределить; пустое поле означает
+// значение по умолчанию (для темы - значение чата)
+type Preferences struct {
+	// Автоматически обрабатывать голосовые и аудио; nil - да
+	Auto *bool `json:"auto,omitempty"`
+	// Язык резюме (ISO 639-1)
+	Language string `json:"language,omitempty"`
+	// Подробность ответов: VerbosityQuiet, VerbosityNormal или VerbosityVerbose
+	Verbosity string `json:"verbosity,omitempty"`
+	// Персона резюме (config.Persona*); пусто - системный промпт по умолчанию
+	Persona string `json:"persona,omitempty"`
+}
+
+// ForTopic возвращает настройки с учетом переопределений темы форума threadID (0 - без темы)
+func (s ChatSettings) ForTopic(threadID int) ChatSettings {
+	topic := s.Topics[strconv.Itoa(threadID)]
+	if threadID == 0 || topic == nil {
+		return s
+	}
+	if topic.Auto != nil {
+		s.Auto = topic.Auto
+	}
+	if topic.Language != "" {
+		s.Language = topic.Language
+	}
+	if topic.Verbosity != "" {
+		s.Verbosity = topic.Verbosity
+	}
+	if topic.Persona != "" {
+		s.Persona = topic.Persona
+	}
+	return s
+}
+
+// Уровни подробности ответов бота
+const (
+	VerbosityQuiet   = "quiet"   // только резюме, без статусов и расшифровки
+	VerbosityNormal  = "normal"  // статусы, расшифровка и резюме
+	VerbosityVerbose = "verbose" // плюс сведения об обработке: модель, токены, время
+)
+
+// Режимы беззвучной отправки
+const (
+	SilentStatus = "status" // без звука статусы, ошибки и расшифровка; со звуком только резюме
+	SilentAll    = "all"    // все сообщения без звука
+)
+
+// SilentFor сообщает, отправлять ли без звука сообщение: итоговое резюме (final) или служебное
+func (s ChatSettings) SilentFor(final bool) bool {
+	return s.Silent == SilentAll || (s.Silent == SilentStatus && !final)
+}
+
+// RetentionPolicy - сколько хранить данные чата; 0 дней означает бессрочное хранение.
+// По истечении TranscriptDays у записи стирается расшифровка, по истечении SummaryDays запись удаляется целиком.
+type RetentionPolicy struct {
+	TranscriptDays int  `json:"transcript_days,omitempty"`
+	SummaryDays    int  `json:"summary_days,omitempty"`
+	Disabled       bool `json:"disabled,omitempty"` // ничего не сохранять
+}
+
+// NotesDestination - куда дублировать резюме чата: страница Notion или папка в хранилище заметок
+type NotesDestination struct {
+	Kind   string `json:"kind"`   // "notion" или "vault"
+	Target string `json:"target"` // ID родительской страницы Notion или папка в хранилище
+}
+
+// Маркеры начала и конца совпадения во фрагментах поиска
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// SearchHit - найденная запись и фрагмент текста с отмеченными совпадениями
+type SearchHit struct {
+	Record
+	Snippet string
+}
+
+// BotSettings - глобальные настройки бота, которые администраторы бота меняют командой /admin
+type BotSettings struct {
+	// Промпты, заданные во время работы; пусто - промпт из конфигурации
+	SystemPrompt        string `json:"system_prompt,omitempty"`
+	UserPromptTemplate  string `json:"user_prompt_template,omitempty"`
+	ShortPromptTemplate string `json:"short_prompt_template,omitempty"`
+	// Режим обслуживания; MaintenanceQueue - откладывать новые записи до его выключения
+	Maintenance      bool `json:"maintenance,omitempty"`
+	MaintenanceQueue bool `json:"maintenance_queue,omitempty"`
+	// Модели, закрепленные за чатами: ключ "платформа:ID чата", значение - имя модели Gemini
+	ChatModels map[string]string `json:"chat_models,omitempty"`
+	// Идущий эксперимент с промптами; nil - экспериментов нет
+	Experiment *Experiment `json:"experiment,omitempty"`
+	// Переопределения флагов функций, ключ - имя флага
+	Flags map[string]FlagRule `json:"flags,omitempty"`
+}
+
+// FlagRule - правило флага функции: доля чатов, в которых он включен, и явные решения для чатов
+type FlagRule struct {
+	Percent int `json:"percent"`
+	// Ключ - ID чата, значение - включен ли флаг в нем независимо от Percent
+	Chats map[string]bool `json:"chats,omitempty"`
+}
+
+// Experiment - A/B-эксперимент: чаты распределяются между вариантами системного промпта
+type Experiment struct {
+	Name      string              `json:"name"`
+	Variants  []ExperimentVariant `json:"variants"`
+	StartedAt time.Time           `json:"started_at"`
+}
+
+// ExperimentVariant - вариант эксперимента; пустой SystemPrompt - действующий системный промпт
+type ExperimentVariant struct {
+	Name         string `json:"name"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// AuditEntry - запись журнала аудита
+type AuditEntry struct {
+	ID        int64
+	CreatedAt time.Time
+	Platform  string
+	ChatID    string
+	UserID    string
+	Action    string
+	Details   string
+}
+
+// AuditQuery - условия выборки из журнала аудита
+type AuditQuery struct {
+	// Только события с этим действием; пусто - все
+	Action string
+	// Только события не раньше Since; нулевое значение - без ограничения
+	Since time.Time
+	// Только последние Limit событий; 0 - все
+	Limit int
+}
+
+// Reminder - напоминание, которое нужно отправить в чат в момент DueAt
+type Reminder struct {
+	ID        int64
+	Kind      string // пусто - напоминание с текстом Text, ReminderUnpin - открепить сообщение MessageID
+	Platform  string
+	ChatID    string
+	UserID    string
+	MessageID string // сообщение, ответом на которое придет напоминание
+	Text      string
+	DueAt     time.Time
+	CreatedAt time.Time
+}
+
+// ReminderUnpin - отложенное открепление автоматически закрепленного резюме (/autopin)
+const ReminderUnpin = "unpin"
+
+// PendingJob - незавершенная обработка сообщения. Payload содержит исходное
+// сообщение платформы в JSON (в нем идентификаторы файлов), чтобы повторить обработку
+type PendingJob struct {
+	Platform  string
+	ChatID    string
+	MessageID string
+	UserID    string
+	Stage     string // queued или running
+	Payload   string
+	Attempts  int    // сколько раз задание уже возобновлялось после перезапуска
+	Owner     string // реплика, которая обрабатывает задание; пока она жива, UpdatedAt обновляется
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Tenant - бот, которого обслуживает процесс в режиме MULTI_TENANT: свой токен, ключ Gemini и
+// настройки. Данные тенанта хранятся в общих таблицах отдельно от других (см. Namespace)
+type Tenant struct {
+	ID           string
+	BotToken     string
+	GoogleAPIKey string // пусто - общий GOOGLE_API_KEY
+	Config       TenantConfig
+	Disabled     bool
+}
+
+// TenantConfig - настройки тенанта поверх конфигурации процесса, хранятся одним JSON-документом;
+// пустое поле - значение из конфигурации процесса
+type TenantConfig struct {
+	PrimaryModel       string  `json:"primary_model,omitempty"`
+	FallbackModel      string  `json:"fallback_model,omitempty"`
+	SystemPrompt       string  `json:"system_prompt,omitempty"`
+	UserPromptTemplate string  `json:"user_prompt_template,omitempty"`
+	SummaryLanguage    string  `json:"summary_language,omitempty"`
+	Timezone           string  `json:"timezone,omitempty"`
+	BotAdminIDs        []int64 `json:"bot_admin_ids,omitempty"`
+	FeatureFlags       string  `json:"feature_flags,omitempty"`
+	// Квоты: лимиты токенов за сутки и месяц на тенанта и на каждый его чат, задания в час
+	// на пользователя, одновременные задания и длительность записи в минутах
+	BudgetDaily        int64 `json:"budget_daily,omitempty"`
+	BudgetMonthly      int64 `json:"budget_monthly,omitempty"`
+	BudgetChatDaily    int64 `json:"budget_chat_daily,omitempty"`
+	BudgetChatMonthly  int64 `json:"budget_chat_monthly,omitempty"`
+	UserRateLimit      int   `json:"user_rate_limit,omitempty"`
+	MaxConcurrentJobs  int   `json:"max_concurrent_jobs,omitempty"`
+	MaxDurationMinutes int   `json:"max_duration_minutes,omitempty"`
+}
+
+// ChatRef - чат, для которого в хранилище есть записи
+type ChatRef struct {
+	Platform string
+	ChatID   string
+}
+
+// filterAudit выбирает из журнала (в хронологическом порядке) события, подходящие под q
+func filterAudit(entries []AuditEntry, q AuditQuery) []AuditEntry {
+	var out []AuditEntry
+	for _, e := range entries {
+		if (q.Action == "" || e.Action == q.Action) && !e.CreatedAt.Before(q.Since) {
+			out = append(out, e)
+		}
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out
+}
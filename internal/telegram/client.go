@@ -2,35 +2,109 @@ package telegram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
+// ErrFileTooLarge - файл больше лимита, заданного SetMaxDownloadSize; скачивание прерывается
+var ErrFileTooLarge = errors.New("файл больше допустимого размера")
+
+// ErrFileExpired - ссылка на файл из getFile больше не действует (404): нужен новый путь через getFile
+var ErrFileExpired = errors.New("ссылка на файл устарела")
+
+// ErrConflict - getUpdates вернул 409 Conflict: обновления уже получает другой экземпляр бота
+// с тем же токеном (или у бота установлен вебхук)
+var ErrConflict = errors.New("обновления получает другой клиент")
+
+// Таймаут long polling по умолчанию
+const defaultPollTimeout = 60 * time.Second
+
 type Client struct {
 	baseURL  string
 	http     *http.Client
 	botToken string
+
+	// Отдельные клиенты для long polling и скачивания файлов; nil - используется http
+	poll     *http.Client
+	download *http.Client
+
+	// Максимальный размер скачиваемого файла в байтах; 0 - без ограничения
+	maxDownloadSize int64
+	// Сколько попыток дается на скачивание одного файла, включая докачки после обрыва
+	downloadAttempts int
+
+	// Сколько getUpdates ждет новых обновлений; 0 - defaultPollTimeout
+	pollTimeout time.Duration
 }
 
 func NewClient(botToken, baseURL string, httpClient *http.Client) *Client {
 	return &Client{baseURL: baseURL, http: httpClient, botToken: botToken}
 }
 
-func (c *Client) GetUpdates(offset int) ([]Update, error) {
-	resp, err := c.http.Get(fmt.Sprintf("%s/getUpdates?offset=%d&timeout=60", c.baseURL, offset))
+// SetPollClient задает HTTP-клиент для long polling getUpdates: запрос висит до таймаута long polling,
+// поэтому общий таймаут клиента API ему не подходит
+func (c *Client) SetPollClient(httpClient *http.Client) { c.poll = httpClient }
+
+// SetDownloadClient задает HTTP-клиент для скачивания файлов с большим таймаутом и пулом соединений
+func (c *Client) SetDownloadClient(httpClient *http.Client) { c.download = httpClient }
+
+func (c *Client) pollClient() *http.Client {
+	if c.poll != nil {
+		return c.poll
+	}
+	return c.http
+}
+
+func (c *Client) downloadClient() *http.Client {
+	if c.download != nil {
+		return c.download
+	}
+	return c.http
+}
+
+// SetMaxDownloadSize ограничивает размер скачиваемых файлов: больший файл не скачивается в память,
+// а Download и DownloadFile возвращают ErrFileTooLarge
+func (c *Client) SetMaxDownloadSize(size int64) { c.maxDownloadSize = size }
+
+// SetDownloadAttempts задает бюджет попыток на скачивание одного файла; меньше 1 - одна попытка
+func (c *Client) SetDownloadAttempts(attempts int) { c.downloadAttempts = attempts }
+
+// SetPollTimeout задает, сколько getUpdates ждет новых обновлений (округляется до секунд)
+func (c *Client) SetPollTimeout(timeout time.Duration) { c.pollTimeout = timeout }
+
+// GetUpdates запрашивает обновления long polling'ом, пока ctx не отменен; при 409 Conflict возвращает ErrConflict
+func (c *Client) GetUpdates(ctx context.Context, offset int) ([]Update, error) {
+	timeout := c.pollTimeout
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/getUpdates?offset=%d&timeout=%d", c.baseURL, offset, int(timeout.Seconds())), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запросе getUpdates: %w", err)
+	}
+	resp, err := c.pollClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при запросе getUpdates: %w", err)
 	}
 	defer resp.Body.Close()
 	var updatesResp GetUpdatesResponse
 	if err := json.NewDecoder(resp.Body).Decode(&updatesResp); err != nil {
-		return nil, fmt.Errorf("ошибка декодирования ответа getUpdates: %w", err)
+		return nil, fmt.Errorf("ошибка декодирования ответа getUpdates (статус %s): %w", resp.Status, err)
+	}
+	if resp.StatusCode == http.StatusConflict {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, updatesResp.Description)
 	}
 	if !updatesResp.Ok {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ответ от getUpdates не 'ok': %s", string(body))
+		return nil, fmt.Errorf("ответ от getUpdates не 'ok' (статус %s): %s", resp.Status, updatesResp.Description)
 	}
 	return updatesResp.Result, nil
 }
@@ -52,42 +126,481 @@ func (c *Client) GetFile(fileID string) (*File, error) {
 	return &fileResp.Result, nil
 }
 
-func (c *Client) DownloadFile(filePath string) ([]byte, error) {
-	fileURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", c.botToken, filePath)
-	resp, err := c.http.Get(fileURL)
+func (c *Client) GetChatMember(chatID, userID int64) (*ChatMember, error) {
+	resp, err := c.http.Get(fmt.Sprintf("%s/getChatMember?chat_id=%d&user_id=%d", c.baseURL, chatID, userID))
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при скачивании файла: %w", err)
+		return nil, fmt.Errorf("ошибка при запросе getChatMember: %w", err)
+	}
+	defer resp.Body.Close()
+	var memberResp GetChatMemberResponse
+	if err := json.NewDecoder(resp.Body).Decode(&memberResp); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ответа getChatMember: %w", err)
+	}
+	if !memberResp.Ok {
+		return nil, fmt.Errorf("ответ от getChatMember не 'ok'")
+	}
+	return &memberResp.Result, nil
+}
+
+type setWebhookPayload struct {
+	URL            string   `json:"url"`
+	SecretToken    string   `json:"secret_token,omitempty"`
+	AllowedUpdates []string `json:"allowed_updates,omitempty"`
+}
+
+// SetWebhook включает доставку обновлений POST-запросами на url; Telegram будет передавать
+// secretToken в заголовке X-Telegram-Bot-Api-Secret-Token каждого запроса
+func (c *Client) SetWebhook(url, secretToken string) error {
+	payloadBytes, err := json.Marshal(setWebhookPayload{URL: url, SecretToken: secretToken})
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для setWebhook: %w", err)
+	}
+	return c.postMethod("setWebhook", payloadBytes)
+}
+
+// DeleteWebhook отключает вебхук, чтобы снова получать обновления через getUpdates
+func (c *Client) DeleteWebhook() error {
+	return c.postMethod("deleteWebhook", []byte("{}"))
+}
+
+func (c *Client) postMethod(method string, payloadBytes []byte) error {
+	resp, err := c.http.Post(fmt.Sprintf("%s/%s", c.baseURL, method), "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса %s: %w", method, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("не удалось скачать файл, статус: %s", resp.Status)
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ошибка %s, статус: %s, тело: %s", method, resp.Status, string(body))
+	}
+	return nil
+}
+
+// GetBusinessConnection возвращает сведения о подключении бота к бизнес-аккаунту
+func (c *Client) GetBusinessConnection(id string) (*BusinessConnection, error) {
+	resp, err := c.http.Get(fmt.Sprintf("%s/getBusinessConnection?business_connection_id=%s", c.baseURL, url.QueryEscape(id)))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запросе getBusinessConnection: %w", err)
 	}
-	return io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	var connResp struct {
+		Ok     bool               `json:"ok"`
+		Result BusinessConnection `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&connResp); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ответа getBusinessConnection: %w", err)
+	}
+	if !connResp.Ok {
+		return nil, fmt.Errorf("ответ от getBusinessConnection не 'ok'")
+	}
+	return &connResp.Result, nil
+}
+
+// Download скачивает файл, полученный через GetFile; размер из ответа getFile проверяется до скачивания
+func (c *Client) Download(f *File) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.DownloadTo(f, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadTo скачивает файл, полученный через GetFile, потоком в w, не собирая его в памяти
+func (c *Client) DownloadTo(f *File, w io.Writer) error {
+	if c.maxDownloadSize > 0 && f.FileSize > c.maxDownloadSize {
+		return fmt.Errorf("%w: %d байт", ErrFileTooLarge, f.FileSize)
+	}
+	var refresh func() (string, error)
+	if f.FileID != "" {
+		// Пути из getFile живут ограниченное время: отложенное или повторенное задание может получить
+		// 404 и тогда запрашивает свежий путь к тому же файлу
+		refresh = func() (string, error) {
+			fresh, err := c.GetFile(f.FileID)
+			if err != nil {
+				return "", err
+			}
+			f.FilePath = fresh.FilePath
+			return fresh.FilePath, nil
+		}
+	}
+	return c.downloadTo(f.FilePath, refresh, w)
+}
+
+// DownloadFile скачивает файл по пути из getFile. При обрыве соединения файл докачивается запросом
+// с Range с места обрыва, пока не исчерпан бюджет попыток (SetDownloadAttempts)
+func (c *Client) DownloadFile(filePath string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.downloadTo(filePath, nil, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetChatMemberCount возвращает число участников чата
+func (c *Client) GetChatMemberCount(chatID int64) (int, error) {
+	resp, err := c.http.Get(fmt.Sprintf("%s/getChatMemberCount?chat_id=%d", c.baseURL, chatID))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при запросе getChatMemberCount: %w", err)
+	}
+	defer resp.Body.Close()
+	var countResp struct {
+		Ok     bool `json:"ok"`
+		Result int  `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&countResp); err != nil {
+		return 0, fmt.Errorf("ошибка декодирования ответа getChatMemberCount: %w", err)
+	}
+	if !countResp.Ok {
+		return 0, fmt.Errorf("ответ от getChatMemberCount не 'ok'")
+	}
+	return countResp.Result, nil
+}
+
+// downloadTo скачивает файл по пути filePath. Если путь устарел, а refresh задан, один раз запрашивает
+// новый путь и продолжает скачивание с того же места, не тратя попытку
+func (c *Client) downloadTo(filePath string, refresh func() (string, error), w io.Writer) error {
+	fileURL := c.fileURL(filePath)
+	attempts := max(c.downloadAttempts, 1)
+	var written int64
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("Повторное скачивание файла с %d байта (попытка %d из %d): %v", written, attempt, attempts, lastErr)
+			time.Sleep(time.Duration(attempt-1) * time.Second)
+		}
+		n, retryable, err := c.downloadFrom(fileURL, written, w)
+		written += n
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrFileExpired) && refresh != nil {
+			log.Printf("Путь к файлу %s устарел, запрашиваю новый", filePath)
+			fresh, refreshErr := refresh()
+			if refreshErr != nil {
+				return fmt.Errorf("%w; не удалось получить новый путь: %w", err, refreshErr)
+			}
+			fileURL, refresh = c.fileURL(fresh), nil
+			attempt--
+			continue
+		}
+		if !retryable {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("не удалось скачать файл за %d попыток: %w", attempts, lastErr)
+}
+
+func (c *Client) fileURL(filePath string) string {
+	return fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", c.botToken, filePath)
+}
+
+// writeErrorWriter запоминает ошибку записи, чтобы отличить ее от обрыва чтения
+type writeErrorWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *writeErrorWriter) Write(p []byte) (int, error) {
+	n, err := ew.w.Write(p)
+	if err != nil {
+		ew.err = err
+	}
+	return n, err
+}
+
+// downloadFrom докачивает файл в w начиная с offset байт. Возвращает число записанных байт и
+// сообщает, имеет ли смысл повторить попытку при ошибке
+func (c *Client) downloadFrom(fileURL string, offset int64, w io.Writer) (int64, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка создания запроса на скачивание файла: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.downloadClient().Do(req)
+	if err != nil {
+		return 0, true, fmt.Errorf("ошибка при скачивании файла: %w", err)
+	}
+	defer resp.Body.Close()
+	total := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Сервер не поддержал Range - пропускаем уже записанное начало файла
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				return 0, true, fmt.Errorf("обрыв соединения при скачивании файла: %w", err)
+			}
+		}
+	case http.StatusPartialContent:
+		if total >= 0 {
+			total += offset
+		}
+	case http.StatusNotFound:
+		return 0, false, fmt.Errorf("%w: статус %s", ErrFileExpired, resp.Status)
+	default:
+		return 0, resp.StatusCode >= http.StatusInternalServerError, fmt.Errorf("не удалось скачать файл, статус: %s", resp.Status)
+	}
+	body := io.Reader(resp.Body)
+	if c.maxDownloadSize > 0 {
+		if total > c.maxDownloadSize {
+			return 0, false, fmt.Errorf("%w: %d байт", ErrFileTooLarge, total)
+		}
+		// Content-Length может отсутствовать или не совпадать с телом: читаем не больше лимита плюс байт,
+		// чтобы отличить файл ровно на лимит от большего
+		body = io.LimitReader(resp.Body, c.maxDownloadSize+1-offset)
+	}
+	ew := &writeErrorWriter{w: w}
+	n, err := io.Copy(ew, body)
+	if c.maxDownloadSize > 0 && offset+n > c.maxDownloadSize {
+		return n, false, fmt.Errorf("%w: больше %d байт", ErrFileTooLarge, c.maxDownloadSize)
+	}
+	if ew.err != nil {
+		return n, false, fmt.Errorf("ошибка записи скачанного файла: %w", ew.err)
+	}
+	if err != nil {
+		return n, true, fmt.Errorf("обрыв соединения при скачивании файла: %w", err)
+	}
+	return n, false, nil
 }
 
 type sendMessagePayload struct {
-	ChatID           int64  `json:"chat_id"`
-	Text             string `json:"text"`
-	ParseMode        string `json:"parse_mode,omitempty"`
-	ReplyToMessageID int    `json:"reply_to_message_id"`
+	ChatID           int64                 `json:"chat_id"`
+	Text             string                `json:"text"`
+	ParseMode        string                `json:"parse_mode,omitempty"`
+	ReplyToMessageID int                   `json:"reply_to_message_id,omitempty"`
+	ReplyMarkup      *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	// ID бизнес-подключения, от имени которого отправляется сообщение
+	BusinessConnectionID string `json:"business_connection_id,omitempty"`
+	ProtectContent       bool   `json:"protect_content,omitempty"`
+	DisableNotification  bool   `json:"disable_notification,omitempty"`
+	MessageEffectID      string `json:"message_effect_id,omitempty"`
 }
 
 func (c *Client) SendMessage(chatID int64, text string, replyTo int, parseMode string) error {
-	payload := sendMessagePayload{ChatID: chatID, Text: text, ParseMode: parseMode, ReplyToMessageID: replyTo}
+	_, err := c.Send(chatID, text, SendOptions{ReplyTo: replyTo, ParseMode: parseMode})
+	return err
+}
+
+// Send отправляет сообщение с дополнительными параметрами и возвращает отправленное сообщение
+func (c *Client) Send(chatID int64, text string, opts SendOptions) (*Message, error) {
+	payload := sendMessagePayload{ChatID: chatID, Text: text, ParseMode: opts.ParseMode, ReplyToMessageID: opts.ReplyTo, ReplyMarkup: opts.ReplyMarkup,
+		BusinessConnectionID: opts.BusinessConnectionID, ProtectContent: opts.ProtectContent,
+		DisableNotification: opts.DisableNotification, MessageEffectID: opts.MessageEffectID}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("ошибка маршалинга payload для sendMessage: %w", err)
+		return nil, fmt.Errorf("ошибка маршалинга payload для sendMessage: %w", err)
 	}
 	resp, err := c.http.Post(fmt.Sprintf("%s/sendMessage", c.baseURL), "application/json", bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return fmt.Errorf("ошибка при отправке запроса sendMessage: %w", err)
+		return nil, fmt.Errorf("ошибка при отправке запроса sendMessage: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("не удалось отправить сообщение, статус: %s, тело: %s", resp.Status, string(body))
+	}
+	var sendResp SendMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sendResp); err != nil {
+		return nil, fmt.Errorf("ошибка декодирования ответа sendMessage: %w", err)
+	}
+	return &sendResp.Result, nil
+}
+
+type editMessageTextPayload struct {
+	ChatID      int64                 `json:"chat_id"`
+	MessageID   int                   `json:"message_id"`
+	Text        string                `json:"text"`
+	ParseMode   string                `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// EditMessageText заменяет текст (и клавиатуру) ранее отправленного ботом сообщения
+func (c *Client) EditMessageText(chatID int64, messageID int, text string, opts SendOptions) error {
+	payload := editMessageTextPayload{ChatID: chatID, MessageID: messageID, Text: text, ParseMode: opts.ParseMode, ReplyMarkup: opts.ReplyMarkup}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для editMessageText: %w", err)
+	}
+	resp, err := c.http.Post(fmt.Sprintf("%s/editMessageText", c.baseURL), "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса editMessageText: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("не удалось изменить сообщение, статус: %s, тело: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+type pinChatMessagePayload struct {
+	ChatID              int64 `json:"chat_id"`
+	MessageID           int   `json:"message_id"`
+	DisableNotification bool  `json:"disable_notification,omitempty"`
+}
+
+// PinChatMessage закрепляет сообщение в чате; в группах боту нужно право закреплять сообщения.
+// С disableNotification участники не получают уведомление о закреплении.
+func (c *Client) PinChatMessage(chatID int64, messageID int, disableNotification bool) error {
+	payloadBytes, err := json.Marshal(pinChatMessagePayload{ChatID: chatID, MessageID: messageID, DisableNotification: disableNotification})
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для pinChatMessage: %w", err)
+	}
+	return c.postMethod("pinChatMessage", payloadBytes)
+}
+
+type unpinChatMessagePayload struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+// UnpinChatMessage открепляет сообщение в чате
+func (c *Client) UnpinChatMessage(chatID int64, messageID int) error {
+	payloadBytes, err := json.Marshal(unpinChatMessagePayload{ChatID: chatID, MessageID: messageID})
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для unpinChatMessage: %w", err)
+	}
+	return c.postMethod("unpinChatMessage", payloadBytes)
+}
+
+type answerCallbackPayload struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+// AnswerCallbackQuery подтверждает нажатие inline-кнопки; text показывается всплывающим уведомлением
+func (c *Client) AnswerCallbackQuery(callbackQueryID, text string) error {
+	payloadBytes, err := json.Marshal(answerCallbackPayload{CallbackQueryID: callbackQueryID, Text: text})
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для answerCallbackQuery: %w", err)
+	}
+	resp, err := c.http.Post(fmt.Sprintf("%s/answerCallbackQuery", c.baseURL), "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса answerCallbackQuery: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("не удалось отправить сообщение, статус: %s, тело: %s", resp.Status, string(body))
+		return fmt.Errorf("не удалось ответить на callback, статус: %s, тело: %s", resp.Status, string(body))
 	}
 	return nil
 }
 
+// ReactionType - реакция на сообщение; поддерживаются только обычные эмодзи
+type ReactionType struct {
+	Type  string `json:"type"` // всегда "emoji"
+	Emoji string `json:"emoji"`
+}
+
+type setReactionPayload struct {
+	ChatID    int64          `json:"chat_id"`
+	MessageID int            `json:"message_id"`
+	Reaction  []ReactionType `json:"reaction"`
+}
 
+// SetMessageReaction ставит боту реакцию emoji на сообщение; пустой emoji снимает реакцию
+func (c *Client) SetMessageReaction(chatID int64, messageID int, emoji string) error {
+	payload := setReactionPayload{ChatID: chatID, MessageID: messageID, Reaction: []ReactionType{}}
+	if emoji != "" {
+		payload.Reaction = append(payload.Reaction, ReactionType{Type: "emoji", Emoji: emoji})
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для setMessageReaction: %w", err)
+	}
+	resp, err := c.http.Post(fmt.Sprintf("%s/setMessageReaction", c.baseURL), "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса setMessageReaction: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("не удалось поставить реакцию, статус: %s, тело: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+type answerInlinePayload struct {
+	InlineQueryID string                     `json:"inline_query_id"`
+	Results       []InlineQueryResultArticle `json:"results"`
+	CacheTime     int                        `json:"cache_time"`
+	IsPersonal    bool                       `json:"is_personal"`
+}
+
+// AnswerInlineQuery отвечает на inline-запрос; результаты персональны и кэшируются Telegram на cacheTime секунд
+func (c *Client) AnswerInlineQuery(queryID string, results []InlineQueryResultArticle, cacheTime int) error {
+	for i := range results {
+		results[i].Type = "article"
+	}
+	if results == nil {
+		results = []InlineQueryResultArticle{}
+	}
+	payloadBytes, err := json.Marshal(answerInlinePayload{InlineQueryID: queryID, Results: results, CacheTime: cacheTime, IsPersonal: true})
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload для answerInlineQuery: %w", err)
+	}
+	resp, err := c.http.Post(fmt.Sprintf("%s/answerInlineQuery", c.baseURL), "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса answerInlineQuery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("не удалось ответить на inline-запрос, статус: %s, тело: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// SendDocument отправляет файл с содержимым data как документ; opts.Caption - подпись под файлом
+func (c *Client) SendDocument(chatID int64, fileName string, data []byte, opts SendOptions) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	if opts.ReplyTo != 0 {
+		_ = w.WriteField("reply_to_message_id", strconv.Itoa(opts.ReplyTo))
+	}
+	if opts.Caption != "" {
+		_ = w.WriteField("caption", opts.Caption)
+		if opts.ParseMode != "" {
+			_ = w.WriteField("parse_mode", opts.ParseMode)
+		}
+	}
+	if opts.ReplyMarkup != nil {
+		markup, err := json.Marshal(opts.ReplyMarkup)
+		if err != nil {
+			return fmt.Errorf("ошибка маршалинга клавиатуры для sendDocument: %w", err)
+		}
+		_ = w.WriteField("reply_markup", string(markup))
+	}
+	if opts.BusinessConnectionID != "" {
+		_ = w.WriteField("business_connection_id", opts.BusinessConnectionID)
+	}
+	if opts.ProtectContent {
+		_ = w.WriteField("protect_content", "true")
+	}
+	if opts.DisableNotification {
+		_ = w.WriteField("disable_notification", "true")
+	}
+	part, err := w.CreateFormFile("document", fileName)
+	if err != nil {
+		return fmt.Errorf("ошибка формирования multipart для sendDocument: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("ошибка записи файла в multipart для sendDocument: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("ошибка формирования multipart для sendDocument: %w", err)
+	}
+	resp, err := c.http.Post(fmt.Sprintf("%s/sendDocument", c.baseURL), w.FormDataContentType(), &body)
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке запроса sendDocument: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("не удалось отправить документ, статус: %s, тело: %s", resp.Status, string(respBody))
+	}
+	return nil
+}
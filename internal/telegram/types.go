@@ -1,31 +1,231 @@
 package telegram
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type Update struct {
-	UpdateID int      `json:"update_id"`
-	Message  *Message `json:"message"`
+	UpdateID      int            `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+	InlineQuery   *InlineQuery   `json:"inline_query"`
+	ChannelPost   *Message       `json:"channel_post"`
+	// Подключение бота к бизнес-аккаунту и сообщения, полученные через него
+	BusinessConnection *BusinessConnection `json:"business_connection"`
+	BusinessMessage    *Message            `json:"business_message"`
+}
+
+// BusinessConnection - подключение бота к аккаунту Telegram Business пользователя
+type BusinessConnection struct {
+	ID         string `json:"id"`
+	User       User   `json:"user"`
+	UserChatID int64  `json:"user_chat_id"`
+	// can_reply из старых версий Bot API; в новых права передаются в rights
+	CanReply  bool               `json:"can_reply"`
+	Rights    *BusinessBotRights `json:"rights"`
+	IsEnabled bool               `json:"is_enabled"`
+}
+
+type BusinessBotRights struct {
+	CanReply bool `json:"can_reply"`
+}
+
+// Replies сообщает, может ли бот отвечать в чатах бизнес-аккаунта от имени пользователя
+func (c *BusinessConnection) Replies() bool {
+	return c.IsEnabled && (c.CanReply || (c.Rights != nil && c.Rights.CanReply))
+}
+
+type InlineQuery struct {
+	ID     string `json:"id"`
+	From   *User  `json:"from"`
+	Query  string `json:"query"`
+	Offset string `json:"offset"`
+}
+
+type InputTextMessageContent struct {
+	MessageText string `json:"message_text"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+// InlineQueryResultArticle - результат inline-запроса, который при выборе отправляет текстовое сообщение
+type InlineQueryResultArticle struct {
+	Type                string                  `json:"type"` // всегда "article"
+	ID                  string                  `json:"id"`
+	Title               string                  `json:"title"`
+	Description         string                  `json:"description,omitempty"`
+	InputMessageContent InputTextMessageContent `json:"input_message_content"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    *User    `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+	// Запрос, который подставится после имени бота в выбранном пользователем чате
+	SwitchInlineQuery string `json:"switch_inline_query,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// SendOptions - необязательные параметры отправки сообщения
+type SendOptions struct {
+	ReplyTo     int
+	ParseMode   string
+	ReplyMarkup *InlineKeyboardMarkup
+	// Отправить от имени пользователя через его бизнес-подключение
+	BusinessConnectionID string
+	// Подпись к файлу (только для SendDocument), размечается по ParseMode
+	Caption string
+	// Запретить пересылку и сохранение отправленного сообщения
+	ProtectContent bool
+	// Отправить без звука уведомления
+	DisableNotification bool
+	// Анимированный эффект сообщения (работает только в личных чатах)
+	MessageEffectID string
 }
 
 type Message struct {
-	MessageID      int        `json:"message_id"`
-	From           *User      `json:"from"`
-	Chat           *Chat      `json:"chat"`
-	Text           string     `json:"text"`
-	ReplyToMessage *Message   `json:"reply_to_message"`
-	Voice          *Voice     `json:"voice"`
-	Audio          *Audio     `json:"audio"`
-	Video          *Video     `json:"video"`
-	VideoNote      *VideoNote `json:"video_note"`
-	Document       *Document  `json:"document"`
-	Animation      *struct{}  `json:"animation"`
-	Sticker        *struct{}  `json:"sticker"`
+	MessageID      int            `json:"message_id"`
+	From           *User          `json:"from"`
+	SenderChat     *Chat          `json:"sender_chat"`
+	Chat           *Chat          `json:"chat"`
+	ForwardOrigin  *MessageOrigin `json:"forward_origin"`
+	Text           string         `json:"text"`
+	ReplyToMessage *Message       `json:"reply_to_message"`
+	Voice          *Voice         `json:"voice"`
+	Audio          *Audio         `json:"audio"`
+	Video          *Video         `json:"video"`
+	VideoNote      *VideoNote     `json:"video_note"`
+	Document       *Document      `json:"document"`
+	Animation      *struct{}      `json:"animation"`
+	Sticker        *struct{}      `json:"sticker"`
+	// ID темы форума; IsTopicMessage - сообщение отправлено в тему
+	MessageThreadID int  `json:"message_thread_id"`
+	IsTopicMessage  bool `json:"is_topic_message"`
+	// ID бизнес-подключения, если сообщение получено через аккаунт Telegram Business
+	BusinessConnectionID string `json:"business_connection_id"`
+	// Платный контент; файлы в нем доступны боту, только если контент уже оплачен
+	PaidMedia *PaidMediaInfo `json:"paid_media"`
+	// Бот, через которого отправлено сообщение (inline-режим или пересылающий бот-мост)
+	ViaBot *User `json:"via_bot"`
 }
 
 type User struct {
-	ID int64 `json:"id"`
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+	// Язык интерфейса Telegram у пользователя (IETF, например "en" или "pt-br"); может быть пустым
+	LanguageCode string `json:"language_code"`
+}
+
+// Name возвращает имя пользователя для показа: "Имя Фамилия (@username)"
+func (u *User) Name() string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	switch {
+	case name == "":
+		name = "@" + u.Username
+	case u.Username != "":
+		name += " (@" + u.Username + ")"
+	}
+	return name
 }
 
 type Chat struct {
-	ID int64 `json:"id"`
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Username string `json:"username"`
+}
+
+// MessageOrigin - источник пересланного сообщения: type "user", "hidden_user", "chat" или "channel"
+type MessageOrigin struct {
+	Type            string `json:"type"`
+	SenderUser      *User  `json:"sender_user"`
+	SenderUserName  string `json:"sender_user_name"`
+	SenderChat      *Chat  `json:"sender_chat"`
+	Chat            *Chat  `json:"chat"`
+	AuthorSignature string `json:"author_signature"`
+}
+
+// Describe возвращает человекочитаемое описание источника: имя пользователя, бота, чата или канала
+func (o *MessageOrigin) Describe() string {
+	var name string
+	switch {
+	case o.SenderUser != nil:
+		name = o.SenderUser.Name()
+		if o.SenderUser.IsBot {
+			name = "бот " + name
+		}
+	case o.SenderUserName != "":
+		name = o.SenderUserName
+	case o.Chat != nil:
+		name = o.Chat.Name()
+		if o.Type == "channel" {
+			name = "канал " + name
+		}
+	case o.SenderChat != nil:
+		name = o.SenderChat.Name()
+	}
+	if o.AuthorSignature != "" {
+		name += ", " + o.AuthorSignature
+	}
+	return name
+}
+
+// Name возвращает название чата для показа
+func (c *Chat) Name() string {
+	switch {
+	case c.Title != "":
+		return c.Title
+	case c.Username != "":
+		return "@" + c.Username
+	}
+	return strconv.FormatInt(c.ID, 10)
+}
+
+// IsPrivate сообщает, является ли чат личной перепиской с ботом
+func (c *Chat) IsPrivate() bool { return c.Type == "private" }
+
+// MessageLink возвращает ссылку на сообщение чата или "", если на сообщения этого чата ссылаться нельзя
+// (личные чаты и обычные группы)
+func (c *Chat) MessageLink(messageID int) string {
+	// В личном чате Username - имя собеседника, а t.me/<имя>/<ID> не ведет на сообщение
+	if c.IsPrivate() {
+		return ""
+	}
+	if c.Username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", c.Username, messageID)
+	}
+	// ID супергрупп и каналов имеют вид -100XXXXXXXXXX, во внутренней ссылке используется XXXXXXXXXX
+	if id := strconv.FormatInt(c.ID, 10); strings.HasPrefix(id, "-100") {
+		return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(id, "-100"), messageID)
+	}
+	return ""
+}
+
+type ChatMember struct {
+	Status string `json:"status"`
+	User   *User  `json:"user"`
+}
+
+// IsAdmin сообщает, является ли участник создателем или администратором чата
+func (m *ChatMember) IsAdmin() bool { return m.Status == "creator" || m.Status == "administrator" }
+
+type GetChatMemberResponse struct {
+	Ok     bool       `json:"ok"`
+	Result ChatMember `json:"result"`
 }
 
 type MediaFile struct {
@@ -40,6 +240,7 @@ type Voice struct{ MediaFile }
 type Audio struct {
 	MediaFile
 	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
 }
 
 type Video struct {
@@ -49,9 +250,31 @@ type Video struct {
 
 type VideoNote struct{ MediaFile }
 
+type PaidMediaInfo struct {
+	StarCount int         `json:"star_count"`
+	PaidMedia []PaidMedia `json:"paid_media"`
+}
+
+// PaidMedia - элемент платного контента: type "preview" (содержимое скрыто), "photo" или "video"
+type PaidMedia struct {
+	Type  string `json:"type"`
+	Video *Video `json:"video"`
+}
+
+// Video возвращает первое доступное боту видео из платного контента или nil
+func (p *PaidMediaInfo) Video() *Video {
+	for _, m := range p.PaidMedia {
+		if m.Type == "video" && m.Video != nil {
+			return m.Video
+		}
+	}
+	return nil
+}
+
 type Document struct {
 	MediaFile
 	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
 }
 
 type File struct {
@@ -66,8 +289,13 @@ type GetFileResponse struct {
 }
 
 type GetUpdatesResponse struct {
-	Ok     bool     `json:"ok"`
-	Result []Update `json:"result"`
+	Ok          bool     `json:"ok"`
+	Result      []Update `json:"result"`
+	Description string   `json:"description"`
 }
 
-
+type SendMessageResponse struct {
+	Ok          bool    `json:"ok"`
+	Result      Message `json:"result"`
+	Description string  `json:"description"`
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+)
+
+type Tokens struct {
+	Prompt     int `json:"prompt"`
+	Candidates int `json:"candidates"`
+	Total      int `json:"total"`
+}
+
+// Payload - тело POST-запроса, отправляемого после каждой обработки
+type Payload struct {
+	Platform        string    `json:"platform"`
+	ChatID          string    `json:"chat_id"`
+	UserID          string    `json:"user_id"`
+	MessageID       string    `json:"message_id"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Transcript      string    `json:"transcript"`
+	Summary         string    `json:"summary"`
+	Tokens          Tokens    `json:"tokens"`
+	Models          []string  `json:"models"`
+	LatencyMs       int64     `json:"latency_ms"`
+	ProcessedAt     time.Time `json:"processed_at"`
+}
+
+// Notifier отправляет результаты обработки на RESULT_WEBHOOK_URL
+type Notifier struct {
+	url  string
+	http *http.Client
+}
+
+func NewNotifier(url string, httpClient *http.Client) *Notifier {
+	return &Notifier{url: url, http: httpClient}
+}
+
+func NewPayload(res *pipeline.Result) Payload {
+	src := res.Job.Source
+	return Payload{
+		Platform:        src.Platform,
+		ChatID:          src.ChatID,
+		UserID:          src.UserID,
+		MessageID:       src.MessageID,
+		DurationSeconds: res.Job.Duration.Seconds(),
+		Transcript:      res.Transcript,
+		Summary:         res.Summary,
+		Tokens:          Tokens{Prompt: res.Usage.PromptTokens, Candidates: res.Usage.CandidatesTokens, Total: res.Usage.TotalTokens},
+		Models:          res.Usage.Models,
+		LatencyMs:       res.Latency.Milliseconds(),
+		ProcessedAt:     time.Now().UTC(),
+	}
+}
+
+func (n *Notifier) Deliver(ctx context.Context, res *pipeline.Result) error {
+	payloadBytes, err := json.Marshal(NewPayload(res))
+	if err != nil {
+		return fmt.Errorf("ошибка маршалинга payload вебхука: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса вебхука: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка при отправке вебхука: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("вебхук вернул статус %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
@@ -2,16 +2,36 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strings"
     "time"
+	// База часовых поясов для TIMEZONE и /timezone: в образе alpine ее нет
+	_ "time/tzdata"
 
 	"github.com/0fl01/voice-shut-up-bot-go/internal/ai"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/archive"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/bot"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/format"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/leader"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/loadtest"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/logging"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/matrix"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/notes"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/pipeline"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/queue"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/retention"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/slack"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
 	"github.com/0fl01/voice-shut-up-bot-go/internal/telegram"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/webhook"
 	"google.golang.org/genai"
 )
 
@@ -19,12 +39,30 @@ func main() {
 	log.Println("Запуск бота...")
 
 	cfg := config.LoadFromEnv()
-	if cfg.BotToken == "" || cfg.GoogleAPIKey == "" {
-		log.Fatalf("Переменные окружения %s и %s должны быть установлены", config.EnvBotToken, config.EnvGoogleAPIKey)
+	logging.Setup(cfg.LogLevel)
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		runLoadTest(cfg, os.Args[2:])
+		return
+	}
+	worker := len(os.Args) > 1 && os.Args[1] == "worker"
+	if worker && (cfg.Platform != config.PlatformTelegram || cfg.JobQueueURL == "" || cfg.MultiTenant) {
+		log.Fatalf("Подкоманда worker работает только с платформой %s без %s и требует %s", config.PlatformTelegram, config.EnvMultiTenant, config.EnvJobQueueURL)
+	}
+	if cfg.MultiTenant {
+		runTenants(cfg)
+		return
+	}
+	if cfg.JobQueueURL != "" && (cfg.RedisURL == "" || !sharedStorage(storageBackend(cfg))) {
+		// Отметка о том, кто выполняет задание, и сохраненные задания в памяти одного процесса
+		// не видны другим: задание с опоздавшим подтверждением выполнили бы два процесса
+		log.Fatalf("С %s нужны общие кэш и хранилище: %s и %s (PostgreSQL) или %s=%s", config.EnvJobQueueURL,
+			config.EnvRedisURL, config.EnvDatabaseURL, config.EnvStorageBackend, config.StorageRedis)
+	}
+	if cfg.GoogleAPIKey == "" {
+		log.Fatalf("Переменная окружения %s должна быть установлена", config.EnvGoogleAPIKey)
 	}
 
-	apiBaseURL := fmt.Sprintf("https://api.telegram.org/bot%s", cfg.BotToken)
-    httpClient := &http.Client{Timeout: 65 * time.Second}
+    httpClient := newHTTPClient(cfg.HTTPTimeout, cfg.HTTPDialTimeout, 0)
 	ctx := context.Background()
 
 	gClient, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: cfg.GoogleAPIKey})
@@ -32,6 +70,335 @@ func main() {
 		log.Fatalf("Не удалось создать клиент Gemini: %v", err)
 	}
 
+	aiSvc := newAIService(gClient, cfg)
+
+	mediaProc := media.NewProcessor()
+	pipe := newPipeline(aiSvc, mediaProc, cfg)
+	if err := setupArchive(ctx, pipe, cfg, httpClient); err != nil {
+		log.Fatalf("Не удалось настроить архив: %v", err)
+	}
+
+	sessionCache, redisCache := openCache(cfg)
+	if redisCache != nil {
+		defer redisCache.Close()
+	}
+	aiSvc.EnableResponseCache(sessionCache, cfg.SummaryCacheTTL)
+
+	stores, closeStores, err := openStores(cfg, redisCache)
+	if err != nil {
+		log.Fatalf("Не удалось открыть хранилище: %v", err)
+	}
+	defer closeStores()
+	setupSinks(ctx, pipe, cfg, stores, httpClient)
+
+	switch cfg.Platform {
+	case config.PlatformTelegram:
+		if cfg.BotToken == "" {
+			log.Fatalf("Переменная окружения %s должна быть установлена", config.EnvBotToken)
+		}
+		tele := newTelegramClient(cfg, cfg.BotToken, httpClient)
+		layout, err := loadLayout(cfg.OutputTemplateFile)
+		if err != nil {
+			log.Fatalf("Не удалось загрузить шаблон сообщений: %v", err)
+		}
+		application := bot.NewApp(cfg, tele, aiSvc, mediaProc, pipe, stores, sessionCache, layout)
+		if cfg.JobQueueURL != "" {
+			jobQueue, err := queue.NewNATS(cfg.JobQueueURL, cfg.JobQueueSubject)
+			if err != nil {
+				log.Fatalf("Не удалось подключить очередь заданий: %v", err)
+			}
+			defer jobQueue.Close()
+			if worker {
+				log.Printf("Обработчик заданий запущен, тема %s.", cfg.JobQueueSubject)
+				if err := application.RunWorker(ctx, jobQueue); err != nil {
+					log.Fatalf("Ошибка очереди заданий: %v", err)
+				}
+				return
+			}
+			application.SetJobQueue(jobQueue)
+		}
+		locks, closeLocks, err := openPollLocks(cfg, redisCache)
+		if err != nil {
+			log.Fatalf("Не удалось подключить блокировку ведущей реплики: %v", err)
+		}
+		defer closeLocks()
+		if locks != nil && cfg.TelegramWebhookURL == "" {
+			// Напоминания и задания возобновляет ведущая реплика, см. PollUpdates
+			application.SetPollLock(locks.Lock(pollLockName(cfg.BotToken)))
+		} else {
+			go application.RunReminders(ctx)
+			application.ResumeJobs(ctx)
+		}
+		if cfg.DashboardListenAddr != "" {
+			go func() {
+				if err := application.RunDashboard(); err != nil {
+					log.Printf("Ошибка веб-панели: %v", err)
+				}
+			}()
+		}
+		log.Println("Бот успешно запущен и готов к работе.")
+		if cfg.TelegramWebhookURL != "" {
+			if err := application.RunWebhook(); err != nil {
+				log.Fatalf("Ошибка вебхука Telegram: %v", err)
+			}
+			return
+		}
+		application.PollUpdates()
+
+	case config.PlatformSlack:
+		if cfg.SlackBotToken == "" || cfg.SlackSigningSecret == "" {
+			log.Fatalf("Переменные окружения %s и %s должны быть установлены", config.EnvSlackBotToken, config.EnvSlackSigningSecret)
+		}
+		adapter := slack.NewAdapter(slack.NewClient(cfg.SlackBotToken, httpClient), pipe, cfg.SlackSigningSecret, cfg.MaxFileSize)
+		log.Printf("Slack Events API слушает на %s", cfg.SlackListenAddr)
+		if err := http.ListenAndServe(cfg.SlackListenAddr, adapter); err != nil {
+			log.Fatalf("Ошибка HTTP-сервера Slack: %v", err)
+		}
+
+	case config.PlatformMatrix:
+		if cfg.MatrixHomeserver == "" || cfg.MatrixAccessToken == "" {
+			log.Fatalf("Переменные окружения %s и %s должны быть установлены", config.EnvMatrixHomeserver, config.EnvMatrixAccessToken)
+		}
+		adapter := matrix.NewAdapter(matrix.NewClient(cfg.MatrixHomeserver, cfg.MatrixAccessToken, httpClient), pipe, cfg.MaxFileSize, cfg.RetryDelay)
+		if err := adapter.Run(); err != nil {
+			log.Fatalf("Ошибка работы с Matrix: %v", err)
+		}
+
+	default:
+		log.Fatalf("Неизвестная платформа %q в %s (допустимо: %s, %s, %s)", cfg.Platform, config.EnvPlatform,
+			config.PlatformTelegram, config.PlatformSlack, config.PlatformMatrix)
+	}
+}
+
+// newPipeline создает конвейер с настройками обработки из конфигурации; архив и получатели
+// результатов подключаются отдельно (setupArchive, setupSinks)
+func newPipeline(aiSvc *ai.Service, mediaProc *media.Processor, cfg config.Config) *pipeline.Pipeline {
+	pipe := pipeline.New(aiSvc, mediaProc, cfg.UserPromptTemplate)
+	pipe.SetSpeechThreshold(cfg.SpeechThreshold)
+	pipe.SetMemoryBudget(cfg.MemoryBudget)
+	if cfg.VerifySummary {
+		pipe.SetVerifyPrompt(cfg.VerifySummaryPrompt)
+	}
+	pipe.SetRefineTranscript(cfg.RefineTranscript)
+	pipe.SetPunctuateTranscript(cfg.PunctuateTranscript)
+	pipe.SetTimestamps(cfg.TranscriptTimestamps)
+	if cfg.TranscriptTimestamps {
+		pipe.SetChapters(cfg.ChaptersMinDuration)
+	}
+	return pipe
+}
+
+// setupArchive подключает к конвейеру архив S3, если он настроен
+func setupArchive(ctx context.Context, pipe *pipeline.Pipeline, cfg config.Config, httpClient *http.Client) error {
+	if cfg.ArchiveS3Endpoint == "" {
+		return nil
+	}
+	if cfg.ArchiveS3Bucket == "" {
+		return fmt.Errorf("для архивирования должна быть установлена переменная %s", config.EnvArchiveS3Bucket)
+	}
+	archiver, err := archive.NewS3(ctx, archive.Config{
+		Endpoint:      cfg.ArchiveS3Endpoint,
+		AccessKey:     cfg.ArchiveS3AccessKey,
+		SecretKey:     cfg.ArchiveS3SecretKey,
+		Bucket:        cfg.ArchiveS3Bucket,
+		Region:        cfg.ArchiveS3Region,
+		UseSSL:        cfg.ArchiveS3UseSSL,
+		Prefix:        cfg.ArchivePrefix,
+		RetentionDays: cfg.ArchiveRetentionDays,
+	}, httpClient)
+	if err != nil {
+		return fmt.Errorf("не удалось подключить архив S3: %w", err)
+	}
+	pipe.SetArchiver(archiver)
+	return nil
+}
+
+// setupSinks подключает к конвейеру получателей результатов: хранилище, счетчики, заметки и вебхук -
+// и запускает очистку по срокам хранения
+func setupSinks(ctx context.Context, pipe *pipeline.Pipeline, cfg config.Config, stores store.Stores, httpClient *http.Client) {
+	if stores.Transcripts != nil {
+		pipe.AddSink(pipeline.NewStoreSink(stores.Transcripts, stores.Settings))
+		purger := retention.NewPurger(stores.Transcripts, stores.Settings, pipe, store.RetentionPolicy{
+			TranscriptDays: cfg.RetentionTranscriptDays,
+			SummaryDays:    cfg.RetentionSummaryDays,
+		}, cfg.RetentionPurgeInterval)
+		go purger.Run(ctx)
+	}
+	pipe.AddSink(pipeline.NewQuotaSink(stores.Quotas))
+
+	syncer := notes.NewSyncer(stores.Settings)
+	if cfg.NotionToken != "" {
+		syncer.Register(notes.KindNotion, notes.NewNotionWriter(cfg.NotionToken, httpClient))
+	}
+	if cfg.NotesWebDAVURL != "" {
+		syncer.Register(notes.KindVault, notes.NewWebDAVWriter(cfg.NotesWebDAVURL, cfg.NotesWebDAVUser, cfg.NotesWebDAVPassword, httpClient))
+	} else if cfg.NotesVaultDir != "" {
+		syncer.Register(notes.KindVault, notes.NewDirWriter(cfg.NotesVaultDir))
+	}
+	if syncer.Supports(notes.KindNotion) || syncer.Supports(notes.KindVault) {
+		pipe.AddSink(syncer)
+	}
+	if cfg.ResultWebhookURL != "" {
+		pipe.AddSink(webhook.NewNotifier(cfg.ResultWebhookURL, httpClient))
+	}
+}
+
+// openCache подключает Redis, если задан REDIS_URL (он же возвращается вторым значением, чтобы его
+// можно было закрыть и использовать как хранилище), иначе возвращает кэш в памяти
+func openCache(cfg config.Config) (cache.Cache, *cache.Redis) {
+	if cfg.RedisURL == "" {
+		return cache.NewMemory(), nil
+	}
+	redisCache, err := cache.NewRedis(cfg.RedisURL)
+	if err != nil {
+		log.Fatalf("Не удалось подключить кэш Redis: %v", err)
+	}
+	return redisCache, redisCache
+}
+
+// newTelegramClient создает клиент Bot API для токена token с настройками HTTP из конфигурации
+func newTelegramClient(cfg config.Config, token string, httpClient *http.Client) *telegram.Client {
+	apiBaseURL := fmt.Sprintf("https://api.telegram.org/bot%s", token)
+	tele := telegram.NewClient(token, apiBaseURL, httpClient)
+	tele.SetMaxDownloadSize(cfg.MaxFileSize)
+	tele.SetDownloadAttempts(cfg.DownloadAttempts)
+	tele.SetPollClient(newHTTPClient(0, cfg.HTTPDialTimeout, 0))
+	tele.SetPollTimeout(cfg.PollTimeout)
+	tele.SetDownloadClient(newHTTPClient(cfg.DownloadTimeout, cfg.HTTPDialTimeout, cfg.DownloadMaxConns))
+	return tele
+}
+
+// minPollLockTTL - наименьший допустимый POLL_LOCK_TTL
+const minPollLockTTL = 3 * time.Second
+
+// openPollLocks подключает блокировки ведущей реплики по POLL_LOCK; без него возвращает nil
+func openPollLocks(cfg config.Config, redisCache *cache.Redis) (leader.Locks, func(), error) {
+	if cfg.PollLock == "" {
+		return nil, func() {}, nil
+	}
+	// С нулевым сроком ключ в Redis не истекает, а time.NewTicker при продлении паникует
+	if cfg.PollLockTTL < minPollLockTTL {
+		return nil, nil, fmt.Errorf("%s должна быть не меньше %s, указано %s", config.EnvPollLockTTL, minPollLockTTL, cfg.PollLockTTL)
+	}
+	switch cfg.PollLock {
+	case config.PollLockRedis:
+		if redisCache == nil {
+			return nil, nil, fmt.Errorf("для блокировки в Redis должна быть установлена переменная %s", config.EnvRedisURL)
+		}
+		return leader.NewRedisLocks(redisCache.Client(), cfg.PollLockTTL), func() {}, nil
+	case config.PollLockPostgres:
+		if cfg.DatabaseURL == "" {
+			return nil, nil, fmt.Errorf("для блокировки в PostgreSQL должна быть установлена переменная %s", config.EnvDatabaseURL)
+		}
+		locks, err := leader.OpenPostgres(cfg.DatabaseURL, cfg.PollLockTTL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return locks, func() { locks.Close() }, nil
+	}
+	return nil, nil, fmt.Errorf("неизвестная блокировка %q в %s (допустимо: %s, %s)", cfg.PollLock, config.EnvPollLock, config.PollLockRedis, config.PollLockPostgres)
+}
+
+// pollLockName - имя блокировки long polling бота с токеном token: реплики одного бота соревнуются
+// за одну блокировку, а разные боты (тенанты) не мешают друг другу
+func pollLockName(token string) string {
+	botID, _, _ := strings.Cut(token, ":")
+	return "poll:" + botID
+}
+
+// storageBackend возвращает хранилище из STORAGE_BACKEND, а без явного выбора - PostgreSQL или SQLite,
+// если они настроены; пустая строка - без постоянного хранилища
+func storageBackend(cfg config.Config) string {
+	if cfg.StorageBackend != "" {
+		return cfg.StorageBackend
+	}
+	switch {
+	case cfg.DatabaseURL != "":
+		return config.StoragePostgres
+	case cfg.DatabasePath != "":
+		return config.StorageSQLite
+	}
+	return ""
+}
+
+// sharedStorage сообщает, доступно ли хранилище backend нескольким процессам на разных машинах
+func sharedStorage(backend string) bool {
+	return backend == config.StoragePostgres || backend == config.StorageRedis
+}
+
+// openStores выбирает реализацию хранилищ по STORAGE_BACKEND. Без явного выбора используется
+// PostgreSQL или SQLite, если они настроены; иначе расшифровки не хранятся, а настройки и счетчики живут в памяти.
+func openStores(cfg config.Config, redisCache *cache.Redis) (store.Stores, func(), error) {
+	backend := storageBackend(cfg)
+	var db *store.SQLStore
+	var err error
+	switch backend {
+	case "":
+		memory := store.NewMemory()
+		return store.Stores{Settings: memory, Quotas: memory, Audit: memory, Reminders: memory, Bot: memory, Jobs: memory}, func() {}, nil
+	case config.StorageMemory:
+		memory := store.NewMemory()
+		return store.Stores{Transcripts: memory, Settings: memory, Quotas: memory, Audit: memory, Reminders: memory, Bot: memory, Jobs: memory}, func() {}, nil
+	case config.StorageRedis:
+		if redisCache == nil {
+			return store.Stores{}, nil, fmt.Errorf("для хранилища Redis должна быть установлена переменная %s", config.EnvRedisURL)
+		}
+		r := store.NewRedis(redisCache.Client())
+		return store.Stores{Transcripts: r, Settings: r, Quotas: r, Audit: r, Reminders: r, Bot: r, Jobs: r}, func() {}, nil
+	case config.StoragePostgres:
+		if cfg.DatabaseURL == "" {
+			return store.Stores{}, nil, fmt.Errorf("для PostgreSQL должна быть установлена переменная %s", config.EnvDatabaseURL)
+		}
+		db, err = store.OpenPostgres(cfg.DatabaseURL)
+	case config.StorageSQLite:
+		if cfg.DatabasePath == "" {
+			return store.Stores{}, nil, fmt.Errorf("для SQLite должна быть установлена переменная %s", config.EnvDatabasePath)
+		}
+		db, err = store.Open(cfg.DatabasePath)
+	default:
+		return store.Stores{}, nil, fmt.Errorf("неизвестное хранилище %q в %s", backend, config.EnvStorageBackend)
+	}
+	if err != nil {
+		return store.Stores{}, nil, err
+	}
+	return store.Stores{Transcripts: db, Settings: db, Quotas: db, Audit: db, Reminders: db, Bot: db, Jobs: db, Tenants: db}, func() { db.Close() }, nil
+}
+
+// loadLayout читает шаблон раскладки сообщений из файла; без файла используется раскладка по умолчанию
+func loadLayout(path string) (*format.Layout, error) {
+	if path == "" {
+		return format.ParseLayout("")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	return format.ParseLayout(string(data))
+}
+
+// newHTTPClient создает HTTP-клиент с общим таймаутом timeout (0 - без него), таймаутом соединения
+// и TLS dialTimeout и пулом до maxConnsPerHost простаивающих соединений на хост (0 - по умолчанию)
+func newHTTPClient(timeout, dialTimeout time.Duration, maxConnsPerHost int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = dialTimeout
+	if maxConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = maxConnsPerHost
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// languageProfiles переводит профили промптов из конфигурации в профили сервиса Gemini
+func languageProfiles(profiles map[string]config.LanguageProfile) map[string]ai.LanguageProfile {
+	out := make(map[string]ai.LanguageProfile, len(profiles))
+	for language, p := range profiles {
+		out[language] = ai.LanguageProfile{System: p.System, User: p.User}
+	}
+	return out
+}
+
+// newAIService создает сервис Gemini с настройками из конфигурации
+func newAIService(gClient *genai.Client, cfg config.Config) *ai.Service {
 	aiSvc := ai.NewService(gClient, ai.Config{
 		PrimaryModel:        cfg.PrimaryModel,
 		FallbackModel:       cfg.FallbackModel,
@@ -41,14 +408,67 @@ func main() {
 		PrimaryModelRetries:  cfg.PrimaryModelRetries,
 		FallbackModelRetries: cfg.FallbackModelRetries,
 		RetryDelay:           cfg.RetryDelay,
+		FlagUnclear:          cfg.FlagUnclear,
+		LanguageProfiles:     languageProfiles(cfg.LanguageProfiles),
+		Timestamps:           cfg.TranscriptTimestamps,
 	})
-
-	tele := telegram.NewClient(cfg.BotToken, apiBaseURL, httpClient)
-	mediaProc := media.NewProcessor()
-
-	application := bot.NewApp(cfg, tele, aiSvc, mediaProc)
-	log.Println("Бот успешно запущен и готов к работе.")
-	application.PollUpdates()
+	aiSvc.EnableCoalescing(ai.CoalesceConfig{
+		Threshold: cfg.CoalesceThreshold,
+		MaxBatch:  cfg.CoalesceMaxBatch,
+		MaxChars:  cfg.CoalesceMaxChars,
+		Wait:      cfg.CoalesceWait,
+	})
+	return aiSvc
 }
 
+// runLoadTest прогоняет синтетические задания через конвейер (подкоманда loadtest). По умолчанию
+// модель заменяется локальной имитацией с задержкой -mock-latency; с -mock=false запросы идут в
+// настоящий Gemini, но не чаще -rate в секунду.
+func runLoadTest(cfg config.Config, args []string) {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	jobs := fs.Int("jobs", 50, "сколько заданий отправить")
+	concurrency := fs.Int("concurrency", max(cfg.MaxConcurrentJobs, 4), "сколько заданий выполнять одновременно")
+	fixtureDuration := fs.Duration("fixture-duration", time.Minute, "длительность синтетической записи, если образцы не заданы")
+	mock := fs.Bool("mock", true, "заменить Gemini локальной имитацией")
+	mockLatency := fs.Duration("mock-latency", 2*time.Second, "задержка ответа имитации Gemini")
+	rate := fs.Float64("rate", 1, "не больше стольких запросов к Gemini в секунду (с -mock=false)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Использование: %s loadtest [флаги] [образец.ogg ...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
 
+	ctx := context.Background()
+	clientCfg := &genai.ClientConfig{APIKey: cfg.GoogleAPIKey, Backend: genai.BackendGeminiAPI}
+	if *mock {
+		server := loadtest.NewMockGemini(*mockLatency)
+		defer server.Close()
+		clientCfg.APIKey = "loadtest"
+		clientCfg.HTTPOptions.BaseURL = server.URL
+	} else {
+		if cfg.GoogleAPIKey == "" {
+			log.Fatalf("Переменная окружения %s должна быть установлена", config.EnvGoogleAPIKey)
+		}
+		if *rate <= 0 {
+			log.Fatalf("Флаг -rate должен быть больше нуля")
+		}
+		clientCfg.HTTPClient = &http.Client{Transport: loadtest.RateLimitedTransport(nil, *rate)}
+	}
+	gClient, err := genai.NewClient(ctx, clientCfg)
+	if err != nil {
+		log.Fatalf("Не удалось создать клиент Gemini: %v", err)
+	}
+
+	pipe := newPipeline(newAIService(gClient, cfg), media.NewProcessor(), cfg)
+	log.Printf("Нагрузочный тест: %d заданий, одновременно %d", *jobs, *concurrency)
+	report, err := loadtest.Run(ctx, pipe, loadtest.Config{
+		Fixtures:        fs.Args(),
+		Jobs:            *jobs,
+		Concurrency:     *concurrency,
+		FixtureDuration: *fixtureDuration,
+	})
+	if err != nil {
+		log.Fatalf("Ошибка нагрузочного теста: %v", err)
+	}
+	report.Print(os.Stdout)
+}
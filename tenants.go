@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/0fl01/voice-shut-up-bot-go/internal/bot"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/cache"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/config"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/media"
+	"github.com/0fl01/voice-shut-up-bot-go/internal/store"
+	"google.golang.org/genai"
+)
+
+// runTenants запускает в одном процессе всех включенных тенантов из таблицы tenants (MULTI_TENANT):
+// у каждого свой клиент Telegram с long polling, свой клиент Gemini, конвейер и настройки, а хранилище
+// и кэш общие, но разделены по тенантам. Новые и измененные тенанты подхватываются после перезапуска.
+func runTenants(cfg config.Config) {
+	if cfg.Platform != config.PlatformTelegram {
+		log.Fatalf("Режим %s поддерживается только для платформы %s", config.EnvMultiTenant, config.PlatformTelegram)
+	}
+	if cfg.TelegramWebhookURL != "" {
+		log.Fatalf("Режим %s работает только через long polling: уберите %s", config.EnvMultiTenant, config.EnvTelegramWebhookURL)
+	}
+	if cfg.DashboardListenAddr != "" {
+		log.Printf("Веб-панель в режиме %s не запускается", config.EnvMultiTenant)
+	}
+	if cfg.JobQueueURL != "" {
+		log.Printf("Очередь заданий в режиме %s не используется", config.EnvMultiTenant)
+	}
+
+	httpClient := newHTTPClient(cfg.HTTPTimeout, cfg.HTTPDialTimeout, 0)
+	ctx := context.Background()
+
+	sessionCache, redisCache := openCache(cfg)
+	if redisCache != nil {
+		defer redisCache.Close()
+	}
+	stores, closeStores, err := openStores(cfg, redisCache)
+	if err != nil {
+		log.Fatalf("Не удалось открыть хранилище: %v", err)
+	}
+	defer closeStores()
+	if stores.Tenants == nil {
+		log.Fatalf("Для режима %s нужно хранилище SQLite или PostgreSQL (%s или %s)", config.EnvMultiTenant, config.EnvDatabasePath, config.EnvDatabaseURL)
+	}
+	tenants, err := stores.Tenants.ListTenants(ctx)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить тенантов: %v", err)
+	}
+	layout, err := loadLayout(cfg.OutputTemplateFile)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить шаблон сообщений: %v", err)
+	}
+	mediaProc := media.NewProcessor()
+	locks, closeLocks, err := openPollLocks(cfg, redisCache)
+	if err != nil {
+		log.Fatalf("Не удалось подключить блокировку ведущей реплики: %v", err)
+	}
+	defer closeLocks()
+
+	started := 0
+	for _, t := range tenants {
+		if t.Disabled {
+			log.Printf("Тенант %s отключен, пропускается", t.ID)
+			continue
+		}
+		if t.ID == "" || strings.ContainsAny(t.ID, "@:") {
+			log.Fatalf("Некорректный ID тенанта %q: ID не может быть пустым и содержать @ или :", t.ID)
+		}
+		if t.BotToken == "" {
+			log.Fatalf("У тенанта %s не задан токен бота", t.ID)
+		}
+		tcfg := tenantConfig(cfg, t)
+		if tcfg.GoogleAPIKey == "" {
+			log.Fatalf("У тенанта %s не задан ключ Gemini, а переменная %s не установлена", t.ID, config.EnvGoogleAPIKey)
+		}
+		gClient, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: tcfg.GoogleAPIKey})
+		if err != nil {
+			log.Fatalf("Не удалось создать клиент Gemini для тенанта %s: %v", t.ID, err)
+		}
+		aiSvc := newAIService(gClient, tcfg)
+		tenantCache := cache.WithPrefix(sessionCache, "tenant:"+t.ID+":")
+		aiSvc.EnableResponseCache(tenantCache, tcfg.SummaryCacheTTL)
+		tenantStores := store.Namespace(stores, t.ID)
+		pipe := newPipeline(aiSvc, mediaProc, tcfg)
+		if err := setupArchive(ctx, pipe, tcfg, httpClient); err != nil {
+			log.Fatalf("Не удалось настроить архив для тенанта %s: %v", t.ID, err)
+		}
+		setupSinks(ctx, pipe, tcfg, tenantStores, httpClient)
+
+		tele := newTelegramClient(tcfg, t.BotToken, httpClient)
+		application := bot.NewApp(tcfg, tele, aiSvc, mediaProc, pipe, tenantStores, tenantCache, layout)
+		if locks != nil {
+			application.SetPollLock(locks.Lock(pollLockName(t.BotToken)))
+		} else {
+			go application.RunReminders(ctx)
+			application.ResumeJobs(ctx)
+		}
+		go application.PollUpdates()
+		log.Printf("Тенант %s запущен", t.ID)
+		started++
+	}
+	if started == 0 {
+		log.Fatalf("В таблице tenants нет включенных тенантов")
+	}
+	log.Printf("Бот успешно запущен и готов к работе: тенантов %d.", started)
+	select {}
+}
+
+// tenantConfig накладывает настройки тенанта t на конфигурацию процесса cfg
+func tenantConfig(cfg config.Config, t store.Tenant) config.Config {
+	tc := t.Config
+	cfg.BotToken = t.BotToken
+	if t.GoogleAPIKey != "" {
+		cfg.GoogleAPIKey = t.GoogleAPIKey
+	}
+	// Архивы тенантов не пересекаются, даже если ID их чатов совпадают
+	cfg.ArchivePrefix += t.ID + "/"
+	if tc.PrimaryModel != "" {
+		cfg.PrimaryModel = tc.PrimaryModel
+	}
+	if tc.FallbackModel != "" {
+		cfg.FallbackModel = tc.FallbackModel
+	}
+	if tc.SystemPrompt != "" {
+		cfg.SystemPrompt = tc.SystemPrompt
+	}
+	if tc.UserPromptTemplate != "" {
+		cfg.UserPromptTemplate = tc.UserPromptTemplate
+	}
+	if tc.SummaryLanguage != "" {
+		cfg.SummaryLanguage = strings.ToLower(tc.SummaryLanguage)
+	}
+	if tc.Timezone != "" {
+		cfg.Timezone = tc.Timezone
+	}
+	if tc.BotAdminIDs != nil {
+		cfg.BotAdminIDs = tc.BotAdminIDs
+	}
+	if tc.FeatureFlags != "" {
+		cfg.FeatureFlags = tc.FeatureFlags
+	}
+	if tc.BudgetDaily > 0 {
+		cfg.BudgetDaily = config.Budget{Tokens: tc.BudgetDaily}
+	}
+	if tc.BudgetMonthly > 0 {
+		cfg.BudgetMonthly = config.Budget{Tokens: tc.BudgetMonthly}
+	}
+	if tc.BudgetChatDaily > 0 {
+		cfg.BudgetChatDaily = config.Budget{Tokens: tc.BudgetChatDaily}
+	}
+	if tc.BudgetChatMonthly > 0 {
+		cfg.BudgetChatMonthly = config.Budget{Tokens: tc.BudgetChatMonthly}
+	}
+	if tc.UserRateLimit > 0 {
+		cfg.UserRateLimit = tc.UserRateLimit
+	}
+	if tc.MaxConcurrentJobs > 0 {
+		cfg.MaxConcurrentJobs = tc.MaxConcurrentJobs
+	}
+	if tc.MaxDurationMinutes > 0 {
+		cfg.MaxDuration = time.Duration(tc.MaxDurationMinutes) * time.Minute
+	}
+	return cfg
+}